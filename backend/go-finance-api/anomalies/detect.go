@@ -0,0 +1,334 @@
+// Package anomalies flags unusual transactions: amounts far above a merchant's norm,
+// large first charges from new merchants, duplicate charges, foreign charges and
+// transactions that push a category's monthly spending well above normal
+package anomalies
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/merchants"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+const (
+	// HistoryDays is how much transaction history norms are drawn from
+	HistoryDays = 180
+
+	// ScoreDays is how far back transactions are scored; older ones were scored by
+	// earlier runs
+	ScoreDays = 30
+
+	// MinMerchantHistory is how many earlier charges from a merchant establish its norm
+	MinMerchantHistory = 4
+
+	// OutlierDeviations is how many standard deviations above a merchant's mean an amount
+	// must be to be unusual
+	OutlierDeviations = 3.0
+
+	// NewMerchantHistoryDays is how much history a user needs before a merchant counts as
+	// new rather than just not seen yet
+	NewMerchantHistoryDays = 60
+
+	// LargeAmount is the smallest first charge from a new merchant that is flagged; it is
+	// raised to LargeMultiple times the user's median charge if that is higher
+	LargeAmount   = 200.0
+	LargeMultiple = 3.0
+
+	// DuplicateWindow is how close in time two identical charges must be to be duplicates
+	DuplicateWindow = 10 * time.Minute
+
+	// SpikeMonths is how many previous months a category's usual spending is averaged
+	// over; SpikeRatio and SpikeMinimum are how far above it the month must go
+	SpikeMonths  = 3
+	SpikeRatio   = 1.5
+	SpikeMinimum = 100.0
+
+	// minHomeTransactions is how many transactions with a country establish the user's
+	// home country
+	minHomeTransactions = 5
+)
+
+// Detect scores the user's outflows from the last ScoreDays against the history in
+// transactions, which must be in date order, and returns those with at least one flag.
+// Pending transactions, transfers and those in exclude, such as reported fraud, are
+// neither scored nor counted in norms.
+func Detect(transactions []*models.Transaction, accounts map[uuid.UUID]*models.Account, exclude map[uuid.UUID]bool, today time.Time) []*models.Anomaly {
+	var eligible []*models.Transaction
+	for _, t := range transactions {
+		if !t.Pending && !t.IsTransfer && !exclude[t.ID] {
+			eligible = append(eligible, t)
+		}
+	}
+	if len(eligible) == 0 {
+		return nil
+	}
+
+	since := today.AddDate(0, 0, -ScoreDays)
+	start := eligible[0].Date
+	home := homeCountry(eligible)
+	large := math.Max(LargeAmount, LargeMultiple*medianOutflow(eligible))
+	spikes := categorySpikes(eligible, start, since)
+
+	var anomalies []*models.Anomaly
+	byMerchant := make(map[string][]*models.Transaction)
+	for _, t := range eligible {
+		if t.Amount <= 0 {
+			continue
+		}
+		key := merchantKey(t)
+		previous := byMerchant[key]
+		byMerchant[key] = append(previous, t)
+		if t.Date.Before(since) {
+			continue
+		}
+
+		var flags []models.AnomalyFlag
+		if flag, ok := unusualAmount(t, previous); ok {
+			flags = append(flags, flag)
+		}
+		if flag, ok := newMerchant(t, previous, start, large); ok {
+			flags = append(flags, flag)
+		}
+		if flag, ok := duplicate(t, previous); ok {
+			flags = append(flags, flag)
+		}
+		if flag, ok := foreign(t, accounts[t.AccountID], home); ok {
+			flags = append(flags, flag)
+		}
+		if flag, ok := spikes[t.ID]; ok {
+			flags = append(flags, flag)
+		}
+		if len(flags) > 0 {
+			anomalies = append(anomalies, models.NewAnomaly(t, Score(flags), flags))
+		}
+	}
+	return anomalies
+}
+
+// Score combines flag severities as independent probabilities, so each additional flag
+// raises the score without exceeding 1
+func Score(flags []models.AnomalyFlag) float64 {
+	unflagged := 1.0
+	for _, flag := range flags {
+		unflagged *= 1 - flag.Score
+	}
+	return round(1 - unflagged)
+}
+
+// unusualAmount flags a charge more than OutlierDeviations standard deviations above the
+// mean of the merchant's earlier charges
+func unusualAmount(t *models.Transaction, previous []*models.Transaction) (models.AnomalyFlag, bool) {
+	if len(previous) < MinMerchantHistory {
+		return models.AnomalyFlag{}, false
+	}
+	var sum, squares float64
+	for _, p := range previous {
+		sum += p.Amount
+	}
+	mean := sum / float64(len(previous))
+	for _, p := range previous {
+		squares += (p.Amount - mean) * (p.Amount - mean)
+	}
+	// A merchant that always charges the same amount would otherwise make every change
+	// infinitely unusual
+	deviation := math.Max(math.Sqrt(squares/float64(len(previous))), math.Max(0.1*mean, 1))
+
+	deviations := (t.Amount - mean) / deviation
+	if deviations < OutlierDeviations {
+		return models.AnomalyFlag{}, false
+	}
+	return models.AnomalyFlag{
+		Kind:   models.AnomalyKindUnusualAmount,
+		Detail: fmt.Sprintf("%.2f is %.1f standard deviations above the usual %.2f at %s", t.Amount, deviations, mean, merchants.Name(t)),
+		Score:  severity(deviations, OutlierDeviations),
+	}, true
+}
+
+// newMerchant flags a large first charge from a merchant, once the user has enough
+// history for the merchant to be new
+func newMerchant(t *models.Transaction, previous []*models.Transaction, start time.Time, large float64) (models.AnomalyFlag, bool) {
+	if len(previous) > 0 || t.Amount < large || t.Date.Sub(start) < NewMerchantHistoryDays*24*time.Hour {
+		return models.AnomalyFlag{}, false
+	}
+	return models.AnomalyFlag{
+		Kind:   models.AnomalyKindNewMerchant,
+		Detail: fmt.Sprintf("First charge from %s is %.2f", merchants.Name(t), t.Amount),
+		Score:  severity(t.Amount, large),
+	}, true
+}
+
+// duplicate flags a charge identical to an earlier one from the same merchant on the same
+// account: within DuplicateWindow when both times are known, otherwise on the same day
+func duplicate(t *models.Transaction, previous []*models.Transaction) (models.AnomalyFlag, bool) {
+	for i := len(previous) - 1; i >= 0; i-- {
+		p := previous[i]
+		if p.Date.Before(t.Date.AddDate(0, 0, -1)) {
+			break
+		}
+		if p.AccountID != t.AccountID || models.ToMinorUnits(p.Amount) != models.ToMinorUnits(t.Amount) {
+			continue
+		}
+		if t.Datetime != nil && p.Datetime != nil {
+			apart := t.Datetime.Sub(*p.Datetime).Abs()
+			if apart <= DuplicateWindow {
+				return models.AnomalyFlag{
+					Kind:   models.AnomalyKindDuplicate,
+					Detail: fmt.Sprintf("Same %.2f charge from %s %d minutes apart", t.Amount, merchants.Name(t), int(apart.Minutes())),
+					Score:  0.8,
+				}, true
+			}
+			continue
+		}
+		if p.Date.Equal(t.Date) {
+			return models.AnomalyFlag{
+				Kind:   models.AnomalyKindDuplicate,
+				Detail: fmt.Sprintf("Same %.2f charge from %s twice on the same day", t.Amount, merchants.Name(t)),
+				Score:  0.5,
+			}, true
+		}
+	}
+	return models.AnomalyFlag{}, false
+}
+
+// foreign flags a charge in a currency other than the account's, or from a country other
+// than the user's home country
+func foreign(t *models.Transaction, account *models.Account, home string) (models.AnomalyFlag, bool) {
+	if account != nil && account.CurrencyCode != "" && t.IsoCurrencyCode != "" && !strings.EqualFold(account.CurrencyCode, t.IsoCurrencyCode) {
+		return models.AnomalyFlag{
+			Kind:   models.AnomalyKindForeign,
+			Detail: fmt.Sprintf("Charged in %s on a %s account", t.IsoCurrencyCode, account.CurrencyCode),
+			Score:  0.4,
+		}, true
+	}
+	if home != "" && t.Country != "" && !strings.EqualFold(home, t.Country) {
+		return models.AnomalyFlag{
+			Kind:   models.AnomalyKindForeign,
+			Detail: fmt.Sprintf("Charged in %s", t.Country),
+			Score:  0.3,
+		}, true
+	}
+	return models.AnomalyFlag{}, false
+}
+
+// categorySpikes flags, for each category and month since the given date, the charge
+// that took the month's spending past SpikeRatio times its average over the previous
+// SpikeMonths and at least SpikeMinimum above it. Months without that much history
+// before them are skipped.
+func categorySpikes(transactions []*models.Transaction, start, since time.Time) map[uuid.UUID]models.AnomalyFlag {
+	monthly := make(map[string]map[time.Time]int64)
+	for _, t := range transactions {
+		if t.Amount <= 0 {
+			continue
+		}
+		category := t.EffectiveCategory()
+		if monthly[category] == nil {
+			monthly[category] = make(map[time.Time]int64)
+		}
+		monthly[category][monthStart(t.Date)] += models.ToMinorUnits(t.Amount)
+	}
+
+	spikes := make(map[uuid.UUID]models.AnomalyFlag)
+	running := make(map[string]int64)
+	for _, t := range transactions {
+		if t.Amount <= 0 {
+			continue
+		}
+		category := t.EffectiveCategory()
+		month := monthStart(t.Date)
+		runningKey := category + "|" + month.Format("2006-01")
+		before := running[runningKey]
+		after := before + models.ToMinorUnits(t.Amount)
+		running[runningKey] = after
+		if t.Date.Before(since) || start.After(month.AddDate(0, -SpikeMonths, 0)) {
+			continue
+		}
+
+		var total int64
+		for k := 1; k <= SpikeMonths; k++ {
+			total += monthly[category][month.AddDate(0, -k, 0)]
+		}
+		usual := models.FromMinorUnits(total) / SpikeMonths
+		if usual <= 0 {
+			continue
+		}
+		threshold := math.Max(usual*SpikeRatio, usual+SpikeMinimum)
+		if models.FromMinorUnits(before) <= threshold && models.FromMinorUnits(after) > threshold {
+			spent := models.FromMinorUnits(after)
+			spikes[t.ID] = models.AnomalyFlag{
+				Kind:   models.AnomalyKindCategorySpike,
+				Detail: fmt.Sprintf("%s spending this month reached %.2f, %.1fx the usual %.2f", category, spent, spent/usual, usual),
+				Score:  severity(spent/usual, SpikeRatio),
+			}
+		}
+	}
+	return spikes
+}
+
+// merchantKey groups a transaction with the others from its merchant
+func merchantKey(t *models.Transaction) string {
+	if t.MerchantID != nil {
+		return t.MerchantID.String()
+	}
+	return merchants.Key(merchants.Name(t))
+}
+
+// homeCountry is the country most of the user's transactions come from, or empty if too
+// few have a country
+func homeCountry(transactions []*models.Transaction) string {
+	counts := make(map[string]int)
+	home := ""
+	for _, t := range transactions {
+		if t.Country == "" {
+			continue
+		}
+		country := strings.ToUpper(t.Country)
+		counts[country]++
+		if counts[country] > counts[home] || (counts[country] == counts[home] && country < home) {
+			home = country
+		}
+	}
+	if counts[home] < minHomeTransactions {
+		return ""
+	}
+	return home
+}
+
+// medianOutflow is the median amount of the user's charges
+func medianOutflow(transactions []*models.Transaction) float64 {
+	var amounts []float64
+	for _, t := range transactions {
+		if t.Amount > 0 {
+			amounts = append(amounts, t.Amount)
+		}
+	}
+	if len(amounts) == 0 {
+		return 0
+	}
+	sort.Float64s(amounts)
+	middle := len(amounts) / 2
+	if len(amounts)%2 == 0 {
+		return (amounts[middle-1] + amounts[middle]) / 2
+	}
+	return amounts[middle]
+}
+
+// severity scales how far a value is past its threshold to a flag score: 0.5 at the
+// threshold, rising to 1 at twice the threshold
+func severity(value, threshold float64) float64 {
+	return round(math.Min(1, 0.5*value/threshold))
+}
+
+// monthStart is the first day of the month of t
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// round rounds a score to two decimal places
+func round(score float64) float64 {
+	return math.Round(score*100) / 100
+}
@@ -0,0 +1,127 @@
+package anomalies
+
+import (
+	"testing"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// testUser builds transactions on a single USD account
+type testUser struct {
+	account      *models.Account
+	transactions []*models.Transaction
+}
+
+// newTestUser creates a testUser
+func newTestUser() *testUser {
+	account := models.NewManualAccount(uuid.New(), "Checking", "depository", "checking", "USD", models.BalanceModeManual, 0)
+	return &testUser{account: account}
+}
+
+// add appends a transaction, which must come in date order
+func (u *testUser) add(name, category string, amount float64, date time.Time) *models.Transaction {
+	t := &models.Transaction{
+		ID:              uuid.New(),
+		AccountID:       u.account.ID,
+		Name:            name,
+		Amount:          amount,
+		IsoCurrencyCode: "USD",
+		Country:         "US",
+		UserCategory:    category,
+		Date:            date,
+	}
+	u.transactions = append(u.transactions, t)
+	return t
+}
+
+// detect runs Detect and returns the flag kinds of each flagged transaction
+func (u *testUser) detect(today time.Time) map[uuid.UUID][]string {
+	accounts := map[uuid.UUID]*models.Account{u.account.ID: u.account}
+	kinds := make(map[uuid.UUID][]string)
+	for _, anomaly := range Detect(u.transactions, accounts, nil, today) {
+		for _, flag := range anomaly.Flags {
+			kinds[anomaly.TransactionID] = append(kinds[anomaly.TransactionID], flag.Kind)
+		}
+	}
+	return kinds
+}
+
+// TestDetect tests each kind of flag against a steady spending history
+func TestDetect(t *testing.T) {
+	today := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	user := newTestUser()
+	for day := HistoryDays; day > ScoreDays; day -= 7 {
+		date := today.AddDate(0, 0, -day)
+		user.add("SQ *BLUE BOTTLE 1234 SF", "Coffee", 5+float64(day%3), date)
+		user.add("SAFEWAY #1234", "Groceries", 80, date)
+	}
+
+	unusual := user.add("SQ *BLUE BOTTLE 0042 OAKLAND", "Coffee", 60, today.AddDate(0, 0, -10))
+	newMerchant := user.add("BEST BUY 00123", "Electronics", 899.99, today.AddDate(0, 0, -9))
+	first := user.add("SAFEWAY #1234", "Groceries", 80, today.AddDate(0, 0, -8))
+	second := user.add("SAFEWAY #1234", "Groceries", 80, today.AddDate(0, 0, -8))
+	abroad := user.add("CAFE DE FLORE", "Coffee", 12, today.AddDate(0, 0, -7))
+	abroad.Country = "FR"
+	abroad.IsoCurrencyCode = "EUR"
+	spike := user.add("SAFEWAY #1234", "Groceries", 600, today.AddDate(0, 0, -3))
+	pending := user.add("SAFEWAY #1234", "Groceries", 900, today.AddDate(0, 0, -1))
+	pending.Pending = true
+
+	kinds := user.detect(today)
+
+	assert.Equal(t, []string{models.AnomalyKindUnusualAmount}, kinds[unusual.ID])
+	assert.Equal(t, []string{models.AnomalyKindNewMerchant}, kinds[newMerchant.ID])
+	assert.Empty(t, kinds[first.ID])
+	assert.Equal(t, []string{models.AnomalyKindDuplicate}, kinds[second.ID])
+	assert.Equal(t, []string{models.AnomalyKindForeign}, kinds[abroad.ID])
+	assert.Equal(t, []string{models.AnomalyKindUnusualAmount, models.AnomalyKindCategorySpike}, kinds[spike.ID])
+	assert.Empty(t, kinds[pending.ID])
+}
+
+// TestDetectDuplicateTimes tests that charges with known times must be minutes apart
+func TestDetectDuplicateTimes(t *testing.T) {
+	today := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	at := func(t *models.Transaction, hour, minute int) {
+		datetime := t.Date.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+		t.Datetime = &datetime
+	}
+	user := newTestUser()
+	day := today.AddDate(0, 0, -2)
+	at(user.add("LYFT *RIDE", "Travel", 14.5, day), 8, 0)
+	lunch := user.add("LYFT *RIDE", "Travel", 14.5, day)
+	at(lunch, 12, 30)
+	repeat := user.add("LYFT *RIDE", "Travel", 14.5, day)
+	at(repeat, 12, 33)
+
+	kinds := user.detect(today)
+
+	assert.Empty(t, kinds[lunch.ID]) // Hours after the first ride
+	assert.Equal(t, []string{models.AnomalyKindDuplicate}, kinds[repeat.ID])
+}
+
+// TestDetectShortHistory tests that nothing is new to a user without enough history
+func TestDetectShortHistory(t *testing.T) {
+	today := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	user := newTestUser()
+	user.add("RENT PAYMENT", "Housing", 1800, today.AddDate(0, 0, -5))
+
+	assert.Empty(t, user.detect(today))
+}
+
+// TestScore tests combining flag severities
+func TestScore(t *testing.T) {
+	assert.Equal(t, 0.0, Score(nil))
+	assert.Equal(t, 0.5, Score([]models.AnomalyFlag{{Score: 0.5}}))
+	assert.Equal(t, 0.8, Score([]models.AnomalyFlag{{Score: 0.5}, {Score: 0.6}}))
+	assert.Equal(t, 1.0, Score([]models.AnomalyFlag{{Score: 1}, {Score: 0.3}}))
+}
+
+// TestSeverity tests scaling flag scores from the threshold
+func TestSeverity(t *testing.T) {
+	assert.Equal(t, 0.5, severity(3, 3))
+	assert.Equal(t, 0.75, severity(4.5, 3))
+	assert.Equal(t, 1.0, severity(10, 3))
+}
@@ -0,0 +1,56 @@
+package anomalies
+
+import (
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// Detector scores a user's recent transactions and stores the anomalies found
+type Detector struct {
+	repos *db.Repositories
+}
+
+// NewDetector creates a new Detector
+func NewDetector(repos *db.Repositories) *Detector {
+	return &Detector{repos: repos}
+}
+
+// DetectForUser scores the user's transactions from the last ScoreDays against their
+// history and stores the flagged ones, clearing open flags that no longer apply.
+// Transactions reported as fraud are left out of the history so they don't skew the
+// norms.
+func (d *Detector) DetectForUser(userID uuid.UUID) ([]*models.Anomaly, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	var transactions []*models.Transaction
+	err := d.repos.Transaction.ForEachByDateRange(userID, today.AddDate(0, 0, -HistoryDays), today, func(t *models.Transaction) error {
+		transactions = append(transactions, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	userAccounts, err := d.repos.Account.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	accounts := make(map[uuid.UUID]*models.Account, len(userAccounts))
+	for _, account := range userAccounts {
+		accounts[account.ID] = account
+	}
+
+	fraud, err := d.repos.Anomaly.GetFraudTransactionIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	anomalies := Detect(transactions, accounts, fraud, today)
+	if err := d.repos.Anomaly.Save(userID, today.AddDate(0, 0, -ScoreDays), anomalies); err != nil {
+		return nil, err
+	}
+	return anomalies, nil
+}
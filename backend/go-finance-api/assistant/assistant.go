@@ -0,0 +1,232 @@
+// Package assistant answers questions about a user's finances with a language model that
+// looks up the user's data through tools
+package assistant
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/llm"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// Feature is the name usage is recorded under
+const Feature = "assistant"
+
+// MaxToolRounds bounds how many times the model may call tools before it must answer
+const MaxToolRounds = 5
+
+// HistoryMessages is how many stored messages are sent back to the model as context
+const HistoryMessages = 40
+
+// titleLength is the number of characters of the first message used as a title
+const titleLength = 60
+
+// Stream event names
+const (
+	EventConversation = "conversation" // The conversation being answered in
+	EventDelta        = "delta"        // A piece of the reply text
+	EventToolCall     = "tool_call"    // The model is looking something up
+	EventDone         = "done"         // The reply is complete
+	EventError        = "error"        // The reply failed
+)
+
+// unansweredResult is stored as the result of a tool call that did not complete, so the
+// call is never left without an answer
+const unansweredResult = `{"error":"lookup did not complete"}`
+
+// systemPrompt instructs the model. %s is today's date.
+const systemPrompt = `You are a helpful personal finance assistant inside a budgeting app.
+Today is %s. Answer questions about the user's own accounts, transactions, budgets and bills.
+Always use the tools to look up numbers instead of guessing, and say so when the data does not answer the question.
+Transaction amounts are positive for money spent and negative for money received.
+Keep answers short and format amounts as currency.`
+
+// toolCaller runs the tools the model calls
+type toolCaller interface {
+	Call(call llm.ToolCall) (string, error)
+}
+
+// Assistant answers a user's messages with the model and their data
+type Assistant struct {
+	repos *db.Repositories
+	meter *llm.Meter
+	now   func() time.Time
+}
+
+// New creates a new Assistant
+func New(repos *db.Repositories, meter *llm.Meter) *Assistant {
+	return &Assistant{repos: repos, meter: meter, now: time.Now}
+}
+
+// Title derives a conversation title from its first message
+func Title(message string) string {
+	title := strings.Join(strings.Fields(message), " ")
+	if runes := []rune(title); len(runes) > titleLength {
+		title = strings.TrimSpace(string(runes[:titleLength])) + "…"
+	}
+	return title
+}
+
+// Reply answers text in a conversation, passing stream events to emit as the reply is
+// generated. The user's message, any tool calls and results, and the reply are stored
+// in the conversation, including whatever was produced before a failure.
+func (a *Assistant) Reply(ctx context.Context, conversation *models.Conversation, text string, emit func(event string, data interface{}) error) error {
+	stored, err := a.repos.Conversation.GetMessages(conversation.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load conversation: %w", err)
+	}
+
+	today := a.now().UTC().Truncate(24 * time.Hour)
+	messages := []llm.Message{{Role: llm.RoleSystem, Content: fmt.Sprintf(systemPrompt, today.Format("Monday, January 2, 2006"))}}
+	messages = append(messages, ToMessages(RecentHistory(stored, HistoryMessages))...)
+	messages = append(messages, llm.Message{Role: llm.RoleUser, Content: text})
+
+	added := []*models.ConversationMessage{models.NewConversationMessage(conversation.ID, llm.RoleUser, text)}
+	provider := a.meter.ForUser(conversation.UserID, Feature)
+	toolset := NewToolset(a.repos, conversation.UserID, today)
+	reply, err := run(ctx, provider, toolset, messages, &added, emit)
+	if saveErr := a.repos.Conversation.AppendMessages(conversation.ID, added); saveErr != nil && err == nil {
+		err = fmt.Errorf("failed to save conversation: %w", saveErr)
+	}
+	if err != nil {
+		return err
+	}
+	return emit(EventDone, map[string]interface{}{"message_id": reply.ID})
+}
+
+// run exchanges messages with the model, running the tools it calls, until it answers.
+// Every message produced is appended to added, including the partial reply of a stream
+// that fails. If a tool call fails, it and the calls after it are answered with
+// unansweredResult so added stays valid history.
+func run(ctx context.Context, provider llm.Provider, tools toolCaller, messages []llm.Message, added *[]*models.ConversationMessage, emit func(event string, data interface{}) error) (*models.ConversationMessage, error) {
+	conversationID := (*added)[0].ConversationID
+
+	for round := 0; ; round++ {
+		req := llm.ChatRequest{Messages: messages, Tools: Definitions()}
+		if round == MaxToolRounds {
+			req.Tools = nil // Out of lookups; the model has to answer with what it has
+		}
+		resp, err := provider.ChatStream(ctx, req, func(delta string) error {
+			return emit(EventDelta, map[string]interface{}{"text": delta})
+		})
+		if err != nil {
+			if resp != nil && resp.Message.Content != "" {
+				// Keep what was already streamed to the client
+				resp.Message.ToolCalls = nil
+				if partial, partialErr := FromMessage(conversationID, resp.Message); partialErr == nil {
+					*added = append(*added, partial)
+				}
+			}
+			return nil, err
+		}
+		if round == MaxToolRounds {
+			resp.Message.ToolCalls = nil // Calls that would never get results
+		}
+
+		reply, err := FromMessage(conversationID, resp.Message)
+		if err != nil {
+			return nil, err
+		}
+		*added = append(*added, reply)
+		messages = append(messages, resp.Message)
+		if len(resp.Message.ToolCalls) == 0 {
+			return reply, nil
+		}
+
+		for i, call := range resp.Message.ToolCalls {
+			result, err := callTool(tools, call, emit)
+			if err != nil {
+				for _, unanswered := range resp.Message.ToolCalls[i:] {
+					*added = append(*added, toolResult(conversationID, unanswered.ID, unansweredResult))
+				}
+				return nil, err
+			}
+			*added = append(*added, toolResult(conversationID, call.ID, result))
+			messages = append(messages, llm.Message{Role: llm.RoleTool, Content: result, ToolCallID: call.ID})
+		}
+	}
+}
+
+// callTool reports a tool call to the client and runs it
+func callTool(tools toolCaller, call llm.ToolCall, emit func(event string, data interface{}) error) (string, error) {
+	if err := emit(EventToolCall, map[string]interface{}{"name": call.Name, "arguments": call.Arguments}); err != nil {
+		return "", err
+	}
+	result, err := tools.Call(call)
+	if err != nil {
+		return "", fmt.Errorf("tool %s failed: %w", call.Name, err)
+	}
+	return result, nil
+}
+
+// toolResult creates the stored result of a tool call
+func toolResult(conversationID uuid.UUID, toolCallID, result string) *models.ConversationMessage {
+	stored := models.NewConversationMessage(conversationID, llm.RoleTool, result)
+	stored.ToolCallID = toolCallID
+	return stored
+}
+
+// RecentHistory returns up to limit of the most recent stored messages, starting at a
+// user message so tool results are never separated from the calls they answer
+func RecentHistory(stored []*models.ConversationMessage, limit int) []*models.ConversationMessage {
+	start := 0
+	if len(stored) > limit {
+		start = len(stored) - limit
+	}
+	for start < len(stored) && stored[start].Role != llm.RoleUser {
+		start++
+	}
+	return stored[start:]
+}
+
+// ToMessages converts stored messages to model messages. Tool calls without a stored
+// result are dropped, since the model rejects history with unanswered calls, along with
+// an assistant message left with neither calls nor text.
+func ToMessages(stored []*models.ConversationMessage) []llm.Message {
+	answered := make(map[string]bool)
+	for _, message := range stored {
+		if message.Role == llm.RoleTool {
+			answered[message.ToolCallID] = true
+		}
+	}
+
+	messages := make([]llm.Message, 0, len(stored))
+	for _, message := range stored {
+		converted := llm.Message{Role: message.Role, Content: message.Content, ToolCallID: message.ToolCallID}
+		if len(message.ToolCalls) > 0 {
+			var toolCalls []llm.ToolCall
+			// Stored by FromMessage, so this does not fail in practice
+			_ = json.Unmarshal(message.ToolCalls, &toolCalls)
+			for _, call := range toolCalls {
+				if answered[call.ID] {
+					converted.ToolCalls = append(converted.ToolCalls, call)
+				}
+			}
+			if len(converted.ToolCalls) == 0 && converted.Content == "" {
+				continue
+			}
+		}
+		messages = append(messages, converted)
+	}
+	return messages
+}
+
+// FromMessage converts a model message to a stored message
+func FromMessage(conversationID uuid.UUID, message llm.Message) (*models.ConversationMessage, error) {
+	stored := models.NewConversationMessage(conversationID, message.Role, message.Content)
+	stored.ToolCallID = message.ToolCallID
+	if len(message.ToolCalls) > 0 {
+		toolCalls, err := json.Marshal(message.ToolCalls)
+		if err != nil {
+			return nil, err
+		}
+		stored.ToolCalls = toolCalls
+	}
+	return stored, nil
+}
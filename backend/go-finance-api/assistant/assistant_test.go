@@ -0,0 +1,192 @@
+package assistant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/llm"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTitle tests deriving a title from the first message
+func TestTitle(t *testing.T) {
+	assert.Equal(t, "How much did I spend on groceries?", Title("  How much did I spend\non groceries?  "))
+
+	long := Title(strings.Repeat("word ", 30))
+	assert.True(t, strings.HasSuffix(long, "…"))
+	assert.LessOrEqual(t, len([]rune(long)), titleLength+1)
+}
+
+// TestRecentHistory tests trimming history so it starts at a user message
+func TestRecentHistory(t *testing.T) {
+	conversationID := uuid.New()
+	stored := []*models.ConversationMessage{
+		models.NewConversationMessage(conversationID, llm.RoleUser, "q1"),
+		models.NewConversationMessage(conversationID, llm.RoleAssistant, ""),
+		models.NewConversationMessage(conversationID, llm.RoleTool, "{}"),
+		models.NewConversationMessage(conversationID, llm.RoleAssistant, "a1"),
+		models.NewConversationMessage(conversationID, llm.RoleUser, "q2"),
+		models.NewConversationMessage(conversationID, llm.RoleAssistant, "a2"),
+	}
+
+	assert.Len(t, RecentHistory(stored, 10), 6)
+
+	recent := RecentHistory(stored, 4) // Would start at the tool result
+	require.Len(t, recent, 2)
+	assert.Equal(t, "q2", recent[0].Content)
+}
+
+// TestMessageConversion tests storing and restoring messages with tool calls
+func TestMessageConversion(t *testing.T) {
+	conversationID := uuid.New()
+	message := llm.Message{
+		Role:      llm.RoleAssistant,
+		ToolCalls: []llm.ToolCall{{ID: "call_1", Name: ToolListAccounts, Arguments: "{}"}},
+	}
+
+	stored, err := FromMessage(conversationID, message)
+	require.NoError(t, err)
+	assert.Equal(t, conversationID, stored.ConversationID)
+	assert.NotEmpty(t, stored.ToolCalls)
+
+	result := models.NewConversationMessage(conversationID, llm.RoleTool, `{"accounts":[]}`)
+	result.ToolCallID = "call_1"
+
+	restored := ToMessages([]*models.ConversationMessage{stored, result})
+	assert.Equal(t, message, restored[0])
+	assert.Equal(t, llm.Message{Role: llm.RoleTool, Content: `{"accounts":[]}`, ToolCallID: "call_1"}, restored[1])
+}
+
+// TestToMessagesDropsUnansweredCalls tests that history with a tool call that never got
+// a result is still valid for the model
+func TestToMessagesDropsUnansweredCalls(t *testing.T) {
+	conversationID := uuid.New()
+	calls, err := FromMessage(conversationID, llm.Message{
+		Role: llm.RoleAssistant,
+		ToolCalls: []llm.ToolCall{
+			{ID: "call_1", Name: ToolListAccounts, Arguments: "{}"},
+			{ID: "call_2", Name: ToolBudgetStatus, Arguments: "{}"},
+		},
+	})
+	require.NoError(t, err)
+	dangling, err := FromMessage(conversationID, llm.Message{
+		Role:      llm.RoleAssistant,
+		ToolCalls: []llm.ToolCall{{ID: "call_3", Name: ToolListAccounts, Arguments: "{}"}},
+	})
+	require.NoError(t, err)
+	result := models.NewConversationMessage(conversationID, llm.RoleTool, "{}")
+	result.ToolCallID = "call_1"
+
+	messages := ToMessages([]*models.ConversationMessage{
+		models.NewConversationMessage(conversationID, llm.RoleUser, "q1"),
+		calls,
+		result,
+		models.NewConversationMessage(conversationID, llm.RoleUser, "q2"),
+		dangling,
+	})
+	require.Len(t, messages, 4)
+	assert.Equal(t, []llm.ToolCall{{ID: "call_1", Name: ToolListAccounts, Arguments: "{}"}}, messages[1].ToolCalls)
+	assert.Equal(t, "q2", messages[3].Content)
+}
+
+// failingTools is a toolCaller whose lookups fail
+type failingTools struct {
+	calls int
+}
+
+func (f *failingTools) Call(call llm.ToolCall) (string, error) {
+	f.calls++
+	return "", errors.New("connection refused")
+}
+
+// TestRunToolFailure tests that every tool call is answered in the stored history when a
+// tool fails
+func TestRunToolFailure(t *testing.T) {
+	conversationID := uuid.New()
+	provider := llm.NewFakeProvider(&llm.ChatResponse{Message: llm.Message{
+		Role: llm.RoleAssistant,
+		ToolCalls: []llm.ToolCall{
+			{ID: "call_1", Name: ToolListAccounts, Arguments: "{}"},
+			{ID: "call_2", Name: ToolRecurringBills, Arguments: "{}"},
+		},
+	}})
+	tools := &failingTools{}
+	added := []*models.ConversationMessage{models.NewConversationMessage(conversationID, llm.RoleUser, "what do I have?")}
+	emit := func(event string, data interface{}) error { return nil }
+
+	_, err := run(context.Background(), provider, tools, []llm.Message{{Role: llm.RoleUser, Content: "what do I have?"}}, &added, emit)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "connection refused")
+	assert.Equal(t, 1, tools.calls)
+
+	require.Len(t, added, 4)
+	assert.Equal(t, llm.RoleAssistant, added[1].Role)
+	for i, id := range []string{"call_1", "call_2"} {
+		assert.Equal(t, llm.RoleTool, added[2+i].Role)
+		assert.Equal(t, id, added[2+i].ToolCallID)
+		assert.Equal(t, unansweredResult, added[2+i].Content)
+	}
+	assert.Len(t, ToMessages(added)[1].ToolCalls, 2)
+}
+
+// TestRunStreamFailure tests that the text streamed before a failure is kept in history
+func TestRunStreamFailure(t *testing.T) {
+	conversationID := uuid.New()
+	provider := llm.NewFakeProvider(&llm.ChatResponse{Message: llm.Message{
+		Content:   "You spent 42 dollars on coffee",
+		ToolCalls: []llm.ToolCall{{ID: "call_1", Name: ToolListAccounts, Arguments: "{}"}},
+	}})
+	added := []*models.ConversationMessage{models.NewConversationMessage(conversationID, llm.RoleUser, "coffee?")}
+	deltas := 0
+	emit := func(event string, data interface{}) error {
+		if deltas++; deltas > 3 {
+			return errors.New("client went away")
+		}
+		return nil
+	}
+
+	_, err := run(context.Background(), provider, &failingTools{}, []llm.Message{{Role: llm.RoleUser, Content: "coffee?"}}, &added, emit)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "client went away")
+
+	require.Len(t, added, 2)
+	assert.Equal(t, llm.RoleAssistant, added[1].Role)
+	assert.Equal(t, "You spent 42 ", added[1].Content)
+	assert.Empty(t, added[1].ToolCalls)
+}
+
+// TestDefinitions tests that every tool has a valid schema without a user parameter
+func TestDefinitions(t *testing.T) {
+	for _, tool := range Definitions() {
+		var schema struct {
+			Properties map[string]interface{} `json:"properties"`
+		}
+		require.NoError(t, json.Unmarshal(tool.Parameters, &schema), tool.Name)
+		assert.NotContains(t, schema.Properties, "user_id", tool.Name)
+	}
+}
+
+// TestCallRejectsBadArguments tests that invalid calls are reported to the model before
+// any data is read
+func TestCallRejectsBadArguments(t *testing.T) {
+	toolset := NewToolset(nil, uuid.New(), time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC))
+
+	result, err := toolset.Call(llm.ToolCall{Name: ToolListAccounts, Arguments: `{"user_id":"someone-else"}`})
+	require.NoError(t, err)
+	assert.Contains(t, result, "unknown field")
+
+	result, err = toolset.Call(llm.ToolCall{Name: ToolSumByCategory, Arguments: `{"from":"March"}`})
+	require.NoError(t, err)
+	assert.Contains(t, result, "YYYY-MM-DD")
+
+	result, err = toolset.Call(llm.ToolCall{Name: "drop_tables", Arguments: `{}`})
+	require.NoError(t, err)
+	assert.Contains(t, result, "unknown tool")
+}
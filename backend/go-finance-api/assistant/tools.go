@@ -0,0 +1,392 @@
+package assistant
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/bills"
+	"github.com/davidwang/go-finance-api/go-finance-api/budgets"
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/llm"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// Search result limits
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
+// Tool names
+const (
+	ToolSearchTransactions = "search_transactions"
+	ToolSumByCategory      = "sum_by_category"
+	ToolListAccounts       = "list_accounts"
+	ToolBudgetStatus       = "budget_status"
+	ToolRecurringBills     = "recurring_bills"
+)
+
+// definitions describes the tools to the model. None of them take a user: every tool
+// runs against the authenticated user's data.
+var definitions = []llm.Tool{
+	{
+		Name:        ToolSearchTransactions,
+		Description: "Search the user's transactions, newest first. Amounts are positive for money spent and negative for money received.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"text": {"type": "string", "description": "Words in the transaction or merchant name"},
+				"category": {"type": "string"},
+				"account_id": {"type": "string", "description": "An account ID from list_accounts"},
+				"from": {"type": "string", "description": "Start date, YYYY-MM-DD"},
+				"to": {"type": "string", "description": "End date, YYYY-MM-DD"},
+				"min_amount": {"type": "number"},
+				"max_amount": {"type": "number"},
+				"limit": {"type": "integer", "description": "At most 50, default 20"}
+			},
+			"additionalProperties": false
+		}`),
+	},
+	{
+		Name:        ToolSumByCategory,
+		Description: "Total the user's spending per category between two dates, excluding transfers. Positive totals are net spending.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"from": {"type": "string", "description": "Start date, YYYY-MM-DD; default 30 days ago"},
+				"to": {"type": "string", "description": "End date, YYYY-MM-DD; default today"}
+			},
+			"additionalProperties": false
+		}`),
+	},
+	{
+		Name:        ToolListAccounts,
+		Description: "List the user's accounts with their current balances.",
+		Parameters:  json.RawMessage(`{"type": "object", "properties": {}, "additionalProperties": false}`),
+	},
+	{
+		Name:        ToolBudgetStatus,
+		Description: "Show the user's budgets for a month with what has been spent and what remains in each category.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"month": {"type": "string", "description": "YYYY-MM; default this month"}
+			},
+			"additionalProperties": false
+		}`),
+	},
+	{
+		Name:        ToolRecurringBills,
+		Description: "List the user's upcoming bills, subscriptions and card payments.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"days": {"type": "integer", "description": "How many days ahead to look, at most 365, default 30"}
+			},
+			"additionalProperties": false
+		}`),
+	},
+}
+
+// Definitions returns the tools the assistant offers the model
+func Definitions() []llm.Tool {
+	return definitions
+}
+
+// Toolset runs tools against one user's data. The user is fixed when the Toolset is
+// created from the authenticated request, so the model cannot reach another user's data.
+type Toolset struct {
+	repos  *db.Repositories
+	userID uuid.UUID
+	today  time.Time
+}
+
+// NewToolset creates a Toolset for userID
+func NewToolset(repos *db.Repositories, userID uuid.UUID, today time.Time) *Toolset {
+	return &Toolset{repos: repos, userID: userID, today: today}
+}
+
+// Call runs a tool call and returns its result as JSON. Invalid arguments or unknown
+// tools are reported to the model in the result; only failures reading the user's data
+// are returned as errors.
+func (t *Toolset) Call(call llm.ToolCall) (string, error) {
+	var result interface{}
+	var err error
+	switch call.Name {
+	case ToolSearchTransactions:
+		result, err = t.searchTransactions(call.Arguments)
+	case ToolSumByCategory:
+		result, err = t.sumByCategory(call.Arguments)
+	case ToolListAccounts:
+		result, err = t.listAccounts(call.Arguments)
+	case ToolBudgetStatus:
+		result, err = t.budgetStatus(call.Arguments)
+	case ToolRecurringBills:
+		result, err = t.recurringBills(call.Arguments)
+	default:
+		err = argumentError{fmt.Errorf("unknown tool %q", call.Name)}
+	}
+	if err != nil {
+		if _, ok := err.(argumentError); !ok {
+			return "", err
+		}
+		result = map[string]string{"error": err.Error()}
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// argumentError is a problem with a tool call's arguments, reported back to the model
+type argumentError struct {
+	err error
+}
+
+func (e argumentError) Error() string {
+	return e.err.Error()
+}
+
+// decodeArguments strictly decodes a tool call's JSON arguments into v. Unknown fields,
+// such as a user ID, are rejected.
+func decodeArguments(arguments string, v interface{}) error {
+	if arguments == "" {
+		arguments = "{}"
+	}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(arguments)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return argumentError{fmt.Errorf("invalid arguments: %v", err)}
+	}
+	return nil
+}
+
+// parseDate parses an optional YYYY-MM-DD argument
+func parseDate(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, argumentError{fmt.Errorf("%s must be a date in YYYY-MM-DD format", name)}
+	}
+	return &date, nil
+}
+
+// transactionResult is the compact form of a transaction shown to the model
+type transactionResult struct {
+	ID       uuid.UUID `json:"id"`
+	Date     string    `json:"date"`
+	Name     string    `json:"name"`
+	Merchant string    `json:"merchant,omitempty"`
+	Amount   float64   `json:"amount"`
+	Category string    `json:"category"`
+	Account  string    `json:"account"`
+	Pending  bool      `json:"pending,omitempty"`
+}
+
+func (t *Toolset) searchTransactions(arguments string) (interface{}, error) {
+	var args struct {
+		Text      string   `json:"text"`
+		Category  string   `json:"category"`
+		AccountID string   `json:"account_id"`
+		From      string   `json:"from"`
+		To        string   `json:"to"`
+		MinAmount *float64 `json:"min_amount"`
+		MaxAmount *float64 `json:"max_amount"`
+		Limit     int      `json:"limit"`
+	}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	filter := models.TransactionFilter{
+		Text:      args.Text,
+		MinAmount: args.MinAmount,
+		MaxAmount: args.MaxAmount,
+	}
+	if args.Category != "" {
+		filter.Categories = []string{args.Category}
+	}
+	var err error
+	if filter.From, err = parseDate("from", args.From); err != nil {
+		return nil, err
+	}
+	if filter.To, err = parseDate("to", args.To); err != nil {
+		return nil, err
+	}
+	if args.AccountID != "" {
+		accountID, err := uuid.Parse(args.AccountID)
+		if err != nil {
+			return nil, argumentError{fmt.Errorf("account_id must be an account ID from list_accounts")}
+		}
+		filter.AccountID = &accountID
+	}
+	limit := args.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	} else if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	transactions, err := t.repos.Transaction.Search(t.userID, filter, limit)
+	if err != nil {
+		return nil, err
+	}
+	names, err := t.accountNames()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]transactionResult, 0, len(transactions))
+	for _, transaction := range transactions {
+		results = append(results, transactionResult{
+			ID:       transaction.ID,
+			Date:     transaction.Date.Format("2006-01-02"),
+			Name:     transaction.DisplayName(),
+			Merchant: transaction.MerchantName,
+			Amount:   transaction.Amount,
+			Category: transaction.EffectiveCategory(),
+			Account:  names[transaction.AccountID],
+			Pending:  transaction.Pending,
+		})
+	}
+	return map[string]interface{}{"transactions": results, "count": len(results)}, nil
+}
+
+func (t *Toolset) sumByCategory(arguments string) (interface{}, error) {
+	var args struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	from, to := t.today.AddDate(0, 0, -30), t.today
+	if date, err := parseDate("from", args.From); err != nil {
+		return nil, err
+	} else if date != nil {
+		from = *date
+	}
+	if date, err := parseDate("to", args.To); err != nil {
+		return nil, err
+	} else if date != nil {
+		to = *date
+	}
+	if to.Before(from) {
+		return nil, argumentError{fmt.Errorf("from must not be after to")}
+	}
+
+	totals, err := t.repos.Transaction.SumByCategory(t.userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if totals == nil {
+		totals = []*models.CategoryTotal{}
+	}
+	return map[string]interface{}{
+		"from":       from.Format("2006-01-02"),
+		"to":         to.Format("2006-01-02"),
+		"categories": totals,
+	}, nil
+}
+
+// accountResult is the compact form of an account shown to the model
+type accountResult struct {
+	ID               uuid.UUID `json:"id"`
+	Name             string    `json:"name"`
+	Type             string    `json:"type"`
+	Subtype          string    `json:"subtype,omitempty"`
+	Mask             string    `json:"mask,omitempty"`
+	CurrentBalance   float64   `json:"current_balance"`
+	AvailableBalance float64   `json:"available_balance"`
+	CurrencyCode     string    `json:"currency_code"`
+}
+
+func (t *Toolset) listAccounts(arguments string) (interface{}, error) {
+	var args struct{}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	accounts, err := t.repos.Account.GetByUserID(t.userID)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]accountResult, 0, len(accounts))
+	for _, account := range accounts {
+		results = append(results, accountResult{
+			ID:               account.ID,
+			Name:             account.Name,
+			Type:             account.Type,
+			Subtype:          account.Subtype,
+			Mask:             account.Mask,
+			CurrentBalance:   account.CurrentBalance,
+			AvailableBalance: account.AvailableBalance,
+			CurrencyCode:     account.CurrencyCode,
+		})
+	}
+	return map[string]interface{}{"accounts": results}, nil
+}
+
+func (t *Toolset) budgetStatus(arguments string) (interface{}, error) {
+	var args struct {
+		Month string `json:"month"`
+	}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	month := budgets.MonthStart(t.today)
+	if args.Month != "" {
+		parsed, err := budgets.ParseMonth(args.Month)
+		if err != nil {
+			return nil, argumentError{err}
+		}
+		month = parsed
+	}
+	return budgets.NewService(t.repos).Report(t.userID, month)
+}
+
+func (t *Toolset) recurringBills(arguments string) (interface{}, error) {
+	var args struct {
+		Days int `json:"days"`
+	}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	days := args.Days
+	if days <= 0 {
+		days = 30
+	} else if days > 365 {
+		days = 365
+	}
+	upcoming, err := bills.NewService(t.repos).Upcoming(t.userID, t.today, t.today.AddDate(0, 0, days))
+	if err != nil {
+		return nil, err
+	}
+	if upcoming == nil {
+		upcoming = []*models.Bill{}
+	}
+	return map[string]interface{}{"bills": upcoming}, nil
+}
+
+// accountNames maps the user's account IDs to their names
+func (t *Toolset) accountNames() (map[uuid.UUID]string, error) {
+	accounts, err := t.repos.Account.GetByUserID(t.userID)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uuid.UUID]string, len(accounts))
+	for _, account := range accounts {
+		names[account.ID] = account.Name
+	}
+	return names, nil
+}
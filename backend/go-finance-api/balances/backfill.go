@@ -0,0 +1,63 @@
+// Package balances reconstructs and aggregates account balance history
+package balances
+
+import (
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+)
+
+// Reconstruct walks backward from an account's balance at the end of asOf and returns
+// its end-of-day balance for every day from..asOf, newest first. dailyNet holds the net
+// posted transaction amount per day (keyed YYYY-MM-DD, Plaid sign convention). Outflows
+// lower an asset's balance but raise the amount owed on a credit card or loan, so
+// undoing a day's transactions moves the balance the opposite way.
+func Reconstruct(account *models.Account, asOf, from time.Time, dailyNet map[string]float64) []*models.BalanceSnapshot {
+	sign := int64(-1)
+	if account.IsLiability() {
+		sign = 1
+	}
+
+	balance := models.ToMinorUnits(account.CurrentBalance)
+	var snapshots []*models.BalanceSnapshot
+	for day := asOf; !day.Before(from); day = day.AddDate(0, 0, -1) {
+		snapshots = append(snapshots, models.NewBalanceSnapshot(
+			account, day, models.FromMinorUnits(balance), models.SnapshotSourceBackfill,
+		))
+		balance -= sign * models.ToMinorUnits(dailyNet[day.Format("2006-01-02")])
+	}
+	return snapshots
+}
+
+// Backfiller fills gaps in balance history from transaction history
+type Backfiller struct {
+	repos *db.Repositories
+}
+
+// NewBackfiller creates a new Backfiller
+func NewBackfiller(repos *db.Repositories) *Backfiller {
+	return &Backfiller{repos: repos}
+}
+
+// BackfillAccount reconstructs the account's daily balances for the past days from its
+// current balance and stores those not already recorded. It returns the number of
+// snapshots written.
+func (b *Backfiller) BackfillAccount(account *models.Account, days int) (int, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from := today.AddDate(0, 0, -days)
+
+	// Transactions on from itself happened before its end-of-day balance, so they aren't needed
+	dailyNet := make(map[string]float64)
+	err := b.repos.Transaction.ForEachByAccountAndDateRange(account.ID, from.AddDate(0, 0, 1), today, func(t *models.Transaction) error {
+		if !t.Pending {
+			dailyNet[t.Date.Format("2006-01-02")] += t.Amount
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return b.repos.BalanceSnapshot.CreateMissing(Reconstruct(account, today, from, dailyNet))
+}
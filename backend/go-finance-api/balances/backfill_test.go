@@ -0,0 +1,47 @@
+package balances
+
+import (
+	"testing"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReconstructAsset tests walking a checking account backward
+func TestReconstructAsset(t *testing.T) {
+	account := models.NewManualAccount(uuid.New(), "Checking", "depository", "checking", "USD", models.BalanceModeManual, 1000)
+	dailyNet := map[string]float64{
+		"2024-03-10": 50.25,   // Spent on the 10th
+		"2024-03-09": -500.00, // Paid on the 9th
+	}
+
+	snapshots := Reconstruct(account, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC), dailyNet)
+
+	require.Len(t, snapshots, 4)
+	assert.Equal(t, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), snapshots[0].Date)
+	assert.Equal(t, 1000.00, snapshots[0].CurrentBalance)
+	assert.Equal(t, 1050.25, snapshots[1].CurrentBalance)
+	assert.Equal(t, 550.25, snapshots[2].CurrentBalance)
+	assert.Equal(t, 550.25, snapshots[3].CurrentBalance)
+	assert.Equal(t, models.SnapshotSourceBackfill, snapshots[3].Source)
+	assert.Nil(t, snapshots[3].AvailableBalance)
+}
+
+// TestReconstructLiability tests that card purchases are undone by lowering the amount owed
+func TestReconstructLiability(t *testing.T) {
+	account := models.NewManualAccount(uuid.New(), "Card", "credit", "credit card", "USD", models.BalanceModeManual, 300)
+	dailyNet := map[string]float64{
+		"2024-03-10": 100,  // Purchase
+		"2024-03-09": -200, // Payment
+	}
+
+	snapshots := Reconstruct(account, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC), dailyNet)
+
+	require.Len(t, snapshots, 3)
+	assert.Equal(t, 300.0, snapshots[0].CurrentBalance)
+	assert.Equal(t, 200.0, snapshots[1].CurrentBalance)
+	assert.Equal(t, 400.0, snapshots[2].CurrentBalance)
+}
@@ -0,0 +1,103 @@
+package balances
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// PrimaryCurrency returns the currency most of the accounts are held in, breaking ties
+// alphabetically. Accounts without a currency code count as DefaultCurrency.
+func PrimaryCurrency(accounts []*models.Account) string {
+	counts := make(map[string]int)
+	for _, account := range accounts {
+		counts[accountCurrency(account)]++
+	}
+	primary := models.DefaultCurrency
+	for currency, n := range counts {
+		if n > counts[primary] || (n == counts[primary] && currency < primary) {
+			primary = currency
+		}
+	}
+	return primary
+}
+
+// InCurrency splits accounts into those held in currency and the rest. Balances in
+// different currencies can't be added up, so net worth is computed over one currency at
+// a time.
+func InCurrency(accounts []*models.Account, currency string) (matching, other []*models.Account) {
+	for _, account := range accounts {
+		if strings.EqualFold(accountCurrency(account), currency) {
+			matching = append(matching, account)
+		} else {
+			other = append(other, account)
+		}
+	}
+	return matching, other
+}
+
+// accountCurrency returns an account's currency code, or DefaultCurrency if it has none
+func accountCurrency(account *models.Account) string {
+	if account.CurrencyCode == "" {
+		return models.DefaultCurrency
+	}
+	return strings.ToUpper(account.CurrencyCode)
+}
+
+// NetWorth computes net worth for every day from..to as assets minus liabilities. Each
+// account contributes its latest snapshot on or before the day; accounts without one yet
+// contribute nothing. Plaid reports credit card and loan balances as positive amounts
+// owed, so those are subtracted. Snapshots may include ones from before from, and
+// snapshots of accounts not in accounts are ignored. The accounts must share a currency;
+// see InCurrency.
+func NetWorth(accounts []*models.Account, snapshots []*models.BalanceSnapshot, from, to time.Time) []*models.NetWorthPoint {
+	byID := make(map[uuid.UUID]*models.Account, len(accounts))
+	for _, account := range accounts {
+		byID[account.ID] = account
+	}
+
+	sorted := append([]*models.BalanceSnapshot(nil), snapshots...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	latest := make(map[uuid.UUID]int64) // Balance in minor units per account
+	next := 0
+	var points []*models.NetWorthPoint
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		for ; next < len(sorted) && !sorted[next].Date.After(day); next++ {
+			if _, ok := byID[sorted[next].AccountID]; ok {
+				latest[sorted[next].AccountID] = models.ToMinorUnits(sorted[next].CurrentBalance)
+			}
+		}
+
+		var assets, liabilities int64
+		byType := make(map[string]int64)
+		for accountID, balance := range latest {
+			account := byID[accountID]
+			if account.IsLiability() {
+				liabilities += balance
+				byType[account.Type] -= balance
+			} else {
+				assets += balance
+				byType[account.Type] += balance
+			}
+		}
+
+		point := &models.NetWorthPoint{
+			Date:        day,
+			Assets:      models.FromMinorUnits(assets),
+			Liabilities: models.FromMinorUnits(liabilities),
+			NetWorth:    models.FromMinorUnits(assets - liabilities),
+			ByType:      make(map[string]float64, len(byType)),
+		}
+		for accountType, total := range byType {
+			point.ByType[accountType] = models.FromMinorUnits(total)
+		}
+		points = append(points, point)
+	}
+	return points
+}
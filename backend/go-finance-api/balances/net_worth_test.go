@@ -0,0 +1,78 @@
+package balances
+
+import (
+	"testing"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNetWorth tests carrying balances forward and subtracting debts
+func TestNetWorth(t *testing.T) {
+	userID := uuid.New()
+	checking := models.NewManualAccount(userID, "Checking", "depository", "checking", "USD", models.BalanceModeManual, 0)
+	card := models.NewManualAccount(userID, "Card", "credit", "credit card", "USD", models.BalanceModeManual, 0)
+	mortgage := models.NewManualAccount(userID, "Mortgage", "loan", "mortgage", "USD", models.BalanceModeManual, 0)
+	brokerage := models.NewManualAccount(userID, "Brokerage", "investment", "brokerage", "USD", models.BalanceModeManual, 0)
+
+	snapshot := func(account *models.Account, date time.Time, balance float64) *models.BalanceSnapshot {
+		return models.NewBalanceSnapshot(account, date, balance, models.SnapshotSourceRefresh)
+	}
+	snapshots := []*models.BalanceSnapshot{
+		snapshot(checking, time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC), 1500),
+		snapshot(checking, time.Date(2024, 2, 20, 0, 0, 0, 0, time.UTC), 1000), // Before the range; carried forward
+		snapshot(card, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), 200),
+		snapshot(mortgage, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 100000),
+		snapshot(brokerage, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 50000),
+	}
+	accounts := []*models.Account{checking, card, mortgage, brokerage}
+
+	points := NetWorth(accounts, snapshots, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC))
+
+	require.Len(t, points, 3)
+	assert.Equal(t, 51000.0, points[0].Assets)
+	assert.Equal(t, 100000.0, points[0].Liabilities)
+	assert.Equal(t, -49000.0, points[0].NetWorth)
+
+	assert.Equal(t, -49200.0, points[1].NetWorth)
+	assert.Equal(t, -200.0, points[1].ByType["credit"])
+
+	assert.Equal(t, time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC), points[2].Date)
+	assert.Equal(t, -48700.0, points[2].NetWorth)
+	assert.Equal(t, map[string]float64{
+		"depository": 1500,
+		"credit":     -200,
+		"loan":       -100000,
+		"investment": 50000,
+	}, points[2].ByType)
+}
+
+// TestInCurrency tests choosing the primary currency and leaving out other currencies
+func TestInCurrency(t *testing.T) {
+	userID := uuid.New()
+	checking := models.NewManualAccount(userID, "Checking", "depository", "checking", "USD", models.BalanceModeManual, 0)
+	savings := models.NewManualAccount(userID, "Savings", "depository", "savings", "usd", models.BalanceModeManual, 0)
+	euro := models.NewManualAccount(userID, "Girokonto", "depository", "checking", "EUR", models.BalanceModeManual, 0)
+	unknown := &models.Account{ID: uuid.New(), Name: "Wallet", Type: "depository"}
+	accounts := []*models.Account{checking, euro, savings, unknown}
+
+	assert.Equal(t, "USD", PrimaryCurrency(accounts))
+	assert.Equal(t, "EUR", PrimaryCurrency([]*models.Account{euro}))
+	assert.Equal(t, models.DefaultCurrency, PrimaryCurrency(nil))
+
+	matching, other := InCurrency(accounts, "USD")
+	assert.Equal(t, []*models.Account{checking, savings, unknown}, matching)
+	assert.Equal(t, []*models.Account{euro}, other)
+
+	// Euro balances don't count towards a dollar net worth
+	snapshots := []*models.BalanceSnapshot{
+		models.NewBalanceSnapshot(checking, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 1000, models.SnapshotSourceRefresh),
+		models.NewBalanceSnapshot(euro, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 5000, models.SnapshotSourceRefresh),
+	}
+	points := NetWorth(matching, snapshots, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	require.Len(t, points, 1)
+	assert.Equal(t, 1000.0, points[0].NetWorth)
+}
@@ -0,0 +1,135 @@
+// Package bills lists upcoming payments and publishes them as an iCalendar feed
+package bills
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/davidwang/go-finance-api/go-finance-api/recurring"
+	"github.com/google/uuid"
+)
+
+// Upcoming lists the bills expected from..to: every expected charge of the active
+// recurring outflows, and the payments due on credit cards and loans. A liability
+// without a minimum payment is expected to be paid in full.
+func Upcoming(series []*models.RecurringSeries, accounts []*models.Account, from, to time.Time) []*models.Bill {
+	byID := make(map[uuid.UUID]*models.Account, len(accounts))
+	for _, account := range accounts {
+		byID[account.ID] = account
+	}
+
+	var bills []*models.Bill
+	for _, s := range series {
+		if s.IsIncome() || s.Status != models.RecurringStatusActive {
+			continue
+		}
+		var account *models.Account
+		if s.AccountID != nil {
+			account = byID[*s.AccountID]
+		}
+		seriesID := s.ID
+		for _, date := range recurring.Dates(s, to) {
+			if date.Before(from) {
+				continue
+			}
+			bill := &models.Bill{
+				Date:     date,
+				Name:     s.MerchantName,
+				Amount:   s.AverageAmount,
+				Category: s.Category,
+				Kind:     models.BillKindRecurring,
+				SeriesID: &seriesID,
+			}
+			setAccount(bill, account)
+			bills = append(bills, bill)
+		}
+	}
+
+	for _, account := range accounts {
+		if !account.IsLiability() || account.PaymentDueDate == nil {
+			continue
+		}
+		due := *account.PaymentDueDate
+		if due.Before(from) || due.After(to) {
+			continue
+		}
+		amount := account.CurrentBalance
+		if account.MinimumPayment != nil {
+			amount = *account.MinimumPayment
+		}
+		bill := &models.Bill{
+			Date:   due,
+			Name:   account.Name + " payment",
+			Amount: amount,
+			Kind:   models.BillKindLiability,
+		}
+		setAccount(bill, account)
+		bills = append(bills, bill)
+	}
+
+	sort.SliceStable(bills, func(i, j int) bool {
+		if !bills[i].Date.Equal(bills[j].Date) {
+			return bills[i].Date.Before(bills[j].Date)
+		}
+		return bills[i].Name < bills[j].Name
+	})
+	return bills
+}
+
+// setAccount records the account a bill is paid from, or for
+func setAccount(bill *models.Bill, account *models.Account) {
+	bill.CurrencyCode = "USD"
+	if account == nil {
+		return
+	}
+	accountID := account.ID
+	bill.AccountID = &accountID
+	bill.AccountName = account.Name
+	if account.CurrencyCode != "" {
+		bill.CurrencyCode = account.CurrencyCode
+	}
+}
+
+// NewFeedToken generates a random calendar feed token and the hash stored for it
+func NewFeedToken() (token, tokenHash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(b)
+	return token, HashFeedToken(token), nil
+}
+
+// HashFeedToken returns the hash stored for a calendar feed token
+func HashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Service lists a user's upcoming bills from stored data
+type Service struct {
+	repos *db.Repositories
+}
+
+// NewService creates a new Service
+func NewService(repos *db.Repositories) *Service {
+	return &Service{repos: repos}
+}
+
+// Upcoming lists the user's bills expected from..to
+func (s *Service) Upcoming(userID uuid.UUID, from, to time.Time) ([]*models.Bill, error) {
+	series, err := s.repos.Recurring.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := s.repos.Account.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return Upcoming(series, accounts, from, to), nil
+}
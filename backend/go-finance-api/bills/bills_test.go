@@ -0,0 +1,99 @@
+package bills
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSeries builds an active recurring series charged to account
+func newTestSeries(account *models.Account, name, frequency string, amount float64, next time.Time) *models.RecurringSeries {
+	accountID := account.ID
+	return &models.RecurringSeries{
+		ID:            uuid.New(),
+		AccountID:     &accountID,
+		MerchantName:  name,
+		Category:      "Service",
+		Frequency:     frequency,
+		AverageAmount: amount,
+		NextDate:      next,
+		Status:        models.RecurringStatusActive,
+	}
+}
+
+// TestUpcoming tests expanding recurring series and adding liability payments
+func TestUpcoming(t *testing.T) {
+	userID := uuid.New()
+	checking := models.NewManualAccount(userID, "Checking", "depository", "checking", "USD", models.BalanceModeManual, 1000)
+	card := models.NewManualAccount(userID, "Visa", "credit", "credit card", "USD", models.BalanceModeManual, 640)
+	due := time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)
+	card.PaymentDueDate = &due
+	loan := models.NewManualAccount(userID, "Car loan", "loan", "auto", "USD", models.BalanceModeManual, 9000)
+	loanDue, minimum := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), 320.0
+	loan.PaymentDueDate, loan.MinimumPayment = &loanDue, &minimum
+
+	missed := newTestSeries(checking, "Gym", models.FrequencyMonthly, 40, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC))
+	missed.Status = models.RecurringStatusMissed
+	series := []*models.RecurringSeries{
+		newTestSeries(checking, "Netflix", models.FrequencyMonthly, 15.49, time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)),
+		newTestSeries(card, "Lunch club", models.FrequencyWeekly, 12, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)),
+		newTestSeries(checking, "Payroll", models.FrequencyBiweekly, -2500, time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC)),
+		missed,
+	}
+
+	bills := Upcoming(series, []*models.Account{checking, card, loan}, time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 21, 0, 0, 0, 0, time.UTC))
+
+	var got []string
+	for _, bill := range bills {
+		got = append(got, bill.Date.Format("01-02")+" "+bill.Name)
+	}
+	assert.Equal(t, []string{
+		"03-05 Car loan payment",
+		"03-05 Netflix",
+		"03-08 Lunch club",
+		"03-15 Lunch club",
+		"03-20 Visa payment",
+	}, got)
+
+	assert.Equal(t, 320.0, bills[0].Amount)
+	assert.Equal(t, models.BillKindLiability, bills[0].Kind)
+	assert.Equal(t, "Checking", bills[1].AccountName)
+	assert.Equal(t, "Visa", bills[2].AccountName)
+	assert.Equal(t, 640.0, bills[4].Amount) // No minimum payment: the full balance
+}
+
+// TestWriteICS tests event output, escaping and line folding
+func TestWriteICS(t *testing.T) {
+	seriesID := uuid.MustParse("11111111-2222-3333-4444-555555555555")
+	bills := []*models.Bill{{
+		Date:         time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC),
+		Name:         "Blue Bottle; Coffee, Subscription with a really quite long merchant name",
+		Amount:       15.5,
+		CurrencyCode: "USD",
+		Category:     "Food and Drink",
+		Kind:         models.BillKindRecurring,
+		SeriesID:     &seriesID,
+		AccountName:  "Checking",
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteICS(&buf, "Bills", bills, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)))
+	out := buf.String()
+
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineOctets)
+	}
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+	assert.Contains(t, unfolded, "UID:11111111-2222-3333-4444-555555555555-20240305@finance-api\r\n")
+	assert.Contains(t, unfolded, "DTSTART;VALUE=DATE:20240305\r\nDTEND;VALUE=DATE:20240306\r\n")
+	assert.Contains(t, unfolded, `SUMMARY:Blue Bottle\; Coffee\, Subscription with a really quite long merchant name (15.50 USD)`+"\r\n")
+	assert.Contains(t, unfolded, `DESCRIPTION:Expected amount: 15.50 USD\nPaid from: Checking`+"\r\n")
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+}
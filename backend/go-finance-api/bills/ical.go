@@ -0,0 +1,104 @@
+package bills
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+)
+
+// maxLineOctets is the longest content line RFC 5545 allows before folding
+const maxLineOctets = 75
+
+// WriteICS writes bills as an iCalendar (RFC 5545) feed of all-day events. Event UIDs
+// are derived from the bill's series or account and date, so calendar apps update
+// events in place when the feed is refreshed.
+func WriteICS(w io.Writer, calendarName string, bills []*models.Bill, stamp time.Time) error {
+	bw := bufio.NewWriter(w)
+	line := func(format string, args ...interface{}) {
+		writeFolded(bw, fmt.Sprintf(format, args...))
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//Finance API//Bills//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:%s", escapeText(calendarName))
+	for _, bill := range bills {
+		amount := fmt.Sprintf("%.2f %s", bill.Amount, bill.CurrencyCode)
+		description := "Expected amount: " + amount
+		if bill.AccountName != "" {
+			if bill.Kind == models.BillKindLiability {
+				description += "\nAccount: " + bill.AccountName
+			} else {
+				description += "\nPaid from: " + bill.AccountName
+			}
+		}
+
+		line("BEGIN:VEVENT")
+		line("UID:%s", eventUID(bill))
+		line("DTSTAMP:%s", stamp.UTC().Format("20060102T150405Z"))
+		line("DTSTART;VALUE=DATE:%s", bill.Date.Format("20060102"))
+		line("DTEND;VALUE=DATE:%s", bill.Date.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY:%s", escapeText(bill.Name+" ("+amount+")"))
+		line("DESCRIPTION:%s", escapeText(description))
+		if bill.Category != "" {
+			line("CATEGORIES:%s", escapeText(bill.Category))
+		}
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+// eventUID identifies a bill's event across feed refreshes
+func eventUID(bill *models.Bill) string {
+	source := "bill"
+	switch {
+	case bill.SeriesID != nil:
+		source = bill.SeriesID.String()
+	case bill.AccountID != nil:
+		source = bill.AccountID.String()
+	}
+	return fmt.Sprintf("%s-%s@finance-api", source, bill.Date.Format("20060102"))
+}
+
+// escapeText escapes a TEXT property value
+func escapeText(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(value)
+}
+
+// writeFolded writes a content line terminated by CRLF, folding it onto continuation
+// lines that start with a space so no line exceeds maxLineOctets. Folds never split a
+// UTF-8 sequence.
+func writeFolded(w *bufio.Writer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		limit = maxLineOctets - 1 // The leading space counts toward the limit
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+// isRuneStart reports whether b begins a UTF-8 sequence
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,92 @@
+// Package budgets compares monthly category budgets with actual spending
+package budgets
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+)
+
+// MaxRolloverMonths bounds how many earlier months can carry into a month's budget
+const MaxRolloverMonths = 24
+
+// ParseMonth parses a YYYY-MM month into the first day of that month (UTC)
+func ParseMonth(value string) (time.Time, error) {
+	month, err := time.Parse("2006-01", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month %q, use YYYY-MM", value)
+	}
+	return month, nil
+}
+
+// MonthStart returns the first day of t's month (UTC)
+func MonthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Evaluate compares the budgets set for month with the spending in each category.
+// history holds the user's budgets for month and the months before it; spending holds
+// monthly category totals covering the same months (Plaid sign convention). A budget
+// with rollover enabled carries what was left, or overspent, into the next month, as
+// long as that month also has a budget for the category.
+func Evaluate(month time.Time, history []*models.Budget, spending []*models.MonthlyCategoryTotal) []*models.BudgetStatus {
+	month = MonthStart(month)
+
+	budgets := make(map[string]map[time.Time]*models.Budget) // Category -> month -> budget
+	for _, budget := range history {
+		if budgets[budget.Category] == nil {
+			budgets[budget.Category] = make(map[time.Time]*models.Budget)
+		}
+		budgets[budget.Category][MonthStart(budget.Month)] = budget
+	}
+	spent := make(map[string]map[time.Time]int64) // Category -> month -> minor units
+	for _, total := range spending {
+		if spent[total.Category] == nil {
+			spent[total.Category] = make(map[time.Time]int64)
+		}
+		spent[total.Category][MonthStart(total.Month)] += models.ToMinorUnits(total.Total)
+	}
+
+	var statuses []*models.BudgetStatus
+	for category, byMonth := range budgets {
+		budget, ok := byMonth[month]
+		if !ok {
+			continue
+		}
+
+		rollover := carryInto(month, byMonth, spent[category], MaxRolloverMonths)
+		budgeted := models.ToMinorUnits(budget.Amount)
+		spentThisMonth := spent[category][month]
+		statuses = append(statuses, &models.BudgetStatus{
+			BudgetID:  budget.ID,
+			Category:  category,
+			Budgeted:  budget.Amount,
+			Rollover:  models.FromMinorUnits(rollover),
+			Available: models.FromMinorUnits(budgeted + rollover),
+			Spent:     models.FromMinorUnits(spentThisMonth),
+			Remaining: models.FromMinorUnits(budgeted + rollover - spentThisMonth),
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Category < statuses[j].Category
+	})
+	return statuses
+}
+
+// carryInto returns the amount, in minor units, that a category's earlier budgets roll
+// into month, looking back at most depth months
+func carryInto(month time.Time, budgets map[time.Time]*models.Budget, spent map[time.Time]int64, depth int) int64 {
+	if depth == 0 {
+		return 0
+	}
+	previous := month.AddDate(0, -1, 0)
+	budget, ok := budgets[previous]
+	if !ok || !budget.Rollover {
+		return 0
+	}
+	return models.ToMinorUnits(budget.Amount) + carryInto(previous, budgets, spent, depth-1) - spent[previous]
+}
@@ -0,0 +1,71 @@
+package budgets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func month(value string) time.Time {
+	m, err := ParseMonth(value)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// TestParseMonth tests month parsing
+func TestParseMonth(t *testing.T) {
+	m, err := ParseMonth("2024-03")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), m)
+
+	_, err = ParseMonth("2024-3-01")
+	assert.Error(t, err)
+}
+
+// TestEvaluate tests spending, refunds and rollover chains
+func TestEvaluate(t *testing.T) {
+	userID := uuid.New()
+	history := []*models.Budget{
+		models.NewBudget(userID, month("2024-01"), "Groceries", 400, true),
+		models.NewBudget(userID, month("2024-02"), "Groceries", 400, true),
+		models.NewBudget(userID, month("2024-03"), "Groceries", 400, true),
+		models.NewBudget(userID, month("2024-02"), "Dining", 200, false),
+		models.NewBudget(userID, month("2024-03"), "Dining", 200, false),
+		models.NewBudget(userID, month("2024-01"), "Travel", 100, true),
+		models.NewBudget(userID, month("2024-03"), "Travel", 100, true), // February gap breaks the chain
+	}
+	spending := []*models.MonthlyCategoryTotal{
+		{Month: month("2024-01"), Category: "Groceries", Total: 350},
+		{Month: month("2024-02"), Category: "Groceries", Total: 500.25},
+		{Month: month("2024-03"), Category: "Groceries", Total: 100},
+		{Month: month("2024-02"), Category: "Dining", Total: 50},
+		{Month: month("2024-03"), Category: "Dining", Total: 260},
+		{Month: month("2024-03"), Category: "Dining", Total: -20}, // Refund
+		{Month: month("2024-01"), Category: "Travel", Total: 10},
+	}
+
+	statuses := Evaluate(month("2024-03"), history, spending)
+	require.Len(t, statuses, 3)
+
+	dining := statuses[0]
+	assert.Equal(t, "Dining", dining.Category)
+	assert.Equal(t, 0.0, dining.Rollover)
+	assert.Equal(t, 240.0, dining.Spent)
+	assert.Equal(t, -40.0, dining.Remaining)
+
+	groceries := statuses[1]
+	assert.Equal(t, "Groceries", groceries.Category)
+	assert.Equal(t, -50.25, groceries.Rollover) // +50 from January, -100.25 from February
+	assert.Equal(t, 349.75, groceries.Available)
+	assert.Equal(t, 249.75, groceries.Remaining)
+
+	travel := statuses[2]
+	assert.Equal(t, 0.0, travel.Rollover)
+	assert.Equal(t, 100.0, travel.Remaining)
+}
@@ -0,0 +1,70 @@
+package budgets
+
+import (
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// Report is a user's budget status for one month
+type Report struct {
+	Month      time.Time                      `json:"month"`
+	Budgets    []*models.BudgetStatus         `json:"budgets"`
+	Unbudgeted []*models.MonthlyCategoryTotal `json:"unbudgeted"` // Spending in categories without a budget
+	Budgeted   float64                        `json:"budgeted"`
+	Spent      float64                        `json:"spent"` // Spending in budgeted categories
+	Remaining  float64                        `json:"remaining"`
+}
+
+// Service builds budget reports from stored budgets and transactions
+type Service struct {
+	repos *db.Repositories
+}
+
+// NewService creates a new Service
+func NewService(repos *db.Repositories) *Service {
+	return &Service{repos: repos}
+}
+
+// Report compares the user's budgets for month with the month's spending, excluding
+// transfers and hidden transactions
+func (s *Service) Report(userID uuid.UUID, month time.Time) (*Report, error) {
+	month = MonthStart(month)
+	first := month.AddDate(0, -MaxRolloverMonths, 0)
+	last := month.AddDate(0, 1, -1)
+
+	history, err := s.repos.Budget.GetByUserAndMonthRange(userID, first, month)
+	if err != nil {
+		return nil, err
+	}
+	spending, err := s.repos.Transaction.SumByCategoryAndMonth(userID, first, last)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Month:      month,
+		Budgets:    Evaluate(month, history, spending),
+		Unbudgeted: []*models.MonthlyCategoryTotal{},
+	}
+
+	budgeted := make(map[string]bool)
+	var totalBudgeted, totalSpent, totalRemaining int64
+	for _, status := range report.Budgets {
+		budgeted[status.Category] = true
+		totalBudgeted += models.ToMinorUnits(status.Budgeted)
+		totalSpent += models.ToMinorUnits(status.Spent)
+		totalRemaining += models.ToMinorUnits(status.Remaining)
+	}
+	for _, total := range spending {
+		if total.Month.Equal(month) && !budgeted[total.Category] {
+			report.Unbudgeted = append(report.Unbudgeted, total)
+		}
+	}
+	report.Budgeted = models.FromMinorUnits(totalBudgeted)
+	report.Spent = models.FromMinorUnits(totalSpent)
+	report.Remaining = models.FromMinorUnits(totalRemaining)
+	return report, nil
+}
@@ -0,0 +1,227 @@
+package categorizer
+
+import (
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// Prediction settings
+const (
+	MinExamples         = 20  // Labelled transactions needed before predicting
+	ConfidenceThreshold = 0.8 // Predictions at least this confident are applied; the rest go to review
+	PredictWindowDays   = 90  // How far back uncategorized transactions are predicted
+	predictBatch        = 500
+	exampleBatch        = 1000
+)
+
+// Result summarizes a training and prediction run
+type Result struct {
+	Learned   int `json:"learned"`   // Examples added to the model
+	Unlearned int `json:"unlearned"` // Examples removed because their label changed
+	Examples  int `json:"examples"`  // Examples in the model
+	Applied   int `json:"applied"`   // Predictions applied to transactions
+	Review    int `json:"review"`    // Predictions sent to the review queue
+}
+
+// Categorizer trains each user's classifier and predicts their transactions' categories
+type Categorizer struct {
+	repos *db.Repositories
+	now   func() time.Time
+}
+
+// New creates a new Categorizer
+func New(repos *db.Repositories) *Categorizer {
+	return &Categorizer{repos: repos, now: time.Now}
+}
+
+// Label returns the category a transaction teaches the classifier: the user's category
+// override, unless that override was set by an applied prediction the user has not
+// changed. applied maps transactions to the categories predictions applied to them.
+func Label(t *models.Transaction, applied map[uuid.UUID]string) string {
+	if t.UserCategory == "" {
+		return ""
+	}
+	if category, ok := applied[t.ID]; ok && category == t.UserCategory {
+		return ""
+	}
+	return t.UserCategory
+}
+
+// Run updates the user's classifier with their latest corrections, then predicts the
+// categories of recent uncategorized transactions. With full set the classifier is
+// rebuilt from every labelled transaction instead.
+func (c *Categorizer) Run(userID uuid.UUID, full bool) (*Result, error) {
+	model, result, err := c.train(userID, full)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.predict(userID, model, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// train learns the labels of transactions changed since the model was last trained,
+// unlearning the previous label of any transaction whose label changed or that was
+// deleted
+func (c *Categorizer) train(userID uuid.UUID, full bool) (*Model, *Result, error) {
+	var model *Model
+	var since time.Time
+	if !full {
+		stored, err := c.repos.Categorizer.GetModel(userID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if stored != nil {
+			if model, err = DecodeModel(stored.Model); err != nil {
+				return nil, nil, err
+			}
+			since = stored.TrainedThrough
+		}
+	}
+	if model == nil {
+		// No usable model: start over from every labelled transaction
+		if err := c.repos.Categorizer.DeleteModel(userID); err != nil {
+			return nil, nil, err
+		}
+		model, since = NewModel(), time.Time{}
+	}
+
+	result := &Result{}
+	var examples []*models.CategorizerExample
+	orphaned, err := c.repos.Categorizer.GetOrphanedExamples(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, example := range orphaned {
+		model.Unlearn(example.Features, example.Category)
+		result.Unlearned++
+		examples = append(examples, &models.CategorizerExample{TransactionID: example.TransactionID, UserID: userID})
+	}
+
+	applied, err := c.repos.Categorizer.GetAppliedCategories(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var changed []*models.Transaction
+	err = c.repos.Transaction.ForEachUpdatedSince(userID, since, func(t *models.Transaction) error {
+		changed = append(changed, t)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	watermark := since
+	for start := 0; start < len(changed); start += exampleBatch {
+		batch := changed[start:min(start+exampleBatch, len(changed))]
+		ids := make([]uuid.UUID, len(batch))
+		for i, t := range batch {
+			ids[i] = t.ID
+		}
+		previous, err := c.repos.Categorizer.GetExamples(ids)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, t := range batch {
+			if t.UpdatedAt.After(watermark) {
+				watermark = t.UpdatedAt
+			}
+			example := Relearn(model, t, Label(t, applied), previous[t.ID], result)
+			if example != nil {
+				examples = append(examples, example)
+			}
+		}
+	}
+
+	data, err := model.Encode()
+	if err != nil {
+		return nil, nil, err
+	}
+	result.Examples = model.Examples()
+	stored := &models.CategorizerModel{
+		UserID:         userID,
+		Model:          data,
+		Examples:       result.Examples,
+		TrainedThrough: watermark,
+	}
+	if err := c.repos.Categorizer.SaveModel(stored, examples); err != nil {
+		return nil, nil, err
+	}
+	return model, result, nil
+}
+
+// Relearn brings the model up to date with a transaction's current label, given the
+// example previously learned from it, if any. It returns the example to store, one with
+// an empty category if the stored example should be deleted, or nil if nothing changed.
+func Relearn(model *Model, t *models.Transaction, label string, previous *models.CategorizerExample, result *Result) *models.CategorizerExample {
+	features := Features(t)
+	if previous != nil && previous.Category == label && sameFeatures(previous.Features, features) {
+		return nil
+	}
+	if previous != nil {
+		model.Unlearn(previous.Features, previous.Category)
+		result.Unlearned++
+	}
+	example := &models.CategorizerExample{TransactionID: t.ID, UserID: t.UserID, Category: label}
+	if label == "" {
+		if previous == nil {
+			return nil
+		}
+		return example
+	}
+	model.Learn(features, label)
+	result.Learned++
+	example.Features = features
+	return example
+}
+
+// predict predicts the categories of the user's recent uncategorized transactions,
+// applying confident predictions and queueing the rest for review
+func (c *Categorizer) predict(userID uuid.UUID, model *Model, result *Result) error {
+	if model.Examples() < MinExamples {
+		return nil
+	}
+
+	since := c.now().UTC().AddDate(0, 0, -PredictWindowDays)
+	transactions, err := c.repos.Transaction.GetUncategorized(userID, since, predictBatch)
+	if err != nil {
+		return err
+	}
+	for _, t := range transactions {
+		category, confidence := model.Predict(Features(t))
+		if category == "" {
+			continue
+		}
+		status := models.PredictionStatusReview
+		if confidence >= ConfidenceThreshold {
+			status = models.PredictionStatusApplied
+		}
+		if err := c.repos.Categorizer.CreatePrediction(models.NewCategoryPrediction(t, category, confidence, status)); err != nil {
+			return err
+		}
+		if status == models.PredictionStatusApplied {
+			result.Applied++
+		} else {
+			result.Review++
+		}
+	}
+	return nil
+}
+
+// sameFeatures reports whether two feature lists are equal
+func sameFeatures(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,201 @@
+// Package categorizer predicts transaction categories with a multinomial naive Bayes
+// classifier trained on each user's own category overrides. It runs entirely in process.
+package categorizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+)
+
+// modelVersion is bumped when Features changes, so stored models are retrained
+const modelVersion = 1
+
+// amountBuckets are the upper bounds of the amount features
+var amountBuckets = []float64{5, 15, 50, 150, 500, 2000}
+
+// Model is a multinomial naive Bayes classifier over transaction features. It learns
+// and unlearns one example at a time, so it can be updated incrementally.
+type Model struct {
+	Version       int                       `json:"version"`
+	Documents     map[string]int            `json:"documents"`      // Examples per category
+	FeatureCounts map[string]map[string]int `json:"feature_counts"` // Feature occurrences per category
+	FeatureTotals map[string]int            `json:"feature_totals"` // All feature occurrences per category
+	Vocabulary    map[string]int            `json:"vocabulary"`     // Occurrences of each feature across categories
+}
+
+// NewModel creates an empty Model
+func NewModel() *Model {
+	return &Model{
+		Version:       modelVersion,
+		Documents:     make(map[string]int),
+		FeatureCounts: make(map[string]map[string]int),
+		FeatureTotals: make(map[string]int),
+		Vocabulary:    make(map[string]int),
+	}
+}
+
+// DecodeModel restores a serialized Model. It returns nil if the model was built from an
+// older version of Features and has to be retrained.
+func DecodeModel(data []byte) (*Model, error) {
+	model := NewModel()
+	if err := json.Unmarshal(data, model); err != nil {
+		return nil, fmt.Errorf("failed to decode categorizer model: %w", err)
+	}
+	if model.Version != modelVersion {
+		return nil, nil
+	}
+	return model, nil
+}
+
+// Encode serializes the Model
+func (m *Model) Encode() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// Examples returns the number of examples learned
+func (m *Model) Examples() int {
+	var n int
+	for _, count := range m.Documents {
+		n += count
+	}
+	return n
+}
+
+// Learn adds an example of category
+func (m *Model) Learn(features []string, category string) {
+	m.update(features, category, 1)
+}
+
+// Unlearn removes an example previously passed to Learn
+func (m *Model) Unlearn(features []string, category string) {
+	if m.Documents[category] == 0 {
+		return
+	}
+	m.update(features, category, -1)
+}
+
+// update adds delta occurrences of an example, dropping counts that reach zero
+func (m *Model) update(features []string, category string, delta int) {
+	m.Documents[category] += delta
+	if m.Documents[category] <= 0 {
+		delete(m.Documents, category)
+	}
+
+	counts := m.FeatureCounts[category]
+	if counts == nil {
+		counts = make(map[string]int)
+		m.FeatureCounts[category] = counts
+	}
+	for _, feature := range features {
+		counts[feature] += delta
+		if counts[feature] <= 0 {
+			delete(counts, feature)
+		}
+		m.Vocabulary[feature] += delta
+		if m.Vocabulary[feature] <= 0 {
+			delete(m.Vocabulary, feature)
+		}
+		m.FeatureTotals[category] += delta
+	}
+	if len(counts) == 0 {
+		delete(m.FeatureCounts, category)
+	}
+	if m.FeatureTotals[category] <= 0 {
+		delete(m.FeatureTotals, category)
+	}
+}
+
+// Predict returns the most probable category for the features and its posterior
+// probability. It returns an empty category if the model has learned nothing.
+func (m *Model) Predict(features []string) (category string, confidence float64) {
+	if len(m.Documents) == 0 {
+		return "", 0
+	}
+
+	categories := make([]string, 0, len(m.Documents))
+	for c := range m.Documents {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories) // Deterministic ties
+
+	examples := float64(m.Examples())
+	vocabulary := float64(len(m.Vocabulary) + 1) // Plus one for unseen features
+	scores := make([]float64, len(categories))
+	best := 0
+	for i, c := range categories {
+		// Laplace-smoothed log prior plus log likelihood of each feature
+		score := math.Log(float64(m.Documents[c]) / examples)
+		denominator := float64(m.FeatureTotals[c]) + vocabulary
+		for _, feature := range features {
+			score += math.Log((float64(m.FeatureCounts[c][feature]) + 1) / denominator)
+		}
+		scores[i] = score
+		if score > scores[best] {
+			best = i
+		}
+	}
+
+	// Normalize into a posterior with the log-sum-exp trick
+	var total float64
+	for _, score := range scores {
+		total += math.Exp(score - scores[best])
+	}
+	return categories[best], 1 / total
+}
+
+// Features extracts the classifier features of a transaction: words of its name and
+// merchant, its merchant as a whole, its direction and amount bucket, and its payment
+// channel
+func Features(t *models.Transaction) []string {
+	var features []string
+	for _, word := range words(t.Name) {
+		features = append(features, "name:"+word)
+	}
+	merchant := words(t.MerchantName)
+	for _, word := range merchant {
+		features = append(features, "merchant:"+word)
+	}
+	if len(merchant) > 0 {
+		features = append(features, "merchant="+strings.Join(merchant, " "))
+	}
+
+	direction := "out"
+	if t.Amount < 0 {
+		direction = "in"
+	}
+	features = append(features, "direction:"+direction, "amount:"+direction+":"+amountBucket(math.Abs(t.Amount)))
+	if t.PaymentChannel != "" {
+		features = append(features, "channel:"+strings.ToLower(t.PaymentChannel))
+	}
+	return features
+}
+
+// words lowercases text and splits it into words of letters, ignoring single letters
+// and numbers such as store numbers
+func words(text string) []string {
+	var result []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		if len([]rune(word)) > 1 {
+			result = append(result, word)
+		}
+	}
+	return result
+}
+
+// amountBucket names the range an amount falls in
+func amountBucket(amount float64) string {
+	for _, bound := range amountBuckets {
+		if amount < bound {
+			return fmt.Sprintf("<%g", bound)
+		}
+	}
+	return fmt.Sprintf(">=%g", amountBuckets[len(amountBuckets)-1])
+}
@@ -0,0 +1,113 @@
+package categorizer
+
+import (
+	"testing"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFeatures tests tokenizing names and merchants and bucketing amounts
+func TestFeatures(t *testing.T) {
+	transaction := &models.Transaction{Name: "STARBUCKS #1234 Seattle", MerchantName: "Starbucks", Amount: 6.45, PaymentChannel: "In Store"}
+	assert.Equal(t, []string{
+		"name:starbucks", "name:seattle",
+		"merchant:starbucks", "merchant=starbucks",
+		"direction:out", "amount:out:<15", "channel:in store",
+	}, Features(transaction))
+
+	refund := &models.Transaction{Name: "Refund", Amount: -2500}
+	assert.Equal(t, []string{"name:refund", "direction:in", "amount:in:>=2000"}, Features(refund))
+}
+
+// TestPredict tests learning, predicting and unlearning examples
+func TestPredict(t *testing.T) {
+	model := NewModel()
+	train := func(name, merchant string, amount float64, category string) {
+		model.Learn(Features(&models.Transaction{Name: name, MerchantName: merchant, Amount: amount}), category)
+	}
+	for i := 0; i < 5; i++ {
+		train("STARBUCKS STORE", "Starbucks", 5.5, "Coffee")
+		train("SAFEWAY 1234", "Safeway", 85, "Groceries")
+	}
+	assert.Equal(t, 10, model.Examples())
+
+	category, confidence := model.Predict(Features(&models.Transaction{Name: "STARBUCKS 99", MerchantName: "Starbucks", Amount: 4.75}))
+	assert.Equal(t, "Coffee", category)
+	assert.Greater(t, confidence, 0.9)
+
+	// Unknown merchants fall back on the amount, with less confidence
+	category, unsure := model.Predict(Features(&models.Transaction{Name: "Corner Deli", Amount: 90}))
+	assert.Equal(t, "Groceries", category)
+	assert.Less(t, unsure, confidence)
+
+	for i := 0; i < 5; i++ {
+		model.Unlearn(Features(&models.Transaction{Name: "SAFEWAY 1234", MerchantName: "Safeway", Amount: 85}), "Groceries")
+	}
+	assert.Equal(t, 5, model.Examples())
+	category, _ = model.Predict(Features(&models.Transaction{Name: "SAFEWAY 1234", MerchantName: "Safeway", Amount: 85}))
+	assert.Equal(t, "Coffee", category) // The only category left
+
+	category, confidence = NewModel().Predict([]string{"name:anything"})
+	assert.Equal(t, "", category)
+	assert.Zero(t, confidence)
+}
+
+// TestEncode tests that a model survives serialization and outdated models are discarded
+func TestEncode(t *testing.T) {
+	model := NewModel()
+	model.Learn([]string{"name:rent", "direction:out"}, "Housing")
+	data, err := model.Encode()
+	require.NoError(t, err)
+
+	decoded, err := DecodeModel(data)
+	require.NoError(t, err)
+	assert.Equal(t, model, decoded)
+
+	model.Version = modelVersion - 1
+	data, err = model.Encode()
+	require.NoError(t, err)
+	decoded, err = DecodeModel(data)
+	require.NoError(t, err)
+	assert.Nil(t, decoded)
+
+	_, err = DecodeModel([]byte("not json"))
+	assert.Error(t, err)
+}
+
+// TestRelearn tests keeping the model in step with a transaction's changing label
+func TestRelearn(t *testing.T) {
+	model := NewModel()
+	result := &Result{}
+	transaction := &models.Transaction{ID: uuid.New(), Name: "Netflix", Amount: 15.49}
+
+	assert.Nil(t, Relearn(model, transaction, "", nil, result)) // Unlabelled and never learned
+
+	example := Relearn(model, transaction, "Entertainment", nil, result)
+	require.NotNil(t, example)
+	assert.Equal(t, "Entertainment", example.Category)
+	assert.Equal(t, 1, model.Documents["Entertainment"])
+
+	assert.Nil(t, Relearn(model, transaction, "Entertainment", example, result)) // Unchanged
+
+	corrected := Relearn(model, transaction, "Subscriptions", example, result)
+	require.NotNil(t, corrected)
+	assert.Equal(t, map[string]int{"Subscriptions": 1}, model.Documents)
+
+	removed := Relearn(model, transaction, "", corrected, result)
+	require.NotNil(t, removed)
+	assert.Empty(t, removed.Category) // Deleted when saved
+	assert.Zero(t, model.Examples())
+	assert.Equal(t, &Result{Learned: 2, Unlearned: 2}, result)
+}
+
+// TestLabel tests that categories applied by predictions are not learned as labels
+func TestLabel(t *testing.T) {
+	transaction := &models.Transaction{ID: uuid.New(), UserCategory: "Travel"}
+	assert.Equal(t, "Travel", Label(transaction, nil))
+	assert.Empty(t, Label(transaction, map[uuid.UUID]string{transaction.ID: "Travel"}))
+	assert.Equal(t, "Travel", Label(transaction, map[uuid.UUID]string{transaction.ID: "Dining"})) // Corrected
+	assert.Empty(t, Label(&models.Transaction{}, nil))
+}
@@ -4,8 +4,12 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 
+	"github.com/davidwang/go-finance-api/go-finance-api/blobstore"
 	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/llm"
 	"github.com/joho/godotenv"
 )
 
@@ -17,6 +21,8 @@ type Config struct {
 	Port          string
 	JWTSecret     string
 	DB            db.DBConfig
+	Blob          blobstore.Config
+	LLM           llm.Config
 }
 
 // Load reads configuration from .env file
@@ -51,6 +57,28 @@ func Load() *Config {
 			DBName:   getEnv("DB_NAME", "finance"),
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
 		},
+		Blob: blobstore.Config{
+			Backend:   getEnv("BLOB_STORE", "local"),
+			LocalDir:  getEnv("BLOB_LOCAL_DIR", filepath.Join("data", "attachments")),
+			Endpoint:  getEnv("S3_ENDPOINT", ""),
+			Region:    getEnv("S3_REGION", "us-east-1"),
+			Bucket:    getEnv("S3_BUCKET", ""),
+			AccessKey: getEnv("S3_ACCESS_KEY", ""),
+			SecretKey: getEnv("S3_SECRET_KEY", ""),
+		},
+		LLM: llm.Config{
+			Backend:        getEnv("LLM_BACKEND", ""),
+			BaseURL:        getEnv("LLM_BASE_URL", "http://localhost:11434/v1"),
+			APIKey:         getEnv("LLM_API_KEY", ""),
+			ChatModel:      getEnv("LLM_CHAT_MODEL", "llama3.1"),
+			EmbeddingModel: getEnv("LLM_EMBEDDING_MODEL", "nomic-embed-text"),
+			Timeout:        time.Duration(getEnvFloat("LLM_TIMEOUT_SECONDS", 120)) * time.Second,
+			Pricing: llm.Pricing{
+				PromptPerMillion:     getEnvFloat("LLM_PROMPT_PRICE", 0),
+				CompletionPerMillion: getEnvFloat("LLM_COMPLETION_PRICE", 0),
+			},
+			MonthlyLimit: getEnvFloat("LLM_MONTHLY_LIMIT", 0),
+		},
 	}
 
 	return config
@@ -63,3 +91,17 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getEnvFloat reads a numeric environment variable with a default fallback
+func getEnvFloat(key string, fallback float64) float64 {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Warning: %s is not a number, using %v", key, fallback)
+		return fallback
+	}
+	return parsed
+}
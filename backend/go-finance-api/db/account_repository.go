@@ -18,15 +18,52 @@ func NewAccountRepository(db *Database) *AccountRepository {
 	return &AccountRepository{db: db}
 }
 
+// accountColumns is the column list matching scanAccount
+const accountColumns = `
+			id, item_id, user_id, COALESCE(plaid_account_id, ''), name, official_name,
+			type, subtype, mask, available_balance, current_balance,
+			currency_code, balance_mode, payment_due_date, minimum_payment,
+			last_updated, created_at, updated_at`
+
+// scanAccount scans a single account row selected with accountColumns
+func scanAccount(row rowScanner) (*models.Account, error) {
+	var account models.Account
+	err := row.Scan(
+		&account.ID,
+		&account.ItemID,
+		&account.UserID,
+		&account.PlaidAccountID,
+		&account.Name,
+		&account.OfficialName,
+		&account.Type,
+		&account.Subtype,
+		&account.Mask,
+		&account.AvailableBalance,
+		&account.CurrentBalance,
+		&account.CurrencyCode,
+		&account.BalanceMode,
+		&account.PaymentDueDate,
+		&account.MinimumPayment,
+		&account.LastUpdated,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
 // Create inserts a new account into the database
 func (r *AccountRepository) Create(account *models.Account) error {
 	query := `
 		INSERT INTO accounts (
 			id, item_id, user_id, plaid_account_id, name, official_name, 
 			type, subtype, mask, available_balance, current_balance, 
-			currency_code, last_updated, created_at, updated_at
+			currency_code, balance_mode, payment_due_date, minimum_payment,
+			last_updated, created_at, updated_at
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 	`
 	_, err := r.db.Exec(
 		query,
@@ -42,147 +79,70 @@ func (r *AccountRepository) Create(account *models.Account) error {
 		account.AvailableBalance,
 		account.CurrentBalance,
 		account.CurrencyCode,
+		account.BalanceMode,
+		account.PaymentDueDate,
+		account.MinimumPayment,
 		account.LastUpdated,
 		account.CreatedAt,
 		account.UpdatedAt,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	return recordBalanceSnapshot(r.db, account.ID)
 }
 
 // GetByID retrieves an account by ID
 func (r *AccountRepository) GetByID(id uuid.UUID) (*models.Account, error) {
-	query := `
-		SELECT 
-			id, item_id, user_id, plaid_account_id, name, official_name, 
-			type, subtype, mask, available_balance, current_balance, 
-			currency_code, last_updated, created_at, updated_at
-		FROM accounts
-		WHERE id = $1
-	`
-	var account models.Account
-	err := r.db.QueryRow(query, id).Scan(
-		&account.ID,
-		&account.ItemID,
-		&account.UserID,
-		&account.PlaidAccountID,
-		&account.Name,
-		&account.OfficialName,
-		&account.Type,
-		&account.Subtype,
-		&account.Mask,
-		&account.AvailableBalance,
-		&account.CurrentBalance,
-		&account.CurrencyCode,
-		&account.LastUpdated,
-		&account.CreatedAt,
-		&account.UpdatedAt,
-	)
+	query := `SELECT ` + accountColumns + ` FROM accounts WHERE id = $1`
+	account, err := scanAccount(r.db.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // Account not found
 		}
 		return nil, err
 	}
-	return &account, nil
+	return account, nil
 }
 
 // GetByPlaidAccountID retrieves an account by its Plaid account ID
 func (r *AccountRepository) GetByPlaidAccountID(plaidAccountID string) (*models.Account, error) {
-	query := `
-		SELECT 
-			id, item_id, user_id, plaid_account_id, name, official_name, 
-			type, subtype, mask, available_balance, current_balance, 
-			currency_code, last_updated, created_at, updated_at
-		FROM accounts
-		WHERE plaid_account_id = $1
-	`
-	var account models.Account
-	err := r.db.QueryRow(query, plaidAccountID).Scan(
-		&account.ID,
-		&account.ItemID,
-		&account.UserID,
-		&account.PlaidAccountID,
-		&account.Name,
-		&account.OfficialName,
-		&account.Type,
-		&account.Subtype,
-		&account.Mask,
-		&account.AvailableBalance,
-		&account.CurrentBalance,
-		&account.CurrencyCode,
-		&account.LastUpdated,
-		&account.CreatedAt,
-		&account.UpdatedAt,
-	)
+	query := `SELECT ` + accountColumns + ` FROM accounts WHERE plaid_account_id = $1`
+	account, err := scanAccount(r.db.QueryRow(query, plaidAccountID))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // Account not found
 		}
 		return nil, err
 	}
-	return &account, nil
+	return account, nil
 }
 
 // GetByItemID retrieves all accounts for a specific item
 func (r *AccountRepository) GetByItemID(itemID uuid.UUID) ([]*models.Account, error) {
 	query := `
-		SELECT 
-			id, item_id, user_id, plaid_account_id, name, official_name, 
-			type, subtype, mask, available_balance, current_balance, 
-			currency_code, last_updated, created_at, updated_at
+		SELECT ` + accountColumns + `
 		FROM accounts
 		WHERE item_id = $1
 		ORDER BY name
 	`
-	rows, err := r.db.Query(query, itemID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var accounts []*models.Account
-	for rows.Next() {
-		var account models.Account
-		err := rows.Scan(
-			&account.ID,
-			&account.ItemID,
-			&account.UserID,
-			&account.PlaidAccountID,
-			&account.Name,
-			&account.OfficialName,
-			&account.Type,
-			&account.Subtype,
-			&account.Mask,
-			&account.AvailableBalance,
-			&account.CurrentBalance,
-			&account.CurrencyCode,
-			&account.LastUpdated,
-			&account.CreatedAt,
-			&account.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		accounts = append(accounts, &account)
-	}
-	if err = rows.Err(); err != nil {
-		return nil, err
-	}
-	return accounts, nil
+	return r.query(query, itemID)
 }
 
-// GetByUserID retrieves all accounts for a specific user
+// GetByUserID retrieves all accounts for a specific user, both Plaid and manual
 func (r *AccountRepository) GetByUserID(userID uuid.UUID) ([]*models.Account, error) {
 	query := `
-		SELECT 
-			id, item_id, user_id, plaid_account_id, name, official_name, 
-			type, subtype, mask, available_balance, current_balance, 
-			currency_code, last_updated, created_at, updated_at
+		SELECT ` + accountColumns + `
 		FROM accounts
 		WHERE user_id = $1
 		ORDER BY name
 	`
-	rows, err := r.db.Query(query, userID)
+	return r.query(query, userID)
+}
+
+// query runs a query that selects accountColumns and scans every row
+func (r *AccountRepository) query(query string, args ...interface{}) ([]*models.Account, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -190,28 +150,11 @@ func (r *AccountRepository) GetByUserID(userID uuid.UUID) ([]*models.Account, er
 
 	var accounts []*models.Account
 	for rows.Next() {
-		var account models.Account
-		err := rows.Scan(
-			&account.ID,
-			&account.ItemID,
-			&account.UserID,
-			&account.PlaidAccountID,
-			&account.Name,
-			&account.OfficialName,
-			&account.Type,
-			&account.Subtype,
-			&account.Mask,
-			&account.AvailableBalance,
-			&account.CurrentBalance,
-			&account.CurrencyCode,
-			&account.LastUpdated,
-			&account.CreatedAt,
-			&account.UpdatedAt,
-		)
+		account, err := scanAccount(rows)
 		if err != nil {
 			return nil, err
 		}
-		accounts = append(accounts, &account)
+		accounts = append(accounts, account)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, err
@@ -219,7 +162,41 @@ func (r *AccountRepository) GetByUserID(userID uuid.UUID) ([]*models.Account, er
 	return accounts, nil
 }
 
-// UpdateBalances updates the account balances
+// Update saves the user-editable details and balances of a manual account
+func (r *AccountRepository) Update(account *models.Account) error {
+	account.UpdatedAt = time.Now().UTC()
+	query := `
+		UPDATE accounts
+		SET
+			name = $2, official_name = $3, type = $4, subtype = $5, mask = $6,
+			available_balance = $7, current_balance = $8, currency_code = $9,
+			balance_mode = $10, payment_due_date = $12, minimum_payment = $13,
+			last_updated = $11, updated_at = $11
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(
+		query,
+		account.ID,
+		account.Name,
+		account.OfficialName,
+		account.Type,
+		account.Subtype,
+		account.Mask,
+		account.AvailableBalance,
+		account.CurrentBalance,
+		account.CurrencyCode,
+		account.BalanceMode,
+		account.UpdatedAt,
+		account.PaymentDueDate,
+		account.MinimumPayment,
+	)
+	if err != nil {
+		return err
+	}
+	return recordBalanceSnapshot(r.db, account.ID)
+}
+
+// UpdateBalances updates the account balances and records them in today's snapshot
 func (r *AccountRepository) UpdateBalances(id uuid.UUID, availableBalance, currentBalance float64) error {
 	query := `
 		UPDATE accounts
@@ -227,10 +204,102 @@ func (r *AccountRepository) UpdateBalances(id uuid.UUID, availableBalance, curre
 		WHERE id = $5
 	`
 	now := time.Now().UTC()
-	_, err := r.db.Exec(query, availableBalance, currentBalance, now, now, id)
+	if _, err := r.db.Exec(query, availableBalance, currentBalance, now, now, id); err != nil {
+		return err
+	}
+	return recordBalanceSnapshot(r.db, id)
+}
+
+// UpdatePaymentDue sets the next payment due date and minimum payment of a credit card
+// or loan
+func (r *AccountRepository) UpdatePaymentDue(id uuid.UUID, dueDate *time.Time, minimumPayment *float64) error {
+	query := `
+		UPDATE accounts
+		SET payment_due_date = $1, minimum_payment = $2, updated_at = $3
+		WHERE id = $4
+	`
+	_, err := r.db.Exec(query, dueDate, minimumPayment, time.Now().UTC(), id)
 	return err
 }
 
+// RecomputeBalance sets the balance of an account in computed mode from its posted
+// transactions. Outflows are positive, so they reduce an asset's balance and increase
+// the amount owed on a credit card or loan. Accounts in other modes are left untouched.
+func (r *AccountRepository) RecomputeBalance(id uuid.UUID) error {
+	query := `
+		WITH totals AS (
+			SELECT COALESCE(SUM(amount), 0) AS total
+			FROM transactions
+			WHERE account_id = $1 AND NOT pending
+		)
+		UPDATE accounts
+		SET
+			current_balance = CASE WHEN type IN ('credit', 'loan') THEN totals.total ELSE -totals.total END,
+			available_balance = CASE WHEN type IN ('credit', 'loan') THEN totals.total ELSE -totals.total END,
+			last_updated = $3,
+			updated_at = $3
+		FROM totals
+		WHERE id = $1 AND balance_mode = $2
+	`
+	result, err := r.db.Exec(query, id, models.BalanceModeComputed, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+	return recordBalanceSnapshot(r.db, id)
+}
+
+// UpsertByPlaidAccountID inserts an account from Plaid or, if its Plaid account ID already
+// exists, refreshes its details and balances. The ID of the stored row is written back to
+// account.ID. The balances are recorded in today's snapshot.
+func (r *AccountRepository) UpsertByPlaidAccountID(account *models.Account) error {
+	query := `
+		INSERT INTO accounts (
+			id, item_id, user_id, plaid_account_id, name, official_name,
+			type, subtype, mask, available_balance, current_balance,
+			currency_code, balance_mode, last_updated, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		ON CONFLICT (plaid_account_id) DO UPDATE SET
+			name = EXCLUDED.name,
+			official_name = EXCLUDED.official_name,
+			type = EXCLUDED.type,
+			subtype = EXCLUDED.subtype,
+			mask = EXCLUDED.mask,
+			available_balance = EXCLUDED.available_balance,
+			current_balance = EXCLUDED.current_balance,
+			currency_code = EXCLUDED.currency_code,
+			last_updated = EXCLUDED.last_updated,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id
+	`
+	err := r.db.QueryRow(
+		query,
+		account.ID,
+		account.ItemID,
+		account.UserID,
+		account.PlaidAccountID,
+		account.Name,
+		account.OfficialName,
+		account.Type,
+		account.Subtype,
+		account.Mask,
+		account.AvailableBalance,
+		account.CurrentBalance,
+		account.CurrencyCode,
+		account.BalanceMode,
+		account.LastUpdated,
+		account.CreatedAt,
+		account.UpdatedAt,
+	).Scan(&account.ID)
+	if err != nil {
+		return err
+	}
+	return recordBalanceSnapshot(r.db, account.ID)
+}
+
 // Delete removes an account from the database
 func (r *AccountRepository) Delete(id uuid.UUID) error {
 	query := `DELETE FROM accounts WHERE id = $1`
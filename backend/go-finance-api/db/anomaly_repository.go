@@ -0,0 +1,180 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// AnomalyRepository handles database operations for flagged transactions
+type AnomalyRepository struct {
+	db *Database
+}
+
+// NewAnomalyRepository creates a new AnomalyRepository
+func NewAnomalyRepository(db *Database) *AnomalyRepository {
+	return &AnomalyRepository{db: db}
+}
+
+// anomalyColumns is the column list matching scanAnomaly
+const anomalyColumns = `transaction_id, user_id, score, flags, status, created_at, updated_at`
+
+// scanAnomaly scans an anomaly row, decoding its JSONB flags
+func scanAnomaly(row rowScanner) (*models.Anomaly, error) {
+	var anomaly models.Anomaly
+	var flags []byte
+	err := row.Scan(
+		&anomaly.TransactionID,
+		&anomaly.UserID,
+		&anomaly.Score,
+		&flags,
+		&anomaly.Status,
+		&anomaly.CreatedAt,
+		&anomaly.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(flags, &anomaly.Flags); err != nil {
+		return nil, err
+	}
+	return &anomaly, nil
+}
+
+// Save stores the anomalies detected among a user's transactions dated from scoredSince
+// on. A transaction that is already flagged has its score and flags refreshed while it
+// is open, and open flags on transactions from scoredSince on that are no longer
+// anomalous are deleted. Dismissed and fraud flags are kept as the user left them.
+func (r *AnomalyRepository) Save(userID uuid.UUID, scoredSince time.Time, anomalies []*models.Anomaly) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	flagged := make([]uuid.UUID, len(anomalies))
+	for i, anomaly := range anomalies {
+		flagged[i] = anomaly.TransactionID
+	}
+	_, err = tx.Exec(`
+		DELETE FROM transaction_anomalies a
+		USING transactions t
+		WHERE t.id = a.transaction_id AND a.user_id = $1 AND a.status = $2
+			AND t.date >= $3 AND NOT (a.transaction_id = ANY($4))
+	`, userID, models.AnomalyStatusOpen, scoredSince, pq.Array(flagged))
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO transaction_anomalies (transaction_id, user_id, score, flags, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (transaction_id) DO UPDATE SET
+			score = EXCLUDED.score,
+			flags = EXCLUDED.flags,
+			updated_at = EXCLUDED.updated_at
+		WHERE transaction_anomalies.status = $8
+	`
+	for _, anomaly := range anomalies {
+		flags, err := json.Marshal(anomaly.Flags)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(
+			query,
+			anomaly.TransactionID,
+			anomaly.UserID,
+			anomaly.Score,
+			flags,
+			anomaly.Status,
+			anomaly.CreatedAt,
+			anomaly.UpdatedAt,
+			models.AnomalyStatusOpen,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetByTransactionID retrieves the anomaly flagged on a transaction
+func (r *AnomalyRepository) GetByTransactionID(transactionID uuid.UUID) (*models.Anomaly, error) {
+	query := `SELECT ` + anomalyColumns + ` FROM transaction_anomalies WHERE transaction_id = $1`
+	anomaly, err := scanAnomaly(r.db.QueryRow(query, transactionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Anomaly not found
+		}
+		return nil, err
+	}
+	return anomaly, nil
+}
+
+// GetByStatus retrieves a user's anomalies with a status, highest score first
+func (r *AnomalyRepository) GetByStatus(userID uuid.UUID, status string, limit, offset int) ([]*models.Anomaly, error) {
+	query := `
+		SELECT ` + anomalyColumns + `
+		FROM transaction_anomalies
+		WHERE user_id = $1 AND status = $2
+		ORDER BY score DESC, created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := r.db.Query(query, userID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	anomalies := []*models.Anomaly{}
+	for rows.Next() {
+		anomaly, err := scanAnomaly(rows)
+		if err != nil {
+			return nil, err
+		}
+		anomalies = append(anomalies, anomaly)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return anomalies, nil
+}
+
+// GetFraudTransactionIDs returns the IDs of the user's transactions reported as fraud
+func (r *AnomalyRepository) GetFraudTransactionIDs(userID uuid.UUID) (map[uuid.UUID]bool, error) {
+	query := `SELECT transaction_id FROM transaction_anomalies WHERE user_id = $1 AND status = $2`
+	rows, err := r.db.Query(query, userID, models.AnomalyStatusFraud)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// UpdateStatus records how the user resolved an anomaly
+func (r *AnomalyRepository) UpdateStatus(anomaly *models.Anomaly, status string) error {
+	now := time.Now().UTC()
+	query := `UPDATE transaction_anomalies SET status = $1, updated_at = $2 WHERE transaction_id = $3`
+	if _, err := r.db.Exec(query, status, now, anomaly.TransactionID); err != nil {
+		return err
+	}
+	anomaly.Status = status
+	anomaly.UpdatedAt = now
+	return nil
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// AttachmentRepository handles database operations for transaction attachments
+type AttachmentRepository struct {
+	db *Database
+}
+
+// NewAttachmentRepository creates a new AttachmentRepository
+func NewAttachmentRepository(db *Database) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+// Create inserts a new attachment into the database
+func (r *AttachmentRepository) Create(attachment *models.Attachment) error {
+	query := `
+		INSERT INTO transaction_attachments (
+			id, transaction_id, user_id, file_name, content_type, size_bytes, storage_key, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.Exec(
+		query,
+		attachment.ID,
+		attachment.TransactionID,
+		attachment.UserID,
+		attachment.FileName,
+		attachment.ContentType,
+		attachment.SizeBytes,
+		attachment.StorageKey,
+		attachment.CreatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves an attachment by ID
+func (r *AttachmentRepository) GetByID(id uuid.UUID) (*models.Attachment, error) {
+	query := `
+		SELECT id, transaction_id, user_id, file_name, content_type, size_bytes, storage_key, created_at
+		FROM transaction_attachments
+		WHERE id = $1
+	`
+	var attachment models.Attachment
+	err := r.db.QueryRow(query, id).Scan(
+		&attachment.ID,
+		&attachment.TransactionID,
+		&attachment.UserID,
+		&attachment.FileName,
+		&attachment.ContentType,
+		&attachment.SizeBytes,
+		&attachment.StorageKey,
+		&attachment.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Attachment not found
+		}
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// GetByTransactionID retrieves the attachments of a transaction
+func (r *AttachmentRepository) GetByTransactionID(transactionID uuid.UUID) ([]*models.Attachment, error) {
+	query := `
+		SELECT id, transaction_id, user_id, file_name, content_type, size_bytes, storage_key, created_at
+		FROM transaction_attachments
+		WHERE transaction_id = $1
+		ORDER BY created_at
+	`
+	return r.list(query, transactionID)
+}
+
+// GetByPlaidTransactionID retrieves the attachments of the transaction with a Plaid
+// transaction ID, so their files can be deleted along with it
+func (r *AttachmentRepository) GetByPlaidTransactionID(plaidTransactionID string) ([]*models.Attachment, error) {
+	query := `
+		SELECT a.id, a.transaction_id, a.user_id, a.file_name, a.content_type, a.size_bytes, a.storage_key, a.created_at
+		FROM transaction_attachments a
+		JOIN transactions t ON t.id = a.transaction_id
+		WHERE t.plaid_transaction_id = $1
+		ORDER BY a.created_at
+	`
+	return r.list(query, plaidTransactionID)
+}
+
+// GetByAccountID retrieves the attachments of an account's transactions, so their files
+// can be deleted along with it
+func (r *AttachmentRepository) GetByAccountID(accountID uuid.UUID) ([]*models.Attachment, error) {
+	query := `
+		SELECT a.id, a.transaction_id, a.user_id, a.file_name, a.content_type, a.size_bytes, a.storage_key, a.created_at
+		FROM transaction_attachments a
+		JOIN transactions t ON t.id = a.transaction_id
+		WHERE t.account_id = $1
+		ORDER BY a.created_at
+	`
+	return r.list(query, accountID)
+}
+
+// list runs an attachment query and scans every row
+func (r *AttachmentRepository) list(query string, args ...interface{}) ([]*models.Attachment, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []*models.Attachment
+	for rows.Next() {
+		var attachment models.Attachment
+		err := rows.Scan(
+			&attachment.ID,
+			&attachment.TransactionID,
+			&attachment.UserID,
+			&attachment.FileName,
+			&attachment.ContentType,
+			&attachment.SizeBytes,
+			&attachment.StorageKey,
+			&attachment.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, &attachment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// Delete removes an attachment record from the database
+func (r *AttachmentRepository) Delete(id uuid.UUID) error {
+	query := `DELETE FROM transaction_attachments WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
@@ -0,0 +1,197 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// BalanceSnapshotRepository handles database operations for account balance snapshots
+type BalanceSnapshotRepository struct {
+	db *Database
+}
+
+// NewBalanceSnapshotRepository creates a new BalanceSnapshotRepository
+func NewBalanceSnapshotRepository(db *Database) *BalanceSnapshotRepository {
+	return &BalanceSnapshotRepository{db: db}
+}
+
+// execer is implemented by both *Database and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// recordBalanceSnapshot stores an account's current balances as today's snapshot,
+// replacing any snapshot already taken today
+func recordBalanceSnapshot(db execer, accountID uuid.UUID) error {
+	now := time.Now().UTC()
+	_, err := db.Exec(`
+		INSERT INTO account_balance_snapshots (
+			id, account_id, user_id, date, current_balance, available_balance,
+			currency_code, source, created_at, updated_at
+		)
+		SELECT $1, id, user_id, $2, COALESCE(current_balance, 0), available_balance,
+			currency_code, $3, $5, $5
+		FROM accounts
+		WHERE id = $4
+		ON CONFLICT (account_id, date) DO UPDATE SET
+			current_balance = EXCLUDED.current_balance,
+			available_balance = EXCLUDED.available_balance,
+			currency_code = EXCLUDED.currency_code,
+			source = EXCLUDED.source,
+			updated_at = EXCLUDED.updated_at
+	`, uuid.New(), now.Format("2006-01-02"), models.SnapshotSourceRefresh, accountID, now)
+	return err
+}
+
+// SnapshotAll records a snapshot of every account for the given day, keeping snapshots
+// already taken that day. It returns the number of snapshots written.
+func (r *BalanceSnapshotRepository) SnapshotAll(date time.Time) (int64, error) {
+	rows, err := r.db.Query(`SELECT ` + accountColumns + ` FROM accounts`)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var snapshots []*models.BalanceSnapshot
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return 0, err
+		}
+		snapshot := models.NewBalanceSnapshot(account, date, account.CurrentBalance, models.SnapshotSourceDaily)
+		available := account.AvailableBalance
+		snapshot.AvailableBalance = &available
+		snapshots = append(snapshots, snapshot)
+	}
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	created, err := r.CreateMissing(snapshots)
+	return int64(created), err
+}
+
+// CreateMissing stores snapshots for days that don't have one yet, leaving recorded
+// snapshots untouched. It returns the number of snapshots written.
+func (r *BalanceSnapshotRepository) CreateMissing(snapshots []*models.BalanceSnapshot) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO account_balance_snapshots (
+			id, account_id, user_id, date, current_balance, available_balance,
+			currency_code, source, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (account_id, date) DO NOTHING
+	`
+	created := 0
+	for _, snapshot := range snapshots {
+		result, err := tx.Exec(
+			query,
+			snapshot.ID,
+			snapshot.AccountID,
+			snapshot.UserID,
+			snapshot.Date,
+			snapshot.CurrentBalance,
+			snapshot.AvailableBalance,
+			snapshot.CurrencyCode,
+			snapshot.Source,
+			snapshot.CreatedAt,
+			snapshot.UpdatedAt,
+		)
+		if err != nil {
+			return 0, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		created += int(n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return created, nil
+}
+
+// snapshotColumns is the column list scanned by query
+const snapshotColumns = `
+			id, account_id, user_id, date, current_balance, available_balance,
+			COALESCE(currency_code, ''), source, created_at, updated_at`
+
+// GetByAccountID retrieves an account's snapshots within a date range, oldest first
+func (r *BalanceSnapshotRepository) GetByAccountID(accountID uuid.UUID, startDate, endDate time.Time) ([]*models.BalanceSnapshot, error) {
+	query := `
+		SELECT ` + snapshotColumns + `
+		FROM account_balance_snapshots
+		WHERE account_id = $1 AND date >= $2 AND date <= $3
+		ORDER BY date
+	`
+	return r.query(query, accountID, startDate, endDate)
+}
+
+// GetByUserIDAsOf retrieves a user's snapshots within a date range, oldest first, along
+// with each account's latest snapshot before the range so balances can be carried forward
+// into days without a snapshot
+func (r *BalanceSnapshotRepository) GetByUserIDAsOf(userID uuid.UUID, startDate, endDate time.Time) ([]*models.BalanceSnapshot, error) {
+	query := `
+		SELECT * FROM (
+			(
+				SELECT DISTINCT ON (account_id) ` + snapshotColumns + `
+				FROM account_balance_snapshots
+				WHERE user_id = $1 AND date < $2
+				ORDER BY account_id, date DESC
+			)
+			UNION ALL
+			(
+				SELECT ` + snapshotColumns + `
+				FROM account_balance_snapshots
+				WHERE user_id = $1 AND date >= $2 AND date <= $3
+			)
+		) s
+		ORDER BY date, account_id
+	`
+	return r.query(query, userID, startDate, endDate)
+}
+
+// query runs a query that selects snapshotColumns and scans every row
+func (r *BalanceSnapshotRepository) query(query string, args ...interface{}) ([]*models.BalanceSnapshot, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []*models.BalanceSnapshot
+	for rows.Next() {
+		var snapshot models.BalanceSnapshot
+		err := rows.Scan(
+			&snapshot.ID,
+			&snapshot.AccountID,
+			&snapshot.UserID,
+			&snapshot.Date,
+			&snapshot.CurrentBalance,
+			&snapshot.AvailableBalance,
+			&snapshot.CurrencyCode,
+			&snapshot.Source,
+			&snapshot.CreatedAt,
+			&snapshot.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, &snapshot)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
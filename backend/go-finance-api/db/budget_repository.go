@@ -0,0 +1,168 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// BudgetRepository handles database operations for monthly category budgets
+type BudgetRepository struct {
+	db *Database
+}
+
+// NewBudgetRepository creates a new BudgetRepository
+func NewBudgetRepository(db *Database) *BudgetRepository {
+	return &BudgetRepository{db: db}
+}
+
+// budgetColumns is the column list scanned by scanBudget
+const budgetColumns = `id, user_id, month, category, amount, rollover, created_at, updated_at`
+
+// scanBudget scans a budget row
+func scanBudget(row rowScanner) (*models.Budget, error) {
+	var budget models.Budget
+	err := row.Scan(
+		&budget.ID,
+		&budget.UserID,
+		&budget.Month,
+		&budget.Category,
+		&budget.Amount,
+		&budget.Rollover,
+		&budget.CreatedAt,
+		&budget.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// Upsert inserts a budget, or updates the amount and rollover of the user's existing
+// budget for the same month and category. budget.ID is set to the stored row's ID.
+func (r *BudgetRepository) Upsert(budget *models.Budget) error {
+	query := `
+		INSERT INTO budgets (` + budgetColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id, month, category) DO UPDATE SET
+			amount = EXCLUDED.amount,
+			rollover = EXCLUDED.rollover,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(
+		query,
+		budget.ID,
+		budget.UserID,
+		budget.Month,
+		budget.Category,
+		budget.Amount,
+		budget.Rollover,
+		budget.CreatedAt,
+		budget.UpdatedAt,
+	).Scan(&budget.ID, &budget.CreatedAt)
+}
+
+// GetByUserMonthAndCategory retrieves the user's budget for a month and category
+func (r *BudgetRepository) GetByUserMonthAndCategory(userID uuid.UUID, month time.Time, category string) (*models.Budget, error) {
+	query := `
+		SELECT ` + budgetColumns + `
+		FROM budgets
+		WHERE user_id = $1 AND month = $2 AND category = $3
+	`
+	budget, err := scanBudget(r.db.QueryRow(query, userID, month, category))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Budget not found
+		}
+		return nil, err
+	}
+	return budget, nil
+}
+
+// GetByUserAndMonthRange retrieves the user's budgets for the months from startMonth to
+// endMonth inclusive, ordered by month and category
+func (r *BudgetRepository) GetByUserAndMonthRange(userID uuid.UUID, startMonth, endMonth time.Time) ([]*models.Budget, error) {
+	query := `
+		SELECT ` + budgetColumns + `
+		FROM budgets
+		WHERE user_id = $1 AND month >= $2 AND month <= $3
+		ORDER BY month, category
+	`
+	rows, err := r.db.Query(query, userID, startMonth, endMonth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var budgets []*models.Budget
+	for rows.Next() {
+		budget, err := scanBudget(rows)
+		if err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, budget)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
+
+// CopyForward copies the user's budgets from one month into another, skipping categories
+// that already have a budget in the target month. It returns the number of budgets created.
+func (r *BudgetRepository) CopyForward(userID uuid.UUID, fromMonth, toMonth time.Time) (int64, error) {
+	budgets, err := r.GetByUserAndMonthRange(userID, fromMonth, fromMonth)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO budgets (` + budgetColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id, month, category) DO NOTHING
+	`
+	var created int64
+	for _, budget := range budgets {
+		copied := models.NewBudget(userID, toMonth, budget.Category, budget.Amount, budget.Rollover)
+		result, err := tx.Exec(
+			query,
+			copied.ID,
+			copied.UserID,
+			copied.Month,
+			copied.Category,
+			copied.Amount,
+			copied.Rollover,
+			copied.CreatedAt,
+			copied.UpdatedAt,
+		)
+		if err != nil {
+			return 0, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		created += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return created, nil
+}
+
+// Delete removes a budget from the database
+func (r *BudgetRepository) Delete(id uuid.UUID) error {
+	query := `DELETE FROM budgets WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
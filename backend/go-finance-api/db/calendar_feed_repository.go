@@ -0,0 +1,56 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// CalendarFeedRepository handles database operations for private calendar feeds
+type CalendarFeedRepository struct {
+	db *Database
+}
+
+// NewCalendarFeedRepository creates a new CalendarFeedRepository
+func NewCalendarFeedRepository(db *Database) *CalendarFeedRepository {
+	return &CalendarFeedRepository{db: db}
+}
+
+// Upsert stores a user's feed, replacing any earlier token so old feed URLs stop working
+func (r *CalendarFeedRepository) Upsert(feed *models.CalendarFeed) error {
+	query := `
+		INSERT INTO calendar_feeds (user_id, token_hash, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET
+			token_hash = EXCLUDED.token_hash,
+			created_at = EXCLUDED.created_at
+	`
+	_, err := r.db.Exec(query, feed.UserID, feed.TokenHash, feed.CreatedAt)
+	return err
+}
+
+// GetByTokenHash retrieves the feed whose token has the given hash
+func (r *CalendarFeedRepository) GetByTokenHash(tokenHash string) (*models.CalendarFeed, error) {
+	query := `
+		SELECT user_id, token_hash, created_at
+		FROM calendar_feeds
+		WHERE token_hash = $1
+	`
+	var feed models.CalendarFeed
+	err := r.db.QueryRow(query, tokenHash).Scan(&feed.UserID, &feed.TokenHash, &feed.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Feed not found
+		}
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// Delete removes a user's feed, disabling its URL
+func (r *CalendarFeedRepository) Delete(userID uuid.UUID) error {
+	query := `DELETE FROM calendar_feeds WHERE user_id = $1`
+	_, err := r.db.Exec(query, userID)
+	return err
+}
@@ -0,0 +1,322 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// CategorizerRepository handles database operations for the category classifier: models,
+// training examples and predictions
+type CategorizerRepository struct {
+	db *Database
+}
+
+// NewCategorizerRepository creates a new CategorizerRepository
+func NewCategorizerRepository(db *Database) *CategorizerRepository {
+	return &CategorizerRepository{db: db}
+}
+
+// GetModel retrieves a user's classifier
+func (r *CategorizerRepository) GetModel(userID uuid.UUID) (*models.CategorizerModel, error) {
+	query := `
+		SELECT user_id, model, examples, trained_through, updated_at
+		FROM categorizer_models
+		WHERE user_id = $1
+	`
+	var model models.CategorizerModel
+	err := r.db.QueryRow(query, userID).Scan(
+		&model.UserID,
+		&model.Model,
+		&model.Examples,
+		&model.TrainedThrough,
+		&model.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No model trained yet
+		}
+		return nil, err
+	}
+	return &model, nil
+}
+
+// SaveModel stores a user's classifier along with the examples learned or unlearned
+// since it was loaded. Examples with an empty category are deleted.
+func (r *CategorizerRepository) SaveModel(model *models.CategorizerModel, examples []*models.CategorizerExample) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, example := range examples {
+		if example.Category == "" {
+			_, err = tx.Exec(`DELETE FROM categorizer_examples WHERE transaction_id = $1`, example.TransactionID)
+		} else {
+			_, err = tx.Exec(`
+				INSERT INTO categorizer_examples (transaction_id, user_id, category, features)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT (transaction_id) DO UPDATE SET
+					category = EXCLUDED.category,
+					features = EXCLUDED.features
+			`, example.TransactionID, example.UserID, example.Category, pq.Array(example.Features))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	model.UpdatedAt = time.Now().UTC()
+	query := `
+		INSERT INTO categorizer_models (user_id, model, examples, trained_through, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE SET
+			model = EXCLUDED.model,
+			examples = EXCLUDED.examples,
+			trained_through = EXCLUDED.trained_through,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err = tx.Exec(query, model.UserID, model.Model, model.Examples, model.TrainedThrough, model.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteModel removes a user's classifier and its examples so it can be trained again
+// from scratch
+func (r *CategorizerRepository) DeleteModel(userID uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM categorizer_examples WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM categorizer_models WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetExamples retrieves the examples learned from the given transactions, by transaction ID
+func (r *CategorizerRepository) GetExamples(transactionIDs []uuid.UUID) (map[uuid.UUID]*models.CategorizerExample, error) {
+	query := `
+		SELECT transaction_id, user_id, category, features
+		FROM categorizer_examples
+		WHERE transaction_id = ANY($1)
+	`
+	rows, err := r.db.Query(query, pq.Array(transactionIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	examples := make(map[uuid.UUID]*models.CategorizerExample)
+	for rows.Next() {
+		var example models.CategorizerExample
+		err := rows.Scan(&example.TransactionID, &example.UserID, &example.Category, pq.Array(&example.Features))
+		if err != nil {
+			return nil, err
+		}
+		examples[example.TransactionID] = &example
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return examples, nil
+}
+
+// GetOrphanedExamples retrieves a user's examples whose transaction has been deleted
+func (r *CategorizerRepository) GetOrphanedExamples(userID uuid.UUID) ([]*models.CategorizerExample, error) {
+	query := `
+		SELECT e.transaction_id, e.user_id, e.category, e.features
+		FROM categorizer_examples e
+		LEFT JOIN transactions t ON t.id = e.transaction_id
+		WHERE e.user_id = $1 AND t.id IS NULL
+	`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var examples []*models.CategorizerExample
+	for rows.Next() {
+		var example models.CategorizerExample
+		err := rows.Scan(&example.TransactionID, &example.UserID, &example.Category, pq.Array(&example.Features))
+		if err != nil {
+			return nil, err
+		}
+		examples = append(examples, &example)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return examples, nil
+}
+
+// predictionColumns is the column list scanned by scanPrediction
+const predictionColumns = `transaction_id, user_id, category, confidence, status, created_at, updated_at`
+
+// scanPrediction scans a prediction row
+func scanPrediction(row rowScanner) (*models.CategoryPrediction, error) {
+	var prediction models.CategoryPrediction
+	err := row.Scan(
+		&prediction.TransactionID,
+		&prediction.UserID,
+		&prediction.Category,
+		&prediction.Confidence,
+		&prediction.Status,
+		&prediction.CreatedAt,
+		&prediction.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &prediction, nil
+}
+
+// GetPrediction retrieves the prediction for a transaction
+func (r *CategorizerRepository) GetPrediction(transactionID uuid.UUID) (*models.CategoryPrediction, error) {
+	query := `SELECT ` + predictionColumns + ` FROM category_predictions WHERE transaction_id = $1`
+	prediction, err := scanPrediction(r.db.QueryRow(query, transactionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No prediction
+		}
+		return nil, err
+	}
+	return prediction, nil
+}
+
+// GetByStatus retrieves a user's predictions with a status, least confident first
+func (r *CategorizerRepository) GetByStatus(userID uuid.UUID, status string, limit, offset int) ([]*models.CategoryPrediction, error) {
+	query := `
+		SELECT ` + predictionColumns + `
+		FROM category_predictions
+		WHERE user_id = $1 AND status = $2
+		ORDER BY confidence, created_at
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := r.db.Query(query, userID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	predictions := []*models.CategoryPrediction{}
+	for rows.Next() {
+		prediction, err := scanPrediction(rows)
+		if err != nil {
+			return nil, err
+		}
+		predictions = append(predictions, prediction)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return predictions, nil
+}
+
+// GetAppliedCategories maps the user's transactions whose category was set by a
+// prediction to that category
+func (r *CategorizerRepository) GetAppliedCategories(userID uuid.UUID) (map[uuid.UUID]string, error) {
+	query := `SELECT transaction_id, category FROM category_predictions WHERE user_id = $1 AND status = $2`
+	rows, err := r.db.Query(query, userID, models.PredictionStatusApplied)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[uuid.UUID]string)
+	for rows.Next() {
+		var transactionID uuid.UUID
+		var category string
+		if err := rows.Scan(&transactionID, &category); err != nil {
+			return nil, err
+		}
+		applied[transactionID] = category
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// CreatePrediction records a prediction. An applied prediction also sets the
+// transaction's category, unless the user categorized it in the meantime.
+func (r *CategorizerRepository) CreatePrediction(prediction *models.CategoryPrediction) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO category_predictions (` + predictionColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (transaction_id) DO NOTHING
+	`
+	_, err = tx.Exec(
+		query,
+		prediction.TransactionID,
+		prediction.UserID,
+		prediction.Category,
+		prediction.Confidence,
+		prediction.Status,
+		prediction.CreatedAt,
+		prediction.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if prediction.Status == models.PredictionStatusApplied {
+		_, err = tx.Exec(`
+			UPDATE transactions
+			SET user_category = $1, updated_at = $2
+			WHERE id = $3 AND COALESCE(user_category, '') = ''
+		`, prediction.Category, prediction.UpdatedAt, prediction.TransactionID)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Resolve closes a prediction in the review queue with a status. If category is not
+// empty it becomes the transaction's category.
+func (r *CategorizerRepository) Resolve(prediction *models.CategoryPrediction, status, category string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	_, err = tx.Exec(`UPDATE category_predictions SET status = $1, updated_at = $2 WHERE transaction_id = $3`,
+		status, now, prediction.TransactionID)
+	if err != nil {
+		return err
+	}
+	if category != "" {
+		_, err = tx.Exec(`UPDATE transactions SET user_category = $1, updated_at = $2 WHERE id = $3`,
+			category, now, prediction.TransactionID)
+		if err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	prediction.Status = status
+	prediction.UpdatedAt = now
+	return nil
+}
@@ -0,0 +1,200 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// ConversationRepository handles database operations for assistant conversations and
+// their messages
+type ConversationRepository struct {
+	db *Database
+}
+
+// NewConversationRepository creates a new ConversationRepository
+func NewConversationRepository(db *Database) *ConversationRepository {
+	return &ConversationRepository{db: db}
+}
+
+// conversationColumns is the column list scanned by scanConversation
+const conversationColumns = `id, user_id, title, created_at, updated_at`
+
+// scanConversation scans a conversation row
+func scanConversation(row rowScanner) (*models.Conversation, error) {
+	var conversation models.Conversation
+	err := row.Scan(
+		&conversation.ID,
+		&conversation.UserID,
+		&conversation.Title,
+		&conversation.CreatedAt,
+		&conversation.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// Create inserts a new conversation into the database
+func (r *ConversationRepository) Create(conversation *models.Conversation) error {
+	query := `
+		INSERT INTO assistant_conversations (` + conversationColumns + `)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := r.db.Exec(
+		query,
+		conversation.ID,
+		conversation.UserID,
+		conversation.Title,
+		conversation.CreatedAt,
+		conversation.UpdatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves a conversation by ID, without its messages
+func (r *ConversationRepository) GetByID(id uuid.UUID) (*models.Conversation, error) {
+	query := `SELECT ` + conversationColumns + ` FROM assistant_conversations WHERE id = $1`
+	conversation, err := scanConversation(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Conversation not found
+		}
+		return nil, err
+	}
+	return conversation, nil
+}
+
+// GetByUserID retrieves a user's conversations, most recently active first
+func (r *ConversationRepository) GetByUserID(userID uuid.UUID, limit, offset int) ([]*models.Conversation, error) {
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM assistant_conversations
+		WHERE user_id = $1
+		ORDER BY updated_at DESC, id
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.db.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conversations := []*models.Conversation{}
+	for rows.Next() {
+		conversation, err := scanConversation(rows)
+		if err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, conversation)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return conversations, nil
+}
+
+// GetMessages retrieves a conversation's messages in order
+func (r *ConversationRepository) GetMessages(conversationID uuid.UUID) ([]*models.ConversationMessage, error) {
+	query := `
+		SELECT id, conversation_id, position, role, content, tool_calls, COALESCE(tool_call_id, ''), created_at
+		FROM assistant_messages
+		WHERE conversation_id = $1
+		ORDER BY position
+	`
+	rows, err := r.db.Query(query, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*models.ConversationMessage
+	for rows.Next() {
+		var message models.ConversationMessage
+		var toolCalls []byte
+		err := rows.Scan(
+			&message.ID,
+			&message.ConversationID,
+			&message.Position,
+			&message.Role,
+			&message.Content,
+			&toolCalls,
+			&message.ToolCallID,
+			&message.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		message.ToolCalls = toolCalls
+		messages = append(messages, &message)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// AppendMessages adds messages to the end of a conversation, assigning their positions,
+// and marks the conversation as updated
+func (r *ConversationRepository) AppendMessages(conversationID uuid.UUID, messages []*models.ConversationMessage) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the conversation so concurrent appends cannot take the same positions
+	var position int
+	err = tx.QueryRow(`SELECT 0 FROM assistant_conversations WHERE id = $1 FOR UPDATE`, conversationID).Scan(&position)
+	if err != nil {
+		return err
+	}
+	err = tx.QueryRow(`SELECT COALESCE(MAX(position), 0) FROM assistant_messages WHERE conversation_id = $1`, conversationID).Scan(&position)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO assistant_messages (id, conversation_id, position, role, content, tool_calls, tool_call_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
+	`
+	for _, message := range messages {
+		position++
+		message.ConversationID = conversationID
+		message.Position = position
+		var toolCalls interface{}
+		if len(message.ToolCalls) > 0 {
+			toolCalls = []byte(message.ToolCalls)
+		}
+		_, err := tx.Exec(
+			query,
+			message.ID,
+			message.ConversationID,
+			message.Position,
+			message.Role,
+			message.Content,
+			toolCalls,
+			message.ToolCallID,
+			message.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE assistant_conversations SET updated_at = $1 WHERE id = $2`, time.Now().UTC(), conversationID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete removes a conversation and its messages
+func (r *ConversationRepository) Delete(id uuid.UUID) error {
+	query := `DELETE FROM assistant_conversations WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
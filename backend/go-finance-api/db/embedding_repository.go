@@ -0,0 +1,201 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// EmbeddingRepository handles database operations for transaction embeddings
+type EmbeddingRepository struct {
+	db *Database
+}
+
+// NewEmbeddingRepository creates a new EmbeddingRepository
+func NewEmbeddingRepository(db *Database) *EmbeddingRepository {
+	return &EmbeddingRepository{db: db}
+}
+
+// embeddingColumns is the column list matching scanEmbedding
+const embeddingColumns = `transaction_id, user_id, model, text_hash, embedding, updated_at`
+
+// scanEmbedding scans an embedding row selected with embeddingColumns
+func scanEmbedding(row rowScanner) (*models.TransactionEmbedding, error) {
+	var embedding models.TransactionEmbedding
+	err := row.Scan(
+		&embedding.TransactionID,
+		&embedding.UserID,
+		&embedding.Model,
+		&embedding.TextHash,
+		(*pq.Float32Array)(&embedding.Vector),
+		&embedding.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &embedding, nil
+}
+
+// VectorSearchAvailable reports whether embeddings have the pgvector column added by
+// migration 30 when pgvector is installed, so Nearest can be used
+func (r *EmbeddingRepository) VectorSearchAvailable() (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_name = 'transaction_embeddings' AND column_name = 'embedding_vector'
+		)
+	`
+	var available bool
+	err := r.db.QueryRow(query).Scan(&available)
+	return available, err
+}
+
+// EnsureVectorIndex creates the HNSW index Nearest uses for vectors of the given
+// dimensions, if it doesn't exist. It requires pgvector; see VectorSearchAvailable.
+func (r *EmbeddingRepository) EnsureVectorIndex(dimensions int) error {
+	// dimensions is an integer, so formatting it into the statement is safe
+	query := fmt.Sprintf(`
+		CREATE INDEX IF NOT EXISTS idx_transaction_embeddings_vector_%[1]d
+		ON transaction_embeddings USING hnsw ((embedding_vector::vector(%[1]d)) vector_cosine_ops)
+		WHERE dimensions = %[1]d
+	`, dimensions)
+	_, err := r.db.Exec(query)
+	return err
+}
+
+// Version returns how many embeddings the user has under a model and when the latest
+// was written, which change whenever one is added, replaced or removed
+func (r *EmbeddingRepository) Version(userID uuid.UUID, model string) (int, time.Time, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(MAX(updated_at), 'epoch')
+		FROM transaction_embeddings
+		WHERE user_id = $1 AND model = $2
+	`
+	var count int
+	var latest time.Time
+	err := r.db.QueryRow(query, userID, model).Scan(&count, &latest)
+	return count, latest, err
+}
+
+// Upsert stores a transaction's embedding, replacing any earlier one
+func (r *EmbeddingRepository) Upsert(embedding *models.TransactionEmbedding) error {
+	query := `
+		INSERT INTO transaction_embeddings (transaction_id, user_id, model, text_hash, embedding, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (transaction_id) DO UPDATE SET
+			model = EXCLUDED.model,
+			text_hash = EXCLUDED.text_hash,
+			embedding = EXCLUDED.embedding,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.Exec(
+		query,
+		embedding.TransactionID,
+		embedding.UserID,
+		embedding.Model,
+		embedding.TextHash,
+		pq.Float32Array(embedding.Vector),
+		embedding.UpdatedAt,
+	)
+	return err
+}
+
+// Touch marks the embeddings of the given transactions as current without changing
+// their vectors, for transactions that changed in ways the embedded text does not show
+func (r *EmbeddingRepository) Touch(transactionIDs []uuid.UUID) error {
+	query := `UPDATE transaction_embeddings SET updated_at = $1 WHERE transaction_id = ANY($2)`
+	_, err := r.db.Exec(query, time.Now().UTC(), pq.Array(transactionIDs))
+	return err
+}
+
+// GetByTransactionIDs retrieves the embeddings of the given transactions under a model,
+// by transaction ID
+func (r *EmbeddingRepository) GetByTransactionIDs(transactionIDs []uuid.UUID, model string) (map[uuid.UUID]*models.TransactionEmbedding, error) {
+	query := `
+		SELECT ` + embeddingColumns + `
+		FROM transaction_embeddings
+		WHERE transaction_id = ANY($1) AND model = $2
+	`
+	rows, err := r.db.Query(query, pq.Array(transactionIDs), model)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	embeddings := make(map[uuid.UUID]*models.TransactionEmbedding)
+	for rows.Next() {
+		embedding, err := scanEmbedding(rows)
+		if err != nil {
+			return nil, err
+		}
+		embeddings[embedding.TransactionID] = embedding
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return embeddings, nil
+}
+
+// ForEachByUserID calls fn with each of the user's embeddings under a model, stopping at
+// the first error
+func (r *EmbeddingRepository) ForEachByUserID(userID uuid.UUID, model string, fn func(*models.TransactionEmbedding) error) error {
+	query := `
+		SELECT ` + embeddingColumns + `
+		FROM transaction_embeddings
+		WHERE user_id = $1 AND model = $2
+	`
+	rows, err := r.db.Query(query, userID, model)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		embedding, err := scanEmbedding(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(embedding); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Nearest retrieves up to limit of the user's transactions whose embeddings under a
+// model are most similar to vector, most similar first, after skipping offset. The
+// exclude transaction, if any, is left out. It requires pgvector; see
+// VectorSearchAvailable. The ordering matches the index from EnsureVectorIndex, which
+// the user and model conditions filter after it is scanned.
+func (r *EmbeddingRepository) Nearest(userID uuid.UUID, model string, vector []float32, exclude uuid.UUID, offset, limit int) ([]*models.EmbeddingMatch, error) {
+	// The dimensions are formatted into the statement so the expression matches the
+	// partial index for them
+	query := fmt.Sprintf(`
+		SELECT transaction_id, 1 - (embedding_vector::vector(%[1]d) <=> $3::real[]::vector(%[1]d)) AS score
+		FROM transaction_embeddings
+		WHERE dimensions = %[1]d AND user_id = $1 AND model = $2 AND transaction_id <> $4
+		ORDER BY embedding_vector::vector(%[1]d) <=> $3::real[]::vector(%[1]d)
+		LIMIT $5 OFFSET $6
+	`, len(vector))
+	rows, err := r.db.Query(query, userID, model, pq.Float32Array(vector), exclude, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []*models.EmbeddingMatch
+	for rows.Next() {
+		var match models.EmbeddingMatch
+		if err := rows.Scan(&match.TransactionID, &match.Score); err != nil {
+			return nil, err
+		}
+		matches = append(matches, &match)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
@@ -0,0 +1,187 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// GoalRepository handles database operations for savings goals and their contributions
+type GoalRepository struct {
+	db *Database
+}
+
+// NewGoalRepository creates a new GoalRepository
+func NewGoalRepository(db *Database) *GoalRepository {
+	return &GoalRepository{db: db}
+}
+
+// goalColumns is the column list scanned by scanGoal
+const goalColumns = `id, user_id, name, target_amount, target_date, account_id, created_at, updated_at`
+
+// scanGoal scans a goal row
+func scanGoal(row rowScanner) (*models.Goal, error) {
+	var goal models.Goal
+	err := row.Scan(
+		&goal.ID,
+		&goal.UserID,
+		&goal.Name,
+		&goal.TargetAmount,
+		&goal.TargetDate,
+		&goal.AccountID,
+		&goal.CreatedAt,
+		&goal.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &goal, nil
+}
+
+// Create inserts a new goal into the database
+func (r *GoalRepository) Create(goal *models.Goal) error {
+	query := `
+		INSERT INTO goals (` + goalColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.Exec(
+		query,
+		goal.ID,
+		goal.UserID,
+		goal.Name,
+		goal.TargetAmount,
+		goal.TargetDate,
+		goal.AccountID,
+		goal.CreatedAt,
+		goal.UpdatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves a goal by ID
+func (r *GoalRepository) GetByID(id uuid.UUID) (*models.Goal, error) {
+	query := `SELECT ` + goalColumns + ` FROM goals WHERE id = $1`
+	goal, err := scanGoal(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Goal not found
+		}
+		return nil, err
+	}
+	return goal, nil
+}
+
+// GetByUserID retrieves all of a user's goals, soonest target date first
+func (r *GoalRepository) GetByUserID(userID uuid.UUID) ([]*models.Goal, error) {
+	query := `
+		SELECT ` + goalColumns + `
+		FROM goals
+		WHERE user_id = $1
+		ORDER BY target_date NULLS LAST, name
+	`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var goals []*models.Goal
+	for rows.Next() {
+		goal, err := scanGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, goal)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return goals, nil
+}
+
+// Update updates a goal's name, target and linked account
+func (r *GoalRepository) Update(goal *models.Goal) error {
+	goal.UpdatedAt = time.Now().UTC()
+	query := `
+		UPDATE goals
+		SET name = $1, target_amount = $2, target_date = $3, account_id = $4, updated_at = $5
+		WHERE id = $6
+	`
+	_, err := r.db.Exec(query, goal.Name, goal.TargetAmount, goal.TargetDate, goal.AccountID, goal.UpdatedAt, goal.ID)
+	return err
+}
+
+// Delete removes a goal from the database along with its contributions
+func (r *GoalRepository) Delete(id uuid.UUID) error {
+	query := `DELETE FROM goals WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+// CreateContribution inserts a new contribution into the database
+func (r *GoalRepository) CreateContribution(contribution *models.GoalContribution) error {
+	query := `
+		INSERT INTO goal_contributions (id, goal_id, date, amount, note, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+	`
+	_, err := r.db.Exec(
+		query,
+		contribution.ID,
+		contribution.GoalID,
+		contribution.Date,
+		contribution.Amount,
+		contribution.Note,
+		contribution.CreatedAt,
+	)
+	return err
+}
+
+// GetContributions retrieves a goal's contributions, oldest first
+func (r *GoalRepository) GetContributions(goalID uuid.UUID) ([]*models.GoalContribution, error) {
+	query := `
+		SELECT id, goal_id, date, amount, COALESCE(note, ''), created_at
+		FROM goal_contributions
+		WHERE goal_id = $1
+		ORDER BY date, created_at
+	`
+	rows, err := r.db.Query(query, goalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contributions []*models.GoalContribution
+	for rows.Next() {
+		var contribution models.GoalContribution
+		err := rows.Scan(
+			&contribution.ID,
+			&contribution.GoalID,
+			&contribution.Date,
+			&contribution.Amount,
+			&contribution.Note,
+			&contribution.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		contributions = append(contributions, &contribution)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return contributions, nil
+}
+
+// DeleteContribution removes one of a goal's contributions. It reports whether the
+// contribution existed.
+func (r *GoalRepository) DeleteContribution(goalID, id uuid.UUID) (bool, error) {
+	query := `DELETE FROM goal_contributions WHERE id = $1 AND goal_id = $2`
+	result, err := r.db.Exec(query, id, goalID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
@@ -44,7 +44,7 @@ func (r *ItemRepository) Create(item *models.Item) error {
 // GetByID retrieves an item by ID
 func (r *ItemRepository) GetByID(id uuid.UUID) (*models.Item, error) {
 	query := `
-		SELECT id, user_id, plaid_item_id, access_token, institution_id, institution_name, status, webhook_url, consent, created_at, updated_at
+		SELECT id, user_id, plaid_item_id, access_token, institution_id, institution_name, status, webhook_url, consent, COALESCE(sync_cursor, ''), created_at, updated_at
 		FROM items
 		WHERE id = $1
 	`
@@ -59,6 +59,7 @@ func (r *ItemRepository) GetByID(id uuid.UUID) (*models.Item, error) {
 		&item.Status,
 		&item.WebhookURL,
 		&item.Consent,
+		&item.SyncCursor,
 		&item.CreatedAt,
 		&item.UpdatedAt,
 	)
@@ -74,7 +75,7 @@ func (r *ItemRepository) GetByID(id uuid.UUID) (*models.Item, error) {
 // GetByPlaidItemID retrieves an item by its Plaid item ID
 func (r *ItemRepository) GetByPlaidItemID(plaidItemID string) (*models.Item, error) {
 	query := `
-		SELECT id, user_id, plaid_item_id, access_token, institution_id, institution_name, status, webhook_url, consent, created_at, updated_at
+		SELECT id, user_id, plaid_item_id, access_token, institution_id, institution_name, status, webhook_url, consent, COALESCE(sync_cursor, ''), created_at, updated_at
 		FROM items
 		WHERE plaid_item_id = $1
 	`
@@ -89,6 +90,7 @@ func (r *ItemRepository) GetByPlaidItemID(plaidItemID string) (*models.Item, err
 		&item.Status,
 		&item.WebhookURL,
 		&item.Consent,
+		&item.SyncCursor,
 		&item.CreatedAt,
 		&item.UpdatedAt,
 	)
@@ -104,7 +106,7 @@ func (r *ItemRepository) GetByPlaidItemID(plaidItemID string) (*models.Item, err
 // GetByUserID retrieves all items for a specific user
 func (r *ItemRepository) GetByUserID(userID uuid.UUID) ([]*models.Item, error) {
 	query := `
-		SELECT id, user_id, plaid_item_id, access_token, institution_id, institution_name, status, webhook_url, consent, created_at, updated_at
+		SELECT id, user_id, plaid_item_id, access_token, institution_id, institution_name, status, webhook_url, consent, COALESCE(sync_cursor, ''), created_at, updated_at
 		FROM items
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -128,6 +130,7 @@ func (r *ItemRepository) GetByUserID(userID uuid.UUID) ([]*models.Item, error) {
 			&item.Status,
 			&item.WebhookURL,
 			&item.Consent,
+			&item.SyncCursor,
 			&item.CreatedAt,
 			&item.UpdatedAt,
 		)
@@ -175,6 +178,17 @@ func (r *ItemRepository) UpdateAccessToken(id uuid.UUID, accessToken string) err
 	return err
 }
 
+// UpdateSyncCursor stores the latest /transactions/sync cursor for an item
+func (r *ItemRepository) UpdateSyncCursor(id uuid.UUID, cursor string) error {
+	query := `
+		UPDATE items
+		SET sync_cursor = $1, updated_at = $2
+		WHERE id = $3
+	`
+	_, err := r.db.Exec(query, cursor, time.Now().UTC(), id)
+	return err
+}
+
 // Delete removes an item from the database
 func (r *ItemRepository) Delete(id uuid.UUID) error {
 	query := `DELETE FROM items WHERE id = $1`
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// LLMUsageRepository handles database operations for language model usage records
+type LLMUsageRepository struct {
+	db *Database
+}
+
+// NewLLMUsageRepository creates a new LLMUsageRepository
+func NewLLMUsageRepository(db *Database) *LLMUsageRepository {
+	return &LLMUsageRepository{db: db}
+}
+
+// Record inserts a usage record
+func (r *LLMUsageRepository) Record(usage *models.LLMUsage) error {
+	query := `
+		INSERT INTO llm_usage (id, user_id, feature, model, prompt_tokens, completion_tokens, cost, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.Exec(
+		query,
+		usage.ID,
+		usage.UserID,
+		usage.Feature,
+		usage.Model,
+		usage.PromptTokens,
+		usage.CompletionTokens,
+		usage.Cost,
+		usage.CreatedAt,
+	)
+	return err
+}
+
+// CostSince sums the cost of a user's requests since a point in time
+func (r *LLMUsageRepository) CostSince(userID uuid.UUID, since time.Time) (float64, error) {
+	var cost float64
+	query := `SELECT COALESCE(SUM(cost), 0) FROM llm_usage WHERE user_id = $1 AND created_at >= $2`
+	err := r.db.QueryRow(query, userID, since).Scan(&cost)
+	return cost, err
+}
+
+// SummarizeSince totals a user's requests, tokens and cost since a point in time
+func (r *LLMUsageRepository) SummarizeSince(userID uuid.UUID, since time.Time) (*models.LLMUsageSummary, error) {
+	summary := &models.LLMUsageSummary{Since: since}
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(completion_tokens), 0), COALESCE(SUM(cost), 0)
+		FROM llm_usage
+		WHERE user_id = $1 AND created_at >= $2
+	`
+	err := r.db.QueryRow(query, userID, since).Scan(
+		&summary.Requests,
+		&summary.PromptTokens,
+		&summary.CompletionTokens,
+		&summary.Cost,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
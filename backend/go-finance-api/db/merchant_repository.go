@@ -0,0 +1,196 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// MerchantRepository handles database operations for merchants
+type MerchantRepository struct {
+	db *Database
+}
+
+// NewMerchantRepository creates a new MerchantRepository
+func NewMerchantRepository(db *Database) *MerchantRepository {
+	return &MerchantRepository{db: db}
+}
+
+// merchantColumns is the column list matching scanMerchant
+const merchantColumns = `
+			id, user_id, key, name, COALESCE(logo_url, ''), COALESCE(website, ''),
+			merged_into, created_at, updated_at`
+
+// scanMerchant scans a single merchant row selected with merchantColumns
+func scanMerchant(row rowScanner) (*models.Merchant, error) {
+	var merchant models.Merchant
+	err := row.Scan(
+		&merchant.ID,
+		&merchant.UserID,
+		&merchant.Key,
+		&merchant.Name,
+		&merchant.LogoURL,
+		&merchant.Website,
+		&merchant.MergedInto,
+		&merchant.CreatedAt,
+		&merchant.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &merchant, nil
+}
+
+// GetByID retrieves a merchant by ID
+func (r *MerchantRepository) GetByID(id uuid.UUID) (*models.Merchant, error) {
+	query := `SELECT ` + merchantColumns + ` FROM merchants WHERE id = $1`
+	merchant, err := scanMerchant(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Merchant not found
+		}
+		return nil, err
+	}
+	return merchant, nil
+}
+
+// GetByUserID retrieves a user's canonical merchants, excluding merged ones, with the
+// number of transactions linked to each
+func (r *MerchantRepository) GetByUserID(userID uuid.UUID) ([]*models.Merchant, error) {
+	query := `
+		SELECT ` + merchantColumns + `,
+			(SELECT COUNT(*) FROM transactions t WHERE t.merchant_id = merchants.id)
+		FROM merchants
+		WHERE user_id = $1 AND merged_into IS NULL
+		ORDER BY name
+	`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	merchants := []*models.Merchant{}
+	for rows.Next() {
+		var merchant models.Merchant
+		err := rows.Scan(
+			&merchant.ID,
+			&merchant.UserID,
+			&merchant.Key,
+			&merchant.Name,
+			&merchant.LogoURL,
+			&merchant.Website,
+			&merchant.MergedInto,
+			&merchant.CreatedAt,
+			&merchant.UpdatedAt,
+			&merchant.Transactions,
+		)
+		if err != nil {
+			return nil, err
+		}
+		merchants = append(merchants, &merchant)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return merchants, nil
+}
+
+// Resolve returns the canonical merchant for a user's normalized key, creating it from
+// merchant if the key is new. A merchant without a logo or website takes merchant's.
+// If the key belongs to a merged merchant, the merchant it was merged into is returned.
+func (r *MerchantRepository) Resolve(merchant *models.Merchant) (*models.Merchant, error) {
+	query := `
+		INSERT INTO merchants (id, user_id, key, name, logo_url, website, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8)
+		ON CONFLICT (user_id, key) DO UPDATE SET
+			logo_url = COALESCE(merchants.logo_url, EXCLUDED.logo_url),
+			website = COALESCE(merchants.website, EXCLUDED.website)
+		RETURNING ` + merchantColumns
+	resolved, err := scanMerchant(r.db.QueryRow(
+		query,
+		merchant.ID,
+		merchant.UserID,
+		merchant.Key,
+		merchant.Name,
+		merchant.LogoURL,
+		merchant.Website,
+		merchant.CreatedAt,
+		merchant.UpdatedAt,
+	))
+	if err != nil {
+		return nil, err
+	}
+	if resolved.MergedInto != nil {
+		return r.GetByID(*resolved.MergedInto)
+	}
+	return resolved, nil
+}
+
+// Update saves a merchant's user-editable name, logo and website. Renaming it bumps its
+// transactions' updated_at so spending summaries are rebuilt under the new name.
+func (r *MerchantRepository) Update(merchant *models.Merchant) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	_, err = tx.Exec(`
+		UPDATE transactions t
+		SET updated_at = $1
+		FROM merchants m
+		WHERE m.id = $2 AND t.merchant_id = m.id AND m.name <> $3
+	`, now, merchant.ID, merchant.Name)
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE merchants
+		SET name = $1, logo_url = NULLIF($2, ''), website = NULLIF($3, ''), updated_at = $4
+		WHERE id = $5
+	`
+	_, err = tx.Exec(query, merchant.Name, merchant.LogoURL, merchant.Website, now, merchant.ID)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	merchant.UpdatedAt = now
+	return nil
+}
+
+// Merge moves the transactions of source, and of every merchant already merged into it,
+// to target. Source is kept as an alias of target so future transactions resolve to it.
+// The moved transactions' updated_at is bumped so spending summaries are rebuilt.
+func (r *MerchantRepository) Merge(source, target *models.Merchant) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	_, err = tx.Exec(`
+		UPDATE merchants
+		SET merged_into = $1, updated_at = $2
+		WHERE id = $3 OR merged_into = $3
+	`, target.ID, now, source.ID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE transactions SET merchant_id = $1, updated_at = $2 WHERE merchant_id = $3`, target.ID, now, source.ID)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	source.MergedInto = &target.ID
+	source.UpdatedAt = now
+	return nil
+}
@@ -124,6 +124,533 @@ var Migrations = []string{
 	 CREATE INDEX idx_plaid_api_events_item_id ON plaid_api_events(item_id);
 	 CREATE INDEX idx_link_events_user_id ON link_events(user_id);
 	 CREATE INDEX idx_link_events_item_id ON link_events(item_id);`,
+
+	// Migration 8: Add sync cursor to items and user-owned columns to transactions
+	`ALTER TABLE items ADD COLUMN sync_cursor TEXT;
+	 ALTER TABLE transactions ADD COLUMN user_category VARCHAR(255);
+	 ALTER TABLE transactions ADD COLUMN custom_name VARCHAR(255);
+	 ALTER TABLE transactions ADD COLUMN is_transfer BOOLEAN NOT NULL DEFAULT FALSE;
+	 ALTER TABLE transactions ADD COLUMN hidden BOOLEAN NOT NULL DEFAULT FALSE;`,
+
+	// Migration 9: Create rules, tags and transaction_tags tables
+	`CREATE TABLE IF NOT EXISTS rules (
+		id UUID PRIMARY KEY,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		name VARCHAR(255) NOT NULL,
+		priority INTEGER NOT NULL DEFAULT 0,
+		enabled BOOLEAN NOT NULL DEFAULT TRUE,
+		conditions JSONB NOT NULL,
+		actions JSONB NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS tags (
+		id UUID PRIMARY KEY,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		name VARCHAR(100) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		UNIQUE (user_id, name)
+	);
+	CREATE TABLE IF NOT EXISTS transaction_tags (
+		transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+		tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		PRIMARY KEY (transaction_id, tag_id)
+	);
+	CREATE INDEX idx_rules_user_id ON rules(user_id);
+	CREATE INDEX idx_transaction_tags_tag_id ON transaction_tags(tag_id);`,
+
+	// Migration 10: Create transaction_splits table
+	`CREATE TABLE IF NOT EXISTS transaction_splits (
+		id UUID PRIMARY KEY,
+		transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		amount DECIMAL(19, 4) NOT NULL,
+		category VARCHAR(255) NOT NULL,
+		note TEXT,
+		stale BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+	CREATE INDEX idx_transaction_splits_transaction_id ON transaction_splits(transaction_id);`,
+
+	// Migration 11: Create transaction_lines view for aggregation. Split transactions
+	// contribute one line per split instead of a line for the parent.
+	`CREATE OR REPLACE VIEW transaction_lines AS
+	SELECT
+		t.id AS transaction_id,
+		NULL::UUID AS split_id,
+		t.user_id,
+		t.account_id,
+		t.date,
+		t.amount,
+		COALESCE(NULLIF(t.user_category, ''), t.category[1], 'Uncategorized') AS category,
+		t.name,
+		t.merchant_name,
+		t.pending,
+		t.is_transfer,
+		t.hidden
+	FROM transactions t
+	WHERE NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id)
+	UNION ALL
+	SELECT
+		t.id AS transaction_id,
+		s.id AS split_id,
+		t.user_id,
+		t.account_id,
+		t.date,
+		s.amount,
+		s.category,
+		t.name,
+		t.merchant_name,
+		t.pending,
+		t.is_transfer,
+		t.hidden
+	FROM transaction_splits s
+	JOIN transactions t ON t.id = s.transaction_id`,
+
+	// Migration 12: Add notes to transactions and create transaction_attachments table
+	`ALTER TABLE transactions ADD COLUMN notes TEXT;
+	CREATE TABLE IF NOT EXISTS transaction_attachments (
+		id UUID PRIMARY KEY,
+		transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		file_name VARCHAR(255) NOT NULL,
+		content_type VARCHAR(255) NOT NULL,
+		size_bytes BIGINT NOT NULL,
+		storage_key VARCHAR(512) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+	CREATE INDEX idx_transaction_attachments_transaction_id ON transaction_attachments(transaction_id);`,
+
+	// Migration 13: Track pending-to-posted transaction links. The pending row is deleted
+	// once Plaid removes it, so its ID is kept without a foreign key for audit.
+	`ALTER TABLE transactions ADD COLUMN pending_transaction_id VARCHAR(255);
+	CREATE TABLE IF NOT EXISTS transaction_links (
+		id UUID PRIMARY KEY,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		pending_transaction_id UUID NOT NULL,
+		pending_plaid_transaction_id VARCHAR(255) NOT NULL,
+		pending_amount DECIMAL(19, 4) NOT NULL,
+		posted_transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+		posted_amount DECIMAL(19, 4) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		UNIQUE (pending_transaction_id, posted_transaction_id)
+	);
+	CREATE INDEX idx_transaction_links_posted_transaction_id ON transaction_links(posted_transaction_id);`,
+
+	// Migration 14: Create transfer_pairs table. A transaction belongs to at most one
+	// pair that hasn't been rejected.
+	`CREATE TABLE IF NOT EXISTS transfer_pairs (
+		id UUID PRIMARY KEY,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		outflow_transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+		inflow_transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+		amount DECIMAL(19, 4) NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		UNIQUE (outflow_transaction_id, inflow_transaction_id)
+	);
+	CREATE INDEX idx_transfer_pairs_user_id ON transfer_pairs(user_id);
+	CREATE UNIQUE INDEX idx_transfer_pairs_active_outflow ON transfer_pairs(outflow_transaction_id) WHERE status <> 'rejected';
+	CREATE UNIQUE INDEX idx_transfer_pairs_active_inflow ON transfer_pairs(inflow_transaction_id) WHERE status <> 'rejected';`,
+
+	// Migration 15: Allow manual accounts and transactions that don't come from Plaid
+	`ALTER TABLE accounts ALTER COLUMN item_id DROP NOT NULL;
+	ALTER TABLE accounts ALTER COLUMN plaid_account_id DROP NOT NULL;
+	ALTER TABLE accounts ADD COLUMN balance_mode VARCHAR(20) NOT NULL DEFAULT 'plaid';
+	ALTER TABLE transactions ALTER COLUMN plaid_transaction_id DROP NOT NULL;`,
+
+	// Migration 16: Deduplicate transactions imported from statement files
+	`ALTER TABLE transactions ADD COLUMN import_hash VARCHAR(64);
+	CREATE UNIQUE INDEX idx_transactions_account_import_hash ON transactions(account_id, import_hash);`,
+
+	// Migration 17: Create account_balance_snapshots table, one row per account and day
+	`CREATE TABLE IF NOT EXISTS account_balance_snapshots (
+		id UUID PRIMARY KEY,
+		account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		date DATE NOT NULL,
+		current_balance DECIMAL(19, 4) NOT NULL,
+		available_balance DECIMAL(19, 4),
+		currency_code VARCHAR(3),
+		source VARCHAR(20) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		UNIQUE (account_id, date)
+	);
+	CREATE INDEX idx_account_balance_snapshots_user_id_date ON account_balance_snapshots(user_id, date);`,
+
+	// Migration 18: Create budgets table, one row per user, month and category
+	`CREATE TABLE IF NOT EXISTS budgets (
+		id UUID PRIMARY KEY,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		month DATE NOT NULL,
+		category VARCHAR(255) NOT NULL,
+		amount DECIMAL(19, 4) NOT NULL,
+		rollover BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		UNIQUE (user_id, month, category)
+	);`,
+
+	// Migration 19: Create goals and goal_contributions tables
+	`CREATE TABLE IF NOT EXISTS goals (
+		id UUID PRIMARY KEY,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		name VARCHAR(255) NOT NULL,
+		target_amount DECIMAL(19, 4) NOT NULL,
+		target_date DATE,
+		account_id UUID REFERENCES accounts(id) ON DELETE SET NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+	CREATE INDEX idx_goals_user_id ON goals(user_id);
+
+	CREATE TABLE IF NOT EXISTS goal_contributions (
+		id UUID PRIMARY KEY,
+		goal_id UUID NOT NULL REFERENCES goals(id) ON DELETE CASCADE,
+		date DATE NOT NULL,
+		amount DECIMAL(19, 4) NOT NULL,
+		note TEXT,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+	CREATE INDEX idx_goal_contributions_goal_id ON goal_contributions(goal_id);`,
+
+	// Migration 20: Create recurring_series table for detected subscriptions, bills and income
+	`CREATE TABLE IF NOT EXISTS recurring_series (
+		id UUID PRIMARY KEY,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		account_id UUID REFERENCES accounts(id) ON DELETE SET NULL,
+		series_key VARCHAR(512) NOT NULL,
+		merchant_name VARCHAR(255) NOT NULL,
+		category VARCHAR(255) NOT NULL,
+		frequency VARCHAR(20) NOT NULL,
+		average_amount DECIMAL(19, 4) NOT NULL,
+		last_amount DECIMAL(19, 4) NOT NULL,
+		last_date DATE NOT NULL,
+		next_date DATE NOT NULL,
+		occurrences INTEGER NOT NULL,
+		price_increase BOOLEAN NOT NULL DEFAULT FALSE,
+		previous_amount DECIMAL(19, 4) NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		source VARCHAR(20) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		UNIQUE (user_id, series_key)
+	);`,
+
+	// Migration 21: Add liability payment due dates and create calendar_feeds table. Only
+	// a hash of each feed token is stored.
+	`ALTER TABLE accounts ADD COLUMN payment_due_date DATE;
+	ALTER TABLE accounts ADD COLUMN minimum_payment DECIMAL(19, 4);
+
+	CREATE TABLE IF NOT EXISTS calendar_feeds (
+		user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		token_hash VARCHAR(64) NOT NULL UNIQUE,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);`,
+
+	// Migration 22: Create spending_summary table for reports: transaction lines rolled up
+	// per user, day, account, category and merchant, without transfers or hidden
+	// transactions. spending_summary_state records the transactions a user's rows were
+	// built from.
+	`CREATE TABLE IF NOT EXISTS spending_summary (
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		date DATE NOT NULL,
+		account_id UUID NOT NULL,
+		category VARCHAR(255) NOT NULL,
+		merchant VARCHAR(255) NOT NULL,
+		spent DECIMAL(19, 4) NOT NULL,
+		received DECIMAL(19, 4) NOT NULL,
+		count INTEGER NOT NULL
+	);
+	CREATE INDEX idx_spending_summary_user_id_date ON spending_summary(user_id, date);
+
+	CREATE TABLE IF NOT EXISTS spending_summary_state (
+		user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		fingerprint TEXT NOT NULL,
+		refreshed_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);`,
+
+	// Migration 23: Create llm_usage table recording the tokens and cost of each request
+	// made to a language model on a user's behalf
+	`CREATE TABLE IF NOT EXISTS llm_usage (
+		id UUID PRIMARY KEY,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		feature VARCHAR(50) NOT NULL,
+		model VARCHAR(255) NOT NULL,
+		prompt_tokens INTEGER NOT NULL,
+		completion_tokens INTEGER NOT NULL,
+		cost DECIMAL(19, 6) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+	CREATE INDEX idx_llm_usage_user_id_created_at ON llm_usage(user_id, created_at);`,
+
+	// Migration 24: Create assistant_conversations and assistant_messages tables for the
+	// finance assistant. Messages are ordered by position within their conversation.
+	`CREATE TABLE IF NOT EXISTS assistant_conversations (
+		id UUID PRIMARY KEY,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		title VARCHAR(255) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+	CREATE INDEX idx_assistant_conversations_user_id ON assistant_conversations(user_id, updated_at);
+
+	CREATE TABLE IF NOT EXISTS assistant_messages (
+		id UUID PRIMARY KEY,
+		conversation_id UUID NOT NULL REFERENCES assistant_conversations(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		role VARCHAR(20) NOT NULL,
+		content TEXT NOT NULL,
+		tool_calls JSONB,
+		tool_call_id VARCHAR(255),
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		UNIQUE(conversation_id, position)
+	);`,
+
+	// Migration 25: Create tables for the per-user category classifier: the serialized
+	// model, the labelled transactions it has learned from, and its predictions, which
+	// include the review queue
+	`CREATE TABLE IF NOT EXISTS categorizer_models (
+		user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		model JSONB NOT NULL,
+		examples INTEGER NOT NULL,
+		trained_through TIMESTAMP WITH TIME ZONE NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS categorizer_examples (
+		transaction_id UUID PRIMARY KEY REFERENCES transactions(id) ON DELETE CASCADE,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		category VARCHAR(255) NOT NULL,
+		features TEXT[] NOT NULL
+	);
+	CREATE INDEX idx_categorizer_examples_user_id ON categorizer_examples(user_id);
+
+	CREATE TABLE IF NOT EXISTS category_predictions (
+		transaction_id UUID PRIMARY KEY REFERENCES transactions(id) ON DELETE CASCADE,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		category VARCHAR(255) NOT NULL,
+		confidence DOUBLE PRECISION NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+	CREATE INDEX idx_category_predictions_user_id_status ON category_predictions(user_id, status);`,
+
+	// Migration 26: Create merchants table of canonical merchants per user and link
+	// transactions to them. A merged merchant keeps its key so later transactions with the
+	// same normalized name resolve to the merchant it was merged into.
+	`CREATE TABLE IF NOT EXISTS merchants (
+		id UUID PRIMARY KEY,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		key VARCHAR(255) NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		logo_url TEXT,
+		website TEXT,
+		merged_into UUID REFERENCES merchants(id) ON DELETE CASCADE,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		UNIQUE(user_id, key)
+	);
+	ALTER TABLE transactions ADD COLUMN merchant_id UUID REFERENCES merchants(id) ON DELETE SET NULL;
+	CREATE INDEX idx_transactions_merchant_id ON transactions(merchant_id);`,
+
+	// Migration 27: Store the time of transactions that have one, and create
+	// transaction_anomalies table of flagged transactions and how the user resolved them
+	`ALTER TABLE transactions ADD COLUMN datetime TIMESTAMP WITH TIME ZONE;
+	CREATE TABLE IF NOT EXISTS transaction_anomalies (
+		transaction_id UUID PRIMARY KEY REFERENCES transactions(id) ON DELETE CASCADE,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		score DOUBLE PRECISION NOT NULL,
+		flags JSONB NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+	CREATE INDEX idx_transaction_anomalies_user_id_status ON transaction_anomalies(user_id, status);`,
+
+	// Migration 28: Create monthly_summaries table, one generated summary per user and month
+	`CREATE TABLE IF NOT EXISTS monthly_summaries (
+		id UUID PRIMARY KEY,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		month DATE NOT NULL,
+		facts JSONB NOT NULL,
+		narrative TEXT NOT NULL,
+		source VARCHAR(20) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		UNIQUE(user_id, month)
+	);`,
+
+	// Migration 29: Create transaction_embeddings table for semantic search. pgvector is
+	// enabled when the server has it, so nearest-neighbour queries can run in the
+	// database; otherwise they run in Go over the same REAL[] vectors.
+	`DO $$
+	BEGIN
+		CREATE EXTENSION IF NOT EXISTS vector;
+	EXCEPTION WHEN OTHERS THEN
+		RAISE NOTICE 'pgvector is not available; semantic search will run in the application';
+	END
+	$$;
+	CREATE TABLE IF NOT EXISTS transaction_embeddings (
+		transaction_id UUID PRIMARY KEY REFERENCES transactions(id) ON DELETE CASCADE,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		model VARCHAR(255) NOT NULL,
+		text_hash VARCHAR(64) NOT NULL,
+		embedding REAL[] NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+	CREATE INDEX idx_transaction_embeddings_user_id_model ON transaction_embeddings(user_id, model);`,
+
+	// Migration 30: Store embeddings as pgvector vectors alongside the REAL[] copy when
+	// pgvector is installed, so nearest-neighbour queries can use a vector index instead
+	// of casting every row. Both columns are generated from embedding, so writes are
+	// unchanged. HNSW indexes need a fixed dimension, so they are created per dimension
+	// by EmbeddingRepository.EnsureVectorIndex.
+	`ALTER TABLE transaction_embeddings
+		ADD COLUMN IF NOT EXISTS dimensions INTEGER GENERATED ALWAYS AS (CARDINALITY(embedding)) STORED;
+	DO $$
+	BEGIN
+		IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'vector') THEN
+			ALTER TABLE transaction_embeddings
+				ADD COLUMN IF NOT EXISTS embedding_vector vector GENERATED ALWAYS AS (embedding::vector) STORED;
+		END IF;
+	END
+	$$;`,
+
+	// Migration 31: Count stale splits at their parent's amount in transaction_lines. A
+	// split is stale once Plaid changes the parent amount, so its amounts no longer add up.
+	`CREATE OR REPLACE VIEW transaction_lines AS
+	SELECT
+		t.id AS transaction_id,
+		NULL::UUID AS split_id,
+		t.user_id,
+		t.account_id,
+		t.date,
+		t.amount,
+		COALESCE(NULLIF(t.user_category, ''), t.category[1], 'Uncategorized') AS category,
+		t.name,
+		t.merchant_name,
+		t.pending,
+		t.is_transfer,
+		t.hidden
+	FROM transactions t
+	WHERE NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id AND NOT s.stale)
+	UNION ALL
+	SELECT
+		t.id AS transaction_id,
+		s.id AS split_id,
+		t.user_id,
+		t.account_id,
+		t.date,
+		s.amount,
+		s.category,
+		t.name,
+		t.merchant_name,
+		t.pending,
+		t.is_transfer,
+		t.hidden
+	FROM transaction_splits s
+	JOIN transactions t ON t.id = s.transaction_id
+	WHERE NOT s.stale`,
+
+	// Migration 32: Remember which transactions of a transfer pair were already marked as
+	// transfers when it was created, so unlinking the pair only clears the flag it set.
+	// Existing pairs can't tell and keep clearing both.
+	`ALTER TABLE transfer_pairs
+		ADD COLUMN outflow_was_transfer BOOLEAN NOT NULL DEFAULT FALSE,
+		ADD COLUMN inflow_was_transfer BOOLEAN NOT NULL DEFAULT FALSE;`,
+
+	// Migration 33: Add the name shown for a transaction line, its custom name when the
+	// user renamed it, so text filters match what the user sees.
+	`CREATE OR REPLACE VIEW transaction_lines AS
+	SELECT
+		t.id AS transaction_id,
+		NULL::UUID AS split_id,
+		t.user_id,
+		t.account_id,
+		t.date,
+		t.amount,
+		COALESCE(NULLIF(t.user_category, ''), t.category[1], 'Uncategorized') AS category,
+		t.name,
+		t.merchant_name,
+		t.pending,
+		t.is_transfer,
+		t.hidden,
+		COALESCE(NULLIF(t.custom_name, ''), t.name) AS display_name
+	FROM transactions t
+	WHERE NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id AND NOT s.stale)
+	UNION ALL
+	SELECT
+		t.id AS transaction_id,
+		s.id AS split_id,
+		t.user_id,
+		t.account_id,
+		t.date,
+		s.amount,
+		s.category,
+		t.name,
+		t.merchant_name,
+		t.pending,
+		t.is_transfer,
+		t.hidden,
+		COALESCE(NULLIF(t.custom_name, ''), t.name) AS display_name
+	FROM transaction_splits s
+	JOIN transactions t ON t.id = s.transaction_id
+	WHERE NOT s.stale`,
+
+	// Migration 34: Keep categorizer examples when their transaction is deleted, so the
+	// next training run can unlearn them before deleting them itself.
+	`ALTER TABLE categorizer_examples DROP CONSTRAINT IF EXISTS categorizer_examples_transaction_id_fkey;`,
+
+	// Migration 35: Add the merchant a transaction line is reported under: the canonical
+	// merchant it is linked to, following a merge, or Plaid's merchant name or the
+	// transaction name when it isn't linked.
+	`CREATE OR REPLACE VIEW transaction_lines AS
+	SELECT
+		t.id AS transaction_id,
+		NULL::UUID AS split_id,
+		t.user_id,
+		t.account_id,
+		t.date,
+		t.amount,
+		COALESCE(NULLIF(t.user_category, ''), t.category[1], 'Uncategorized') AS category,
+		t.name,
+		t.merchant_name,
+		t.pending,
+		t.is_transfer,
+		t.hidden,
+		COALESCE(NULLIF(t.custom_name, ''), t.name) AS display_name,
+		COALESCE(c.name, NULLIF(t.merchant_name, ''), t.name) AS merchant
+	FROM transactions t
+	LEFT JOIN merchants m ON m.id = t.merchant_id
+	LEFT JOIN merchants c ON c.id = COALESCE(m.merged_into, m.id)
+	WHERE NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id AND NOT s.stale)
+	UNION ALL
+	SELECT
+		t.id AS transaction_id,
+		s.id AS split_id,
+		t.user_id,
+		t.account_id,
+		t.date,
+		s.amount,
+		s.category,
+		t.name,
+		t.merchant_name,
+		t.pending,
+		t.is_transfer,
+		t.hidden,
+		COALESCE(NULLIF(t.custom_name, ''), t.name) AS display_name,
+		COALESCE(c.name, NULLIF(t.merchant_name, ''), t.name) AS merchant
+	FROM transaction_splits s
+	JOIN transactions t ON t.id = s.transaction_id
+	LEFT JOIN merchants m ON m.id = t.merchant_id
+	LEFT JOIN merchants c ON c.id = COALESCE(m.merged_into, m.id)
+	WHERE NOT s.stale`,
 }
 
 // MigrateDB executes all migrations on the database
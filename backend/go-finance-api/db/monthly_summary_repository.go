@@ -0,0 +1,119 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// MonthlySummaryRepository handles database operations for monthly summaries
+type MonthlySummaryRepository struct {
+	db *Database
+}
+
+// NewMonthlySummaryRepository creates a new MonthlySummaryRepository
+func NewMonthlySummaryRepository(db *Database) *MonthlySummaryRepository {
+	return &MonthlySummaryRepository{db: db}
+}
+
+// monthlySummaryColumns is the column list matching scanMonthlySummary
+const monthlySummaryColumns = `id, user_id, month, facts, narrative, source, created_at, updated_at`
+
+// scanMonthlySummary scans a monthly summary row, decoding its JSONB facts
+func scanMonthlySummary(row rowScanner) (*models.MonthlySummary, error) {
+	var summary models.MonthlySummary
+	var facts []byte
+	err := row.Scan(
+		&summary.ID,
+		&summary.UserID,
+		&summary.Month,
+		&facts,
+		&summary.Narrative,
+		&summary.Source,
+		&summary.CreatedAt,
+		&summary.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(facts, &summary.Facts); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// Upsert stores a summary, replacing any earlier summary of the same month. The ID and
+// creation time of the stored row are written back to summary.
+func (r *MonthlySummaryRepository) Upsert(summary *models.MonthlySummary) error {
+	facts, err := json.Marshal(summary.Facts)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO monthly_summaries (id, user_id, month, facts, narrative, source, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id, month) DO UPDATE SET
+			facts = EXCLUDED.facts,
+			narrative = EXCLUDED.narrative,
+			source = EXCLUDED.source,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(
+		query,
+		summary.ID,
+		summary.UserID,
+		summary.Month,
+		facts,
+		summary.Narrative,
+		summary.Source,
+		summary.CreatedAt,
+		summary.UpdatedAt,
+	).Scan(&summary.ID, &summary.CreatedAt)
+}
+
+// GetByUserAndMonth retrieves a user's summary of a month
+func (r *MonthlySummaryRepository) GetByUserAndMonth(userID uuid.UUID, month time.Time) (*models.MonthlySummary, error) {
+	query := `SELECT ` + monthlySummaryColumns + ` FROM monthly_summaries WHERE user_id = $1 AND month = $2`
+	summary, err := scanMonthlySummary(r.db.QueryRow(query, userID, month))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Summary not found
+		}
+		return nil, err
+	}
+	return summary, nil
+}
+
+// GetByUserID retrieves a page of a user's summaries, latest month first
+func (r *MonthlySummaryRepository) GetByUserID(userID uuid.UUID, limit, offset int) ([]*models.MonthlySummary, error) {
+	query := `
+		SELECT ` + monthlySummaryColumns + `
+		FROM monthly_summaries
+		WHERE user_id = $1
+		ORDER BY month DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.db.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []*models.MonthlySummary{}
+	for rows.Next() {
+		summary, err := scanMonthlySummary(rows)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
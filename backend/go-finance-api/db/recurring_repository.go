@@ -0,0 +1,140 @@
+package db
+
+import (
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// RecurringRepository handles database operations for recurring transaction series
+type RecurringRepository struct {
+	db *Database
+}
+
+// NewRecurringRepository creates a new RecurringRepository
+func NewRecurringRepository(db *Database) *RecurringRepository {
+	return &RecurringRepository{db: db}
+}
+
+// recurringColumns is the column list scanned by GetByUserID
+const recurringColumns = `
+			id, user_id, account_id, series_key, merchant_name, category, frequency,
+			average_amount, last_amount, last_date, next_date, occurrences, price_increase,
+			previous_amount, status, source, created_at, updated_at`
+
+// GetByUserID retrieves a user's recurring series, soonest next date first
+func (r *RecurringRepository) GetByUserID(userID uuid.UUID) ([]*models.RecurringSeries, error) {
+	query := `
+		SELECT ` + recurringColumns + `
+		FROM recurring_series
+		WHERE user_id = $1
+		ORDER BY next_date, merchant_name
+	`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var series []*models.RecurringSeries
+	for rows.Next() {
+		var s models.RecurringSeries
+		err := rows.Scan(
+			&s.ID,
+			&s.UserID,
+			&s.AccountID,
+			&s.SeriesKey,
+			&s.MerchantName,
+			&s.Category,
+			&s.Frequency,
+			&s.AverageAmount,
+			&s.LastAmount,
+			&s.LastDate,
+			&s.NextDate,
+			&s.Occurrences,
+			&s.PriceIncrease,
+			&s.PreviousAmount,
+			&s.Status,
+			&s.Source,
+			&s.CreatedAt,
+			&s.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, &s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// ReplaceForUser stores the result of a detection run: each series is upserted by its
+// key, keeping the ID and creation time of a series seen before, and the user's series
+// that weren't found again are deleted
+func (r *RecurringRepository) ReplaceForUser(userID uuid.UUID, series []*models.RecurringSeries) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO recurring_series (` + recurringColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		ON CONFLICT (user_id, series_key) DO UPDATE SET
+			account_id = EXCLUDED.account_id,
+			merchant_name = EXCLUDED.merchant_name,
+			category = EXCLUDED.category,
+			frequency = EXCLUDED.frequency,
+			average_amount = EXCLUDED.average_amount,
+			last_amount = EXCLUDED.last_amount,
+			last_date = EXCLUDED.last_date,
+			next_date = EXCLUDED.next_date,
+			occurrences = EXCLUDED.occurrences,
+			price_increase = EXCLUDED.price_increase,
+			previous_amount = EXCLUDED.previous_amount,
+			status = EXCLUDED.status,
+			source = EXCLUDED.source,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at
+	`
+	keys := make([]string, 0, len(series))
+	for _, s := range series {
+		err := tx.QueryRow(
+			query,
+			s.ID,
+			userID,
+			s.AccountID,
+			s.SeriesKey,
+			s.MerchantName,
+			s.Category,
+			s.Frequency,
+			s.AverageAmount,
+			s.LastAmount,
+			s.LastDate,
+			s.NextDate,
+			s.Occurrences,
+			s.PriceIncrease,
+			s.PreviousAmount,
+			s.Status,
+			s.Source,
+			s.CreatedAt,
+			s.UpdatedAt,
+		).Scan(&s.ID, &s.CreatedAt)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, s.SeriesKey)
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM recurring_series
+		WHERE user_id = $1 AND NOT (series_key = ANY($2))
+	`, userID, pq.Array(keys))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -2,22 +2,58 @@ package db
 
 // Repositories holds all the repository instances
 type Repositories struct {
-	User          *UserRepository
-	Item          *ItemRepository
-	Account       *AccountRepository
-	Transaction   *TransactionRepository
-	PlaidAPIEvent *PlaidAPIEventRepository
-	LinkEvent     *LinkEventRepository
+	User            *UserRepository
+	Item            *ItemRepository
+	Account         *AccountRepository
+	Transaction     *TransactionRepository
+	PlaidAPIEvent   *PlaidAPIEventRepository
+	LinkEvent       *LinkEventRepository
+	Rule            *RuleRepository
+	Tag             *TagRepository
+	Split           *SplitRepository
+	Attachment      *AttachmentRepository
+	Transfer        *TransferRepository
+	BalanceSnapshot *BalanceSnapshotRepository
+	Budget          *BudgetRepository
+	Goal            *GoalRepository
+	Recurring       *RecurringRepository
+	CalendarFeed    *CalendarFeedRepository
+	SpendingSummary *SpendingSummaryRepository
+	LLMUsage        *LLMUsageRepository
+	Conversation    *ConversationRepository
+	Categorizer     *CategorizerRepository
+	Merchant        *MerchantRepository
+	Anomaly         *AnomalyRepository
+	MonthlySummary  *MonthlySummaryRepository
+	Embedding       *EmbeddingRepository
 }
 
 // NewRepositories creates a new Repositories instance
 func NewRepositories(db *Database) *Repositories {
 	return &Repositories{
-		User:          NewUserRepository(db),
-		Item:          NewItemRepository(db),
-		Account:       NewAccountRepository(db),
-		Transaction:   NewTransactionRepository(db),
-		PlaidAPIEvent: NewPlaidAPIEventRepository(db),
-		LinkEvent:     NewLinkEventRepository(db),
+		User:            NewUserRepository(db),
+		Item:            NewItemRepository(db),
+		Account:         NewAccountRepository(db),
+		Transaction:     NewTransactionRepository(db),
+		PlaidAPIEvent:   NewPlaidAPIEventRepository(db),
+		LinkEvent:       NewLinkEventRepository(db),
+		Rule:            NewRuleRepository(db),
+		Tag:             NewTagRepository(db),
+		Split:           NewSplitRepository(db),
+		Attachment:      NewAttachmentRepository(db),
+		Transfer:        NewTransferRepository(db),
+		BalanceSnapshot: NewBalanceSnapshotRepository(db),
+		Budget:          NewBudgetRepository(db),
+		Goal:            NewGoalRepository(db),
+		Recurring:       NewRecurringRepository(db),
+		CalendarFeed:    NewCalendarFeedRepository(db),
+		SpendingSummary: NewSpendingSummaryRepository(db),
+		LLMUsage:        NewLLMUsageRepository(db),
+		Conversation:    NewConversationRepository(db),
+		Categorizer:     NewCategorizerRepository(db),
+		Merchant:        NewMerchantRepository(db),
+		Anomaly:         NewAnomalyRepository(db),
+		MonthlySummary:  NewMonthlySummaryRepository(db),
+		Embedding:       NewEmbeddingRepository(db),
 	}
 }
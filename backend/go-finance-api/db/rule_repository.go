@@ -0,0 +1,173 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// RuleRepository handles database operations for transaction rules
+type RuleRepository struct {
+	db *Database
+}
+
+// NewRuleRepository creates a new RuleRepository
+func NewRuleRepository(db *Database) *RuleRepository {
+	return &RuleRepository{db: db}
+}
+
+// scanRule scans a rule row, decoding its JSONB conditions and actions
+func scanRule(row rowScanner) (*models.Rule, error) {
+	var rule models.Rule
+	var conditions, actions []byte
+	err := row.Scan(
+		&rule.ID,
+		&rule.UserID,
+		&rule.Name,
+		&rule.Priority,
+		&rule.Enabled,
+		&conditions,
+		&actions,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(conditions, &rule.Conditions); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(actions, &rule.Actions); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// Create inserts a new rule into the database
+func (r *RuleRepository) Create(rule *models.Rule) error {
+	conditions, err := json.Marshal(rule.Conditions)
+	if err != nil {
+		return err
+	}
+	actions, err := json.Marshal(rule.Actions)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO rules (id, user_id, name, priority, enabled, conditions, actions, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err = r.db.Exec(
+		query,
+		rule.ID,
+		rule.UserID,
+		rule.Name,
+		rule.Priority,
+		rule.Enabled,
+		conditions,
+		actions,
+		rule.CreatedAt,
+		rule.UpdatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves a rule by ID
+func (r *RuleRepository) GetByID(id uuid.UUID) (*models.Rule, error) {
+	query := `
+		SELECT id, user_id, name, priority, enabled, conditions, actions, created_at, updated_at
+		FROM rules
+		WHERE id = $1
+	`
+	rule, err := scanRule(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Rule not found
+		}
+		return nil, err
+	}
+	return rule, nil
+}
+
+// GetByUserID retrieves all rules for a user in evaluation order
+func (r *RuleRepository) GetByUserID(userID uuid.UUID) ([]*models.Rule, error) {
+	return r.query(`
+		SELECT id, user_id, name, priority, enabled, conditions, actions, created_at, updated_at
+		FROM rules
+		WHERE user_id = $1
+		ORDER BY priority, created_at
+	`, userID)
+}
+
+// GetEnabledByUserID retrieves the enabled rules for a user in evaluation order
+func (r *RuleRepository) GetEnabledByUserID(userID uuid.UUID) ([]*models.Rule, error) {
+	return r.query(`
+		SELECT id, user_id, name, priority, enabled, conditions, actions, created_at, updated_at
+		FROM rules
+		WHERE user_id = $1 AND enabled
+		ORDER BY priority, created_at
+	`, userID)
+}
+
+// query runs a rule SELECT and scans every row
+func (r *RuleRepository) query(query string, args ...interface{}) ([]*models.Rule, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*models.Rule
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Update updates an existing rule
+func (r *RuleRepository) Update(rule *models.Rule) error {
+	conditions, err := json.Marshal(rule.Conditions)
+	if err != nil {
+		return err
+	}
+	actions, err := json.Marshal(rule.Actions)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE rules
+		SET name = $1, priority = $2, enabled = $3, conditions = $4, actions = $5, updated_at = $6
+		WHERE id = $7
+	`
+	rule.UpdatedAt = time.Now().UTC()
+	_, err = r.db.Exec(
+		query,
+		rule.Name,
+		rule.Priority,
+		rule.Enabled,
+		conditions,
+		actions,
+		rule.UpdatedAt,
+		rule.ID,
+	)
+	return err
+}
+
+// Delete removes a rule from the database
+func (r *RuleRepository) Delete(id uuid.UUID) error {
+	query := `DELETE FROM rules WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
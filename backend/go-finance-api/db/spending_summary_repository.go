@@ -0,0 +1,138 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// SpendingSummaryRepository maintains and queries the spending_summary table that backs
+// reports
+type SpendingSummaryRepository struct {
+	db *Database
+}
+
+// NewSpendingSummaryRepository creates a new SpendingSummaryRepository
+func NewSpendingSummaryRepository(db *Database) *SpendingSummaryRepository {
+	return &SpendingSummaryRepository{db: db}
+}
+
+// summaryDimensions maps report dimensions to the spending_summary expression grouped on
+var summaryDimensions = map[string]string{
+	models.ReportByCategory:  "category",
+	models.ReportByMerchant:  "merchant",
+	models.ReportByAccount:   "account_id::TEXT",
+	models.ReportByDayOfWeek: "EXTRACT(DOW FROM date)::INTEGER::TEXT",
+	models.ReportByMonth:     "TO_CHAR(date, 'YYYY-MM')",
+}
+
+// fingerprintQuery summarizes a user's transactions and splits. Every write, including
+// renaming or merging the merchants transactions are linked to, bumps updated_at or
+// changes a count, so a new fingerprint means the summary is out of date.
+const fingerprintQuery = `
+		SELECT
+			(SELECT COUNT(*) || ':' || COALESCE(MAX(updated_at)::TEXT, '') FROM transactions WHERE user_id = $1)
+			|| '|' ||
+			(SELECT COUNT(*) || ':' || COALESCE(MAX(updated_at)::TEXT, '') FROM transaction_splits WHERE user_id = $1)
+	`
+
+// Refresh rebuilds a user's summary rows from their transaction lines
+func (r *SpendingSummaryRepository) Refresh(userID uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := refreshSummary(tx, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// EnsureFresh rebuilds a user's summary rows if their transactions have changed since
+// the last refresh. It reports whether a rebuild was needed.
+func (r *SpendingSummaryRepository) EnsureFresh(userID uuid.UUID) (bool, error) {
+	var current string
+	if err := r.db.QueryRow(fingerprintQuery, userID).Scan(&current); err != nil {
+		return false, err
+	}
+	var stored string
+	err := r.db.QueryRow(`SELECT fingerprint FROM spending_summary_state WHERE user_id = $1`, userID).Scan(&stored)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+	if stored == current {
+		return false, nil
+	}
+	return true, r.Refresh(userID)
+}
+
+// refreshSummary replaces a user's summary rows and records the fingerprint they were
+// built from. Rebuilds of the same user are serialized by an advisory lock held until
+// the transaction ends, so a sync's refresh and a report's EnsureFresh can't both
+// insert rows.
+func refreshSummary(tx *sql.Tx, userID uuid.UUID) error {
+	statements := []string{
+		`SELECT pg_advisory_xact_lock(hashtext('spending_summary:' || $1::TEXT))`,
+		`DELETE FROM spending_summary WHERE user_id = $1`,
+		`INSERT INTO spending_summary (user_id, date, account_id, category, merchant, spent, received, count)
+		SELECT
+			user_id, date, account_id, category,
+			LEFT(merchant, 255),
+			SUM(GREATEST(amount, 0)), SUM(GREATEST(-amount, 0)), COUNT(*)
+		FROM transaction_lines
+		WHERE user_id = $1 AND NOT is_transfer AND NOT hidden
+		GROUP BY user_id, date, account_id, category, LEFT(merchant, 255)`,
+		`INSERT INTO spending_summary_state (user_id, fingerprint, refreshed_at)
+		VALUES ($1, (` + fingerprintQuery + `), NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			fingerprint = EXCLUDED.fingerprint,
+			refreshed_at = EXCLUDED.refreshed_at`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Aggregate totals a user's summary rows within a date range by a report dimension,
+// largest net spend first
+func (r *SpendingSummaryRepository) Aggregate(userID uuid.UUID, dimension string, startDate, endDate time.Time) ([]*models.SpendingTotal, error) {
+	expression, ok := summaryDimensions[dimension]
+	if !ok {
+		return nil, fmt.Errorf("unknown report dimension %q", dimension)
+	}
+
+	query := `
+		SELECT ` + expression + ` AS key, SUM(spent), SUM(received), SUM(count)
+		FROM spending_summary
+		WHERE user_id = $1 AND date BETWEEN $2 AND $3
+		GROUP BY key
+		ORDER BY SUM(spent) - SUM(received) DESC, key
+	`
+	rows, err := r.db.Query(query, userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []*models.SpendingTotal
+	for rows.Next() {
+		var total models.SpendingTotal
+		if err := rows.Scan(&total.Key, &total.Spent, &total.Received, &total.Count); err != nil {
+			return nil, err
+		}
+		total.Net = models.FromMinorUnits(models.ToMinorUnits(total.Spent) - models.ToMinorUnits(total.Received))
+		totals = append(totals, &total)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return totals, nil
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// SplitRepository handles database operations for transaction splits
+type SplitRepository struct {
+	db *Database
+}
+
+// NewSplitRepository creates a new SplitRepository
+func NewSplitRepository(db *Database) *SplitRepository {
+	return &SplitRepository{db: db}
+}
+
+// GetByTransactionID retrieves the splits of a transaction
+func (r *SplitRepository) GetByTransactionID(transactionID uuid.UUID) ([]*models.TransactionSplit, error) {
+	query := `
+		SELECT id, transaction_id, user_id, amount, category, COALESCE(note, ''), stale, created_at, updated_at
+		FROM transaction_splits
+		WHERE transaction_id = $1
+		ORDER BY created_at, id
+	`
+	return r.query(query, transactionID)
+}
+
+// query runs a query that selects every split column and scans each row
+func (r *SplitRepository) query(query string, args ...interface{}) ([]*models.TransactionSplit, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var splits []*models.TransactionSplit
+	for rows.Next() {
+		var split models.TransactionSplit
+		err := rows.Scan(
+			&split.ID,
+			&split.TransactionID,
+			&split.UserID,
+			&split.Amount,
+			&split.Category,
+			&split.Note,
+			&split.Stale,
+			&split.CreatedAt,
+			&split.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		splits = append(splits, &split)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return splits, nil
+}
+
+// GetByUserAndDateRange retrieves the splits of a user's transactions within a date
+// range. Stale splits are left out, so their transactions count at the parent amount as
+// they do in the transaction_lines view.
+func (r *SplitRepository) GetByUserAndDateRange(userID uuid.UUID, startDate, endDate time.Time) ([]*models.TransactionSplit, error) {
+	query := `
+		SELECT s.id, s.transaction_id, s.user_id, s.amount, s.category, COALESCE(s.note, ''), s.stale, s.created_at, s.updated_at
+		FROM transaction_splits s
+		JOIN transactions t ON t.id = s.transaction_id
+		WHERE s.user_id = $1 AND t.date >= $2 AND t.date <= $3 AND NOT s.stale
+		ORDER BY s.created_at, s.id
+	`
+	return r.query(query, userID, startDate, endDate)
+}
+
+// Replace atomically swaps all splits of a transaction for the given ones
+func (r *SplitRepository) Replace(transactionID uuid.UUID, splits []*models.TransactionSplit) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM transaction_splits WHERE transaction_id = $1`, transactionID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO transaction_splits (id, transaction_id, user_id, amount, category, note, stale, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	for _, split := range splits {
+		_, err := tx.Exec(
+			query,
+			split.ID,
+			transactionID,
+			split.UserID,
+			split.Amount,
+			split.Category,
+			split.Note,
+			split.Stale,
+			split.CreatedAt,
+			split.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteByTransactionID removes all splits of a transaction
+func (r *SplitRepository) DeleteByTransactionID(transactionID uuid.UUID) error {
+	query := `DELETE FROM transaction_splits WHERE transaction_id = $1`
+	_, err := r.db.Exec(query, transactionID)
+	return err
+}
+
+// MarkStaleIfUnbalanced flags a transaction's splits as stale when they no longer sum to
+// the transaction amount, e.g. after Plaid changes it. It reports whether any split was
+// newly flagged.
+func (r *SplitRepository) MarkStaleIfUnbalanced(transactionID uuid.UUID) (bool, error) {
+	query := `
+		UPDATE transaction_splits
+		SET stale = TRUE, updated_at = NOW()
+		WHERE transaction_id = $1
+			AND NOT stale
+			AND (SELECT SUM(amount) FROM transaction_splits WHERE transaction_id = $1)
+				<> (SELECT amount FROM transactions WHERE id = $1)
+	`
+	result, err := r.db.Exec(query, transactionID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
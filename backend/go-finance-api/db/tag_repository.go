@@ -0,0 +1,140 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// TagRepository handles database operations for transaction tags
+type TagRepository struct {
+	db *Database
+}
+
+// NewTagRepository creates a new TagRepository
+func NewTagRepository(db *Database) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// GetByID retrieves a tag by ID
+func (r *TagRepository) GetByID(id uuid.UUID) (*models.Tag, error) {
+	query := `
+		SELECT id, user_id, name, created_at
+		FROM tags
+		WHERE id = $1
+	`
+	var tag models.Tag
+	err := r.db.QueryRow(query, id).Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Tag not found
+		}
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// GetByUserID retrieves all of a user's tags
+func (r *TagRepository) GetByUserID(userID uuid.UUID) ([]*models.Tag, error) {
+	query := `
+		SELECT id, user_id, name, created_at
+		FROM tags
+		WHERE user_id = $1
+		ORDER BY name
+	`
+	return r.query(query, userID)
+}
+
+// GetByTransactionID retrieves the tags attached to a transaction
+func (r *TagRepository) GetByTransactionID(transactionID uuid.UUID) ([]*models.Tag, error) {
+	query := `
+		SELECT t.id, t.user_id, t.name, t.created_at
+		FROM tags t
+		JOIN transaction_tags tt ON tt.tag_id = t.id
+		WHERE tt.transaction_id = $1
+		ORDER BY t.name
+	`
+	return r.query(query, transactionID)
+}
+
+// query runs a tag SELECT and scans every row
+func (r *TagRepository) query(query string, args ...interface{}) ([]*models.Tag, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []*models.Tag
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt); err != nil {
+			return nil, err
+		}
+		tags = append(tags, &tag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// AddToTransaction attaches a tag to a transaction, creating the tag if needed
+func (r *TagRepository) AddToTransaction(userID, transactionID uuid.UUID, name string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := addTransactionTag(tx, userID, transactionID, name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveFromTransaction detaches a tag from a transaction. The tag itself is kept.
+func (r *TagRepository) RemoveFromTransaction(userID, transactionID uuid.UUID, name string) error {
+	query := `
+		DELETE FROM transaction_tags
+		WHERE transaction_id = $1
+			AND tag_id IN (SELECT id FROM tags WHERE user_id = $2 AND name = $3)
+	`
+	_, err := r.db.Exec(query, transactionID, userID, name)
+	return err
+}
+
+// Delete removes a tag from the database along with all of its transaction links
+func (r *TagRepository) Delete(id uuid.UUID) error {
+	query := `DELETE FROM tags WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+// addTransactionTag creates the user's tag if it doesn't exist and links it to the transaction
+func addTransactionTag(tx *sql.Tx, userID, transactionID uuid.UUID, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	_, err := tx.Exec(`
+		INSERT INTO tags (id, user_id, name, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, name) DO NOTHING
+	`, uuid.New(), userID, name, now)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO transaction_tags (transaction_id, tag_id, created_at)
+		SELECT $1, id, $2 FROM tags WHERE user_id = $3 AND name = $4
+		ON CONFLICT DO NOTHING
+	`, transactionID, now, userID, name)
+	return err
+}
@@ -2,6 +2,8 @@ package db
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/davidwang/go-finance-api/go-finance-api/models"
@@ -19,21 +21,202 @@ func NewTransactionRepository(db *Database) *TransactionRepository {
 	return &TransactionRepository{db: db}
 }
 
+// transactionColumns is the column list shared by every transaction SELECT
+const transactionColumns = `
+			id, account_id, user_id, COALESCE(plaid_transaction_id, ''), category_id, category,
+			name, merchant_name, amount, iso_currency_code, date, datetime, pending,
+			COALESCE(pending_transaction_id, ''), payment_channel, address, city,
+			region, postal_code, country, latitude, longitude,
+			COALESCE(user_category, ''), COALESCE(custom_name, ''),
+			is_transfer, hidden, COALESCE(notes, ''), COALESCE(import_hash, ''),
+			merchant_id, created_at, updated_at`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTransaction scans a row selected with transactionColumns
+func scanTransaction(row rowScanner) (*models.Transaction, error) {
+	var transaction models.Transaction
+	err := row.Scan(
+		&transaction.ID,
+		&transaction.AccountID,
+		&transaction.UserID,
+		&transaction.PlaidTransactionID,
+		&transaction.CategoryID,
+		pq.Array(&transaction.Category),
+		&transaction.Name,
+		&transaction.MerchantName,
+		&transaction.Amount,
+		&transaction.IsoCurrencyCode,
+		&transaction.Date,
+		&transaction.Datetime,
+		&transaction.Pending,
+		&transaction.PendingTransactionID,
+		&transaction.PaymentChannel,
+		&transaction.Address,
+		&transaction.City,
+		&transaction.Region,
+		&transaction.PostalCode,
+		&transaction.Country,
+		&transaction.Latitude,
+		&transaction.Longitude,
+		&transaction.UserCategory,
+		&transaction.CustomName,
+		&transaction.IsTransfer,
+		&transaction.Hidden,
+		&transaction.Notes,
+		&transaction.ImportHash,
+		&transaction.MerchantID,
+		&transaction.CreatedAt,
+		&transaction.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// insertTransactionQuery inserts every column of a transaction; see insertArgs
+const insertTransactionQuery = `
+		INSERT INTO transactions (
+			id, account_id, user_id, plaid_transaction_id, category_id, category,
+			name, merchant_name, amount, iso_currency_code, date, pending, 
+			payment_channel, address, city, region, postal_code, country,
+			latitude, longitude, user_category, custom_name, is_transfer, hidden,
+			notes, import_hash, created_at, updated_at
+		)
+		VALUES (
+			$1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $11, $12, $13, 
+			$14, $15, $16, $17, $18, $19, $20, NULLIF($21, ''), NULLIF($22, ''),
+			$23, $24, NULLIF($25, ''), NULLIF($26, ''), $27, $28
+		)
+	`
+
+// insertArgs returns the arguments for insertTransactionQuery
+func insertArgs(transaction *models.Transaction) []interface{} {
+	return []interface{}{
+		transaction.ID,
+		transaction.AccountID,
+		transaction.UserID,
+		transaction.PlaidTransactionID,
+		transaction.CategoryID,
+		pq.Array(transaction.Category),
+		transaction.Name,
+		transaction.MerchantName,
+		transaction.Amount,
+		transaction.IsoCurrencyCode,
+		transaction.Date,
+		transaction.Pending,
+		transaction.PaymentChannel,
+		transaction.Address,
+		transaction.City,
+		transaction.Region,
+		transaction.PostalCode,
+		transaction.Country,
+		transaction.Latitude,
+		transaction.Longitude,
+		transaction.UserCategory,
+		transaction.CustomName,
+		transaction.IsTransfer,
+		transaction.Hidden,
+		transaction.Notes,
+		transaction.ImportHash,
+		transaction.CreatedAt,
+		transaction.UpdatedAt,
+	}
+}
+
 // Create inserts a new transaction into the database
 func (r *TransactionRepository) Create(transaction *models.Transaction) error {
+	_, err := r.db.Exec(insertTransactionQuery, insertArgs(transaction)...)
+	return err
+}
+
+// CreateImported inserts a transaction imported from a statement unless the account
+// already has one with the same import hash. It reports whether the row was inserted.
+func (r *TransactionRepository) CreateImported(transaction *models.Transaction) (bool, error) {
+	result, err := r.db.Exec(
+		insertTransactionQuery+`ON CONFLICT (account_id, import_hash) DO NOTHING`,
+		insertArgs(transaction)...,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// GetExistingImportHashes returns which of the given import hashes an account already has
+func (r *TransactionRepository) GetExistingImportHashes(accountID uuid.UUID, hashes []string) (map[string]bool, error) {
+	query := `
+		SELECT import_hash
+		FROM transactions
+		WHERE account_id = $1 AND import_hash = ANY($2)
+	`
+	rows, err := r.db.Query(query, accountID, pq.Array(hashes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		existing[hash] = true
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// Upsert inserts a transaction from Plaid or refreshes the Plaid-owned columns of an
+// existing one. User annotations are never overwritten. The stored row's ID is written
+// back to transaction.ID.
+func (r *TransactionRepository) Upsert(transaction *models.Transaction) error {
 	query := `
 		INSERT INTO transactions (
 			id, account_id, user_id, plaid_transaction_id, category_id, category,
-			name, merchant_name, amount, iso_currency_code, date, pending, 
-			payment_channel, address, city, region, postal_code, country,
-			latitude, longitude, created_at, updated_at
+			name, merchant_name, amount, iso_currency_code, date, pending,
+			pending_transaction_id, payment_channel, address, city, region,
+			postal_code, country, latitude, longitude, created_at, updated_at, datetime
 		)
 		VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, 
-			$14, $15, $16, $17, $18, $19, $20, $21, $22
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NULLIF($13, ''),
+			$14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24
 		)
+		ON CONFLICT (plaid_transaction_id) DO UPDATE SET
+			account_id = EXCLUDED.account_id,
+			category_id = EXCLUDED.category_id,
+			category = EXCLUDED.category,
+			name = EXCLUDED.name,
+			merchant_name = EXCLUDED.merchant_name,
+			amount = EXCLUDED.amount,
+			iso_currency_code = EXCLUDED.iso_currency_code,
+			date = EXCLUDED.date,
+			pending = EXCLUDED.pending,
+			pending_transaction_id = EXCLUDED.pending_transaction_id,
+			payment_channel = EXCLUDED.payment_channel,
+			address = EXCLUDED.address,
+			city = EXCLUDED.city,
+			region = EXCLUDED.region,
+			postal_code = EXCLUDED.postal_code,
+			country = EXCLUDED.country,
+			latitude = EXCLUDED.latitude,
+			longitude = EXCLUDED.longitude,
+			updated_at = EXCLUDED.updated_at,
+			datetime = EXCLUDED.datetime
+		RETURNING id
 	`
-	_, err := r.db.Exec(
+	return r.db.QueryRow(
 		query,
 		transaction.ID,
 		transaction.AccountID,
@@ -47,6 +230,7 @@ func (r *TransactionRepository) Create(transaction *models.Transaction) error {
 		transaction.IsoCurrencyCode,
 		transaction.Date,
 		transaction.Pending,
+		transaction.PendingTransactionID,
 		transaction.PaymentChannel,
 		transaction.Address,
 		transaction.City,
@@ -57,108 +241,48 @@ func (r *TransactionRepository) Create(transaction *models.Transaction) error {
 		transaction.Longitude,
 		transaction.CreatedAt,
 		transaction.UpdatedAt,
-	)
-	return err
+		transaction.Datetime,
+	).Scan(&transaction.ID)
 }
 
 // GetByID retrieves a transaction by ID
 func (r *TransactionRepository) GetByID(id uuid.UUID) (*models.Transaction, error) {
 	query := `
-		SELECT 
-			id, account_id, user_id, plaid_transaction_id, category_id, category,
-			name, merchant_name, amount, iso_currency_code, date, pending, 
-			payment_channel, address, city, region, postal_code, country,
-			latitude, longitude, created_at, updated_at
+		SELECT ` + transactionColumns + `
 		FROM transactions
 		WHERE id = $1
 	`
-	var transaction models.Transaction
-	err := r.db.QueryRow(query, id).Scan(
-		&transaction.ID,
-		&transaction.AccountID,
-		&transaction.UserID,
-		&transaction.PlaidTransactionID,
-		&transaction.CategoryID,
-		pq.Array(&transaction.Category),
-		&transaction.Name,
-		&transaction.MerchantName,
-		&transaction.Amount,
-		&transaction.IsoCurrencyCode,
-		&transaction.Date,
-		&transaction.Pending,
-		&transaction.PaymentChannel,
-		&transaction.Address,
-		&transaction.City,
-		&transaction.Region,
-		&transaction.PostalCode,
-		&transaction.Country,
-		&transaction.Latitude,
-		&transaction.Longitude,
-		&transaction.CreatedAt,
-		&transaction.UpdatedAt,
-	)
+	transaction, err := scanTransaction(r.db.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // Transaction not found
 		}
 		return nil, err
 	}
-	return &transaction, nil
+	return transaction, nil
 }
 
 // GetByPlaidTransactionID retrieves a transaction by its Plaid transaction ID
 func (r *TransactionRepository) GetByPlaidTransactionID(plaidTransactionID string) (*models.Transaction, error) {
 	query := `
-		SELECT 
-			id, account_id, user_id, plaid_transaction_id, category_id, category,
-			name, merchant_name, amount, iso_currency_code, date, pending, 
-			payment_channel, address, city, region, postal_code, country,
-			latitude, longitude, created_at, updated_at
+		SELECT ` + transactionColumns + `
 		FROM transactions
 		WHERE plaid_transaction_id = $1
 	`
-	var transaction models.Transaction
-	err := r.db.QueryRow(query, plaidTransactionID).Scan(
-		&transaction.ID,
-		&transaction.AccountID,
-		&transaction.UserID,
-		&transaction.PlaidTransactionID,
-		&transaction.CategoryID,
-		pq.Array(&transaction.Category),
-		&transaction.Name,
-		&transaction.MerchantName,
-		&transaction.Amount,
-		&transaction.IsoCurrencyCode,
-		&transaction.Date,
-		&transaction.Pending,
-		&transaction.PaymentChannel,
-		&transaction.Address,
-		&transaction.City,
-		&transaction.Region,
-		&transaction.PostalCode,
-		&transaction.Country,
-		&transaction.Latitude,
-		&transaction.Longitude,
-		&transaction.CreatedAt,
-		&transaction.UpdatedAt,
-	)
+	transaction, err := scanTransaction(r.db.QueryRow(query, plaidTransactionID))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // Transaction not found
 		}
 		return nil, err
 	}
-	return &transaction, nil
+	return transaction, nil
 }
 
 // GetByAccountID retrieves transactions for a specific account
 func (r *TransactionRepository) GetByAccountID(accountID uuid.UUID, limit, offset int) ([]*models.Transaction, error) {
 	query := `
-		SELECT 
-			id, account_id, user_id, plaid_transaction_id, category_id, category,
-			name, merchant_name, amount, iso_currency_code, date, pending, 
-			payment_channel, address, city, region, postal_code, country,
-			latitude, longitude, created_at, updated_at
+		SELECT ` + transactionColumns + `
 		FROM transactions
 		WHERE account_id = $1
 		ORDER BY date DESC, created_at DESC
@@ -172,35 +296,11 @@ func (r *TransactionRepository) GetByAccountID(accountID uuid.UUID, limit, offse
 
 	var transactions []*models.Transaction
 	for rows.Next() {
-		var transaction models.Transaction
-		err := rows.Scan(
-			&transaction.ID,
-			&transaction.AccountID,
-			&transaction.UserID,
-			&transaction.PlaidTransactionID,
-			&transaction.CategoryID,
-			pq.Array(&transaction.Category),
-			&transaction.Name,
-			&transaction.MerchantName,
-			&transaction.Amount,
-			&transaction.IsoCurrencyCode,
-			&transaction.Date,
-			&transaction.Pending,
-			&transaction.PaymentChannel,
-			&transaction.Address,
-			&transaction.City,
-			&transaction.Region,
-			&transaction.PostalCode,
-			&transaction.Country,
-			&transaction.Latitude,
-			&transaction.Longitude,
-			&transaction.CreatedAt,
-			&transaction.UpdatedAt,
-		)
+		transaction, err := scanTransaction(rows)
 		if err != nil {
 			return nil, err
 		}
-		transactions = append(transactions, &transaction)
+		transactions = append(transactions, transaction)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, err
@@ -211,11 +311,7 @@ func (r *TransactionRepository) GetByAccountID(accountID uuid.UUID, limit, offse
 // GetByUserID retrieves transactions for a specific user
 func (r *TransactionRepository) GetByUserID(userID uuid.UUID, limit, offset int) ([]*models.Transaction, error) {
 	query := `
-		SELECT 
-			id, account_id, user_id, plaid_transaction_id, category_id, category,
-			name, merchant_name, amount, iso_currency_code, date, pending, 
-			payment_channel, address, city, region, postal_code, country,
-			latitude, longitude, created_at, updated_at
+		SELECT ` + transactionColumns + `
 		FROM transactions
 		WHERE user_id = $1
 		ORDER BY date DESC, created_at DESC
@@ -229,35 +325,11 @@ func (r *TransactionRepository) GetByUserID(userID uuid.UUID, limit, offset int)
 
 	var transactions []*models.Transaction
 	for rows.Next() {
-		var transaction models.Transaction
-		err := rows.Scan(
-			&transaction.ID,
-			&transaction.AccountID,
-			&transaction.UserID,
-			&transaction.PlaidTransactionID,
-			&transaction.CategoryID,
-			pq.Array(&transaction.Category),
-			&transaction.Name,
-			&transaction.MerchantName,
-			&transaction.Amount,
-			&transaction.IsoCurrencyCode,
-			&transaction.Date,
-			&transaction.Pending,
-			&transaction.PaymentChannel,
-			&transaction.Address,
-			&transaction.City,
-			&transaction.Region,
-			&transaction.PostalCode,
-			&transaction.Country,
-			&transaction.Latitude,
-			&transaction.Longitude,
-			&transaction.CreatedAt,
-			&transaction.UpdatedAt,
-		)
+		transaction, err := scanTransaction(rows)
 		if err != nil {
 			return nil, err
 		}
-		transactions = append(transactions, &transaction)
+		transactions = append(transactions, transaction)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, err
@@ -268,11 +340,7 @@ func (r *TransactionRepository) GetByUserID(userID uuid.UUID, limit, offset int)
 // GetByDateRange retrieves transactions for a specific user within a date range
 func (r *TransactionRepository) GetByDateRange(userID uuid.UUID, startDate, endDate time.Time, limit, offset int) ([]*models.Transaction, error) {
 	query := `
-		SELECT 
-			id, account_id, user_id, plaid_transaction_id, category_id, category,
-			name, merchant_name, amount, iso_currency_code, date, pending, 
-			payment_channel, address, city, region, postal_code, country,
-			latitude, longitude, created_at, updated_at
+		SELECT ` + transactionColumns + `
 		FROM transactions
 		WHERE user_id = $1 AND date BETWEEN $2 AND $3
 		ORDER BY date DESC, created_at DESC
@@ -286,42 +354,609 @@ func (r *TransactionRepository) GetByDateRange(userID uuid.UUID, startDate, endD
 
 	var transactions []*models.Transaction
 	for rows.Next() {
-		var transaction models.Transaction
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// Search retrieves up to limit of a user's transactions matching a filter, newest first.
+// Hidden transactions are left out.
+func (r *TransactionRepository) Search(userID uuid.UUID, filter models.TransactionFilter, limit int) ([]*models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE user_id = $1 AND NOT hidden
+			AND ($2 = '' OR COALESCE(NULLIF(custom_name, ''), name) ILIKE $2 OR merchant_name ILIKE $2)
+			AND (CARDINALITY($3::TEXT[]) = 0 OR LOWER(COALESCE(NULLIF(user_category, ''), category[1], 'Uncategorized')) = ANY($3))
+			AND ` + filterConditions + `
+		ORDER BY date DESC, created_at DESC
+		LIMIT $10
+	`
+	return r.list(query, append(filterArgs(userID, filter), limit)...)
+}
+
+// lineGroupings maps the groupings of AggregateLines to the transaction_lines expression
+// grouped on
+var lineGroupings = map[string]string{
+	"":                      "''",
+	models.ReportByCategory: "category",
+	models.ReportByMerchant: "merchant",
+	models.ReportByAccount:  "account_id::TEXT",
+	models.ReportByMonth:    "TO_CHAR(date, 'YYYY-MM')",
+}
+
+// AggregateLines totals a user's transaction lines matching a filter, grouped by
+// category, merchant, account or month, or into a single total if groupBy is empty.
+// Split transactions count once per split. Transfers and hidden transactions are left out.
+func (r *TransactionRepository) AggregateLines(userID uuid.UUID, filter models.TransactionFilter, groupBy string) ([]*models.SpendingTotal, error) {
+	expression, ok := lineGroupings[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown grouping %q", groupBy)
+	}
+
+	query := `
+		SELECT ` + expression + ` AS key,
+			COALESCE(SUM(GREATEST(amount, 0)), 0), COALESCE(SUM(GREATEST(-amount, 0)), 0), COUNT(*)
+		FROM transaction_lines
+		WHERE user_id = $1 AND NOT is_transfer AND NOT hidden
+			AND ($2 = '' OR display_name ILIKE $2 OR merchant_name ILIKE $2)
+			AND (CARDINALITY($3::TEXT[]) = 0 OR LOWER(category) = ANY($3))
+			AND ` + filterConditions + `
+		GROUP BY key
+		ORDER BY SUM(amount) DESC, key
+	`
+	rows, err := r.db.Query(query, filterArgs(userID, filter)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []*models.SpendingTotal
+	for rows.Next() {
+		var total models.SpendingTotal
+		if err := rows.Scan(&total.Key, &total.Spent, &total.Received, &total.Count); err != nil {
+			return nil, err
+		}
+		total.Net = models.FromMinorUnits(models.ToMinorUnits(total.Spent) - models.ToMinorUnits(total.Received))
+		totals = append(totals, &total)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return totals, nil
+}
+
+// SearchLines retrieves up to limit of a user's transaction lines matching a filter,
+// newest first, counting the same lines as AggregateLines: split transactions give a
+// line per split, and transfers and hidden transactions are left out.
+func (r *TransactionRepository) SearchLines(userID uuid.UUID, filter models.TransactionFilter, limit int) ([]*models.TransactionLine, error) {
+	query := `
+		SELECT transaction_id, split_id, account_id, date, amount, category, display_name,
+			COALESCE(merchant_name, ''), pending
+		FROM transaction_lines
+		WHERE user_id = $1 AND NOT is_transfer AND NOT hidden
+			AND ($2 = '' OR display_name ILIKE $2 OR merchant_name ILIKE $2)
+			AND (CARDINALITY($3::TEXT[]) = 0 OR LOWER(category) = ANY($3))
+			AND ` + filterConditions + `
+		ORDER BY date DESC, transaction_id, split_id
+		LIMIT $10
+	`
+	rows, err := r.db.Query(query, append(filterArgs(userID, filter), limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []*models.TransactionLine{}
+	for rows.Next() {
+		var line models.TransactionLine
 		err := rows.Scan(
-			&transaction.ID,
-			&transaction.AccountID,
-			&transaction.UserID,
-			&transaction.PlaidTransactionID,
-			&transaction.CategoryID,
-			pq.Array(&transaction.Category),
-			&transaction.Name,
-			&transaction.MerchantName,
-			&transaction.Amount,
-			&transaction.IsoCurrencyCode,
-			&transaction.Date,
-			&transaction.Pending,
-			&transaction.PaymentChannel,
-			&transaction.Address,
-			&transaction.City,
-			&transaction.Region,
-			&transaction.PostalCode,
-			&transaction.Country,
-			&transaction.Latitude,
-			&transaction.Longitude,
-			&transaction.CreatedAt,
-			&transaction.UpdatedAt,
+			&line.TransactionID, &line.SplitID, &line.AccountID, &line.Date, &line.Amount,
+			&line.Category, &line.Name, &line.MerchantName, &line.Pending,
 		)
 		if err != nil {
 			return nil, err
 		}
-		transactions = append(transactions, &transaction)
+		lines = append(lines, &line)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
+	return lines, nil
+}
+
+// filterConditions are the TransactionFilter conditions shared by the transactions
+// table and the transaction_lines view, for the arguments $4 to $9 of filterArgs
+const filterConditions = `($4 = '' OR ($4 = 'outflow' AND amount > 0) OR ($4 = 'inflow' AND amount < 0))
+			AND ($5::UUID IS NULL OR account_id = $5)
+			AND ($6::DATE IS NULL OR date >= $6)
+			AND ($7::DATE IS NULL OR date <= $7)
+			AND ($8::DECIMAL IS NULL OR amount >= $8)
+			AND ($9::DECIMAL IS NULL OR amount <= $9)`
+
+// filterArgs returns the query arguments $1 to $9 for a user and filter: the user, the
+// text pattern, the lowercased categories, then those of filterConditions
+func filterArgs(userID uuid.UUID, filter models.TransactionFilter) []interface{} {
+	var pattern string
+	if filter.Text != "" {
+		pattern = "%" + likeEscaper.Replace(filter.Text) + "%"
+	}
+	categories := make([]string, len(filter.Categories))
+	for i, category := range filter.Categories {
+		categories[i] = strings.ToLower(category)
+	}
+	return []interface{}{
+		userID,
+		pattern,
+		pq.Array(categories),
+		filter.Direction,
+		filter.AccountID,
+		filter.From,
+		filter.To,
+		filter.MinAmount,
+		filter.MaxAmount,
+	}
+}
+
+// likeEscaper escapes the LIKE wildcards in user input
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// list runs a query that selects transactionColumns and scans every row
+func (r *TransactionRepository) list(query string, args ...interface{}) ([]*models.Transaction, error) {
+	var transactions []*models.Transaction
+	err := r.forEach(query, func(transaction *models.Transaction) error {
+		transactions = append(transactions, transaction)
+		return nil
+	}, args...)
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// UpdateDetails saves the editable details of a manually entered transaction
+func (r *TransactionRepository) UpdateDetails(transaction *models.Transaction) error {
+	transaction.UpdatedAt = time.Now().UTC()
+	query := `
+		UPDATE transactions
+		SET
+			name = $2, merchant_name = $3, amount = $4, iso_currency_code = $5, date = $6,
+			pending = $7, user_category = NULLIF($8, ''), notes = NULLIF($9, ''), updated_at = $10
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(
+		query,
+		transaction.ID,
+		transaction.Name,
+		transaction.MerchantName,
+		transaction.Amount,
+		transaction.IsoCurrencyCode,
+		transaction.Date,
+		transaction.Pending,
+		transaction.UserCategory,
+		transaction.Notes,
+		transaction.UpdatedAt,
+	)
+	return err
+}
+
+// ApplyRuleActions writes the actions of matching rules to a transaction. Unless overwrite
+// is set, the category and name are only filled in when the user hasn't set them already.
+// Tags are created for the transaction's owner as needed.
+func (r *TransactionRepository) ApplyRuleActions(transaction *models.Transaction, actions models.RuleActions, overwrite bool) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE transactions
+		SET
+			user_category = CASE
+				WHEN $1 <> '' AND ($5 OR COALESCE(user_category, '') = '') THEN $1
+				ELSE user_category END,
+			custom_name = CASE
+				WHEN $2 <> '' AND ($5 OR COALESCE(custom_name, '') = '') THEN $2
+				ELSE custom_name END,
+			is_transfer = is_transfer OR $3,
+			hidden = hidden OR $4,
+			updated_at = $6
+		WHERE id = $7
+	`
+	now := time.Now().UTC()
+	_, err = tx.Exec(
+		query,
+		actions.SetCategory,
+		actions.Rename,
+		actions.MarkTransfer,
+		actions.Hide,
+		overwrite,
+		now,
+		transaction.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range actions.AddTags {
+		if err := addTransactionTag(tx, transaction.UserID, transaction.ID, name); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ReconcilePending carries the user's annotations over from a pending transaction to the
+// posted transaction that replaces it, before Plaid's removal of the pending row deletes
+// them. Categories, names, flags and notes set on the pending row take precedence; tags
+// are merged; splits and attachments move to the posted row if it has none of its own.
+// A TransactionLink is recorded for audit. It reports whether a pending row was found and
+// reconciled; reconciling the same pair twice is a no-op. Moved splits may no longer sum
+// to the posted amount, so callers should check them with SplitRepository.MarkStaleIfUnbalanced.
+func (r *TransactionRepository) ReconcilePending(posted *models.Transaction) (bool, error) {
+	if posted.PendingTransactionID == "" {
+		return false, nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	pending, err := scanTransaction(tx.QueryRow(`
+		SELECT `+transactionColumns+`
+		FROM transactions
+		WHERE plaid_transaction_id = $1 AND user_id = $2
+		FOR UPDATE
+	`, posted.PendingTransactionID, posted.UserID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil // Pending transaction already gone or never synced
+		}
+		return false, err
+	}
+	current, err := scanTransaction(tx.QueryRow(`SELECT `+transactionColumns+` FROM transactions WHERE id = $1 FOR UPDATE`, posted.ID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil // Posted transaction removed in the meantime
+		}
+		return false, err
+	}
+	if !current.Replaces(pending) {
+		return false, nil
+	}
+	pendingID, pendingAmount := pending.ID, pending.Amount
+
+	var linked bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM transaction_links
+			WHERE pending_transaction_id = $1 AND posted_transaction_id = $2
+		)
+	`, pendingID, posted.ID).Scan(&linked)
+	if err != nil || linked {
+		return false, err
+	}
+
+	current.InheritAnnotations(pending)
+	now := current.UpdatedAt
+	_, err = tx.Exec(`
+		UPDATE transactions
+		SET user_category = $2, custom_name = $3, is_transfer = $4, hidden = $5, notes = $6, updated_at = $7
+		WHERE id = $1
+	`, current.ID, current.UserCategory, current.CustomName, current.IsTransfer, current.Hidden, current.Notes, now)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO transaction_tags (transaction_id, tag_id, created_at)
+		SELECT $2, tag_id, created_at FROM transaction_tags WHERE transaction_id = $1
+		ON CONFLICT DO NOTHING
+	`, pendingID, posted.ID)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE transaction_splits
+		SET transaction_id = $2, updated_at = $3
+		WHERE transaction_id = $1
+			AND NOT EXISTS (SELECT 1 FROM transaction_splits WHERE transaction_id = $2)
+	`, pendingID, posted.ID, now)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(`UPDATE transaction_attachments SET transaction_id = $2 WHERE transaction_id = $1`, pendingID, posted.ID)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO transaction_links (
+			id, user_id, pending_transaction_id, pending_plaid_transaction_id, pending_amount,
+			posted_transaction_id, posted_amount, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, uuid.New(), posted.UserID, pendingID, posted.PendingTransactionID, pendingAmount, posted.ID, posted.Amount, now)
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetLinksByTransactionID retrieves the pending-to-posted links that involve a transaction
+func (r *TransactionRepository) GetLinksByTransactionID(transactionID uuid.UUID) ([]*models.TransactionLink, error) {
+	query := `
+		SELECT
+			id, user_id, pending_transaction_id, pending_plaid_transaction_id, pending_amount,
+			posted_transaction_id, posted_amount, created_at
+		FROM transaction_links
+		WHERE posted_transaction_id = $1 OR pending_transaction_id = $1
+		ORDER BY created_at
+	`
+	rows, err := r.db.Query(query, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []*models.TransactionLink
+	for rows.Next() {
+		var link models.TransactionLink
+		err := rows.Scan(
+			&link.ID,
+			&link.UserID,
+			&link.PendingTransactionID,
+			&link.PendingPlaidTransactionID,
+			&link.PendingAmount,
+			&link.PostedTransactionID,
+			&link.PostedAmount,
+			&link.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, &link)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// ForEachByUserID streams every transaction for a user, newest first, to fn without
+// loading them all into memory. Iteration stops at the first error returned by fn.
+func (r *TransactionRepository) ForEachByUserID(userID uuid.UUID, fn func(*models.Transaction) error) error {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE user_id = $1
+		ORDER BY date DESC, created_at DESC
+	`
+	return r.forEach(query, fn, userID)
+}
+
+// ForEachByDateRange streams a user's transactions within a date range, oldest first, to
+// fn without loading them all into memory. Iteration stops at the first error returned by fn.
+func (r *TransactionRepository) ForEachByDateRange(userID uuid.UUID, startDate, endDate time.Time, fn func(*models.Transaction) error) error {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE user_id = $1 AND date >= $2 AND date <= $3
+		ORDER BY date, created_at, id
+	`
+	return r.forEach(query, fn, userID, startDate, endDate)
+}
+
+// ForEachByAccountAndDateRange streams an account's transactions within a date range,
+// oldest first, to fn. Iteration stops at the first error returned by fn.
+func (r *TransactionRepository) ForEachByAccountAndDateRange(accountID uuid.UUID, startDate, endDate time.Time, fn func(*models.Transaction) error) error {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE account_id = $1 AND date >= $2 AND date <= $3
+		ORDER BY date, created_at, id
+	`
+	return r.forEach(query, fn, accountID, startDate, endDate)
+}
+
+// ForEachUpdatedSince streams a user's transactions created or changed after a point in
+// time, oldest change first, to fn. Iteration stops at the first error returned by fn.
+func (r *TransactionRepository) ForEachUpdatedSince(userID uuid.UUID, since time.Time, fn func(*models.Transaction) error) error {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE user_id = $1 AND updated_at > $2
+		ORDER BY updated_at, id
+	`
+	return r.forEach(query, fn, userID, since)
+}
+
+// GetUncategorized retrieves up to limit of a user's posted transactions dated on or
+// after since that have no category override and no category prediction, newest first.
+// Transfers and hidden transactions are left out.
+func (r *TransactionRepository) GetUncategorized(userID uuid.UUID, since time.Time, limit int) ([]*models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions t
+		WHERE user_id = $1 AND date >= $2 AND COALESCE(user_category, '') = ''
+			AND NOT pending AND NOT is_transfer AND NOT hidden
+			AND NOT EXISTS (SELECT 1 FROM category_predictions p WHERE p.transaction_id = t.id)
+		ORDER BY date DESC, created_at DESC
+		LIMIT $3
+	`
+	return r.list(query, userID, since, limit)
+}
+
+// GetWithoutMerchant retrieves up to limit of a user's transactions that are not yet
+// linked to a merchant, newest first
+func (r *TransactionRepository) GetWithoutMerchant(userID uuid.UUID, limit int) ([]*models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE user_id = $1 AND merchant_id IS NULL
+		ORDER BY date DESC, created_at DESC
+		LIMIT $2
+	`
+	return r.list(query, userID, limit)
+}
+
+// GetWithoutEmbedding retrieves up to limit of a user's transactions that have no
+// embedding under model, or have changed since they were embedded, newest first
+func (r *TransactionRepository) GetWithoutEmbedding(userID uuid.UUID, model string, limit int) ([]*models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE user_id = $1 AND NOT EXISTS (
+			SELECT 1 FROM transaction_embeddings e
+			WHERE e.transaction_id = transactions.id AND e.model = $2 AND e.updated_at >= transactions.updated_at
+		)
+		ORDER BY date DESC, created_at DESC
+		LIMIT $3
+	`
+	return r.list(query, userID, model, limit)
+}
+
+// GetByIDs retrieves transactions by ID, keyed by ID
+func (r *TransactionRepository) GetByIDs(ids []uuid.UUID) (map[uuid.UUID]*models.Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE id = ANY($1)`
+	transactions := make(map[uuid.UUID]*models.Transaction, len(ids))
+	err := r.forEach(query, func(transaction *models.Transaction) error {
+		transactions[transaction.ID] = transaction
+		return nil
+	}, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
 	return transactions, nil
 }
 
+// forEach runs a query that selects transactionColumns and passes each row to fn
+func (r *TransactionRepository) forEach(query string, fn func(*models.Transaction) error, args ...interface{}) error {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(transaction); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// SumByCategory totals a user's transactions per category within a date range. Split
+// transactions count once per split under the split's category. Transfers and hidden
+// transactions are excluded. Totals follow the Plaid sign convention (positive = outflow).
+func (r *TransactionRepository) SumByCategory(userID uuid.UUID, startDate, endDate time.Time) ([]*models.CategoryTotal, error) {
+	query := `
+		SELECT category, SUM(amount), COUNT(*)
+		FROM transaction_lines
+		WHERE user_id = $1 AND date BETWEEN $2 AND $3 AND NOT is_transfer AND NOT hidden
+		GROUP BY category
+		ORDER BY SUM(amount) DESC
+	`
+	rows, err := r.db.Query(query, userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []*models.CategoryTotal
+	for rows.Next() {
+		var total models.CategoryTotal
+		if err := rows.Scan(&total.Category, &total.Total, &total.Count); err != nil {
+			return nil, err
+		}
+		totals = append(totals, &total)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return totals, nil
+}
+
+// SumByCategoryAndMonth totals a user's transactions per month and category within a
+// date range, with the same rules as SumByCategory
+func (r *TransactionRepository) SumByCategoryAndMonth(userID uuid.UUID, startDate, endDate time.Time) ([]*models.MonthlyCategoryTotal, error) {
+	query := `
+		SELECT DATE_TRUNC('month', date)::DATE AS month, category, SUM(amount)
+		FROM transaction_lines
+		WHERE user_id = $1 AND date BETWEEN $2 AND $3 AND NOT is_transfer AND NOT hidden
+		GROUP BY month, category
+		ORDER BY month, category
+	`
+	rows, err := r.db.Query(query, userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []*models.MonthlyCategoryTotal
+	for rows.Next() {
+		var total models.MonthlyCategoryTotal
+		if err := rows.Scan(&total.Month, &total.Category, &total.Total); err != nil {
+			return nil, err
+		}
+		totals = append(totals, &total)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return totals, nil
+}
+
+// UpdateNotes sets the markdown notes of a transaction
+func (r *TransactionRepository) UpdateNotes(id uuid.UUID, notes string) error {
+	query := `
+		UPDATE transactions
+		SET notes = NULLIF($1, ''), updated_at = $2
+		WHERE id = $3
+	`
+	_, err := r.db.Exec(query, notes, time.Now().UTC(), id)
+	return err
+}
+
+// UpdateCategory sets or, if category is empty, clears the user's category override
+func (r *TransactionRepository) UpdateCategory(id uuid.UUID, category string) error {
+	query := `
+		UPDATE transactions
+		SET user_category = NULLIF($1, ''), updated_at = $2
+		WHERE id = $3
+	`
+	_, err := r.db.Exec(query, category, time.Now().UTC(), id)
+	return err
+}
+
+// UpdateMerchant links a transaction to its canonical merchant
+func (r *TransactionRepository) UpdateMerchant(id, merchantID uuid.UUID) error {
+	query := `UPDATE transactions SET merchant_id = $1 WHERE id = $2 AND merchant_id IS DISTINCT FROM $1`
+	_, err := r.db.Exec(query, merchantID, id)
+	return err
+}
+
 // UpdatePendingStatus updates the pending status of a transaction
 func (r *TransactionRepository) UpdatePendingStatus(id uuid.UUID, pending bool) error {
 	query := `
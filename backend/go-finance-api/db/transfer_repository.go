@@ -0,0 +1,215 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// TransferRepository handles database operations for transfer pairs
+type TransferRepository struct {
+	db *Database
+}
+
+// NewTransferRepository creates a new TransferRepository
+func NewTransferRepository(db *Database) *TransferRepository {
+	return &TransferRepository{db: db}
+}
+
+// transferPairColumns is the column list of the transfer_pairs table
+const transferPairColumns = `
+	id, user_id, outflow_transaction_id, inflow_transaction_id, amount, status, created_at, updated_at
+`
+
+// transferPairSelect selects transfer pairs with the accounts of both sides, matching
+// scanTransferPair
+const transferPairSelect = `
+	SELECT
+		p.id, p.user_id, p.outflow_transaction_id, p.inflow_transaction_id,
+		o.account_id, i.account_id, p.amount, p.status, p.created_at, p.updated_at
+	FROM transfer_pairs p
+	JOIN transactions o ON o.id = p.outflow_transaction_id
+	JOIN transactions i ON i.id = p.inflow_transaction_id
+`
+
+// scanTransferPair scans a single transfer pair row selected with transferPairSelect
+func scanTransferPair(row rowScanner) (*models.TransferPair, error) {
+	var pair models.TransferPair
+	err := row.Scan(
+		&pair.ID,
+		&pair.UserID,
+		&pair.OutflowTransactionID,
+		&pair.InflowTransactionID,
+		&pair.OutflowAccountID,
+		&pair.InflowAccountID,
+		&pair.Amount,
+		&pair.Status,
+		&pair.CreatedAt,
+		&pair.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &pair, nil
+}
+
+// Create stores a transfer pair and marks both of its transactions as transfers. Which
+// of them were already marked, by a rule or the user, is remembered for Unlink.
+func (r *TransferRepository) Create(pair *models.TransferPair) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO transfer_pairs (`+transferPairColumns+`, outflow_was_transfer, inflow_was_transfer)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8,
+			(SELECT is_transfer FROM transactions WHERE id = $3),
+			(SELECT is_transfer FROM transactions WHERE id = $4))
+	`,
+		pair.ID,
+		pair.UserID,
+		pair.OutflowTransactionID,
+		pair.InflowTransactionID,
+		pair.Amount,
+		pair.Status,
+		pair.CreatedAt,
+		pair.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE transactions
+		SET is_transfer = TRUE, updated_at = $3
+		WHERE id IN ($1, $2)
+	`, pair.OutflowTransactionID, pair.InflowTransactionID, pair.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetByID retrieves a transfer pair by ID
+func (r *TransferRepository) GetByID(id uuid.UUID) (*models.TransferPair, error) {
+	row := r.db.QueryRow(transferPairSelect+`WHERE p.id = $1`, id)
+	pair, err := scanTransferPair(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return pair, nil
+}
+
+// GetByUserID retrieves all of a user's transfer pairs, including unlinked ones
+func (r *TransferRepository) GetByUserID(userID uuid.UUID) ([]*models.TransferPair, error) {
+	rows, err := r.db.Query(transferPairSelect+`
+		WHERE p.user_id = $1
+		ORDER BY p.created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pairs []*models.TransferPair
+	for rows.Next() {
+		pair, err := scanTransferPair(rows)
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, pair)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return pairs, nil
+}
+
+// GetCandidates retrieves the user's posted, visible transactions since a date that are
+// not already part of a transfer pair
+func (r *TransferRepository) GetCandidates(userID uuid.UUID, since time.Time) ([]*models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions t
+		WHERE t.user_id = $1
+			AND t.date >= $2
+			AND NOT t.pending
+			AND NOT t.hidden
+			AND t.amount <> 0
+			AND NOT EXISTS (
+				SELECT 1 FROM transfer_pairs p
+				WHERE p.status <> $3
+					AND (p.outflow_transaction_id = t.id OR p.inflow_transaction_id = t.id)
+			)
+		ORDER BY t.date
+	`
+	rows, err := r.db.Query(query, userID, since, models.TransferStatusRejected)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []*models.Transaction
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// Confirm marks a transfer pair as confirmed by the user
+func (r *TransferRepository) Confirm(pair *models.TransferPair) error {
+	pair.Status = models.TransferStatusConfirmed
+	pair.UpdatedAt = time.Now().UTC()
+	_, err := r.db.Exec(`
+		UPDATE transfer_pairs SET status = $2, updated_at = $3 WHERE id = $1
+	`, pair.ID, pair.Status, pair.UpdatedAt)
+	return err
+}
+
+// Unlink rejects a transfer pair and clears the transfer flag it set, so its
+// transactions count towards spending and income again. A transaction that was already
+// marked as a transfer when the pair was created stays marked. The rejected pair is
+// kept so that detection doesn't pair the two transactions again.
+func (r *TransferRepository) Unlink(pair *models.TransferPair) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	pair.Status = models.TransferStatusRejected
+	pair.UpdatedAt = time.Now().UTC()
+	_, err = tx.Exec(`
+		UPDATE transfer_pairs SET status = $2, updated_at = $3 WHERE id = $1
+	`, pair.ID, pair.Status, pair.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE transactions t
+		SET is_transfer = FALSE, updated_at = $2
+		FROM transfer_pairs p
+		WHERE p.id = $1
+			AND ((t.id = p.outflow_transaction_id AND NOT p.outflow_was_transfer)
+				OR (t.id = p.inflow_transaction_id AND NOT p.inflow_was_transfer))
+	`, pair.ID, pair.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -89,6 +89,28 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 	return &user, nil
 }
 
+// GetIDs retrieves the IDs of every user, for jobs that run for each user
+func (r *UserRepository) GetIDs() ([]uuid.UUID, error) {
+	rows, err := r.db.Query(`SELECT id FROM users ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
 // Update updates user information
 func (r *UserRepository) Update(user *models.User) error {
 	query := `
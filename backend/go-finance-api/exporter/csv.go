@@ -0,0 +1,82 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+)
+
+// csvHeader is the header row of a CSV export
+var csvHeader = []string{
+	"date", "transaction_id", "account", "name", "merchant_name", "category",
+	"amount", "currency", "pending", "transfer", "notes",
+}
+
+// csvWriter writes one row per transaction, or one row per split of a split transaction.
+// Amounts use the Plaid sign convention (positive = outflow).
+type csvWriter struct {
+	w    *csv.Writer
+	book *Book
+}
+
+func newCSVWriter(w io.Writer, book *Book) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w), book: book}
+	if err := cw.w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+// WriteTransaction writes a transaction's rows
+func (cw *csvWriter) WriteTransaction(t *models.Transaction) error {
+	accountName := ""
+	if account := cw.book.Accounts[t.AccountID]; account != nil {
+		accountName = account.Name
+	}
+
+	row := func(category string, amount float64) []string {
+		return []string{
+			t.Date.Format("2006-01-02"),
+			t.ID.String(),
+			accountName,
+			t.DisplayName(),
+			t.MerchantName,
+			category,
+			formatAmount(amount),
+			currencyOf(t),
+			strconv.FormatBool(t.Pending),
+			strconv.FormatBool(t.IsTransfer || cw.book.Transfers[t.ID] != nil),
+			t.Notes,
+		}
+	}
+
+	if splits := usableSplits(cw.book, t); splits != nil {
+		for _, split := range splits {
+			if err := cw.w.Write(row(split.Category, split.Amount)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return cw.w.Write(row(t.EffectiveCategory(), t.Amount))
+}
+
+// Close flushes buffered rows
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// usableSplits returns a transaction's splits, or nil if it has none or they no longer
+// sum to the transaction amount
+func usableSplits(book *Book, t *models.Transaction) []*models.TransactionSplit {
+	splits := book.Splits[t.ID]
+	for _, split := range splits {
+		if split.Stale {
+			return nil
+		}
+	}
+	return splits
+}
@@ -0,0 +1,189 @@
+// Package exporter writes a user's transactions as CSV, OFX or plain-text accounting
+// journals (Ledger and Beancount). Transactions are streamed from the database rather
+// than loaded into memory.
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// Supported export formats
+const (
+	FormatCSV       = "csv"
+	FormatOFX       = "ofx"
+	FormatLedger    = "ledger"
+	FormatBeancount = "beancount"
+)
+
+// contentTypes maps each supported format to its MIME type
+var contentTypes = map[string]string{
+	FormatCSV:       "text/csv; charset=utf-8",
+	FormatOFX:       "application/x-ofx",
+	FormatLedger:    "text/plain; charset=utf-8",
+	FormatBeancount: "text/plain; charset=utf-8",
+}
+
+// ContentType returns the MIME type of a format and whether the format is supported
+func ContentType(format string) (string, bool) {
+	contentType, ok := contentTypes[format]
+	return contentType, ok
+}
+
+// Book holds the context needed to render transactions: the user's accounts, the splits
+// of transactions in the exported range, and active transfer pairs keyed by the IDs of
+// both of their transactions
+type Book struct {
+	Accounts  map[uuid.UUID]*models.Account
+	Splits    map[uuid.UUID][]*models.TransactionSplit
+	Transfers map[uuid.UUID]*models.TransferPair
+}
+
+// writer renders transactions in one format
+type writer interface {
+	WriteTransaction(t *models.Transaction) error
+	Close() error
+}
+
+// newWriter creates the writer for a format
+func newWriter(format string, w io.Writer, book *Book, from, to time.Time) (writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, book)
+	case FormatOFX:
+		return newOFXWriter(w, book, from, to)
+	case FormatLedger:
+		return newLedgerWriter(w, book, from, to)
+	case FormatBeancount:
+		return newBeancountWriter(w, book)
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+// Exporter exports a user's transactions from the database
+type Exporter struct {
+	repos *db.Repositories
+}
+
+// NewExporter creates a new Exporter
+func NewExporter(repos *db.Repositories) *Exporter {
+	return &Exporter{repos: repos}
+}
+
+// Export writes the user's transactions dated from..to (inclusive) to w in the given format.
+// Output is buffered, so nothing is written to w if loading the accounts, splits and
+// transfers fails.
+func (e *Exporter) Export(w io.Writer, format string, userID uuid.UUID, from, to time.Time) error {
+	book, err := e.loadBook(userID, from, to)
+	if err != nil {
+		return err
+	}
+
+	buffered := bufio.NewWriter(w)
+	out, err := newWriter(format, buffered, book, from, to)
+	if err != nil {
+		return err
+	}
+
+	if format == FormatOFX {
+		// OFX groups transactions into one statement per account
+		for _, account := range ofxAccountOrder(book) {
+			err := e.repos.Transaction.ForEachByAccountAndDateRange(account.ID, from, to, out.WriteTransaction)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		if err := e.repos.Transaction.ForEachByDateRange(userID, from, to, out.WriteTransaction); err != nil {
+			return err
+		}
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+// loadBook loads the context shared by every format
+func (e *Exporter) loadBook(userID uuid.UUID, from, to time.Time) (*Book, error) {
+	book := &Book{
+		Accounts:  make(map[uuid.UUID]*models.Account),
+		Splits:    make(map[uuid.UUID][]*models.TransactionSplit),
+		Transfers: make(map[uuid.UUID]*models.TransferPair),
+	}
+
+	accounts, err := e.repos.Account.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load accounts: %w", err)
+	}
+	for _, account := range accounts {
+		book.Accounts[account.ID] = account
+	}
+
+	splits, err := e.repos.Split.GetByUserAndDateRange(userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load splits: %w", err)
+	}
+	for _, split := range splits {
+		book.Splits[split.TransactionID] = append(book.Splits[split.TransactionID], split)
+	}
+
+	pairs, err := e.repos.Transfer.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load transfers: %w", err)
+	}
+	for _, pair := range pairs {
+		if pair.Status == models.TransferStatusRejected {
+			continue
+		}
+		book.Transfers[pair.OutflowTransactionID] = pair
+		book.Transfers[pair.InflowTransactionID] = pair
+	}
+	return book, nil
+}
+
+// ofxAccountOrder returns the book's accounts in the order OFX requires: bank statements
+// before credit card statements, then by name
+func ofxAccountOrder(book *Book) []*models.Account {
+	accounts := make([]*models.Account, 0, len(book.Accounts))
+	for _, account := range book.Accounts {
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		ci, cj := accounts[i].Type == "credit", accounts[j].Type == "credit"
+		if ci != cj {
+			return cj
+		}
+		if accounts[i].Name != accounts[j].Name {
+			return accounts[i].Name < accounts[j].Name
+		}
+		return accounts[i].ID.String() < accounts[j].ID.String()
+	})
+	return accounts
+}
+
+// formatAmount formats an amount with two decimal places
+func formatAmount(amount float64) string {
+	s := strconv.FormatFloat(amount, 'f', 2, 64)
+	if s == "-0.00" {
+		return "0.00"
+	}
+	return s
+}
+
+// currencyOf returns a transaction's currency, defaulting to USD
+func currencyOf(t *models.Transaction) string {
+	if t.IsoCurrencyCode == "" {
+		return "USD"
+	}
+	return t.IsoCurrencyCode
+}
@@ -0,0 +1,142 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/importer"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBook builds a checking account and a credit card with a purchase, a split purchase
+// and a card payment detected as a transfer
+func testBook(t *testing.T) (*Book, []*models.Transaction) {
+	t.Helper()
+	userID := uuid.New()
+	checking := models.NewManualAccount(userID, "Chase Checking", "depository", "checking", "USD", models.BalanceModeManual, 1000)
+	checking.Mask = "1234"
+	card := models.NewManualAccount(userID, "Sapphire", "credit", "credit card", "USD", models.BalanceModeManual, 250)
+
+	coffee := models.NewTransaction(card.ID, userID, "", "", []string{"Food and Drink"}, "Blue Bottle", "Blue Bottle", 4.5, "USD", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), false, "in store")
+	groceries := models.NewTransaction(card.ID, userID, "", "", nil, "Costco", "Costco", 100, "USD", time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), false, "in store")
+	payment := models.NewTransaction(checking.ID, userID, "", "", nil, "CHASE CARD PAYMENT", "", 250, "USD", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), false, "other")
+	received := models.NewTransaction(card.ID, userID, "", "", nil, "PAYMENT THANK YOU", "", -250, "USD", time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC), false, "other")
+
+	pair := models.NewTransferPair(userID, payment, received)
+	book := &Book{
+		Accounts: map[uuid.UUID]*models.Account{checking.ID: checking, card.ID: card},
+		Splits: map[uuid.UUID][]*models.TransactionSplit{
+			groceries.ID: {
+				models.NewTransactionSplit(groceries.ID, userID, 70, "Groceries", ""),
+				models.NewTransactionSplit(groceries.ID, userID, 30, "Household", ""),
+			},
+		},
+		Transfers: map[uuid.UUID]*models.TransferPair{payment.ID: pair, received.ID: pair},
+	}
+	return book, []*models.Transaction{coffee, groceries, payment, received}
+}
+
+// render writes transactions with the writer for a format
+func render(t *testing.T, format string, book *Book, transactions []*models.Transaction) string {
+	t.Helper()
+	var buf bytes.Buffer
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	w, err := newWriter(format, &buf, book, from, to)
+	require.NoError(t, err)
+	for _, transaction := range transactions {
+		require.NoError(t, w.WriteTransaction(transaction))
+	}
+	require.NoError(t, w.Close())
+	return buf.String()
+}
+
+// TestLedger tests account naming, splits and balanced transfer postings
+func TestLedger(t *testing.T) {
+	book, transactions := testBook(t)
+	out := render(t, FormatLedger, book, transactions)
+
+	assert.Contains(t, out, "2024/03/01 * Blue Bottle\n")
+	assert.Regexp(t, `Liabilities:CreditCard:Sapphire\s+-4.50 USD`, out)
+	assert.Regexp(t, `Expenses:Food-And-Drink\s+4.50 USD`, out)
+	assert.Regexp(t, `Expenses:Groceries\s+70.00 USD`, out)
+	assert.Regexp(t, `Expenses:Household\s+30.00 USD`, out)
+
+	// The card payment is a single entry moving money from checking to the card
+	assert.Regexp(t, `Assets:Bank:Chase-Checking-1234\s+-250.00 USD\n\s+Liabilities:CreditCard:Sapphire\s+250.00 USD`, out)
+	assert.NotContains(t, out, "PAYMENT THANK YOU")
+	assert.NotContains(t, out, "Income:")
+}
+
+// TestBeancount tests that every entry balances
+func TestBeancount(t *testing.T) {
+	book, transactions := testBook(t)
+	transactions[0].Notes = "Oat \"latte\""
+	out := render(t, FormatBeancount, book, transactions)
+
+	assert.True(t, strings.HasPrefix(out, `plugin "beancount.plugins.auto_accounts"`))
+	assert.Contains(t, out, `2024-03-01 * "Blue Bottle" "Blue Bottle"`)
+	assert.Contains(t, out, `note: "Oat \"latte\""`)
+
+	entries := strings.Split(strings.TrimSpace(out), "\n\n")[1:]
+	require.Len(t, entries, 3)
+	for _, entry := range entries {
+		var sum int64
+		for _, line := range strings.Split(entry, "\n")[1:] {
+			fields := strings.Fields(line)
+			if len(fields) != 3 || fields[2] != "USD" {
+				continue // Metadata
+			}
+			amount, err := strconv.ParseFloat(fields[1], 64)
+			require.NoError(t, err)
+			sum += models.ToMinorUnits(amount)
+		}
+		assert.Zero(t, sum, entry)
+	}
+}
+
+// TestCSV tests one row per transaction or split
+func TestCSV(t *testing.T) {
+	book, transactions := testBook(t)
+	out := render(t, FormatCSV, book, transactions)
+
+	rows, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 6)
+	assert.Equal(t, csvHeader, rows[0])
+	assert.Equal(t, []string{"2024-03-02", "Groceries", "70.00"}, []string{rows[2][0], rows[2][5], rows[2][6]})
+	assert.Equal(t, "true", rows[4][9])
+}
+
+// TestOFXRoundTrip tests that the importer reads an exported OFX file back
+func TestOFXRoundTrip(t *testing.T) {
+	book, transactions := testBook(t)
+	// OFX wants statements grouped by account, bank first
+	ordered := []*models.Transaction{transactions[2], transactions[0], transactions[1], transactions[3]}
+	out := render(t, FormatOFX, book, ordered)
+
+	assert.Less(t, strings.Index(out, "<BANKMSGSRSV1>"), strings.Index(out, "<CREDITCARDMSGSRSV1>"))
+
+	records, err := importer.ParseOFX(strings.NewReader(out))
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, 250.0, records[0].Amount)
+	assert.Equal(t, "Blue Bottle", records[1].Name)
+	assert.Equal(t, 4.5, records[1].Amount)
+	assert.Equal(t, -250.0, records[3].Amount)
+	assert.Equal(t, transactions[0].ID.String(), records[1].SourceID)
+}
+
+// TestAccountComponent tests sanitizing names into account components
+func TestAccountComponent(t *testing.T) {
+	assert.Equal(t, "Food-And-Drink", accountComponent("Food and Drink"))
+	assert.Equal(t, "Amex-Gold-1008", accountComponent("Amex Gold •• 1008"))
+	assert.Equal(t, "Unknown", accountComponent("  "))
+}
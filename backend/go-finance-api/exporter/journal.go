@@ -0,0 +1,234 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// transferClearingAccount balances transactions marked as transfers that have no detected
+// counterpart in another account
+const transferClearingAccount = "Equity:Transfers"
+
+// posting is one line of a double-entry journal transaction
+type posting struct {
+	Account  string
+	Amount   float64
+	Currency string
+}
+
+// journal turns transactions into balanced double-entry postings. Bank and card accounts
+// become Assets and Liabilities accounts, categories become Expenses (outflows) or Income
+// (inflows) accounts, and a transfer pair becomes a single entry moving money between the
+// two accounts.
+type journal struct {
+	book    *Book
+	emitted map[uuid.UUID]bool // Transfer pairs already written via their other side
+}
+
+func newJournal(book *Book) *journal {
+	return &journal{book: book, emitted: make(map[uuid.UUID]bool)}
+}
+
+// postings returns the postings for a transaction, or nil if it was already written as
+// the other side of a transfer
+func (j *journal) postings(t *models.Transaction) []posting {
+	currency := currencyOf(t)
+	source := posting{Account: AccountName(j.book.Accounts[t.AccountID]), Amount: -t.Amount, Currency: currency}
+
+	if pair := j.book.Transfers[t.ID]; pair != nil {
+		if j.emitted[pair.ID] {
+			return nil
+		}
+		j.emitted[pair.ID] = true
+
+		counterpartID := pair.InflowAccountID
+		if t.ID == pair.InflowTransactionID {
+			counterpartID = pair.OutflowAccountID
+		}
+		return []posting{
+			source,
+			{Account: AccountName(j.book.Accounts[counterpartID]), Amount: t.Amount, Currency: currency},
+		}
+	}
+
+	if t.IsTransfer {
+		return []posting{source, {Account: transferClearingAccount, Amount: t.Amount, Currency: currency}}
+	}
+
+	if splits := usableSplits(j.book, t); splits != nil {
+		postings := []posting{source}
+		for _, split := range splits {
+			postings = append(postings, posting{Account: CategoryAccountName(split.Category, split.Amount), Amount: split.Amount, Currency: currency})
+		}
+		return postings
+	}
+
+	return []posting{
+		source,
+		{Account: CategoryAccountName(t.EffectiveCategory(), t.Amount), Amount: t.Amount, Currency: currency},
+	}
+}
+
+// AccountName maps an account to a hierarchical ledger account name such as
+// Assets:Bank:Chase-Checking-1234 or Liabilities:CreditCard:Sapphire-4321
+func AccountName(account *models.Account) string {
+	if account == nil {
+		return "Assets:Unknown"
+	}
+
+	var prefix string
+	switch account.Type {
+	case "depository":
+		prefix = "Assets:Bank"
+	case "investment", "brokerage":
+		prefix = "Assets:Investments"
+	case "credit":
+		prefix = "Liabilities:CreditCard"
+	case "loan":
+		prefix = "Liabilities:Loans"
+	default:
+		prefix = "Assets:Other"
+	}
+	return prefix + ":" + accountComponent(account.Name+" "+account.Mask)
+}
+
+// CategoryAccountName maps a category to an Expenses account for outflows or an Income
+// account for inflows, such as Expenses:Food-And-Drink
+func CategoryAccountName(category string, amount float64) string {
+	if amount < 0 {
+		return "Income:" + accountComponent(category)
+	}
+	return "Expenses:" + accountComponent(category)
+}
+
+// accountComponent turns free text into an account name component that both Ledger and
+// Beancount accept: ASCII words, capitalized and joined by hyphens
+func accountComponent(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	})
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	if len(words) == 0 {
+		return "Unknown"
+	}
+	return strings.Join(words, "-")
+}
+
+// ledgerWriter writes a Ledger journal
+type ledgerWriter struct {
+	w       io.Writer
+	journal *journal
+}
+
+func newLedgerWriter(w io.Writer, book *Book, from, to time.Time) (*ledgerWriter, error) {
+	_, err := fmt.Fprintf(w, "; Transactions from %s to %s\n\n", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	return &ledgerWriter{w: w, journal: newJournal(book)}, nil
+}
+
+// WriteTransaction writes a transaction entry
+func (lw *ledgerWriter) WriteTransaction(t *models.Transaction) error {
+	postings := lw.journal.postings(t)
+	if postings == nil {
+		return nil
+	}
+
+	flag := "*"
+	if t.Pending {
+		flag = "!"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s\n", t.Date.Format("2006/01/02"), flag, singleLine(t.DisplayName()))
+	for _, line := range noteLines(t.Notes) {
+		fmt.Fprintf(&b, "    ; %s\n", line)
+	}
+	for _, p := range postings {
+		fmt.Fprintf(&b, "    %-50s  %s %s\n", p.Account, formatAmount(p.Amount), p.Currency)
+	}
+	b.WriteString("\n")
+
+	_, err := io.WriteString(lw.w, b.String())
+	return err
+}
+
+// Close is a no-op; entries are written as they arrive
+func (lw *ledgerWriter) Close() error {
+	return nil
+}
+
+// beancountWriter writes a Beancount journal. Accounts are opened automatically by the
+// auto_accounts plugin, since they aren't all known before streaming.
+type beancountWriter struct {
+	w       io.Writer
+	journal *journal
+}
+
+func newBeancountWriter(w io.Writer, book *Book) (*beancountWriter, error) {
+	_, err := io.WriteString(w, "plugin \"beancount.plugins.auto_accounts\"\n\n")
+	if err != nil {
+		return nil, err
+	}
+	return &beancountWriter{w: w, journal: newJournal(book)}, nil
+}
+
+// WriteTransaction writes a transaction entry
+func (bw *beancountWriter) WriteTransaction(t *models.Transaction) error {
+	postings := bw.journal.postings(t)
+	if postings == nil {
+		return nil
+	}
+
+	flag := "*"
+	if t.Pending {
+		flag = "!"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s %s\n", t.Date.Format("2006-01-02"), flag, quote(t.MerchantName), quote(t.DisplayName()))
+	if t.Notes != "" {
+		fmt.Fprintf(&b, "  note: %s\n", quote(strings.Join(noteLines(t.Notes), " ")))
+	}
+	for _, p := range postings {
+		fmt.Fprintf(&b, "  %-50s  %s %s\n", p.Account, formatAmount(p.Amount), p.Currency)
+	}
+	b.WriteString("\n")
+
+	_, err := io.WriteString(bw.w, b.String())
+	return err
+}
+
+// Close is a no-op; entries are written as they arrive
+func (bw *beancountWriter) Close() error {
+	return nil
+}
+
+// quote returns s as a Beancount string literal
+func quote(s string) string {
+	s = strings.ReplaceAll(singleLine(s), `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// singleLine collapses whitespace, including newlines, into single spaces
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// noteLines splits notes into non-empty lines
+func noteLines(notes string) []string {
+	var lines []string
+	for _, line := range strings.Split(notes, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
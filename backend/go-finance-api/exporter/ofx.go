@@ -0,0 +1,189 @@
+package exporter
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// ofxWriter writes an OFX 2.2 (XML) document with one statement per account. Transactions
+// must arrive grouped by account, with bank accounts before credit cards.
+type ofxWriter struct {
+	w        io.Writer
+	book     *Book
+	from, to time.Time
+	account  *models.Account // Account of the open statement, if any
+	msgSet   string          // Open message set aggregate, if any
+}
+
+func newOFXWriter(w io.Writer, book *Book, from, to time.Time) (*ofxWriter, error) {
+	now := ofxDate(time.Now().UTC())
+	_, err := fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS><DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE></SONRS></SIGNONMSGSRSV1>
+`, now)
+	if err != nil {
+		return nil, err
+	}
+	return &ofxWriter{w: w, book: book, from: from, to: to}, nil
+}
+
+// WriteTransaction writes a STMTTRN, starting a new statement when the account changes
+func (ow *ofxWriter) WriteTransaction(t *models.Transaction) error {
+	if ow.account == nil || ow.account.ID != t.AccountID {
+		if err := ow.startStatement(t.AccountID); err != nil {
+			return err
+		}
+	}
+
+	trnType := "DEBIT"
+	if t.Amount < 0 {
+		trnType = "CREDIT"
+	}
+	var b strings.Builder
+	b.WriteString("<STMTTRN>")
+	fmt.Fprintf(&b, "<TRNTYPE>%s</TRNTYPE>", trnType)
+	fmt.Fprintf(&b, "<DTPOSTED>%s</DTPOSTED>", ofxDate(t.Date))
+	fmt.Fprintf(&b, "<TRNAMT>%s</TRNAMT>", formatAmount(-t.Amount)) // OFX amounts are signed from the account holder's view
+	fmt.Fprintf(&b, "<FITID>%s</FITID>", t.ID)
+	fmt.Fprintf(&b, "<NAME>%s</NAME>", ofxText(truncate(t.DisplayName(), 32)))
+	if memo := t.EffectiveCategory(); memo != "" {
+		fmt.Fprintf(&b, "<MEMO>%s</MEMO>", ofxText(truncate(memo, 255)))
+	}
+	b.WriteString("</STMTTRN>\n")
+
+	_, err := io.WriteString(ow.w, b.String())
+	return err
+}
+
+// Close ends the open statement and the document
+func (ow *ofxWriter) Close() error {
+	if err := ow.endStatement(); err != nil {
+		return err
+	}
+	if ow.msgSet != "" {
+		if _, err := fmt.Fprintf(ow.w, "</%s>\n", ow.msgSet); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(ow.w, "</OFX>\n")
+	return err
+}
+
+// startStatement ends the open statement and starts one for an account, switching
+// message sets between bank and credit card statements as needed
+func (ow *ofxWriter) startStatement(accountID uuid.UUID) error {
+	if err := ow.endStatement(); err != nil {
+		return err
+	}
+	account := ow.book.Accounts[accountID]
+	if account == nil {
+		return fmt.Errorf("unknown account %s", accountID)
+	}
+
+	msgSet := "BANKMSGSRSV1"
+	if account.Type == "credit" {
+		msgSet = "CREDITCARDMSGSRSV1"
+	}
+	var b strings.Builder
+	if msgSet != ow.msgSet {
+		if ow.msgSet != "" {
+			fmt.Fprintf(&b, "</%s>\n", ow.msgSet)
+		}
+		fmt.Fprintf(&b, "<%s>\n", msgSet)
+	}
+
+	acctID := account.Mask
+	if acctID == "" {
+		acctID = account.ID.String()
+	}
+	if msgSet == "CREDITCARDMSGSRSV1" {
+		b.WriteString("<CCSTMTTRNRS><TRNUID>0</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS><CCSTMTRS>")
+		fmt.Fprintf(&b, "<CURDEF>%s</CURDEF>", accountCurrency(account))
+		fmt.Fprintf(&b, "<CCACCTFROM><ACCTID>%s</ACCTID></CCACCTFROM>", ofxText(acctID))
+	} else {
+		b.WriteString("<STMTTRNRS><TRNUID>0</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS><STMTRS>")
+		fmt.Fprintf(&b, "<CURDEF>%s</CURDEF>", accountCurrency(account))
+		fmt.Fprintf(&b, "<BANKACCTFROM><BANKID>000000000</BANKID><ACCTID>%s</ACCTID><ACCTTYPE>%s</ACCTTYPE></BANKACCTFROM>",
+			ofxText(acctID), ofxAccountType(account))
+	}
+	fmt.Fprintf(&b, "\n<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>\n", ofxDate(ow.from), ofxDate(ow.to))
+
+	if _, err := io.WriteString(ow.w, b.String()); err != nil {
+		return err
+	}
+	ow.account, ow.msgSet = account, msgSet
+	return nil
+}
+
+// endStatement closes the open statement, if any, with the account's current balance
+func (ow *ofxWriter) endStatement() error {
+	if ow.account == nil {
+		return nil
+	}
+
+	// Balances are positive for both assets and debts; OFX shows debts as negative
+	balance := ow.account.CurrentBalance
+	if ow.account.IsLiability() {
+		balance = -balance
+	}
+	ledgerBal := fmt.Sprintf("<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>",
+		formatAmount(balance), ofxDate(ow.account.LastUpdated))
+
+	var err error
+	if ow.msgSet == "CREDITCARDMSGSRSV1" {
+		_, err = fmt.Fprintf(ow.w, "</BANKTRANLIST>%s</CCSTMTRS></CCSTMTTRNRS>\n", ledgerBal)
+	} else {
+		_, err = fmt.Fprintf(ow.w, "</BANKTRANLIST>%s</STMTRS></STMTTRNRS>\n", ledgerBal)
+	}
+	ow.account = nil
+	return err
+}
+
+// ofxAccountType maps an account to an OFX ACCTTYPE
+func ofxAccountType(account *models.Account) string {
+	switch {
+	case account.Type == "loan":
+		return "CREDITLINE"
+	case account.Subtype == "savings":
+		return "SAVINGS"
+	case account.Subtype == "money market":
+		return "MONEYMRKT"
+	case account.Subtype == "cd":
+		return "CD"
+	}
+	return "CHECKING"
+}
+
+// accountCurrency returns an account's currency, defaulting to USD
+func accountCurrency(account *models.Account) string {
+	if account.CurrencyCode == "" {
+		return "USD"
+	}
+	return account.CurrencyCode
+}
+
+// ofxDate formats a time as an OFX date-time
+func ofxDate(t time.Time) string {
+	return t.UTC().Format("20060102150405")
+}
+
+// ofxText escapes text for an OFX element
+func ofxText(s string) string {
+	return html.EscapeString(singleLine(s))
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
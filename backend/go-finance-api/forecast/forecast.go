@@ -0,0 +1,220 @@
+// Package forecast projects depository account balances from recurring transactions and
+// recent spending
+package forecast
+
+import (
+	"math"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/davidwang/go-finance-api/go-finance-api/recurring"
+	"github.com/google/uuid"
+)
+
+const (
+	// HistoryDays is how much history the average discretionary spend is measured over
+	HistoryDays = 90
+
+	// bandZ scales the band around a projection to cover about 80% of outcomes
+	bandZ = 1.2816
+)
+
+// Point is an account's projected end-of-day balance with its confidence band
+type Point struct {
+	Date    time.Time `json:"date"`
+	Balance float64   `json:"balance"`
+	Low     float64   `json:"low"`
+	High    float64   `json:"high"`
+}
+
+// Warning reports that an account is projected to fall below the threshold
+type Warning struct {
+	Date          time.Time `json:"date"` // First day below the threshold
+	LowestBalance float64   `json:"lowest_balance"`
+	LowestDate    time.Time `json:"lowest_date"`
+}
+
+// AccountForecast is the projection for one account
+type AccountForecast struct {
+	AccountID          uuid.UUID `json:"account_id"`
+	AccountName        string    `json:"account_name"`
+	CurrencyCode       string    `json:"currency_code"`
+	StartingBalance    float64   `json:"starting_balance"`
+	DailyDiscretionary float64   `json:"daily_discretionary"` // Average net outflow per day outside recurring series
+	Points             []*Point  `json:"points"`
+	Warning            *Warning  `json:"warning,omitempty"`
+}
+
+// Project forecasts an account's balance for the days after today. It starts from the
+// current balance, applies every expected transaction of the account's active recurring
+// series, and subtracts the average daily net of the account's other transactions during
+// history, the HistoryDays before today. The band widens with the day-to-day variance of
+// that spending. Series already due but not yet charged are applied on the first day.
+func Project(account *models.Account, series []*models.RecurringSeries, history []*models.Transaction, today time.Time, days int, threshold float64) *AccountForecast {
+	var own []*models.RecurringSeries
+	for _, s := range series {
+		if s.AccountID != nil && *s.AccountID == account.ID && s.Status == models.RecurringStatusActive {
+			own = append(own, s)
+		}
+	}
+	mean, stddev := discretionary(own, history, today)
+
+	// Expected recurring amounts per day, in minor units and Plaid sign convention
+	end := today.AddDate(0, 0, days)
+	expected := make(map[time.Time]int64)
+	for _, s := range own {
+		for _, date := range recurring.Dates(s, end) {
+			day := date
+			if !day.After(today) {
+				day = today.AddDate(0, 0, 1)
+			}
+			expected[day] += models.ToMinorUnits(s.AverageAmount)
+		}
+	}
+
+	forecast := &AccountForecast{
+		AccountID:          account.ID,
+		AccountName:        account.Name,
+		CurrencyCode:       account.CurrencyCode,
+		StartingBalance:    account.CurrentBalance,
+		DailyDiscretionary: roundCents(mean),
+		Points: []*Point{{
+			Date:    today,
+			Balance: account.CurrentBalance,
+			Low:     account.CurrentBalance,
+			High:    account.CurrentBalance,
+		}},
+	}
+
+	balance := float64(models.ToMinorUnits(account.CurrentBalance))
+	lowest := forecast.Points[0]
+	var below *time.Time
+	for k := 1; k <= days; k++ {
+		date := today.AddDate(0, 0, k)
+		// Outflows are positive, so they lower the balance
+		balance -= float64(expected[date]) + mean*10000
+		band := bandZ * stddev * math.Sqrt(float64(k)) * 10000
+
+		point := &Point{
+			Date:    date,
+			Balance: roundCents(balance / 10000),
+			Low:     roundCents((balance - band) / 10000),
+			High:    roundCents((balance + band) / 10000),
+		}
+		forecast.Points = append(forecast.Points, point)
+
+		if point.Balance < lowest.Balance {
+			lowest = point
+		}
+		if below == nil && point.Balance < threshold {
+			below = &point.Date
+		}
+	}
+
+	if below != nil {
+		forecast.Warning = &Warning{
+			Date:          *below,
+			LowestBalance: lowest.Balance,
+			LowestDate:    lowest.Date,
+		}
+	}
+	return forecast
+}
+
+// discretionary returns the mean and standard deviation of an account's daily net
+// amount over the HistoryDays before today, leaving out pending transactions and those
+// belonging to a recurring series. An account with a shorter history, such as one just
+// linked, is averaged over the days since its first transaction.
+func discretionary(series []*models.RecurringSeries, history []*models.Transaction, today time.Time) (mean, stddev float64) {
+	start := today.AddDate(0, 0, -HistoryDays)
+	first := today
+	daily := make(map[time.Time]float64)
+	for _, t := range history {
+		if t.Pending || t.Date.Before(start) || !t.Date.Before(today) {
+			continue
+		}
+		if t.Date.Before(first) {
+			first = t.Date
+		}
+		if matchesAny(series, t) {
+			continue
+		}
+		daily[t.Date] += t.Amount
+	}
+
+	days := int(today.Sub(first).Hours() / 24)
+	if days == 0 {
+		return 0, 0
+	}
+
+	var sum float64
+	for _, amount := range daily {
+		sum += amount
+	}
+	mean = sum / float64(days)
+
+	var squares float64
+	for k := 0; k < days; k++ {
+		diff := daily[first.AddDate(0, 0, k)] - mean
+		squares += diff * diff
+	}
+	return mean, math.Sqrt(squares / float64(days))
+}
+
+// matchesAny reports whether a transaction belongs to one of the series
+func matchesAny(series []*models.RecurringSeries, t *models.Transaction) bool {
+	for _, s := range series {
+		if recurring.Matches(s, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// roundCents rounds an amount to whole cents
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// Service forecasts a user's accounts from stored data
+type Service struct {
+	repos *db.Repositories
+}
+
+// NewService creates a new Service
+func NewService(repos *db.Repositories) *Service {
+	return &Service{repos: repos}
+}
+
+// ForecastUser projects the balance of each of the user's depository accounts for the
+// given number of days
+func (s *Service) ForecastUser(userID uuid.UUID, days int, threshold float64) ([]*AccountForecast, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	accounts, err := s.repos.Account.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	series, err := s.repos.Recurring.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	forecasts := []*AccountForecast{}
+	for _, account := range accounts {
+		if account.Type != "depository" {
+			continue
+		}
+		var history []*models.Transaction
+		err := s.repos.Transaction.ForEachByAccountAndDateRange(account.ID, today.AddDate(0, 0, -HistoryDays), today.AddDate(0, 0, -1), func(t *models.Transaction) error {
+			history = append(history, t)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		forecasts = append(forecasts, Project(account, series, history, today, days, threshold))
+	}
+	return forecasts, nil
+}
@@ -0,0 +1,97 @@
+package forecast
+
+import (
+	"testing"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSeries builds an active series on account
+func newTestSeries(account *models.Account, key, frequency string, amount float64, next time.Time) *models.RecurringSeries {
+	accountID := account.ID
+	return &models.RecurringSeries{
+		ID:            uuid.New(),
+		AccountID:     &accountID,
+		SeriesKey:     key,
+		Frequency:     frequency,
+		AverageAmount: amount,
+		NextDate:      next,
+		Status:        models.RecurringStatusActive,
+	}
+}
+
+// TestProject tests applying recurring series, discretionary spend and the threshold
+func TestProject(t *testing.T) {
+	today := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	checking := models.NewManualAccount(uuid.New(), "Checking", "depository", "checking", "USD", models.BalanceModeManual, 1000)
+	series := []*models.RecurringSeries{
+		newTestSeries(checking, "in:acme payroll:biweekly", models.FrequencyBiweekly, -2000, today.AddDate(0, 0, 3)),
+		newTestSeries(checking, "out:landlord:monthly", models.FrequencyMonthly, 1500, today.AddDate(0, 0, 10)),
+		newTestSeries(checking, "out:gym:monthly", models.FrequencyMonthly, 40, today.AddDate(0, 0, -2)), // Due, not yet charged
+	}
+
+	// $10 a day of everyday spending, plus rent that belongs to a series
+	var history []*models.Transaction
+	for k := 1; k <= HistoryDays; k++ {
+		history = append(history, &models.Transaction{AccountID: checking.ID, Name: "Corner Store", Amount: 10, Date: today.AddDate(0, 0, -k)})
+	}
+	history = append(history, &models.Transaction{AccountID: checking.ID, Name: "LANDLORD", Amount: 1500, Date: today.AddDate(0, 0, -20)})
+
+	forecast := Project(checking, series, history, today, 30, 1500)
+
+	assert.Equal(t, 10.0, forecast.DailyDiscretionary)
+	require.Len(t, forecast.Points, 31)
+	assert.Equal(t, 1000.0, forecast.Points[0].Balance)
+	assert.Equal(t, 950.0, forecast.Points[1].Balance)  // Gym and a day of spending
+	assert.Equal(t, 2930.0, forecast.Points[3].Balance) // Payday
+	assert.Equal(t, 1360.0, forecast.Points[10].Balance)
+	assert.Equal(t, forecast.Points[10].Balance, forecast.Points[10].Low) // No variance
+
+	require.NotNil(t, forecast.Warning)
+	assert.Equal(t, today.AddDate(0, 0, 1), forecast.Warning.Date)
+	assert.Equal(t, 940.0, forecast.Warning.LowestBalance) // The day before payday
+	assert.Equal(t, today.AddDate(0, 0, 2), forecast.Warning.LowestDate)
+}
+
+// TestProjectBands tests that the band widens with spending variance
+func TestProjectBands(t *testing.T) {
+	today := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	savings := models.NewManualAccount(uuid.New(), "Savings", "depository", "savings", "USD", models.BalanceModeManual, 5000)
+	var history []*models.Transaction
+	for k := 2; k <= HistoryDays; k += 2 {
+		history = append(history, &models.Transaction{Name: "Shop", Amount: 40, Date: today.AddDate(0, 0, -k)})
+	}
+
+	forecast := Project(savings, nil, history, today, 90, 0)
+
+	assert.Nil(t, forecast.Warning)
+	assert.Equal(t, 20.0, forecast.DailyDiscretionary)
+	first, last := forecast.Points[1], forecast.Points[90]
+	assert.Equal(t, 4980.0, first.Balance)
+	assert.Equal(t, 3200.0, last.Balance)
+	assert.Greater(t, last.High-last.Low, first.High-first.Low)
+	assert.InDelta(t, last.Balance, (last.High+last.Low)/2, 0.01)
+}
+
+// TestProjectShortHistory tests averaging a newly linked account over the days it covers
+func TestProjectShortHistory(t *testing.T) {
+	today := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	checking := models.NewManualAccount(uuid.New(), "Checking", "depository", "checking", "USD", models.BalanceModeManual, 1000)
+	var history []*models.Transaction
+	for k := 1; k <= 10; k++ {
+		history = append(history, &models.Transaction{Name: "Corner Store", Amount: 10, Date: today.AddDate(0, 0, -k)})
+	}
+
+	forecast := Project(checking, nil, history, today, 30, 0)
+	assert.Equal(t, 10.0, forecast.DailyDiscretionary)
+	assert.Equal(t, 700.0, forecast.Points[30].Balance)
+
+	// No history at all
+	forecast = Project(checking, nil, nil, today, 30, 0)
+	assert.Equal(t, 0.0, forecast.DailyDiscretionary)
+	assert.Equal(t, 1000.0, forecast.Points[30].Balance)
+}
@@ -0,0 +1,98 @@
+// Package goals tracks progress toward savings goals
+package goals
+
+import (
+	"math"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+)
+
+const (
+	// RateWindowDays is how far back the recent saving rate is measured
+	RateWindowDays = 90
+
+	// minRateDays is the shortest balance history a saving rate is measured over
+	minRateDays = 14
+
+	// daysPerMonth is the average length of a month
+	daysPerMonth = 365.25 / 12
+)
+
+// Evaluate computes a goal's progress as of today from the amount saved so far and the
+// recent saving rate per month
+func Evaluate(goal *models.Goal, saved, monthlyRate float64, today time.Time) *models.GoalProgress {
+	remaining := math.Max(goal.TargetAmount-saved, 0)
+	progress := &models.GoalProgress{
+		Saved:       roundCents(saved),
+		Remaining:   roundCents(remaining),
+		MonthlyRate: roundCents(monthlyRate),
+	}
+	if goal.TargetAmount > 0 {
+		progress.PercentComplete = math.Round(math.Min(saved/goal.TargetAmount, 1)*10000) / 100
+	} else {
+		progress.PercentComplete = 100
+	}
+
+	switch {
+	case remaining == 0:
+		progress.ProjectedCompletion = &today
+	case monthlyRate > 0:
+		days := math.Ceil(remaining / monthlyRate * daysPerMonth)
+		completion := today.AddDate(0, 0, int(days))
+		progress.ProjectedCompletion = &completion
+	}
+
+	if goal.TargetDate != nil {
+		// Anything due within a month, or already overdue, is needed this month
+		months := math.Max(goal.TargetDate.Sub(today).Hours()/24/daysPerMonth, 1)
+		required := roundCents(remaining / months)
+		progress.RequiredMonthly = &required
+
+		onTrack := progress.ProjectedCompletion != nil && !progress.ProjectedCompletion.After(*goal.TargetDate)
+		progress.OnTrack = &onTrack
+	}
+	return progress
+}
+
+// RateFromSnapshots measures the monthly change in a linked account's balance between the
+// oldest and newest of its snapshots, which must be sorted oldest first. Histories
+// shorter than two weeks give a rate of 0.
+func RateFromSnapshots(snapshots []*models.BalanceSnapshot) float64 {
+	if len(snapshots) < 2 {
+		return 0
+	}
+	oldest, newest := snapshots[0], snapshots[len(snapshots)-1]
+	days := newest.Date.Sub(oldest.Date).Hours() / 24
+	if days < minRateDays {
+		return 0
+	}
+	return (newest.CurrentBalance - oldest.CurrentBalance) / days * daysPerMonth
+}
+
+// SumContributions totals a goal's logged contributions
+func SumContributions(contributions []*models.GoalContribution) float64 {
+	var total int64
+	for _, contribution := range contributions {
+		total += models.ToMinorUnits(contribution.Amount)
+	}
+	return models.FromMinorUnits(total)
+}
+
+// RateFromContributions measures the monthly rate of contributions logged during the
+// RateWindowDays before today
+func RateFromContributions(contributions []*models.GoalContribution, today time.Time) float64 {
+	since := today.AddDate(0, 0, -RateWindowDays)
+	var total int64
+	for _, contribution := range contributions {
+		if contribution.Date.After(since) && !contribution.Date.After(today) {
+			total += models.ToMinorUnits(contribution.Amount)
+		}
+	}
+	return models.FromMinorUnits(total) / RateWindowDays * daysPerMonth
+}
+
+// roundCents rounds an amount to whole cents
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
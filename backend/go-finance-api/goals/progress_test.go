@@ -0,0 +1,76 @@
+package goals
+
+import (
+	"testing"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEvaluate tests required contributions and projected completion
+func TestEvaluate(t *testing.T) {
+	targetDate := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	goal := models.NewGoal(uuid.New(), "Vacation", 6000, &targetDate, nil)
+	today := time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC)
+
+	progress := Evaluate(goal, 1500, 500, today)
+	assert.Equal(t, 4500.0, progress.Remaining)
+	assert.Equal(t, 25.0, progress.PercentComplete)
+	require.NotNil(t, progress.RequiredMonthly)
+	assert.InDelta(t, 4500/(183/daysPerMonth), *progress.RequiredMonthly, 0.01)
+	require.NotNil(t, progress.ProjectedCompletion)
+	assert.Equal(t, time.Date(2025, 4, 2, 0, 0, 0, 0, time.UTC), *progress.ProjectedCompletion) // 9 average months at 500
+	require.NotNil(t, progress.OnTrack)
+	assert.False(t, *progress.OnTrack)
+
+	// Not saving: no projection
+	progress = Evaluate(goal, 1500, -20, today)
+	assert.Nil(t, progress.ProjectedCompletion)
+	assert.False(t, *progress.OnTrack)
+
+	// Reached, and past the target date
+	progress = Evaluate(goal, 6100, 0, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, 0.0, progress.Remaining)
+	assert.Equal(t, 100.0, progress.PercentComplete)
+	assert.Equal(t, 0.0, *progress.RequiredMonthly)
+
+	// Overdue amounts are needed this month
+	progress = Evaluate(goal, 5000, 0, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, 1000.0, *progress.RequiredMonthly)
+
+	// No target date
+	open := models.NewGoal(uuid.New(), "Emergency fund", 10000, nil, nil)
+	progress = Evaluate(open, 2000, 1000, today)
+	assert.Nil(t, progress.RequiredMonthly)
+	assert.Nil(t, progress.OnTrack)
+	assert.Equal(t, today.AddDate(0, 0, 244), *progress.ProjectedCompletion)
+}
+
+// TestRates tests saving rates from snapshots and contributions
+func TestRates(t *testing.T) {
+	account := &models.Account{ID: uuid.New(), UserID: uuid.New()}
+	snapshot := func(date time.Time, balance float64) *models.BalanceSnapshot {
+		return models.NewBalanceSnapshot(account, date, balance, models.SnapshotSourceDaily)
+	}
+
+	assert.Equal(t, 0.0, RateFromSnapshots([]*models.BalanceSnapshot{snapshot(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 100)}))
+	assert.Equal(t, 0.0, RateFromSnapshots([]*models.BalanceSnapshot{
+		snapshot(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 100), snapshot(time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC), 300),
+	}))
+	assert.InDelta(t, 2000/60.0*daysPerMonth, RateFromSnapshots([]*models.BalanceSnapshot{
+		snapshot(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 1000), snapshot(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), 1500), snapshot(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 3000),
+	}), 0.0001)
+
+	goalID := uuid.New()
+	contributions := []*models.GoalContribution{
+		models.NewGoalContribution(goalID, time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), 5000, "Opening deposit"),
+		models.NewGoalContribution(goalID, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), 300, ""),
+		models.NewGoalContribution(goalID, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 300, ""),
+		models.NewGoalContribution(goalID, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), -100, "Withdrawal"),
+	}
+	assert.Equal(t, 5500.0, SumContributions(contributions))
+	assert.InDelta(t, 500/90.0*daysPerMonth, RateFromContributions(contributions, time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)), 0.0001)
+}
@@ -0,0 +1,48 @@
+package goals
+
+import (
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+)
+
+// Service computes goal progress from stored balances and contributions
+type Service struct {
+	repos *db.Repositories
+}
+
+// NewService creates a new Service
+func NewService(repos *db.Repositories) *Service {
+	return &Service{repos: repos}
+}
+
+// Progress computes a goal's progress as of today. A goal linked to an account uses the
+// account's balance snapshots; other goals use their contribution log.
+func (s *Service) Progress(goal *models.Goal) (*models.GoalProgress, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	if goal.AccountID != nil {
+		account, err := s.repos.Account.GetByID(*goal.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		if account != nil {
+			snapshots, err := s.repos.BalanceSnapshot.GetByAccountID(account.ID, today.AddDate(0, 0, -RateWindowDays), today)
+			if err != nil {
+				return nil, err
+			}
+			saved := account.CurrentBalance
+			if len(snapshots) > 0 {
+				saved = snapshots[len(snapshots)-1].CurrentBalance
+			}
+			return Evaluate(goal, saved, RateFromSnapshots(snapshots), today), nil
+		}
+	}
+
+	contributions, err := s.repos.Goal.GetContributions(goal.ID)
+	if err != nil {
+		return nil, err
+	}
+	return Evaluate(goal, SumContributions(contributions), RateFromContributions(contributions, today), today), nil
+}
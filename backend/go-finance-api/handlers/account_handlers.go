@@ -0,0 +1,431 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/blobstore"
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/gin-gonic/gin"
+)
+
+// AccountHandler handles the user's accounts, including manual accounts that aren't
+// linked through Plaid and their transactions
+type AccountHandler struct {
+	accountRepo     *db.AccountRepository
+	transactionRepo *db.TransactionRepository
+	attachmentRepo  *db.AttachmentRepository
+	blobStore       blobstore.BlobStore
+}
+
+// NewAccountHandler creates a new AccountHandler
+func NewAccountHandler(
+	accountRepo *db.AccountRepository,
+	transactionRepo *db.TransactionRepository,
+	attachmentRepo *db.AttachmentRepository,
+	blobStore blobstore.BlobStore,
+) *AccountHandler {
+	return &AccountHandler{
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		attachmentRepo:  attachmentRepo,
+		blobStore:       blobStore,
+	}
+}
+
+// ManualAccountRequest is the request body for creating or updating a manual account
+type ManualAccountRequest struct {
+	Name           string   `json:"name" binding:"required,max=255"`
+	Type           string   `json:"type" binding:"required,oneof=depository credit loan investment other"`
+	Subtype        string   `json:"subtype" binding:"max=50"`
+	CurrencyCode   string   `json:"currency_code" binding:"omitempty,len=3"`
+	BalanceMode    string   `json:"balance_mode" binding:"required,oneof=manual computed"`
+	CurrentBalance float64  `json:"current_balance"`  // Ignored for computed balances
+	PaymentDueDate string   `json:"payment_due_date"` // Optional, YYYY-MM-DD; credit cards and loans
+	MinimumPayment *float64 `json:"minimum_payment"`
+}
+
+// ManualTransactionRequest is the request body for creating or updating a transaction in
+// a manual account
+type ManualTransactionRequest struct {
+	Name         string  `json:"name" binding:"required,max=255"`
+	MerchantName string  `json:"merchant_name" binding:"max=255"`
+	Amount       float64 `json:"amount"` // Plaid sign convention (positive = outflow)
+	CurrencyCode string  `json:"currency_code" binding:"omitempty,len=3"`
+	Date         string  `json:"date" binding:"required"` // YYYY-MM-DD
+	Category     string  `json:"category" binding:"max=255"`
+	Notes        string  `json:"notes"`
+	Pending      bool    `json:"pending"`
+}
+
+// ListAccounts returns all of the user's accounts, Plaid and manual
+func (h *AccountHandler) ListAccounts(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	accounts, err := h.accountRepo.GetByUserID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"accounts": accounts})
+}
+
+// GetAccount returns a single account
+func (h *AccountHandler) GetAccount(c *gin.Context) {
+	account, ok := h.loadAccount(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// CreateAccount creates a manual account
+func (h *AccountHandler) CreateAccount(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req ManualAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account := models.NewManualAccount(
+		userID,
+		req.Name,
+		req.Type,
+		req.Subtype,
+		currencyOr(req.CurrencyCode, "USD"),
+		req.BalanceMode,
+		req.CurrentBalance,
+	)
+	if !setPaymentDue(c, account, &req) {
+		return
+	}
+	if err := h.accountRepo.Create(account); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create account"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
+// UpdateAccount updates a manual account
+func (h *AccountHandler) UpdateAccount(c *gin.Context) {
+	account, ok := h.loadManualAccount(c)
+	if !ok {
+		return
+	}
+
+	var req ManualAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account.Name = req.Name
+	account.Type = req.Type
+	account.Subtype = req.Subtype
+	account.CurrencyCode = currencyOr(req.CurrencyCode, "USD")
+	account.BalanceMode = req.BalanceMode
+	if req.BalanceMode == models.BalanceModeManual {
+		account.AvailableBalance = req.CurrentBalance
+		account.CurrentBalance = req.CurrentBalance
+	}
+	if !setPaymentDue(c, account, &req) {
+		return
+	}
+	if err := h.accountRepo.Update(account); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account"})
+		return
+	}
+
+	h.respondWithAccount(c, account)
+}
+
+// DeleteAccount deletes a manual account and all of its transactions, along with their
+// attachment files
+func (h *AccountHandler) DeleteAccount(c *gin.Context) {
+	account, ok := h.loadManualAccount(c)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.attachmentRepo.GetByAccountID(account.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+	if err := h.accountRepo.Delete(account.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+	h.deleteAttachmentFiles(c, attachments)
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListAccountTransactions returns an account's transactions, newest first
+func (h *AccountHandler) ListAccountTransactions(c *gin.Context) {
+	account, ok := h.loadAccount(c)
+	if !ok {
+		return
+	}
+	limit, offset, ok := queryPagination(c, 100, 500)
+	if !ok {
+		return
+	}
+
+	transactions, err := h.transactionRepo.GetByAccountID(account.ID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transactions": transactions})
+}
+
+// CreateAccountTransaction adds a transaction to a manual account
+func (h *AccountHandler) CreateAccountTransaction(c *gin.Context) {
+	account, ok := h.loadManualAccount(c)
+	if !ok {
+		return
+	}
+
+	var req ManualTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		return
+	}
+
+	transaction := models.NewTransaction(
+		account.ID,
+		account.UserID,
+		"",
+		"",
+		nil,
+		req.Name,
+		req.MerchantName,
+		req.Amount,
+		currencyOr(req.CurrencyCode, account.CurrencyCode),
+		date,
+		req.Pending,
+		"other",
+	)
+	transaction.UserCategory = req.Category
+	transaction.Notes = req.Notes
+	if err := h.transactionRepo.Create(transaction); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaction"})
+		return
+	}
+	if !h.recomputeBalance(c, account) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, transaction)
+}
+
+// UpdateAccountTransaction updates a transaction in a manual account
+func (h *AccountHandler) UpdateAccountTransaction(c *gin.Context) {
+	account, transaction, ok := h.loadManualTransaction(c)
+	if !ok {
+		return
+	}
+
+	var req ManualTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		return
+	}
+
+	transaction.Name = req.Name
+	transaction.MerchantName = req.MerchantName
+	transaction.Amount = req.Amount
+	transaction.IsoCurrencyCode = currencyOr(req.CurrencyCode, account.CurrencyCode)
+	transaction.Date = date
+	transaction.UserCategory = req.Category
+	transaction.Notes = req.Notes
+	transaction.Pending = req.Pending
+	if err := h.transactionRepo.UpdateDetails(transaction); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
+		return
+	}
+	if !h.recomputeBalance(c, account) {
+		return
+	}
+
+	c.JSON(http.StatusOK, transaction)
+}
+
+// DeleteAccountTransaction deletes a transaction from a manual account, along with its
+// attachment files
+func (h *AccountHandler) DeleteAccountTransaction(c *gin.Context) {
+	account, transaction, ok := h.loadManualTransaction(c)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.attachmentRepo.GetByTransactionID(transaction.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete transaction"})
+		return
+	}
+	if err := h.transactionRepo.Delete(transaction.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete transaction"})
+		return
+	}
+	h.deleteAttachmentFiles(c, attachments)
+	if !h.recomputeBalance(c, account) {
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// deleteAttachmentFiles deletes the stored files of attachments whose rows are already
+// gone. The deletion has happened by then, so failures are only logged.
+func (h *AccountHandler) deleteAttachmentFiles(c *gin.Context, attachments []*models.Attachment) {
+	for _, attachment := range attachments {
+		if err := h.blobStore.Delete(c.Request.Context(), attachment.StorageKey); err != nil {
+			log.Printf("Failed to delete attachment file %s: %v", attachment.StorageKey, err)
+		}
+	}
+}
+
+// respondWithAccount recomputes a computed balance and writes the refreshed account
+func (h *AccountHandler) respondWithAccount(c *gin.Context, account *models.Account) {
+	if !h.recomputeBalance(c, account) {
+		return
+	}
+	refreshed, err := h.accountRepo.GetByID(account.ID)
+	if err != nil || refreshed == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch account"})
+		return
+	}
+	c.JSON(http.StatusOK, refreshed)
+}
+
+// recomputeBalance refreshes the balance of an account in computed mode after its
+// transactions change. On failure an error response is written and false is returned.
+func (h *AccountHandler) recomputeBalance(c *gin.Context, account *models.Account) bool {
+	if account.BalanceMode != models.BalanceModeComputed {
+		return true
+	}
+	if err := h.accountRepo.RecomputeBalance(account.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account balance"})
+		return false
+	}
+	return true
+}
+
+// loadAccount fetches the account named by the :id path parameter; see loadUserAccount
+func (h *AccountHandler) loadAccount(c *gin.Context) (*models.Account, bool) {
+	return loadUserAccount(c, h.accountRepo)
+}
+
+// loadManualAccount is loadAccount for requests that only apply to manual accounts;
+// Plaid accounts are managed by sync
+func (h *AccountHandler) loadManualAccount(c *gin.Context) (*models.Account, bool) {
+	account, ok := h.loadAccount(c)
+	if !ok {
+		return nil, false
+	}
+	if !account.IsManual() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Plaid accounts can't be edited"})
+		return nil, false
+	}
+	return account, true
+}
+
+// loadManualTransaction fetches the manual account named by :id and its transaction named
+// by :transactionId. On failure an error response is written and ok is false.
+func (h *AccountHandler) loadManualTransaction(c *gin.Context) (*models.Account, *models.Transaction, bool) {
+	account, ok := h.loadManualAccount(c)
+	if !ok {
+		return nil, nil, false
+	}
+	id, ok := pathUUID(c, "transactionId")
+	if !ok {
+		return nil, nil, false
+	}
+
+	transaction, err := h.transactionRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transaction"})
+		return nil, nil, false
+	}
+	if transaction == nil || transaction.AccountID != account.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return nil, nil, false
+	}
+	return account, transaction, true
+}
+
+// setPaymentDue copies the payment due date and minimum payment of a manual account
+// request onto the account. If the date is malformed, a 400 response is written and
+// false is returned.
+func setPaymentDue(c *gin.Context, account *models.Account, req *ManualAccountRequest) bool {
+	account.PaymentDueDate = nil
+	if req.PaymentDueDate != "" {
+		dueDate, err := time.Parse("2006-01-02", req.PaymentDueDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment_due_date format. Use YYYY-MM-DD"})
+			return false
+		}
+		account.PaymentDueDate = &dueDate
+	}
+	account.MinimumPayment = req.MinimumPayment
+	return true
+}
+
+// currencyOr returns code, or fallback if it is empty
+func currencyOr(code, fallback string) string {
+	if code == "" {
+		return fallback
+	}
+	return code
+}
+
+// loadUserAccount fetches the account named by the :id path parameter and checks that it
+// belongs to the authenticated user. On failure an error response is written and ok is
+// false.
+func loadUserAccount(c *gin.Context, accountRepo *db.AccountRepository) (*models.Account, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return nil, false
+	}
+	id, ok := pathUUID(c, "id")
+	if !ok {
+		return nil, false
+	}
+
+	account, err := accountRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch account"})
+		return nil, false
+	}
+	if account == nil || account.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return nil, false
+	}
+	return account, true
+}
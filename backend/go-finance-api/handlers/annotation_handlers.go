@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/blobstore"
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/gin-gonic/gin"
+)
+
+// maxAttachmentSize is the largest file accepted as a transaction attachment
+const maxAttachmentSize = 10 << 20 // 10 MB
+
+// AnnotationHandler handles user annotations on transactions: notes, tags and attachments
+type AnnotationHandler struct {
+	transactionRepo *db.TransactionRepository
+	tagRepo         *db.TagRepository
+	attachmentRepo  *db.AttachmentRepository
+	blobStore       blobstore.BlobStore
+}
+
+// NewAnnotationHandler creates a new AnnotationHandler
+func NewAnnotationHandler(
+	transactionRepo *db.TransactionRepository,
+	tagRepo *db.TagRepository,
+	attachmentRepo *db.AttachmentRepository,
+	blobStore blobstore.BlobStore,
+) *AnnotationHandler {
+	return &AnnotationHandler{
+		transactionRepo: transactionRepo,
+		tagRepo:         tagRepo,
+		attachmentRepo:  attachmentRepo,
+		blobStore:       blobStore,
+	}
+}
+
+// UpdateNotesRequest is the request body for setting transaction notes
+type UpdateNotesRequest struct {
+	Notes string `json:"notes"`
+}
+
+// AddTagRequest is the request body for tagging a transaction
+type AddTagRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// UpdateNotes sets the markdown notes of a transaction
+func (h *AnnotationHandler) UpdateNotes(c *gin.Context) {
+	transaction, ok := loadUserTransaction(c, h.transactionRepo)
+	if !ok {
+		return
+	}
+
+	var req UpdateNotesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.transactionRepo.UpdateNotes(transaction.ID, req.Notes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save notes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notes": req.Notes})
+}
+
+// ListTags returns all of the user's tags
+func (h *AnnotationHandler) ListTags(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	tags, err := h.tagRepo.GetByUserID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// DeleteTag deletes one of the user's tags and removes it from every transaction
+func (h *AnnotationHandler) DeleteTag(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	tag, err := h.tagRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tag"})
+		return
+	}
+	if tag == nil || tag.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		return
+	}
+
+	if err := h.tagRepo.Delete(tag.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tag"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetTransactionTags returns the tags on a transaction
+func (h *AnnotationHandler) GetTransactionTags(c *gin.Context) {
+	transaction, ok := loadUserTransaction(c, h.transactionRepo)
+	if !ok {
+		return
+	}
+
+	tags, err := h.tagRepo.GetByTransactionID(transaction.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// AddTransactionTag tags a transaction, creating the tag if it doesn't exist yet
+func (h *AnnotationHandler) AddTransactionTag(c *gin.Context) {
+	transaction, ok := loadUserTransaction(c, h.transactionRepo)
+	if !ok {
+		return
+	}
+
+	var req AddTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.tagRepo.AddToTransaction(transaction.UserID, transaction.ID, req.Name); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add tag"})
+		return
+	}
+
+	h.GetTransactionTags(c)
+}
+
+// RemoveTransactionTag removes a tag from a transaction
+func (h *AnnotationHandler) RemoveTransactionTag(c *gin.Context) {
+	transaction, ok := loadUserTransaction(c, h.transactionRepo)
+	if !ok {
+		return
+	}
+
+	if err := h.tagRepo.RemoveFromTransaction(transaction.UserID, transaction.ID, c.Param("name")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove tag"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListAttachments returns the attachments on a transaction
+func (h *AnnotationHandler) ListAttachments(c *gin.Context) {
+	transaction, ok := loadUserTransaction(c, h.transactionRepo)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.attachmentRepo.GetByTransactionID(transaction.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attachments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attachments": attachments})
+}
+
+// UploadAttachment stores a file from the multipart "file" field and attaches it to a transaction
+func (h *AnnotationHandler) UploadAttachment(c *gin.Context) {
+	transaction, ok := loadUserTransaction(c, h.transactionRepo)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAttachmentSize+1<<20)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	if fileHeader.Size > maxAttachmentSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File exceeds the 10 MB limit"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	contentType := fileHeader.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	attachment := models.NewAttachment(
+		transaction.ID,
+		transaction.UserID,
+		filepath.Base(fileHeader.Filename),
+		contentType,
+		fileHeader.Size,
+	)
+
+	if err := h.blobStore.Put(c.Request.Context(), attachment.StorageKey, file, attachment.SizeBytes, contentType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+	if err := h.attachmentRepo.Create(attachment); err != nil {
+		// Don't leave an orphaned blob behind
+		h.blobStore.Delete(c.Request.Context(), attachment.StorageKey)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save attachment"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// DownloadAttachment streams an attachment's contents
+func (h *AnnotationHandler) DownloadAttachment(c *gin.Context) {
+	attachment, ok := h.loadAttachment(c)
+	if !ok {
+		return
+	}
+
+	r, err := h.blobStore.Get(c.Request.Context(), attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment file not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read attachment"})
+		return
+	}
+	defer r.Close()
+
+	c.Header("Content-Disposition", "attachment; filename="+strconv.Quote(attachment.FileName))
+	c.DataFromReader(http.StatusOK, attachment.SizeBytes, attachment.ContentType, r, nil)
+}
+
+// DeleteAttachment removes an attachment and its stored file
+func (h *AnnotationHandler) DeleteAttachment(c *gin.Context) {
+	attachment, ok := h.loadAttachment(c)
+	if !ok {
+		return
+	}
+
+	if err := h.attachmentRepo.Delete(attachment.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachment"})
+		return
+	}
+	if err := h.blobStore.Delete(c.Request.Context(), attachment.StorageKey); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachment file"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// loadAttachment fetches the attachment named by the :attachmentId path parameter and
+// checks that it belongs to the transaction named by :id, which must belong to the
+// authenticated user. On failure an error response is written and ok is false.
+func (h *AnnotationHandler) loadAttachment(c *gin.Context) (*models.Attachment, bool) {
+	transaction, ok := loadUserTransaction(c, h.transactionRepo)
+	if !ok {
+		return nil, false
+	}
+	id, ok := pathUUID(c, "attachmentId")
+	if !ok {
+		return nil, false
+	}
+
+	attachment, err := h.attachmentRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attachment"})
+		return nil, false
+	}
+	if attachment == nil || attachment.TransactionID != transaction.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return nil, false
+	}
+	return attachment, true
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/anomalies"
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AnomalyHandler handles requests for transactions flagged as unusual
+type AnomalyHandler struct {
+	repos    *db.Repositories
+	detector *anomalies.Detector
+}
+
+// NewAnomalyHandler creates a new AnomalyHandler
+func NewAnomalyHandler(repos *db.Repositories) *AnomalyHandler {
+	return &AnomalyHandler{repos: repos, detector: anomalies.NewDetector(repos)}
+}
+
+// anomalyStatuses are the statuses anomalies can be listed by
+var anomalyStatuses = map[string]bool{
+	models.AnomalyStatusOpen:      true,
+	models.AnomalyStatusDismissed: true,
+	models.AnomalyStatusFraud:     true,
+}
+
+// ListAnomalies returns the user's flagged transactions with a status, open by default,
+// highest score first
+func (h *AnomalyHandler) ListAnomalies(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	status := c.DefaultQuery("status", models.AnomalyStatusOpen)
+	if !anomalyStatuses[status] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be open, dismissed or fraud"})
+		return
+	}
+	limit, offset, ok := queryPagination(c, 50, 200)
+	if !ok {
+		return
+	}
+
+	flagged, err := h.repos.Anomaly.GetByStatus(userID, status, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch anomalies"})
+		return
+	}
+	ids := make([]uuid.UUID, len(flagged))
+	for i, anomaly := range flagged {
+		ids[i] = anomaly.TransactionID
+	}
+	transactions, err := h.repos.Transaction.GetByIDs(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
+	}
+	for _, anomaly := range flagged {
+		anomaly.Transaction = transactions[anomaly.TransactionID]
+	}
+
+	c.JSON(http.StatusOK, gin.H{"anomalies": flagged})
+}
+
+// DetectAnomalies scores the user's recent transactions and returns the ones flagged
+func (h *AnomalyHandler) DetectAnomalies(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	flagged, err := h.detector.DetectForUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to detect anomalies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"anomalies": flagged})
+}
+
+// DismissAnomaly marks a flagged transaction as recognized by the user
+func (h *AnomalyHandler) DismissAnomaly(c *gin.Context) {
+	h.resolve(c, models.AnomalyStatusDismissed)
+}
+
+// ReportFraud marks a flagged transaction as fraud. It is left out of the norms that
+// later transactions are scored against.
+func (h *AnomalyHandler) ReportFraud(c *gin.Context) {
+	h.resolve(c, models.AnomalyStatusFraud)
+}
+
+// resolve sets the status of the anomaly on the transaction named by the :transactionId
+// path parameter, after checking that it belongs to the authenticated user
+func (h *AnomalyHandler) resolve(c *gin.Context, status string) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	transactionID, ok := pathUUID(c, "transactionId")
+	if !ok {
+		return
+	}
+
+	anomaly, err := h.repos.Anomaly.GetByTransactionID(transactionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch anomaly"})
+		return
+	}
+	if anomaly == nil || anomaly.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Anomaly not found"})
+		return
+	}
+
+	if err := h.repos.Anomaly.UpdateStatus(anomaly, status); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update anomaly"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"anomaly": anomaly})
+}
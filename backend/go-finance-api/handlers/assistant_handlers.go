@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/assistant"
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/llm"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxChatMessageLength bounds the length of a chat message in characters
+const maxChatMessageLength = 4000
+
+// AssistantHandler handles conversations with the finance assistant
+type AssistantHandler struct {
+	conversationRepo *db.ConversationRepository
+	meter            *llm.Meter // nil when no provider is configured
+	assistant        *assistant.Assistant
+}
+
+// NewAssistantHandler creates a new AssistantHandler
+func NewAssistantHandler(repos *db.Repositories, meter *llm.Meter) *AssistantHandler {
+	return &AssistantHandler{
+		conversationRepo: repos.Conversation,
+		meter:            meter,
+		assistant:        assistant.New(repos, meter),
+	}
+}
+
+// ChatRequest is the body of a chat message. Without a conversation ID a new
+// conversation is started.
+type ChatRequest struct {
+	ConversationID *uuid.UUID `json:"conversation_id"`
+	Message        string     `json:"message" binding:"required"`
+}
+
+// Chat answers a message, streaming the reply as server-sent events: conversation, then
+// delta and tool_call events as the reply is generated, then done or error
+func (h *AssistantHandler) Chat(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	if h.meter == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "The assistant is not configured"})
+		return
+	}
+
+	var req ChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len([]rune(req.Message)) > maxChatMessageLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Message must be at most 4000 characters"})
+		return
+	}
+
+	if err := h.meter.Check(userID); err != nil {
+		if errors.Is(err, llm.ErrCostLimitExceeded) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Monthly assistant usage limit reached"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check usage"})
+		return
+	}
+
+	var conversation *models.Conversation
+	if req.ConversationID != nil {
+		var err error
+		conversation, err = h.conversationRepo.GetByID(*req.ConversationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch conversation"})
+			return
+		}
+		if conversation == nil || conversation.UserID != userID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+			return
+		}
+	} else {
+		conversation = models.NewConversation(userID, assistant.Title(req.Message))
+		if err := h.conversationRepo.Create(conversation); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create conversation"})
+			return
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	emit := func(event string, data interface{}) error {
+		c.SSEvent(event, data)
+		c.Writer.Flush()
+		return c.Request.Context().Err()
+	}
+	if err := emit(assistant.EventConversation, gin.H{"id": conversation.ID, "title": conversation.Title}); err != nil {
+		return
+	}
+
+	if err := h.assistant.Reply(c.Request.Context(), conversation, req.Message, emit); err != nil {
+		log.Printf("Assistant reply failed for conversation %s: %v", conversation.ID, err)
+		message := "Failed to generate a reply"
+		if errors.Is(err, llm.ErrCostLimitExceeded) {
+			message = "Monthly assistant usage limit reached"
+		}
+		emit(assistant.EventError, gin.H{"error": message})
+	}
+}
+
+// ListConversations lists the user's conversations, most recently active first
+func (h *AssistantHandler) ListConversations(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	limit, offset, ok := queryPagination(c, 50, 200)
+	if !ok {
+		return
+	}
+
+	conversations, err := h.conversationRepo.GetByUserID(userID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve conversations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, conversations)
+}
+
+// GetConversation returns a conversation with its messages
+func (h *AssistantHandler) GetConversation(c *gin.Context) {
+	conversation, ok := h.loadConversation(c)
+	if !ok {
+		return
+	}
+
+	messages, err := h.conversationRepo.GetMessages(conversation.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve messages"})
+		return
+	}
+	conversation.Messages = messages
+
+	c.JSON(http.StatusOK, conversation)
+}
+
+// DeleteConversation deletes a conversation and its messages
+func (h *AssistantHandler) DeleteConversation(c *gin.Context) {
+	conversation, ok := h.loadConversation(c)
+	if !ok {
+		return
+	}
+
+	if err := h.conversationRepo.Delete(conversation.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete conversation"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// loadConversation fetches the conversation in the :id path parameter. It writes a 404
+// response if the conversation does not exist or belongs to another user.
+func (h *AssistantHandler) loadConversation(c *gin.Context) (*models.Conversation, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return nil, false
+	}
+	id, ok := pathUUID(c, "id")
+	if !ok {
+		return nil, false
+	}
+
+	conversation, err := h.conversationRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch conversation"})
+		return nil, false
+	}
+	if conversation == nil || conversation.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+		return nil, false
+	}
+	return conversation, true
+}
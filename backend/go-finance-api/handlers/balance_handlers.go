@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/balances"
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/gin-gonic/gin"
+)
+
+// maxBackfillDays bounds the days parameter of BackfillBalances
+const maxBackfillDays = 3650
+
+// BalanceHandler handles account balance history
+type BalanceHandler struct {
+	accountRepo  *db.AccountRepository
+	snapshotRepo *db.BalanceSnapshotRepository
+	backfiller   *balances.Backfiller
+}
+
+// NewBalanceHandler creates a new BalanceHandler
+func NewBalanceHandler(repos *db.Repositories) *BalanceHandler {
+	return &BalanceHandler{
+		accountRepo:  repos.Account,
+		snapshotRepo: repos.BalanceSnapshot,
+		backfiller:   balances.NewBackfiller(repos),
+	}
+}
+
+// GetBalances returns an account's daily balance snapshots for the from/to date range,
+// defaulting to the last 90 days
+func (h *BalanceHandler) GetBalances(c *gin.Context) {
+	account, ok := loadUserAccount(c, h.accountRepo)
+	if !ok {
+		return
+	}
+	from, to, ok := queryDateRange(c, 90)
+	if !ok {
+		return
+	}
+
+	snapshots, err := h.snapshotRepo.GetByAccountID(account.ID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch balances"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"balances": snapshots})
+}
+
+// BackfillBalances reconstructs an account's missing daily balances from its transactions.
+// The optional days query parameter sets how far back to go, defaulting to a year.
+func (h *BalanceHandler) BackfillBalances(c *gin.Context) {
+	account, ok := loadUserAccount(c, h.accountRepo)
+	if !ok {
+		return
+	}
+
+	days := 365
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxBackfillDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 3650"})
+			return
+		}
+		days = parsed
+	}
+
+	created, err := h.backfiller.BackfillAccount(account, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to backfill balances"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"created": created})
+}
+
+// GetNetWorth returns the user's daily net worth for the from/to date range, defaulting
+// to the last 365 days, with a breakdown by account type. It covers the accounts held in
+// the currency query parameter, defaulting to the user's primary currency; accounts in
+// other currencies are listed as excluded.
+func (h *BalanceHandler) GetNetWorth(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	from, to, ok := queryDateRange(c, 365)
+	if !ok {
+		return
+	}
+
+	accounts, err := h.accountRepo.GetByUserID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
+		return
+	}
+	snapshots, err := h.snapshotRepo.GetByUserIDAsOf(userID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch balances"})
+		return
+	}
+
+	currency := strings.ToUpper(c.Query("currency"))
+	if currency == "" {
+		currency = balances.PrimaryCurrency(accounts)
+	}
+	matching, excluded := balances.InCurrency(accounts, currency)
+	if excluded == nil {
+		excluded = []*models.Account{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"currency":          currency,
+		"net_worth":         balances.NetWorth(matching, snapshots, from, to),
+		"excluded_accounts": excluded,
+	})
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/bills"
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxBillDays bounds the days parameter of ListBills
+	maxBillDays = 365
+
+	// calendarFeedDays is how far ahead the calendar feed lists bills
+	calendarFeedDays = 365
+)
+
+// calendarFeedStore stores calendar feed tokens, as db.CalendarFeedRepository does
+type calendarFeedStore interface {
+	Upsert(feed *models.CalendarFeed) error
+	GetByTokenHash(tokenHash string) (*models.CalendarFeed, error)
+	Delete(userID uuid.UUID) error
+}
+
+// billLister lists a user's upcoming bills, as bills.Service does
+type billLister interface {
+	Upcoming(userID uuid.UUID, from, to time.Time) ([]*models.Bill, error)
+}
+
+// BillHandler handles upcoming bills and the private calendar feed that publishes them
+type BillHandler struct {
+	feedRepo calendarFeedStore
+	service  billLister
+}
+
+// NewBillHandler creates a new BillHandler
+func NewBillHandler(repos *db.Repositories) *BillHandler {
+	return &BillHandler{
+		feedRepo: repos.CalendarFeed,
+		service:  bills.NewService(repos),
+	}
+}
+
+// ListBills returns the user's bills expected over the next 30 days, or the number of
+// days in the optional days query parameter
+func (h *BillHandler) ListBills(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	days := 30
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxBillDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 365"})
+			return
+		}
+		days = parsed
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	upcoming, err := h.service.Upcoming(userID, today, today.AddDate(0, 0, days))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bills"})
+		return
+	}
+	if upcoming == nil {
+		upcoming = []*models.Bill{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"bills": upcoming})
+}
+
+// CreateCalendarFeed issues a new private calendar feed URL for the user's bills. Any
+// earlier URL stops working. The token is only returned here.
+func (h *BillHandler) CreateCalendarFeed(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	token, tokenHash, err := bills.NewFeedToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate feed token"})
+		return
+	}
+	feed := &models.CalendarFeed{UserID: userID, TokenHash: tokenHash, CreatedAt: time.Now().UTC()}
+	if err := h.feedRepo.Upsert(feed); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save calendar feed"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token": token,
+		"path":  "/api/calendar/" + token + ".ics",
+	})
+}
+
+// DeleteCalendarFeed disables the user's calendar feed URL
+func (h *BillHandler) DeleteCalendarFeed(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.feedRepo.Delete(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete calendar feed"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CalendarFeed serves a user's upcoming bills as an iCalendar feed at
+// /api/calendar/:token, where the token may end in .ics. It is authenticated by the
+// token in the URL rather than a session, so calendar apps can subscribe to it.
+func (h *BillHandler) CalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	feed, err := h.feedRepo.GetByTokenHash(bills.HashFeedToken(token))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch calendar feed"})
+		return
+	}
+	if feed == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Calendar feed not found"})
+		return
+	}
+
+	now := time.Now().UTC()
+	today := now.Truncate(24 * time.Hour)
+	upcoming, err := h.service.Upcoming(feed.UserID, today, today.AddDate(0, 0, calendarFeedDays))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bills"})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := bills.WriteICS(&buf, "Bills", upcoming, now); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render calendar"})
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", buf.Bytes())
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFeedStore keeps calendar feeds in memory
+type fakeFeedStore struct {
+	feeds map[uuid.UUID]*models.CalendarFeed
+}
+
+func (s *fakeFeedStore) Upsert(feed *models.CalendarFeed) error {
+	s.feeds[feed.UserID] = feed
+	return nil
+}
+
+func (s *fakeFeedStore) GetByTokenHash(tokenHash string) (*models.CalendarFeed, error) {
+	for _, feed := range s.feeds {
+		if feed.TokenHash == tokenHash {
+			return feed, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *fakeFeedStore) Delete(userID uuid.UUID) error {
+	delete(s.feeds, userID)
+	return nil
+}
+
+// fakeBills returns the same bills for every user
+type fakeBills []*models.Bill
+
+func (b fakeBills) Upcoming(userID uuid.UUID, from, to time.Time) ([]*models.Bill, error) {
+	return b, nil
+}
+
+// TestCalendarFeedRoundTrip tests that the path handed out for a new feed serves it
+func TestCalendarFeedRoundTrip(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userID := uuid.New()
+	store := &fakeFeedStore{feeds: make(map[uuid.UUID]*models.CalendarFeed)}
+	handler := &BillHandler{feedRepo: store, service: fakeBills{{
+		Date:         time.Now().UTC().AddDate(0, 0, 3),
+		Name:         "Netflix",
+		Amount:       15.49,
+		CurrencyCode: "USD",
+		Kind:         models.BillKindRecurring,
+	}}}
+
+	// Routed as in main.go: the feed is public, creating one requires a session
+	router := gin.New()
+	api := router.Group("/api")
+	api.GET("/calendar/:token", handler.CalendarFeed)
+	protected := api.Group("", func(c *gin.Context) { c.Set("userID", userID) })
+	protected.POST("/bills/calendar", handler.CreateCalendarFeed)
+	protected.DELETE("/bills/calendar", handler.DeleteCalendarFeed)
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/api/bills/calendar", nil))
+	require.Equal(t, http.StatusCreated, resp.Code)
+	var created struct {
+		Token string `json:"token"`
+		Path  string `json:"path"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+	require.True(t, strings.HasSuffix(created.Path, ".ics"))
+
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, created.Path, nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Header().Get("Content-Type"), "text/calendar")
+	assert.Contains(t, resp.Body.String(), "BEGIN:VCALENDAR")
+	assert.Contains(t, resp.Body.String(), "Netflix")
+
+	// Unknown tokens and deleted feeds are not found
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/api/calendar/not-a-token.ics", nil))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodDelete, "/api/bills/calendar", nil))
+	require.Equal(t, http.StatusNoContent, resp.Code)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, created.Path, nil))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
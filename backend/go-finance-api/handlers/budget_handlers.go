@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/budgets"
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/gin-gonic/gin"
+)
+
+// BudgetHandler handles monthly category budgets
+type BudgetHandler struct {
+	budgetRepo *db.BudgetRepository
+	service    *budgets.Service
+}
+
+// NewBudgetHandler creates a new BudgetHandler
+func NewBudgetHandler(repos *db.Repositories) *BudgetHandler {
+	return &BudgetHandler{
+		budgetRepo: repos.Budget,
+		service:    budgets.NewService(repos),
+	}
+}
+
+// BudgetRequest is the request body for setting a category's budget for a month
+type BudgetRequest struct {
+	Category string   `json:"category" binding:"required"`
+	Amount   *float64 `json:"amount" binding:"required"`
+	Rollover bool     `json:"rollover"`
+}
+
+// GetBudgets returns budgeted, spent and remaining amounts per category for a month
+func (h *BudgetHandler) GetBudgets(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	month, ok := pathMonth(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.Report(userID, month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch budgets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// SetBudget creates or replaces a category's budget for a month
+func (h *BudgetHandler) SetBudget(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	month, ok := pathMonth(c)
+	if !ok {
+		return
+	}
+
+	var req BudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	category := strings.TrimSpace(req.Category)
+	if category == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category is required"})
+		return
+	}
+	if *req.Amount < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must not be negative"})
+		return
+	}
+
+	budget := models.NewBudget(userID, month, category, *req.Amount, req.Rollover)
+	if err := h.budgetRepo.Upsert(budget); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save budget"})
+		return
+	}
+
+	c.JSON(http.StatusOK, budget)
+}
+
+// DeleteBudget removes the budget for the category query parameter from a month
+func (h *BudgetHandler) DeleteBudget(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	month, ok := pathMonth(c)
+	if !ok {
+		return
+	}
+
+	budget, err := h.budgetRepo.GetByUserMonthAndCategory(userID, month, c.Query("category"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch budget"})
+		return
+	}
+	if budget == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
+		return
+	}
+
+	if err := h.budgetRepo.Delete(budget.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete budget"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CopyBudgets copies the previous month's budgets into a month, keeping any categories
+// already budgeted there
+func (h *BudgetHandler) CopyBudgets(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	month, ok := pathMonth(c)
+	if !ok {
+		return
+	}
+
+	created, err := h.budgetRepo.CopyForward(userID, month.AddDate(0, -1, 0), month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to copy budgets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"created": created})
+}
+
+// pathMonth parses the YYYY-MM month path parameter. If it is malformed, a 400 response
+// is written and ok is false.
+func pathMonth(c *gin.Context) (month time.Time, ok bool) {
+	month, err := budgets.ParseMonth(c.Param("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return time.Time{}, false
+	}
+	return month, true
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/categorizer"
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CategorizerHandler handles requests for category overrides, the user's category
+// classifier and its review queue
+type CategorizerHandler struct {
+	repos       *db.Repositories
+	categorizer *categorizer.Categorizer
+}
+
+// NewCategorizerHandler creates a new CategorizerHandler
+func NewCategorizerHandler(repos *db.Repositories) *CategorizerHandler {
+	return &CategorizerHandler{repos: repos, categorizer: categorizer.New(repos)}
+}
+
+// SetCategoryRequest is the request body for overriding a transaction's category
+type SetCategoryRequest struct {
+	Category string `json:"category"` // Empty clears the override
+}
+
+// ReviewRequest is the request body for accepting or correcting a prediction
+type ReviewRequest struct {
+	Category string `json:"category"` // Defaults to the predicted category
+}
+
+// SetCategory overrides a transaction's category. Overrides are the classifier's labels,
+// so one made on a predicted transaction also resolves the prediction.
+func (h *CategorizerHandler) SetCategory(c *gin.Context) {
+	transaction, ok := loadUserTransaction(c, h.repos.Transaction)
+	if !ok {
+		return
+	}
+
+	var req SetCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repos.Transaction.UpdateCategory(transaction.ID, req.Category); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
+		return
+	}
+	transaction.UserCategory = req.Category
+
+	prediction, err := h.repos.Categorizer.GetPrediction(transaction.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prediction"})
+		return
+	}
+	if prediction != nil && req.Category != "" {
+		status := ""
+		switch {
+		case prediction.Status == models.PredictionStatusReview && req.Category == prediction.Category:
+			status = models.PredictionStatusAccepted
+		case (prediction.Status == models.PredictionStatusReview || prediction.Status == models.PredictionStatusApplied) &&
+			req.Category != prediction.Category:
+			status = models.PredictionStatusCorrected
+		}
+		if status != "" {
+			if err := h.repos.Categorizer.Resolve(prediction, status, ""); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update prediction"})
+				return
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transaction": transaction})
+}
+
+// GetStatus returns how much the user's classifier has learned and the size of the
+// review queue
+func (h *CategorizerHandler) GetStatus(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	model, err := h.repos.Categorizer.GetModel(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categorizer"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"model":                model,
+		"min_examples":         categorizer.MinExamples,
+		"confidence_threshold": categorizer.ConfidenceThreshold,
+	})
+}
+
+// Train updates the user's classifier with their latest category overrides and predicts
+// the categories of recent uncategorized transactions. With full=true the classifier is
+// rebuilt from scratch first.
+func (h *CategorizerHandler) Train(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.categorizer.Run(userID, c.Query("full") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to train categorizer"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": result})
+}
+
+// ListReview returns the predictions waiting for review, least confident first
+func (h *CategorizerHandler) ListReview(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	limit, offset, ok := queryPagination(c, 50, 200)
+	if !ok {
+		return
+	}
+
+	predictions, err := h.repos.Categorizer.GetByStatus(userID, models.PredictionStatusReview, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch review queue"})
+		return
+	}
+	ids := make([]uuid.UUID, len(predictions))
+	for i, prediction := range predictions {
+		ids[i] = prediction.TransactionID
+	}
+	transactions, err := h.repos.Transaction.GetByIDs(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
+	}
+	for _, prediction := range predictions {
+		prediction.Transaction = transactions[prediction.TransactionID]
+	}
+
+	c.JSON(http.StatusOK, gin.H{"predictions": predictions})
+}
+
+// ResolveReview sets the category of a transaction in the review queue, either the
+// predicted category or the one in the request body
+func (h *CategorizerHandler) ResolveReview(c *gin.Context) {
+	prediction, ok := h.loadReviewPrediction(c)
+	if !ok {
+		return
+	}
+
+	var req ReviewRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	status := models.PredictionStatusAccepted
+	if req.Category == "" {
+		req.Category = prediction.Category
+	} else if req.Category != prediction.Category {
+		status = models.PredictionStatusCorrected
+	}
+
+	if err := h.repos.Categorizer.Resolve(prediction, status, req.Category); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update prediction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"prediction": prediction})
+}
+
+// DismissReview removes a transaction from the review queue without categorizing it
+func (h *CategorizerHandler) DismissReview(c *gin.Context) {
+	prediction, ok := h.loadReviewPrediction(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repos.Categorizer.Resolve(prediction, models.PredictionStatusDismissed, ""); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update prediction"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// loadReviewPrediction fetches the prediction for the transaction named by the
+// :transactionId path parameter and checks that it belongs to the authenticated user and
+// is waiting for review. On failure an error response is written and ok is false.
+func (h *CategorizerHandler) loadReviewPrediction(c *gin.Context) (*models.CategoryPrediction, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return nil, false
+	}
+	transactionID, ok := pathUUID(c, "transactionId")
+	if !ok {
+		return nil, false
+	}
+
+	prediction, err := h.repos.Categorizer.GetPrediction(transactionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prediction"})
+		return nil, false
+	}
+	if prediction == nil || prediction.UserID != userID || prediction.Status != models.PredictionStatusReview {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prediction not found"})
+		return nil, false
+	}
+	return prediction, true
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// currentUserID returns the authenticated user's ID set by the auth middleware.
+// If it is missing, an error response is written and ok is false.
+func currentUserID(c *gin.Context) (userID uuid.UUID, ok bool) {
+	userIDValue, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return uuid.Nil, false
+	}
+
+	userID, ok = userIDValue.(uuid.UUID)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// pathUUID parses a UUID path parameter. If it is malformed, a 400 response is written
+// and ok is false.
+func pathUUID(c *gin.Context, name string) (id uuid.UUID, ok bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// queryDateRange parses the optional from and to query parameters (YYYY-MM-DD). Missing
+// values default to the given number of days before today and today. If either value is
+// malformed, a 400 response is written and ok is false.
+func queryDateRange(c *gin.Context, defaultDays int) (from, to time.Time, ok bool) {
+	to = time.Now().UTC().Truncate(24 * time.Hour)
+	from = to.AddDate(0, 0, -defaultDays)
+
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from format. Use YYYY-MM-DD"})
+			return from, to, false
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to format. Use YYYY-MM-DD"})
+			return from, to, false
+		}
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// queryPagination parses the optional limit and offset query parameters. limit defaults
+// to defaultLimit and is capped at maxLimit. If either value is malformed, a 400 response
+// is written and ok is false.
+func queryPagination(c *gin.Context, defaultLimit, maxLimit int) (limit, offset int, ok bool) {
+	limit = defaultLimit
+
+	var err error
+	if v := c.Query("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return 0, 0, false
+		}
+	}
+	if v := c.Query("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative integer"})
+			return 0, 0, false
+		}
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	return limit, offset, true
+}
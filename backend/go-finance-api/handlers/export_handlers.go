@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/exporter"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportHandler handles exporting transactions to files
+type ExportHandler struct {
+	exporter *exporter.Exporter
+}
+
+// NewExportHandler creates a new ExportHandler
+func NewExportHandler(repos *db.Repositories) *ExportHandler {
+	return &ExportHandler{exporter: exporter.NewExporter(repos)}
+}
+
+// Export streams the user's transactions in the format given by the format query parameter
+// (csv, ofx, ledger or beancount) for the from/to date range, defaulting to the last year
+func (h *ExportHandler) Export(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", exporter.FormatCSV)
+	contentType, ok := exporter.ContentType(format)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be one of csv, ofx, ledger or beancount"})
+		return
+	}
+	from, to, ok := queryDateRange(c, 365)
+	if !ok {
+		return
+	}
+
+	fileName := fmt.Sprintf("transactions-%s-%s.%s", from.Format("20060102"), to.Format("20060102"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+
+	if err := h.exporter.Export(c.Writer, format, userID, from, to); err != nil {
+		if c.Writer.Written() {
+			// Too late to report the failure; the client sees a truncated file
+			log.Printf("Export for user %s failed mid-stream: %v", userID, err)
+			return
+		}
+		c.Writer.Header().Del("Content-Disposition")
+		c.Writer.Header().Del("Content-Type")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export transactions"})
+	}
+}
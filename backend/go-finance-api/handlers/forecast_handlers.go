@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/forecast"
+	"github.com/gin-gonic/gin"
+)
+
+// maxForecastDays bounds the days parameter of GetForecast
+const maxForecastDays = 365
+
+// ForecastHandler handles cash-flow forecasts
+type ForecastHandler struct {
+	service *forecast.Service
+}
+
+// NewForecastHandler creates a new ForecastHandler
+func NewForecastHandler(repos *db.Repositories) *ForecastHandler {
+	return &ForecastHandler{service: forecast.NewService(repos)}
+}
+
+// GetForecast projects the daily balance of each of the user's depository accounts. The
+// optional days query parameter sets the horizon, defaulting to 90 days, and accounts
+// projected to fall below the optional threshold (default 0) carry a warning.
+func (h *ForecastHandler) GetForecast(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	days := 90
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxForecastDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 365"})
+			return
+		}
+		days = parsed
+	}
+	var threshold float64
+	if value := c.Query("threshold"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "threshold must be a number"})
+			return
+		}
+		threshold = parsed
+	}
+
+	forecasts, err := h.service.ForecastUser(userID, days, threshold)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute forecast"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"threshold": threshold, "accounts": forecasts})
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/goals"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GoalHandler handles savings goals and their contribution logs
+type GoalHandler struct {
+	goalRepo    *db.GoalRepository
+	accountRepo *db.AccountRepository
+	service     *goals.Service
+}
+
+// NewGoalHandler creates a new GoalHandler
+func NewGoalHandler(repos *db.Repositories) *GoalHandler {
+	return &GoalHandler{
+		goalRepo:    repos.Goal,
+		accountRepo: repos.Account,
+		service:     goals.NewService(repos),
+	}
+}
+
+// GoalRequest is the request body for creating or updating a goal
+type GoalRequest struct {
+	Name         string     `json:"name" binding:"required,max=255"`
+	TargetAmount float64    `json:"target_amount" binding:"required,gt=0"`
+	TargetDate   string     `json:"target_date"` // Optional, YYYY-MM-DD
+	AccountID    *uuid.UUID `json:"account_id"`  // Optional; progress follows this account's balance
+}
+
+// ContributionRequest is the request body for logging a contribution to a goal
+type ContributionRequest struct {
+	Amount float64 `json:"amount" binding:"required"` // Negative for withdrawals
+	Date   string  `json:"date"`                      // YYYY-MM-DD, defaults to today
+	Note   string  `json:"note"`
+}
+
+// GoalResponse is a goal with its current progress
+type GoalResponse struct {
+	*models.Goal
+	Progress      *models.GoalProgress       `json:"progress"`
+	Contributions []*models.GoalContribution `json:"contributions,omitempty"`
+}
+
+// ListGoals returns the user's goals with their progress
+func (h *GoalHandler) ListGoals(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	userGoals, err := h.goalRepo.GetByUserID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch goals"})
+		return
+	}
+
+	responses := make([]*GoalResponse, 0, len(userGoals))
+	for _, goal := range userGoals {
+		progress, err := h.service.Progress(goal)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute goal progress"})
+			return
+		}
+		responses = append(responses, &GoalResponse{Goal: goal, Progress: progress})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"goals": responses})
+}
+
+// GetGoal returns a goal with its progress and contribution log
+func (h *GoalHandler) GetGoal(c *gin.Context) {
+	goal, ok := h.loadGoal(c)
+	if !ok {
+		return
+	}
+	h.respondWithGoal(c, http.StatusOK, goal)
+}
+
+// CreateGoal creates a new goal
+func (h *GoalHandler) CreateGoal(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req GoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	targetDate, ok := h.validateGoalRequest(c, userID, &req)
+	if !ok {
+		return
+	}
+
+	goal := models.NewGoal(userID, req.Name, req.TargetAmount, targetDate, req.AccountID)
+	if err := h.goalRepo.Create(goal); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create goal"})
+		return
+	}
+
+	h.respondWithGoal(c, http.StatusCreated, goal)
+}
+
+// UpdateGoal replaces a goal's name, target and linked account
+func (h *GoalHandler) UpdateGoal(c *gin.Context) {
+	goal, ok := h.loadGoal(c)
+	if !ok {
+		return
+	}
+
+	var req GoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	targetDate, ok := h.validateGoalRequest(c, goal.UserID, &req)
+	if !ok {
+		return
+	}
+
+	goal.Name = req.Name
+	goal.TargetAmount = req.TargetAmount
+	goal.TargetDate = targetDate
+	goal.AccountID = req.AccountID
+	if err := h.goalRepo.Update(goal); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update goal"})
+		return
+	}
+
+	h.respondWithGoal(c, http.StatusOK, goal)
+}
+
+// DeleteGoal deletes a goal and its contributions
+func (h *GoalHandler) DeleteGoal(c *gin.Context) {
+	goal, ok := h.loadGoal(c)
+	if !ok {
+		return
+	}
+
+	if err := h.goalRepo.Delete(goal.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete goal"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AddContribution logs a contribution to a goal that isn't linked to an account
+func (h *GoalHandler) AddContribution(c *gin.Context) {
+	goal, ok := h.loadGoal(c)
+	if !ok {
+		return
+	}
+	if goal.AccountID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Progress of a goal linked to an account follows its balance"})
+		return
+	}
+
+	var req ContributionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	date := time.Now().UTC().Truncate(24 * time.Hour)
+	if req.Date != "" {
+		parsed, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+		date = parsed
+	}
+
+	contribution := models.NewGoalContribution(goal.ID, date, req.Amount, req.Note)
+	if err := h.goalRepo.CreateContribution(contribution); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save contribution"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, contribution)
+}
+
+// DeleteContribution removes a contribution from a goal's log
+func (h *GoalHandler) DeleteContribution(c *gin.Context) {
+	goal, ok := h.loadGoal(c)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(c, "contributionId")
+	if !ok {
+		return
+	}
+
+	deleted, err := h.goalRepo.DeleteContribution(goal.ID, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete contribution"})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contribution not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// respondWithGoal writes a goal with its progress and contribution log
+func (h *GoalHandler) respondWithGoal(c *gin.Context, status int, goal *models.Goal) {
+	progress, err := h.service.Progress(goal)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute goal progress"})
+		return
+	}
+	contributions, err := h.goalRepo.GetContributions(goal.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contributions"})
+		return
+	}
+
+	c.JSON(status, &GoalResponse{Goal: goal, Progress: progress, Contributions: contributions})
+}
+
+// validateGoalRequest parses the target date and checks that the linked account, if any,
+// is one of the user's asset accounts. On failure an error response is written and ok is
+// false.
+func (h *GoalHandler) validateGoalRequest(c *gin.Context, userID uuid.UUID, req *GoalRequest) (targetDate *time.Time, ok bool) {
+	if req.TargetDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.TargetDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target_date format. Use YYYY-MM-DD"})
+			return nil, false
+		}
+		targetDate = &parsed
+	}
+
+	if req.AccountID != nil {
+		account, err := h.accountRepo.GetByID(*req.AccountID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch account"})
+			return nil, false
+		}
+		if account == nil || account.UserID != userID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Account not found"})
+			return nil, false
+		}
+		if account.IsLiability() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Goals can only be linked to asset accounts"})
+			return nil, false
+		}
+	}
+	return targetDate, true
+}
+
+// loadGoal fetches the goal named by the :id path parameter and checks that it belongs to
+// the authenticated user. On failure an error response is written and ok is false.
+func (h *GoalHandler) loadGoal(c *gin.Context) (*models.Goal, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return nil, false
+	}
+	id, ok := pathUUID(c, "id")
+	if !ok {
+		return nil, false
+	}
+
+	goal, err := h.goalRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch goal"})
+		return nil, false
+	}
+	if goal == nil || goal.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Goal not found"})
+		return nil, false
+	}
+	return goal, true
+}
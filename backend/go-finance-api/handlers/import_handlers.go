@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/importer"
+	"github.com/gin-gonic/gin"
+)
+
+// maxStatementSize is the largest statement file accepted for import
+const maxStatementSize = 20 << 20 // 20 MB
+
+// ImportHandler handles importing statement files into accounts
+type ImportHandler struct {
+	accountRepo *db.AccountRepository
+	importer    *importer.Importer
+}
+
+// NewImportHandler creates a new ImportHandler
+func NewImportHandler(repos *db.Repositories) *ImportHandler {
+	return &ImportHandler{
+		accountRepo: repos.Account,
+		importer:    importer.NewImporter(repos),
+	}
+}
+
+// PreviewImport parses a statement and reports which transactions would be imported,
+// without storing anything
+func (h *ImportHandler) PreviewImport(c *gin.Context) {
+	h.handleImport(c, true)
+}
+
+// ImportStatement imports a statement into an account, skipping transactions that were
+// imported before
+func (h *ImportHandler) ImportStatement(c *gin.Context) {
+	h.handleImport(c, false)
+}
+
+// handleImport reads the multipart "file" field and parses it according to the "format"
+// field (csv, ofx, qfx or qif; guessed from the file extension if omitted) and the
+// optional "options" field, a JSON-encoded importer.Options
+func (h *ImportHandler) handleImport(c *gin.Context, dryRun bool) {
+	account, ok := loadUserAccount(c, h.accountRepo)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxStatementSize+1<<20)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	if fileHeader.Size > maxStatementSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File exceeds the 20 MB limit"})
+		return
+	}
+
+	format := c.PostForm("format")
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+	}
+	var opts importer.Options
+	if raw := c.PostForm("options"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &opts); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid options: " + err.Error()})
+			return
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	records, err := importer.Parse(file, format, opts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var result *importer.Result
+	if dryRun {
+		result, err = h.importer.Preview(account, records)
+	} else {
+		result, err = h.importer.Import(account, records)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/llm"
+	"github.com/gin-gonic/gin"
+)
+
+// LLMHandler reports language model usage
+type LLMHandler struct {
+	usage *db.LLMUsageRepository
+	meter *llm.Meter // nil when no provider is configured
+}
+
+// NewLLMHandler creates a new LLMHandler
+func NewLLMHandler(usage *db.LLMUsageRepository, meter *llm.Meter) *LLMHandler {
+	return &LLMHandler{usage: usage, meter: meter}
+}
+
+// GetUsage returns the user's language model requests, tokens and cost this month, and
+// the monthly cost limit if there is one
+func (h *LLMHandler) GetUsage(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if h.meter != nil {
+		since = h.meter.MonthStart()
+	}
+	summary, err := h.usage.SummarizeSince(userID, since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve usage"})
+		return
+	}
+	if h.meter != nil && h.meter.MonthlyLimit() > 0 {
+		limit := h.meter.MonthlyLimit()
+		summary.Limit = &limit
+	}
+
+	c.JSON(http.StatusOK, gin.H{"enabled": h.meter != nil, "usage": summary})
+}
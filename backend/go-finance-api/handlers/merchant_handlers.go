@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/merchants"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// MerchantHandler handles requests for the user's canonical merchants
+type MerchantHandler struct {
+	repos        *db.Repositories
+	merchantRepo *db.MerchantRepository
+}
+
+// NewMerchantHandler creates a new MerchantHandler
+func NewMerchantHandler(repos *db.Repositories) *MerchantHandler {
+	return &MerchantHandler{repos: repos, merchantRepo: repos.Merchant}
+}
+
+// MerchantRequest is the request body for updating a merchant
+type MerchantRequest struct {
+	Name    string `json:"name" binding:"required,max=255"`
+	LogoURL string `json:"logo_url" binding:"omitempty,url"`
+	Website string `json:"website" binding:"omitempty,url"`
+}
+
+// MergeMerchantRequest is the request body for merging a merchant into another
+type MergeMerchantRequest struct {
+	IntoID uuid.UUID `json:"into_id" binding:"required"`
+}
+
+// ListMerchants returns the user's merchants with their transaction counts
+func (h *MerchantHandler) ListMerchants(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	userMerchants, err := h.merchantRepo.GetByUserID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch merchants"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"merchants": userMerchants})
+}
+
+// LinkMerchants links the user's transactions that have no merchant yet
+func (h *MerchantHandler) LinkMerchants(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	linked, err := merchants.NewResolver(h.repos).LinkUnlinked(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link merchants"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"linked": linked})
+}
+
+// UpdateMerchant renames a merchant or sets its logo and website
+func (h *MerchantHandler) UpdateMerchant(c *gin.Context) {
+	merchant, ok := h.loadMerchant(c)
+	if !ok {
+		return
+	}
+
+	var req MerchantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	merchant.Name = req.Name
+	merchant.LogoURL = req.LogoURL
+	merchant.Website = req.Website
+	if err := h.merchantRepo.Update(merchant); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update merchant"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"merchant": merchant})
+}
+
+// MergeMerchant merges a merchant into another. Its transactions move to the other
+// merchant, and so will future transactions with its name.
+func (h *MerchantHandler) MergeMerchant(c *gin.Context) {
+	source, ok := h.loadMerchant(c)
+	if !ok {
+		return
+	}
+
+	var req MergeMerchantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	target, err := h.merchantRepo.GetByID(req.IntoID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch merchant"})
+		return
+	}
+	if target == nil || target.UserID != source.UserID || target.MergedInto != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Merchant not found"})
+		return
+	}
+	if target.ID == source.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A merchant cannot be merged into itself"})
+		return
+	}
+
+	if err := h.merchantRepo.Merge(source, target); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge merchants"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"merchant": target})
+}
+
+// loadMerchant fetches the unmerged merchant named by the :id path parameter and checks
+// that it belongs to the authenticated user. On failure an error response is written and
+// ok is false.
+func (h *MerchantHandler) loadMerchant(c *gin.Context) (*models.Merchant, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return nil, false
+	}
+	id, ok := pathUUID(c, "id")
+	if !ok {
+		return nil, false
+	}
+
+	merchant, err := h.merchantRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch merchant"})
+		return nil, false
+	}
+	if merchant == nil || merchant.UserID != userID || merchant.MergedInto != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Merchant not found"})
+		return nil, false
+	}
+	return merchant, true
+}
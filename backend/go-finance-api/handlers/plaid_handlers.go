@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"io"
 	"net/http"
 	"time"
@@ -10,9 +11,21 @@ import (
 	plaidlib "github.com/plaid/plaid-go/v20/plaid"
 )
 
+// plaidAPI is the part of the Plaid client the handler calls, as plaid.Client does
+type plaidAPI interface {
+	CreateLinkToken(userID string, clientName string, products []plaidlib.Products) (string, error)
+	ExchangePublicToken(publicToken string) (string, string, error)
+	GetAccounts(accessToken string) (*plaidlib.AccountsGetResponse, error)
+	GetTransactions(accessToken string, startDate, endDate time.Time, options *plaidlib.TransactionsGetRequestOptions) (*plaidlib.TransactionsGetResponse, error)
+	SyncTransactions(accessToken string, cursor string) (*plaidlib.TransactionsSyncResponse, error)
+	GetItem(accessToken string) (*plaidlib.ItemGetResponse, error)
+	UpdateItemWebhook(accessToken, webhookURL string) (*plaidlib.ItemWebhookUpdateResponse, error)
+	VerifyWebhook(body []byte) bool
+}
+
 // PlaidHandler handles Plaid API related requests
 type PlaidHandler struct {
-	plaidClient *plaid.Client
+	plaidClient plaidAPI
 }
 
 // NewPlaidHandler creates a new PlaidHandler
@@ -243,9 +256,9 @@ func (h *PlaidHandler) HandleWebhook(c *gin.Context) {
 		return
 	}
 
-	// Parse the webhook
+	// Parse the webhook from the body already read for verification
 	var webhookData map[string]interface{}
-	if err := c.ShouldBindJSON(&webhookData); err != nil {
+	if err := json.Unmarshal(body, &webhookData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook data"})
 		return
 	}
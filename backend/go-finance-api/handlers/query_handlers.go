@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/llm"
+	"github.com/davidwang/go-finance-api/go-finance-api/nlquery"
+	"github.com/gin-gonic/gin"
+)
+
+// maxQuestionLength bounds the length of a question in characters
+const maxQuestionLength = 500
+
+// QueryHandler handles natural-language transaction queries
+type QueryHandler struct {
+	meter   *llm.Meter // nil when no provider is configured
+	service *nlquery.Service
+}
+
+// NewQueryHandler creates a new QueryHandler
+func NewQueryHandler(repos *db.Repositories, meter *llm.Meter) *QueryHandler {
+	return &QueryHandler{meter: meter, service: nlquery.NewService(repos, meter)}
+}
+
+// QuestionRequest is the body of a natural-language query
+type QuestionRequest struct {
+	Question string `json:"question" binding:"required"`
+}
+
+// Ask answers a question such as "how much did I spend on restaurants in March vs
+// April". The response includes the spec the question was read as, which can be edited
+// and sent to RunSpec.
+func (h *QueryHandler) Ask(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	if h.meter == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Natural-language queries are not configured"})
+		return
+	}
+
+	var req QuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len([]rune(req.Question)) > maxQuestionLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Question must be at most 500 characters"})
+		return
+	}
+
+	result, err := h.service.Ask(c.Request.Context(), userID, req.Question)
+	switch {
+	case errors.Is(err, nlquery.ErrNotUnderstood):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Could not understand the question. Try rephrasing it."})
+		return
+	case errors.Is(err, llm.ErrCostLimitExceeded):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Monthly assistant usage limit reached"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to answer question"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// RunSpec runs a query spec given as the request body, such as one returned by Ask and
+// edited by the user
+func (h *QueryHandler) RunSpec(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 64*1024))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+	spec, err := nlquery.ParseSpec(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.Run(userID, spec)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to run query"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/davidwang/go-finance-api/go-finance-api/plaid"
+	"github.com/davidwang/go-finance-api/go-finance-api/recurring"
+	"github.com/gin-gonic/gin"
+)
+
+// RecurringHandler handles recurring transactions such as subscriptions, bills and income
+type RecurringHandler struct {
+	recurringRepo *db.RecurringRepository
+	detector      *recurring.Detector
+}
+
+// NewRecurringHandler creates a new RecurringHandler
+func NewRecurringHandler(repos *db.Repositories, plaidClient *plaid.Client) *RecurringHandler {
+	return &RecurringHandler{
+		recurringRepo: repos.Recurring,
+		detector:      recurring.NewDetector(repos, plaidClient),
+	}
+}
+
+// ListRecurring returns the user's recurring series. The optional status query parameter
+// (active or missed) filters them.
+func (h *RecurringHandler) ListRecurring(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	status := c.Query("status")
+	if status != "" && status != models.RecurringStatusActive && status != models.RecurringStatusMissed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be active or missed"})
+		return
+	}
+
+	series, err := h.recurringRepo.GetByUserID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recurring transactions"})
+		return
+	}
+
+	filtered := make([]*models.RecurringSeries, 0, len(series))
+	for _, s := range series {
+		if status == "" || s.Status == status {
+			filtered = append(filtered, s)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recurring": filtered})
+}
+
+// DetectRecurring re-runs recurring detection over the user's transaction history
+func (h *RecurringHandler) DetectRecurring(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	series, err := h.detector.DetectForUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to detect recurring transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recurring": series})
+}
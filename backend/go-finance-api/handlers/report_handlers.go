@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/budgets"
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/davidwang/go-finance-api/go-finance-api/reports"
+	"github.com/gin-gonic/gin"
+)
+
+// maxCashFlowMonths bounds the range of months in GetCashFlowReport
+const maxCashFlowMonths = 60
+
+// ReportHandler handles spending reports
+type ReportHandler struct {
+	service *reports.Service
+}
+
+// NewReportHandler creates a new ReportHandler
+func NewReportHandler(repos *db.Repositories) *ReportHandler {
+	return &ReportHandler{service: reports.NewService(repos)}
+}
+
+// GetSpendingReport totals the user's spending between the optional from and to dates
+// (default the last 30 days) by category, merchant, account, day_of_week or month, with
+// each row compared to the previous period and the same period last year
+func (h *ReportHandler) GetSpendingReport(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	by := c.DefaultQuery("by", models.ReportByCategory)
+	if !reports.ValidDimension(by) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "by must be one of " + strings.Join(reports.Dimensions, ", ")})
+		return
+	}
+	from, to, ok := queryDateRange(c, 30)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.Report(userID, by, reports.Period{From: from, To: to})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetCashFlowReport reports the user's monthly income, expenses, net savings and savings
+// rate, with expenses broken down into fixed and discretionary spend. The optional from
+// and to query parameters (YYYY-MM) default to the last 12 months.
+func (h *ReportHandler) GetCashFlowReport(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	to := budgets.MonthStart(time.Now())
+	from := to.AddDate(0, -11, 0)
+	var err error
+	if value := c.Query("from"); value != "" {
+		if from, err = budgets.ParseMonth(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if to, err = budgets.ParseMonth(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return
+	}
+	if to.After(from.AddDate(0, maxCashFlowMonths-1, 0)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Range must not exceed 60 months"})
+		return
+	}
+
+	report, err := h.service.CashFlow(userID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build cash flow report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/davidwang/go-finance-api/go-finance-api/rules"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxPreviewMatches caps the number of transactions returned by a rule preview
+const maxPreviewMatches = 100
+
+// RuleHandler handles transaction rule requests
+type RuleHandler struct {
+	ruleRepo        *db.RuleRepository
+	transactionRepo *db.TransactionRepository
+}
+
+// NewRuleHandler creates a new RuleHandler
+func NewRuleHandler(ruleRepo *db.RuleRepository, transactionRepo *db.TransactionRepository) *RuleHandler {
+	return &RuleHandler{
+		ruleRepo:        ruleRepo,
+		transactionRepo: transactionRepo,
+	}
+}
+
+// RuleRequest is the request body for creating or updating a rule
+type RuleRequest struct {
+	Name       string                `json:"name" binding:"required"`
+	Priority   int                   `json:"priority"`
+	Enabled    *bool                 `json:"enabled"`
+	Conditions models.RuleConditions `json:"conditions"`
+	Actions    models.RuleActions    `json:"actions"`
+}
+
+// PreviewRuleRequest is the request body for previewing a rule's matches
+type PreviewRuleRequest struct {
+	Conditions models.RuleConditions `json:"conditions"`
+}
+
+// ListRules returns the user's rules in evaluation order
+func (h *RuleHandler) ListRules(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	userRules, err := h.ruleRepo.GetByUserID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": userRules})
+}
+
+// CreateRule creates a new rule
+func (h *RuleHandler) CreateRule(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := models.NewRule(userID, req.Name, req.Priority, req.Conditions, req.Actions)
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if err := rules.Validate(rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.ruleRepo.Create(rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save rule"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateRule replaces an existing rule
+func (h *RuleHandler) UpdateRule(c *gin.Context) {
+	rule, ok := h.loadRule(c)
+	if !ok {
+		return
+	}
+
+	var req RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule.Name = req.Name
+	rule.Priority = req.Priority
+	rule.Conditions = req.Conditions
+	rule.Actions = req.Actions
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if err := rules.Validate(rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.ruleRepo.Update(rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule deletes a rule
+func (h *RuleHandler) DeleteRule(c *gin.Context) {
+	rule, ok := h.loadRule(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ruleRepo.Delete(rule.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete rule"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// PreviewRule lists the user's existing transactions that the given conditions would match
+func (h *RuleHandler) PreviewRule(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req PreviewRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Preview only evaluates conditions, so any non-empty action will do
+	rule := models.NewRule(userID, "preview", 0, req.Conditions, models.RuleActions{Hide: true})
+	engine, err := rules.NewEngine([]*models.Rule{rule})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	matches := make([]*models.Transaction, 0)
+	count := 0
+	err = h.transactionRepo.ForEachByUserID(userID, func(tx *models.Transaction) error {
+		if engine.Evaluate(tx).Matched() {
+			count++
+			if len(matches) < maxPreviewMatches {
+				matches = append(matches, tx)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":        count,
+		"transactions": matches,
+	})
+}
+
+// ApplyRule retroactively applies a single saved rule to the user's existing transactions.
+// Pass ?overwrite=true to replace categories and names the user has already set.
+func (h *RuleHandler) ApplyRule(c *gin.Context) {
+	rule, ok := h.loadRule(c)
+	if !ok {
+		return
+	}
+
+	// Applying a specific rule on demand should work even if it is disabled for sync
+	ruleCopy := *rule
+	ruleCopy.Enabled = true
+	h.applyRules(c, rule.UserID, []*models.Rule{&ruleCopy})
+}
+
+// ApplyAllRules retroactively applies every enabled rule to the user's existing transactions
+func (h *RuleHandler) ApplyAllRules(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	userRules, err := h.ruleRepo.GetEnabledByUserID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rules"})
+		return
+	}
+
+	h.applyRules(c, userID, userRules)
+}
+
+// applyRules runs the given rules over all of a user's transactions and writes the results
+func (h *RuleHandler) applyRules(c *gin.Context, userID uuid.UUID, userRules []*models.Rule) {
+	overwrite := c.Query("overwrite") == "true"
+
+	engine, err := rules.NewEngine(userRules)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Collect matches first so writes don't contend with the open result set
+	type match struct {
+		transaction *models.Transaction
+		actions     models.RuleActions
+	}
+	var matches []match
+	err = h.transactionRepo.ForEachByUserID(userID, func(tx *models.Transaction) error {
+		if outcome := engine.Evaluate(tx); outcome.Matched() {
+			matches = append(matches, match{transaction: tx, actions: outcome.Actions})
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
+	}
+
+	for _, m := range matches {
+		if err := h.transactionRepo.ApplyRuleActions(m.transaction, m.actions, overwrite); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply rules"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": len(matches)})
+}
+
+// loadRule fetches the rule named by the :id path parameter and checks that it belongs
+// to the authenticated user. On failure an error response is written and ok is false.
+func (h *RuleHandler) loadRule(c *gin.Context) (*models.Rule, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return nil, false
+	}
+	id, ok := pathUUID(c, "id")
+	if !ok {
+		return nil, false
+	}
+
+	rule, err := h.ruleRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rule"})
+		return nil, false
+	}
+	if rule == nil || rule.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found"})
+		return nil, false
+	}
+	return rule, true
+}
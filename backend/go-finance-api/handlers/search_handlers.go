@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/llm"
+	"github.com/davidwang/go-finance-api/go-finance-api/semantic"
+	"github.com/gin-gonic/gin"
+)
+
+// maxSearchLength bounds the length of a search query in characters
+const maxSearchLength = 200
+
+// SearchHandler handles semantic searches over transactions
+type SearchHandler struct {
+	transactionRepo *db.TransactionRepository
+	service         *semantic.Service
+}
+
+// NewSearchHandler creates a new SearchHandler that embeds with model. meter may be nil,
+// in which case transactions are embedded locally by hashing their words.
+func NewSearchHandler(repos *db.Repositories, meter *llm.Meter, model string) *SearchHandler {
+	return &SearchHandler{
+		transactionRepo: repos.Transaction,
+		service:         semantic.NewService(repos, meter, model),
+	}
+}
+
+// Search returns the user's transactions closest in meaning to the q query parameter,
+// such as "coffee" or "weekend trips", most similar first
+func (h *SearchHandler) Search(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	if len([]rune(query)) > maxSearchLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q must be at most 200 characters"})
+		return
+	}
+	limit, offset, ok := queryPagination(c, 20, 100)
+	if !ok {
+		return
+	}
+
+	results, err := h.service.Search(c.Request.Context(), userID, query, offset, limit)
+	if err != nil {
+		h.writeSearchError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// Similar returns the user's other transactions most like the given one
+func (h *SearchHandler) Similar(c *gin.Context) {
+	transaction, ok := loadUserTransaction(c, h.transactionRepo)
+	if !ok {
+		return
+	}
+	limit, offset, ok := queryPagination(c, 20, 100)
+	if !ok {
+		return
+	}
+
+	results, err := h.service.Similar(c.Request.Context(), transaction.UserID, transaction, offset, limit)
+	if err != nil {
+		h.writeSearchError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// IndexTransactions embeds all of the user's new and changed transactions now, rather
+// than a batch at a time as searches run
+func (h *SearchHandler) IndexTransactions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	indexed, err := h.service.Index(c.Request.Context(), userID, 0)
+	if err != nil {
+		h.writeSearchError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"indexed": indexed})
+}
+
+// writeSearchError writes the response for a failed search or indexing run
+func (h *SearchHandler) writeSearchError(c *gin.Context, err error) {
+	if errors.Is(err, llm.ErrCostLimitExceeded) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Monthly assistant usage limit reached"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search transactions"})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/budgets"
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/llm"
+	"github.com/davidwang/go-finance-api/go-finance-api/summaries"
+	"github.com/gin-gonic/gin"
+)
+
+// SummaryHandler handles requests for monthly financial summaries
+type SummaryHandler struct {
+	summaryRepo *db.MonthlySummaryRepository
+	service     *summaries.Service
+}
+
+// NewSummaryHandler creates a new SummaryHandler. meter may be nil, in which case
+// summaries are rendered from the template.
+func NewSummaryHandler(repos *db.Repositories, meter *llm.Meter) *SummaryHandler {
+	return &SummaryHandler{
+		summaryRepo: repos.MonthlySummary,
+		service:     summaries.NewService(repos, meter),
+	}
+}
+
+// ListSummaries returns a page of the user's monthly summaries, latest month first
+func (h *SummaryHandler) ListSummaries(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	limit, offset, ok := queryPagination(c, 12, 60)
+	if !ok {
+		return
+	}
+
+	userSummaries, err := h.summaryRepo.GetByUserID(userID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch summaries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"summaries": userSummaries})
+}
+
+// GetSummary returns the user's summary of a month (YYYY-MM)
+func (h *SummaryHandler) GetSummary(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	month, ok := pathMonth(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.summaryRepo.GetByUserAndMonth(userID, month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch summary"})
+		return
+	}
+	if summary == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Summary not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"summary": summary})
+}
+
+// GenerateSummary writes the user's summary of a month (YYYY-MM), replacing any earlier
+// one. The current month is summarized to date.
+func (h *SummaryHandler) GenerateSummary(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	month, ok := pathMonth(c)
+	if !ok {
+		return
+	}
+	if month.After(budgets.MonthStart(time.Now().UTC())) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot summarize a future month"})
+		return
+	}
+
+	summary, err := h.service.Generate(c.Request.Context(), userID, month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate summary"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"summary": summary})
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/syncer"
+	"github.com/gin-gonic/gin"
+)
+
+// SyncHandler handles syncing Plaid data into the database
+type SyncHandler struct {
+	syncer *syncer.Syncer
+}
+
+// NewSyncHandler creates a new SyncHandler
+func NewSyncHandler(syncer *syncer.Syncer) *SyncHandler {
+	return &SyncHandler{syncer: syncer}
+}
+
+// Sync pulls the latest accounts and transactions for all of the user's Items
+func (h *SyncHandler) Sync(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.syncer.SyncUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/gin-gonic/gin"
+)
+
+// TransactionHandler handles requests for stored transactions
+type TransactionHandler struct {
+	transactionRepo *db.TransactionRepository
+	splitRepo       *db.SplitRepository
+}
+
+// NewTransactionHandler creates a new TransactionHandler
+func NewTransactionHandler(transactionRepo *db.TransactionRepository, splitRepo *db.SplitRepository) *TransactionHandler {
+	return &TransactionHandler{
+		transactionRepo: transactionRepo,
+		splitRepo:       splitRepo,
+	}
+}
+
+// SplitRequest is a single split in a SetSplitsRequest
+type SplitRequest struct {
+	Amount   float64 `json:"amount"`
+	Category string  `json:"category" binding:"required"`
+	Note     string  `json:"note"`
+}
+
+// SetSplitsRequest is the request body for splitting a transaction
+type SetSplitsRequest struct {
+	Splits []SplitRequest `json:"splits" binding:"required,dive"`
+}
+
+// GetCategoryTotals returns spending per category for a date range, using splits where they exist
+func (h *TransactionHandler) GetCategoryTotals(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	from, to, ok := queryDateRange(c, 30)
+	if !ok {
+		return
+	}
+
+	totals, err := h.transactionRepo.SumByCategory(userID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch category totals"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"categories": totals})
+}
+
+// GetSplits returns the splits of a transaction
+func (h *TransactionHandler) GetSplits(c *gin.Context) {
+	transaction, ok := loadUserTransaction(c, h.transactionRepo)
+	if !ok {
+		return
+	}
+
+	splits, err := h.splitRepo.GetByTransactionID(transaction.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch splits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"splits": splits})
+}
+
+// SetSplits replaces the splits of a transaction. The split amounts must sum exactly to
+// the transaction amount.
+func (h *TransactionHandler) SetSplits(c *gin.Context) {
+	transaction, ok := loadUserTransaction(c, h.transactionRepo)
+	if !ok {
+		return
+	}
+
+	var req SetSplitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	splits := make([]*models.TransactionSplit, 0, len(req.Splits))
+	for _, s := range req.Splits {
+		splits = append(splits, models.NewTransactionSplit(transaction.ID, transaction.UserID, s.Amount, s.Category, s.Note))
+	}
+	if err := models.ValidateSplits(transaction.Amount, splits); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.splitRepo.Replace(transaction.ID, splits); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save splits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"splits": splits})
+}
+
+// DeleteSplits removes all splits from a transaction
+func (h *TransactionHandler) DeleteSplits(c *gin.Context) {
+	transaction, ok := loadUserTransaction(c, h.transactionRepo)
+	if !ok {
+		return
+	}
+
+	if err := h.splitRepo.DeleteByTransactionID(transaction.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete splits"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetLinks returns the audit trail of pending transactions reconciled into a posted transaction
+func (h *TransactionHandler) GetLinks(c *gin.Context) {
+	transaction, ok := loadUserTransaction(c, h.transactionRepo)
+	if !ok {
+		return
+	}
+
+	links, err := h.transactionRepo.GetLinksByTransactionID(transaction.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transaction links"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"links": links})
+}
+
+// loadUserTransaction fetches the transaction named by the :id path parameter and checks
+// that it belongs to the authenticated user. On failure an error response is written and
+// ok is false.
+func loadUserTransaction(c *gin.Context, transactionRepo *db.TransactionRepository) (*models.Transaction, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return nil, false
+	}
+	id, ok := pathUUID(c, "id")
+	if !ok {
+		return nil, false
+	}
+
+	transaction, err := transactionRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transaction"})
+		return nil, false
+	}
+	if transaction == nil || transaction.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return nil, false
+	}
+	return transaction, true
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/davidwang/go-finance-api/go-finance-api/transfers"
+	"github.com/gin-gonic/gin"
+)
+
+// maxTransferWindowDays bounds the window_days parameter of DetectTransfers
+const maxTransferWindowDays = 14
+
+// TransferHandler handles transfers between a user's own accounts
+type TransferHandler struct {
+	transferRepo *db.TransferRepository
+	detector     *transfers.Detector
+}
+
+// NewTransferHandler creates a new TransferHandler
+func NewTransferHandler(transferRepo *db.TransferRepository) *TransferHandler {
+	return &TransferHandler{
+		transferRepo: transferRepo,
+		detector:     transfers.NewDetector(transferRepo),
+	}
+}
+
+// ListTransfers returns the user's transfer pairs, including unlinked ones
+func (h *TransferHandler) ListTransfers(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	pairs, err := h.transferRepo.GetByUserID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transfers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transfers": pairs})
+}
+
+// DetectTransfers pairs the user's recent transactions into transfers. The optional
+// window_days query parameter sets how many days apart the two sides may post.
+func (h *TransferHandler) DetectTransfers(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	windowDays := transfers.DefaultWindowDays
+	if value := c.Query("window_days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 || parsed > maxTransferWindowDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "window_days must be between 0 and 14"})
+			return
+		}
+		windowDays = parsed
+	}
+
+	pairs, err := h.detector.DetectForUser(userID, windowDays)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to detect transfers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"count": len(pairs), "transfers": pairs})
+}
+
+// ConfirmTransfer marks a transfer pair as confirmed
+func (h *TransferHandler) ConfirmTransfer(c *gin.Context) {
+	pair, ok := h.loadTransfer(c)
+	if !ok {
+		return
+	}
+	if pair.Status == models.TransferStatusRejected {
+		c.JSON(http.StatusConflict, gin.H{"error": "Transfer has been unlinked"})
+		return
+	}
+
+	if err := h.transferRepo.Confirm(pair); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm transfer"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pair)
+}
+
+// UnlinkTransfer splits a transfer pair back into two ordinary transactions
+func (h *TransferHandler) UnlinkTransfer(c *gin.Context) {
+	pair, ok := h.loadTransfer(c)
+	if !ok {
+		return
+	}
+
+	if pair.Status != models.TransferStatusRejected {
+		if err := h.transferRepo.Unlink(pair); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink transfer"})
+			return
+		}
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// loadTransfer fetches the transfer pair named by the :id path parameter and checks that
+// it belongs to the authenticated user. On failure an error response is written and ok
+// is false.
+func (h *TransferHandler) loadTransfer(c *gin.Context) (*models.TransferPair, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return nil, false
+	}
+	id, ok := pathUUID(c, "id")
+	if !ok {
+		return nil, false
+	}
+
+	pair, err := h.transferRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transfer"})
+		return nil, false
+	}
+	if pair == nil || pair.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transfer not found"})
+		return nil, false
+	}
+	return pair, true
+}
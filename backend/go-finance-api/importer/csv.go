@@ -0,0 +1,175 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Amount sign conventions for CSV amount columns
+const (
+	SignInflowPositive  = "inflow_positive"  // Deposits are positive; most bank exports
+	SignOutflowPositive = "outflow_positive" // Purchases are positive, as in Plaid
+)
+
+// CSVMapping describes the layout of a CSV export. Columns are named by their header,
+// matched case-insensitively. Amounts come either from a single signed amount column or
+// from separate unsigned debit and credit columns.
+type CSVMapping struct {
+	DateColumn     string `json:"date_column"`
+	NameColumn     string `json:"name_column"`
+	AmountColumn   string `json:"amount_column,omitempty"`
+	DebitColumn    string `json:"debit_column,omitempty"`  // Money out
+	CreditColumn   string `json:"credit_column,omitempty"` // Money in
+	MerchantColumn string `json:"merchant_column,omitempty"`
+	MemoColumn     string `json:"memo_column,omitempty"`
+	CategoryColumn string `json:"category_column,omitempty"`
+	DateFormat     string `json:"date_format,omitempty"` // Go reference layout, default 2006-01-02
+	AmountSign     string `json:"amount_sign,omitempty"` // Default SignInflowPositive
+	DecimalComma   bool   `json:"decimal_comma,omitempty"`
+	Delimiter      string `json:"delimiter,omitempty"` // Default ","
+}
+
+// validate checks that the mapping names the required columns
+func (m CSVMapping) validate() error {
+	if m.DateColumn == "" || m.NameColumn == "" {
+		return errors.New("date_column and name_column are required")
+	}
+	if m.AmountColumn == "" && m.DebitColumn == "" && m.CreditColumn == "" {
+		return errors.New("amount_column or debit_column/credit_column is required")
+	}
+	switch m.AmountSign {
+	case "", SignInflowPositive, SignOutflowPositive:
+	default:
+		return fmt.Errorf("invalid amount_sign %q", m.AmountSign)
+	}
+	if utf8.RuneCountInString(m.Delimiter) > 1 {
+		return errors.New("delimiter must be a single character")
+	}
+	return nil
+}
+
+// ParseCSV parses a CSV export with a header row using the given column mapping
+func ParseCSV(r io.Reader, mapping CSVMapping) ([]Record, error) {
+	if err := mapping.validate(); err != nil {
+		return nil, err
+	}
+	dateFormat := mapping.DateFormat
+	if dateFormat == "" {
+		dateFormat = "2006-01-02"
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if mapping.Delimiter != "" {
+		reader.Comma, _ = utf8.DecodeRuneInString(mapping.Delimiter)
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	index := func(name string) (int, error) {
+		if name == "" {
+			return -1, nil
+		}
+		i, ok := columns[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return -1, fmt.Errorf("column %q not found", name)
+		}
+		return i, nil
+	}
+
+	var dateCol, nameCol, amountCol, debitCol, creditCol, merchantCol, memoCol, categoryCol int
+	for _, c := range []struct {
+		name string
+		dest *int
+	}{
+		{mapping.DateColumn, &dateCol},
+		{mapping.NameColumn, &nameCol},
+		{mapping.AmountColumn, &amountCol},
+		{mapping.DebitColumn, &debitCol},
+		{mapping.CreditColumn, &creditCol},
+		{mapping.MerchantColumn, &merchantCol},
+		{mapping.MemoColumn, &memoCol},
+		{mapping.CategoryColumn, &categoryCol},
+	} {
+		if *c.dest, err = index(c.name); err != nil {
+			return nil, err
+		}
+	}
+
+	var records []Record
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		field := func(i int) string {
+			if i < 0 || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+		if strings.Join(row, "") == "" {
+			continue // Blank line
+		}
+
+		date, err := time.Parse(dateFormat, field(dateCol))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid date %q", line, field(dateCol))
+		}
+
+		var amount float64
+		if amountCol >= 0 {
+			value, err := parseAmount(field(amountCol), mapping.DecimalComma)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			amount = value
+			if mapping.AmountSign != SignOutflowPositive {
+				amount = -amount
+			}
+		} else {
+			for _, c := range []struct {
+				col  int
+				sign float64
+			}{{debitCol, 1}, {creditCol, -1}} {
+				if field(c.col) == "" {
+					continue
+				}
+				value, err := parseAmount(field(c.col), mapping.DecimalComma)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", line, err)
+				}
+				if value < 0 {
+					value = -value
+				}
+				amount += c.sign * value
+			}
+		}
+
+		records = append(records, Record{
+			Date:     date,
+			Amount:   amount,
+			Name:     field(nameCol),
+			Merchant: field(merchantCol),
+			Memo:     field(memoCol),
+			Category: field(categoryCol),
+		})
+	}
+	return records, nil
+}
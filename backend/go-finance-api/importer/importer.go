@@ -0,0 +1,138 @@
+// Package importer parses bank statement exports (CSV, OFX/QFX and QIF) into records that
+// can be imported into an account. Every record gets a stable hash so that importing the
+// same or an overlapping statement twice doesn't create duplicates.
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+)
+
+// Supported statement formats
+const (
+	FormatCSV = "csv"
+	FormatOFX = "ofx"
+	FormatQFX = "qfx" // Quicken's OFX variant, parsed as OFX
+	FormatQIF = "qif"
+)
+
+// Record is a single transaction parsed from a statement
+type Record struct {
+	Date     time.Time `json:"date"`
+	Amount   float64   `json:"amount"` // Plaid sign convention (positive = outflow)
+	Name     string    `json:"name"`
+	Merchant string    `json:"merchant_name,omitempty"`
+	Memo     string    `json:"memo,omitempty"`
+	Category string    `json:"category,omitempty"`
+	Currency string    `json:"currency,omitempty"`
+	SourceID string    `json:"source_id,omitempty"` // The bank's own transaction ID, such as an OFX FITID
+	Hash     string    `json:"hash"`
+}
+
+// Options configures format-specific parsing
+type Options struct {
+	CSV          CSVMapping `json:"csv"`
+	QIFDateOrder string     `json:"qif_date_order"` // "mdy" (default) or "dmy"
+}
+
+// Parse reads a statement in the given format and returns its records with hashes assigned
+func Parse(r io.Reader, format string, opts Options) ([]Record, error) {
+	var records []Record
+	var err error
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		records, err = ParseCSV(r, opts.CSV)
+	case FormatOFX, FormatQFX:
+		records, err = ParseOFX(r)
+	case FormatQIF:
+		records, err = ParseQIF(r, opts.QIFDateOrder)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("no transactions found")
+	}
+
+	AssignHashes(records)
+	return records, nil
+}
+
+// AssignHashes sets a stable hash on every record. Records carrying the bank's own ID are
+// identified by it; others by date, amount and name. Identical records in one statement,
+// such as two equal coffees on the same day, are told apart by their order, so
+// re-importing an overlapping statement that covers the same whole days matches them up.
+func AssignHashes(records []Record) {
+	occurrences := make(map[string]int)
+	for i := range records {
+		record := &records[i]
+		var key string
+		if record.SourceID != "" {
+			key = "id\x1f" + record.SourceID
+		} else {
+			key = strings.Join([]string{
+				record.Date.Format("2006-01-02"),
+				strconv.FormatInt(models.ToMinorUnits(record.Amount), 10),
+				strings.ToLower(strings.Join(strings.Fields(record.Name), " ")),
+			}, "\x1f")
+		}
+		occurrences[key]++
+
+		sum := sha256.Sum256([]byte(key + "\x1f" + strconv.Itoa(occurrences[key])))
+		record.Hash = hex.EncodeToString(sum[:])
+	}
+}
+
+// parseAmount parses a formatted amount such as "1,234.56", "-$12.00", "(12.00)" or
+// "12.00-". With decimalComma, "1.234,56" is read as 1234.56.
+func parseAmount(value string, decimalComma bool) (float64, error) {
+	s := strings.TrimSpace(value)
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = s[1 : len(s)-1]
+	}
+
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case '$', '€', '£', '¥', ' ', '\u00a0':
+			return -1
+		}
+		return r
+	}, s)
+	if strings.HasSuffix(s, "-") {
+		negative = !negative
+		s = strings.TrimSuffix(s, "-")
+	}
+	if strings.HasPrefix(s, "-") {
+		negative = !negative
+		s = strings.TrimPrefix(s, "-")
+	}
+	s = strings.TrimPrefix(s, "+")
+
+	if decimalComma {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.ReplaceAll(s, ",", ".")
+	} else {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+
+	amount, err := strconv.ParseFloat(s, 64)
+	if err != nil || s == "" {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
@@ -0,0 +1,143 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseCSVSignedAmount tests a bank export with a single signed amount column
+func TestParseCSVSignedAmount(t *testing.T) {
+	input := "Posted Date,Description,Amount,Category\n" +
+		"01/15/2024,BLUE BOTTLE COFFEE,-4.50,Coffee\n" +
+		"01/16/2024,PAYROLL,\"2,500.00\",Income\n"
+
+	records, err := ParseCSV(strings.NewReader(input), CSVMapping{
+		DateColumn:     "posted date",
+		NameColumn:     "Description",
+		AmountColumn:   "Amount",
+		CategoryColumn: "Category",
+		DateFormat:     "01/02/2006",
+	})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), records[0].Date)
+	assert.Equal(t, "BLUE BOTTLE COFFEE", records[0].Name)
+	assert.Equal(t, 4.50, records[0].Amount)
+	assert.Equal(t, "Coffee", records[0].Category)
+	assert.Equal(t, -2500.00, records[1].Amount)
+}
+
+// TestParseCSVDebitCredit tests separate debit and credit columns with European formatting
+func TestParseCSVDebitCredit(t *testing.T) {
+	input := "Datum;Text;Soll;Haben\n" +
+		"15.01.2024;Miete;1.200,00;\n" +
+		"16.01.2024;Gehalt;;3.000,50\n"
+
+	records, err := ParseCSV(strings.NewReader(input), CSVMapping{
+		DateColumn:   "Datum",
+		NameColumn:   "Text",
+		DebitColumn:  "Soll",
+		CreditColumn: "Haben",
+		DateFormat:   "02.01.2006",
+		DecimalComma: true,
+		Delimiter:    ";",
+	})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, 1200.00, records[0].Amount)
+	assert.Equal(t, -3000.50, records[1].Amount)
+}
+
+// TestParseCSVRejectsBadMapping tests mapping validation
+func TestParseCSVRejectsBadMapping(t *testing.T) {
+	_, err := ParseCSV(strings.NewReader("Date,Name\n"), CSVMapping{DateColumn: "Date", NameColumn: "Name"})
+	assert.Error(t, err)
+
+	_, err = ParseCSV(strings.NewReader("Date,Name,Amount\n"), CSVMapping{DateColumn: "Date", NameColumn: "Payee", AmountColumn: "Amount"})
+	assert.Error(t, err)
+}
+
+// TestParseOFX tests an OFX 1.x SGML statement
+func TestParseOFX(t *testing.T) {
+	input := `OFXHEADER:100
+DATA:OFXSGML
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>USD
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240115120000[-5:EST]
+<TRNAMT>-42.17
+<FITID>2024011501
+<NAME>WHOLE FOODS &amp; CO
+<MEMO>POS PURCHASE
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20240116
+<TRNAMT>1000.00
+<FITID>2024011602
+<MEMO>TRANSFER IN
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>`
+
+	records, err := ParseOFX(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), records[0].Date)
+	assert.Equal(t, 42.17, records[0].Amount)
+	assert.Equal(t, "WHOLE FOODS & CO", records[0].Name)
+	assert.Equal(t, "2024011501", records[0].SourceID)
+	assert.Equal(t, "USD", records[0].Currency)
+
+	assert.Equal(t, -1000.00, records[1].Amount)
+	assert.Equal(t, "TRANSFER IN", records[1].Name)
+}
+
+// TestParseQIF tests a QIF bank statement with both date orders
+func TestParseQIF(t *testing.T) {
+	input := "!Type:Bank\n" +
+		"D1/15'24\nT-4.50\nPBlue Bottle\nLDining:Coffee\n^\n" +
+		"D01/16/2024\nT2,500.00\nPPayroll\n^\n" +
+		"D01/17/2024\nT-200.00\nPTo savings\nL[Savings]\n^\n"
+
+	records, err := ParseQIF(strings.NewReader(input), "")
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	assert.Equal(t, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), records[0].Date)
+	assert.Equal(t, 4.50, records[0].Amount)
+	assert.Equal(t, "Dining:Coffee", records[0].Category)
+	assert.Equal(t, -2500.00, records[1].Amount)
+	assert.Empty(t, records[2].Category)
+
+	records, err = ParseQIF(strings.NewReader("D15/01/2024\nT-1\nPX\n^\n"), "dmy")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), records[0].Date)
+}
+
+// TestAssignHashes tests that hashes are stable and tell identical records apart
+func TestAssignHashes(t *testing.T) {
+	coffee := Record{Date: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), Amount: 4.5, Name: "Blue  Bottle"}
+	first := []Record{coffee, coffee, {Date: time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC), Amount: 4.5, Name: "Blue Bottle"}}
+	second := []Record{coffee, {Date: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), Amount: 4.5, Name: "blue bottle"}}
+
+	AssignHashes(first)
+	AssignHashes(second)
+
+	assert.NotEqual(t, first[0].Hash, first[1].Hash)
+	assert.NotEqual(t, first[0].Hash, first[2].Hash)
+	assert.Equal(t, first[0].Hash, second[0].Hash)
+	assert.Equal(t, first[1].Hash, second[1].Hash)
+	assert.Len(t, first[0].Hash, 64)
+}
@@ -0,0 +1,98 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// ParseOFX parses an OFX or QFX statement. Both OFX 1.x (SGML, where leaf elements have
+// no closing tags) and OFX 2.x (XML) are accepted. OFX amounts are signed from the
+// account holder's view, so they are negated into the Plaid convention.
+func ParseOFX(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	body := string(data)
+	start := strings.Index(strings.ToUpper(body), "<OFX>")
+	if start < 0 {
+		return nil, errors.New("not an OFX file")
+	}
+	body = body[start:]
+
+	var records []Record
+	var current *Record
+	var currency string
+	for len(body) > 0 {
+		open := strings.IndexByte(body, '<')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(body[open:], '>')
+		if end < 0 {
+			break
+		}
+		tag := strings.ToUpper(strings.TrimSpace(body[open+1 : open+end]))
+		body = body[open+end+1:]
+
+		next := strings.IndexByte(body, '<')
+		if next < 0 {
+			next = len(body)
+		}
+		value := strings.TrimSpace(html.UnescapeString(body[:next]))
+
+		switch tag {
+		case "STMTTRN":
+			current = &Record{}
+		case "/STMTTRN":
+			if current != nil {
+				if current.Name == "" {
+					current.Name = current.Memo
+				}
+				records = append(records, *current)
+				current = nil
+			}
+		case "CURDEF":
+			currency = value
+		}
+		if current == nil || value == "" {
+			continue
+		}
+
+		switch tag {
+		case "DTPOSTED":
+			if len(value) < 8 {
+				return nil, fmt.Errorf("invalid DTPOSTED %q", value)
+			}
+			date, err := time.Parse("20060102", value[:8])
+			if err != nil {
+				return nil, fmt.Errorf("invalid DTPOSTED %q", value)
+			}
+			current.Date = date
+		case "TRNAMT":
+			amount, err := parseAmount(value, false)
+			if err != nil {
+				return nil, err
+			}
+			current.Amount = -amount
+		case "NAME":
+			current.Name = value
+		case "MEMO":
+			current.Memo = value
+		case "FITID":
+			current.SourceID = value
+		}
+	}
+
+	for i := range records {
+		if records[i].Date.IsZero() {
+			return nil, fmt.Errorf("transaction %d has no DTPOSTED", i+1)
+		}
+		records[i].Currency = currency
+	}
+	return records, nil
+}
@@ -0,0 +1,127 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseQIF parses a Quicken Interchange Format statement. QIF dates carry no order, so
+// dateOrder says whether they are month-first ("mdy", the default) or day-first ("dmy").
+// QIF amounts are signed from the account holder's view and are negated into the Plaid
+// convention. Split lines are ignored in favour of the transaction total.
+func ParseQIF(r io.Reader, dateOrder string) ([]Record, error) {
+	if dateOrder == "" {
+		dateOrder = "mdy"
+	}
+	if dateOrder != "mdy" && dateOrder != "dmy" {
+		return nil, fmt.Errorf("invalid date order %q", dateOrder)
+	}
+
+	var records []Record
+	var current Record
+	hasData := false
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text == "" || strings.HasPrefix(text, "!") {
+			continue
+		}
+		code, value := text[0], strings.TrimSpace(text[1:])
+
+		switch code {
+		case '^':
+			if hasData {
+				if current.Date.IsZero() {
+					return nil, fmt.Errorf("line %d: transaction has no date", line)
+				}
+				records = append(records, current)
+			}
+			current, hasData = Record{}, false
+			continue
+		case 'D':
+			date, err := parseQIFDate(value, dateOrder)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			current.Date = date
+		case 'T', 'U':
+			amount, err := parseAmount(value, false)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			current.Amount = -amount
+		case 'P':
+			current.Name = value
+		case 'M':
+			current.Memo = value
+		case 'L':
+			// Transfers are written as [Account Name]
+			if !strings.HasPrefix(value, "[") {
+				current.Category = value
+			}
+		default:
+			continue
+		}
+		hasData = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if hasData && !current.Date.IsZero() {
+		records = append(records, current) // Missing final ^
+	}
+
+	for i := range records {
+		if records[i].Name == "" {
+			records[i].Name = records[i].Memo
+		}
+	}
+	return records, nil
+}
+
+// parseQIFDate parses dates such as 1/15/2024, 01/15'24, 15.01.2024 or 2024-01-15
+func parseQIFDate(value, dateOrder string) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+
+	parts := strings.FieldsFunc(value, func(r rune) bool {
+		return r == '/' || r == '\'' || r == '-' || r == '.'
+	})
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	nums := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q", value)
+		}
+		nums[i] = n
+	}
+
+	month, day, year := nums[0], nums[1], nums[2]
+	if dateOrder == "dmy" {
+		month, day = day, month
+	}
+	if year < 100 {
+		// Quicken's two-digit years pivot at 1970
+		if year < 70 {
+			year += 2000
+		} else {
+			year += 1900
+		}
+	}
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return date, nil
+}
@@ -0,0 +1,128 @@
+package importer
+
+import (
+	"fmt"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/davidwang/go-finance-api/go-finance-api/rules"
+)
+
+// Importer stores parsed statement records in an account
+type Importer struct {
+	repos *db.Repositories
+}
+
+// NewImporter creates a new Importer
+func NewImporter(repos *db.Repositories) *Importer {
+	return &Importer{repos: repos}
+}
+
+// PreviewRecord is a parsed record annotated with whether it was already imported
+type PreviewRecord struct {
+	Record
+	Duplicate bool `json:"duplicate"`
+}
+
+// Result summarizes an import or a dry run
+type Result struct {
+	Total        int             `json:"total"`
+	New          int             `json:"new"`
+	Duplicates   int             `json:"duplicates"`
+	RulesApplied int             `json:"rules_applied"`
+	Records      []PreviewRecord `json:"records,omitempty"` // Dry runs only
+}
+
+// Preview reports which records would be imported into the account without storing them
+func (i *Importer) Preview(account *models.Account, records []Record) (*Result, error) {
+	hashes := make([]string, len(records))
+	for n, record := range records {
+		hashes[n] = record.Hash
+	}
+	existing, err := i.repos.Transaction.GetExistingImportHashes(account.ID, hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Total: len(records)}
+	for _, record := range records {
+		duplicate := existing[record.Hash]
+		if duplicate {
+			result.Duplicates++
+		} else {
+			result.New++
+		}
+		result.Records = append(result.Records, PreviewRecord{Record: record, Duplicate: duplicate})
+	}
+	return result, nil
+}
+
+// Import stores the records in the account, skipping any imported before, and runs the
+// user's rules over the new transactions
+func (i *Importer) Import(account *models.Account, records []Record) (*Result, error) {
+	userRules, err := i.repos.Rule.GetEnabledByUserID(account.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rules: %w", err)
+	}
+	engine, err := rules.NewEngine(userRules)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Total: len(records)}
+	for _, record := range records {
+		transaction := toTransaction(account, record)
+		inserted, err := i.repos.Transaction.CreateImported(transaction)
+		if err != nil {
+			return nil, err
+		}
+		if !inserted {
+			result.Duplicates++
+			continue
+		}
+		result.New++
+
+		outcome := engine.Evaluate(transaction)
+		if !outcome.Matched() {
+			continue
+		}
+		if err := i.repos.Transaction.ApplyRuleActions(transaction, outcome.Actions, false); err != nil {
+			return nil, err
+		}
+		result.RulesApplied++
+	}
+
+	if result.New > 0 {
+		if err := i.repos.Account.RecomputeBalance(account.ID); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// toTransaction converts a record into a Transaction in the account
+func toTransaction(account *models.Account, record Record) *models.Transaction {
+	currency := record.Currency
+	if currency == "" {
+		currency = account.CurrencyCode
+	}
+
+	transaction := models.NewTransaction(
+		account.ID,
+		account.UserID,
+		"",
+		"",
+		nil,
+		record.Name,
+		record.Merchant,
+		record.Amount,
+		currency,
+		record.Date,
+		false,
+		"other",
+	)
+	transaction.UserCategory = record.Category
+	transaction.Notes = record.Memo
+	transaction.ImportHash = record.Hash
+	return transaction
+}
@@ -0,0 +1,39 @@
+// Package jobs runs periodic background work
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Daily runs fn once a day at the given UTC hour in a background goroutine until ctx is
+// cancelled. Errors are logged and the job runs again the next day.
+func Daily(ctx context.Context, name string, hourUTC int, fn func(ctx context.Context) error) {
+	go func() {
+		for {
+			wait := time.Until(nextRun(time.Now().UTC(), hourUTC))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+
+			started := time.Now()
+			if err := fn(ctx); err != nil {
+				log.Printf("Job %q failed: %v", name, err)
+				continue
+			}
+			log.Printf("Job %q finished in %s", name, time.Since(started).Round(time.Millisecond))
+		}
+	}()
+}
+
+// nextRun returns the first time after now at the given UTC hour
+func nextRun(now time.Time, hourUTC int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hourUTC, 0, 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
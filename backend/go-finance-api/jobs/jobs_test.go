@@ -0,0 +1,17 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNextRun tests scheduling later today or tomorrow
+func TestNextRun(t *testing.T) {
+	now := time.Date(2024, 3, 10, 5, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2024, 3, 10, 6, 0, 0, 0, time.UTC), nextRun(now, 6))
+	assert.Equal(t, time.Date(2024, 3, 11, 5, 0, 0, 0, time.UTC), nextRun(now, 5))
+	assert.Equal(t, time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), nextRun(now, 0))
+}
@@ -0,0 +1,176 @@
+package llm
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// HashDimensions is the size of the vectors returned by HashEmbed
+const HashDimensions = 256
+
+// FakeProvider is a deterministic Provider for tests and local development. It replies
+// with scripted responses in order, then echoes the last user message once the script
+// runs out. Embeddings come from HashEmbed.
+type FakeProvider struct {
+	mu       sync.Mutex
+	script   []FakeStep
+	requests []ChatRequest
+}
+
+// FakeStep is one scripted reply: a response, or an error to return instead
+type FakeStep struct {
+	Response *ChatResponse
+	Err      error
+}
+
+// NewFakeProvider creates a FakeProvider that replies with the given responses in order
+func NewFakeProvider(responses ...*ChatResponse) *FakeProvider {
+	p := &FakeProvider{}
+	for _, response := range responses {
+		p.script = append(p.script, FakeStep{Response: response})
+	}
+	return p
+}
+
+// Script appends steps to the provider's script
+func (p *FakeProvider) Script(steps ...FakeStep) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.script = append(p.script, steps...)
+}
+
+// Requests returns the chat requests received so far
+func (p *FakeProvider) Requests() []ChatRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]ChatRequest(nil), p.requests...)
+}
+
+// Chat returns the next scripted reply
+func (p *FakeProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.requests = append(p.requests, req)
+	var step FakeStep
+	if len(p.script) > 0 {
+		step, p.script = p.script[0], p.script[1:]
+	}
+	p.mu.Unlock()
+
+	if step.Err != nil {
+		return nil, step.Err
+	}
+	response := step.Response
+	if response == nil {
+		response = &ChatResponse{Message: Message{Content: "You said: " + lastUserMessage(req)}}
+	}
+
+	result := *response
+	result.Message.Role = RoleAssistant
+	if result.Model == "" {
+		result.Model = "fake"
+	}
+	if result.FinishReason == "" {
+		result.FinishReason = "stop"
+		if len(result.Message.ToolCalls) > 0 {
+			result.FinishReason = "tool_calls"
+		}
+	}
+	if result.Usage.Total() == 0 {
+		result.Usage = estimateUsage(req, result.Message)
+	}
+	return &result, nil
+}
+
+// ChatStream returns the next scripted reply, passing its content to onDelta one word at
+// a time. If onDelta fails, the words sent so far are returned with the error.
+func (p *FakeProvider) ChatStream(ctx context.Context, req ChatRequest, onDelta func(delta string) error) (*ChatResponse, error) {
+	response, err := p.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var sent strings.Builder
+	for _, delta := range splitWords(response.Message.Content) {
+		if err := onDelta(delta); err != nil {
+			partial := *response
+			partial.Message.Content = sent.String()
+			partial.Message.ToolCalls = nil
+			partial.Usage.CompletionTokens = EstimateTokens(partial.Message.Content)
+			return &partial, err
+		}
+		sent.WriteString(delta)
+	}
+	return response, nil
+}
+
+// Embed returns HashEmbed vectors for each text
+func (p *FakeProvider) Embed(ctx context.Context, texts []string) (*EmbedResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	result := &EmbedResponse{Model: "hash", Vectors: make([][]float32, len(texts))}
+	for i, text := range texts {
+		result.Vectors[i] = HashEmbed(text)
+		result.Usage.PromptTokens += EstimateTokens(text)
+	}
+	return result, nil
+}
+
+// HashEmbed maps text to a unit vector by feature hashing its lowercased words, so texts
+// sharing words have similar vectors. It needs no model and is stable across runs.
+func HashEmbed(text string) []float32 {
+	vector := make([]float32, HashDimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		sum := h.Sum64()
+		if sum>>63 == 1 {
+			vector[sum%HashDimensions]--
+		} else {
+			vector[sum%HashDimensions]++
+		}
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vector {
+			vector[i] *= scale
+		}
+	}
+	return vector
+}
+
+// lastUserMessage returns the content of the last user message in a request
+func lastUserMessage(req ChatRequest) string {
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == RoleUser {
+			return req.Messages[i].Content
+		}
+	}
+	return ""
+}
+
+// splitWords splits text after each space, so joining the pieces restores the text
+func splitWords(text string) []string {
+	var pieces []string
+	for _, piece := range strings.SplitAfter(text, " ") {
+		if piece != "" {
+			pieces = append(pieces, piece)
+		}
+	}
+	return pieces
+}
@@ -0,0 +1,147 @@
+// Package llm provides a common interface to large language model providers for chat,
+// tool calling, streaming and embeddings, with per-user token accounting and cost limits
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotConfigured is returned by New when no provider is configured
+var ErrNotConfigured = errors.New("no LLM provider configured")
+
+// Message roles
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// Message is one turn of a chat
+type Message struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`   // Tools the assistant wants to call
+	ToolCallID string     `json:"tool_call_id,omitempty"` // The call a tool message answers
+}
+
+// Tool describes a function the model may call
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"` // JSON Schema of the arguments object
+}
+
+// ToolCall is a model's request to call a tool
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"` // JSON object
+}
+
+// ChatRequest is a chat completion request. An empty Model uses the provider's default.
+type ChatRequest struct {
+	Model       string
+	Messages    []Message
+	Tools       []Tool
+	Temperature *float64
+	MaxTokens   int
+}
+
+// Usage counts the tokens used by a request
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// Total returns the number of prompt and completion tokens
+func (u Usage) Total() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// ChatResponse is the assistant's reply to a chat request
+type ChatResponse struct {
+	Model        string  `json:"model"`
+	Message      Message `json:"message"`
+	FinishReason string  `json:"finish_reason"`
+	Usage        Usage   `json:"usage"`
+}
+
+// EmbedResponse holds one embedding per input text
+type EmbedResponse struct {
+	Model   string      `json:"model"`
+	Vectors [][]float32 `json:"vectors"`
+	Usage   Usage       `json:"usage"`
+}
+
+// Provider is a large language model backend
+type Provider interface {
+	// Chat returns the assistant's reply to the request's messages
+	Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error)
+	// ChatStream is like Chat but passes each piece of reply text to onDelta as it is
+	// generated. Streaming stops at the first error returned by onDelta. Once the
+	// model has started replying, an error comes with the partial response, whose
+	// usage must still be paid for.
+	ChatStream(ctx context.Context, req ChatRequest, onDelta func(delta string) error) (*ChatResponse, error)
+	// Embed returns an embedding vector for each text
+	Embed(ctx context.Context, texts []string) (*EmbedResponse, error)
+}
+
+// Config holds the LLM configuration
+type Config struct {
+	Backend        string // "openai" for any OpenAI-compatible endpoint, "fake", or empty to disable
+	BaseURL        string // e.g. https://api.openai.com/v1 or http://localhost:11434/v1 for Ollama
+	APIKey         string
+	ChatModel      string
+	EmbeddingModel string
+	Timeout        time.Duration
+	Pricing        Pricing
+	MonthlyLimit   float64 // Spend allowed per user per calendar month; 0 means unlimited
+}
+
+// New creates the Provider selected by the configuration
+func New(cfg Config) (Provider, error) {
+	switch cfg.Backend {
+	case "":
+		return nil, ErrNotConfigured
+	case "openai":
+		return NewOpenAIProvider(cfg.BaseURL, cfg.APIKey, cfg.ChatModel, cfg.EmbeddingModel, cfg.Timeout), nil
+	case "fake":
+		return NewFakeProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM backend %q", cfg.Backend)
+	}
+}
+
+// EstimateTokens approximates the number of tokens in text, for providers that do not
+// report usage. English text averages about four characters per token.
+func EstimateTokens(text string) int {
+	n := len([]rune(text))
+	if n == 0 {
+		return 0
+	}
+	return (n + 3) / 4
+}
+
+// estimateUsage approximates the usage of a chat request and its reply
+func estimateUsage(req ChatRequest, reply Message) Usage {
+	var prompt int
+	for _, message := range req.Messages {
+		prompt += EstimateTokens(message.Content) + 4 // Role and separators
+		for _, call := range message.ToolCalls {
+			prompt += EstimateTokens(call.Name) + EstimateTokens(call.Arguments)
+		}
+	}
+	for _, tool := range req.Tools {
+		prompt += EstimateTokens(tool.Name) + EstimateTokens(tool.Description) + EstimateTokens(string(tool.Parameters))
+	}
+	completion := EstimateTokens(reply.Content)
+	for _, call := range reply.ToolCalls {
+		completion += EstimateTokens(call.Name) + EstimateTokens(call.Arguments)
+	}
+	return Usage{PromptTokens: prompt, CompletionTokens: completion}
+}
@@ -0,0 +1,254 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOpenAIChat tests a chat request with tools against an OpenAI-compatible server
+func TestOpenAIChat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "llama3", body["model"])
+		tools := body["tools"].([]interface{})
+		assert.Equal(t, "function", tools[0].(map[string]interface{})["type"])
+
+		fmt.Fprint(w, `{"model":"llama3","choices":[{"message":{"role":"assistant","content":null,
+			"tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_balance","arguments":"{\"account\":\"checking\"}"}}]},
+			"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":42,"completion_tokens":7}}`)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider(server.URL+"/v1/", "secret", "llama3", "", time.Second)
+	resp, err := provider.Chat(context.Background(), ChatRequest{
+		Messages: []Message{{Role: RoleUser, Content: "What is my checking balance?"}},
+		Tools:    []Tool{{Name: "get_balance", Description: "Get a balance", Parameters: json.RawMessage(`{"type":"object"}`)}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "tool_calls", resp.FinishReason)
+	require.Len(t, resp.Message.ToolCalls, 1)
+	assert.Equal(t, ToolCall{ID: "call_1", Name: "get_balance", Arguments: `{"account":"checking"}`}, resp.Message.ToolCalls[0])
+	assert.Equal(t, Usage{PromptTokens: 42, CompletionTokens: 7}, resp.Usage)
+}
+
+// TestOpenAIChatStream tests assembling streamed content and tool call fragments
+func TestOpenAIChatStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunks := []string{
+			`{"model":"m","choices":[{"delta":{"role":"assistant","content":"Hello"}}]}`,
+			`{"choices":[{"delta":{"content":" there"}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"c1","function":{"name":"search","arguments":"{\"q\":"}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"rent\"}"}}]},"finish_reason":"tool_calls"}]}`,
+		}
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	var deltas []string
+	provider := NewOpenAIProvider(server.URL, "", "m", "", time.Second)
+	resp, err := provider.ChatStream(context.Background(), ChatRequest{
+		Messages: []Message{{Role: RoleUser, Content: "hi"}},
+	}, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Hello", " there"}, deltas)
+	assert.Equal(t, "Hello there", resp.Message.Content)
+	assert.Equal(t, []ToolCall{{ID: "c1", Name: "search", Arguments: `{"q":"rent"}`}}, resp.Message.ToolCalls)
+	assert.Equal(t, "tool_calls", resp.FinishReason)
+	assert.Greater(t, resp.Usage.Total(), 0) // Estimated without a usage chunk
+}
+
+// TestOpenAIChatStreamInterrupted tests that a stream stopped by onDelta returns the
+// reply so far with its usage
+func TestOpenAIChatStreamInterrupted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"model\":\"m\",\"choices\":[{\"delta\":{\"content\":\"Hello\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\" there\"}}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	gone := errors.New("client disconnected")
+	provider := NewOpenAIProvider(server.URL, "", "m", "", time.Second)
+	resp, err := provider.ChatStream(context.Background(), ChatRequest{
+		Messages: []Message{{Role: RoleUser, Content: "hi"}},
+	}, func(delta string) error {
+		return gone
+	})
+	assert.Equal(t, gone, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, "Hello", resp.Message.Content)
+	assert.Greater(t, resp.Usage.Total(), 0)
+}
+
+// TestOpenAIChatStreamOutlastsTimeout tests that a stream may run longer than the
+// request timeout once it has started
+func TestOpenAIChatStreamOutlastsTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, word := range []string{"slow", " and", " steady"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", word)
+			w.(http.Flusher).Flush()
+			time.Sleep(60 * time.Millisecond)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider(server.URL, "", "m", "", 100*time.Millisecond)
+	resp, err := provider.ChatStream(context.Background(), ChatRequest{}, func(delta string) error {
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "slow and steady", resp.Message.Content)
+}
+
+// TestOpenAIErrorStatus tests that error responses are reported
+func TestOpenAIErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := NewOpenAIProvider(server.URL, "", "m", "e", time.Second).Embed(context.Background(), []string{"x"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "model not found")
+}
+
+// TestFakeProvider tests scripted replies, streaming and the echo fallback
+func TestFakeProvider(t *testing.T) {
+	boom := errors.New("boom")
+	provider := NewFakeProvider(&ChatResponse{Message: Message{Content: "first reply"}})
+	provider.Script(FakeStep{Err: boom})
+
+	var streamed strings.Builder
+	resp, err := provider.ChatStream(context.Background(), ChatRequest{Messages: []Message{{Role: RoleUser, Content: "one"}}}, func(delta string) error {
+		streamed.WriteString(delta)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "first reply", streamed.String())
+	assert.Equal(t, RoleAssistant, resp.Message.Role)
+	assert.Greater(t, resp.Usage.PromptTokens, 0)
+
+	_, err = provider.Chat(context.Background(), ChatRequest{})
+	assert.Equal(t, boom, err)
+
+	resp, err = provider.Chat(context.Background(), ChatRequest{Messages: []Message{{Role: RoleUser, Content: "ping"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "You said: ping", resp.Message.Content)
+	assert.Len(t, provider.Requests(), 3)
+}
+
+// TestHashEmbed tests that hash embeddings are normalized and reflect shared words
+func TestHashEmbed(t *testing.T) {
+	coffee := HashEmbed("Blue Bottle Coffee")
+	assert.Len(t, coffee, HashDimensions)
+	assert.InDelta(t, 1.0, dot(coffee, coffee), 1e-5)
+	assert.Equal(t, coffee, HashEmbed("blue bottle coffee!"))
+	assert.Greater(t, dot(coffee, HashEmbed("coffee")), dot(coffee, HashEmbed("airline tickets")))
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+// memoryUsageStore is an in-memory UsageStore
+type memoryUsageStore struct {
+	records []*models.LLMUsage
+}
+
+func (s *memoryUsageStore) Record(usage *models.LLMUsage) error {
+	s.records = append(s.records, usage)
+	return nil
+}
+
+func (s *memoryUsageStore) CostSince(userID uuid.UUID, since time.Time) (float64, error) {
+	var cost float64
+	for _, record := range s.records {
+		if record.UserID == userID && !record.CreatedAt.Before(since) {
+			cost += record.Cost
+		}
+	}
+	return cost, nil
+}
+
+// TestMeter tests recording usage and enforcing the monthly limit per user
+func TestMeter(t *testing.T) {
+	fake := NewFakeProvider(
+		&ChatResponse{Message: Message{Content: "a"}, Usage: Usage{PromptTokens: 1000000, CompletionTokens: 500000}},
+		&ChatResponse{Message: Message{Content: "b"}, Usage: Usage{PromptTokens: 10, CompletionTokens: 10}},
+	)
+	store := &memoryUsageStore{}
+	meter := NewMeter(fake, store, Pricing{PromptPerMillion: 1, CompletionPerMillion: 2}, 2)
+
+	alice, bob := uuid.New(), uuid.New()
+	_, err := meter.ForUser(alice, "assistant").Chat(context.Background(), ChatRequest{})
+	require.NoError(t, err)
+	require.Len(t, store.records, 1)
+	assert.Equal(t, "assistant", store.records[0].Feature)
+	assert.Equal(t, 2.0, store.records[0].Cost)
+
+	_, err = meter.ForUser(alice, "assistant").Chat(context.Background(), ChatRequest{})
+	assert.Equal(t, ErrCostLimitExceeded, err)
+
+	_, err = meter.ForUser(bob, "summary").Chat(context.Background(), ChatRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, 0.00003, store.records[1].Cost)
+}
+
+// TestMeterInterruptedStream tests that a stream cut short is still charged
+func TestMeterInterruptedStream(t *testing.T) {
+	fake := NewFakeProvider(&ChatResponse{
+		Message: Message{Content: "a long and costly reply"},
+		Usage:   Usage{PromptTokens: 1000000, CompletionTokens: 10},
+	})
+	store := &memoryUsageStore{}
+	meter := NewMeter(fake, store, Pricing{PromptPerMillion: 1, CompletionPerMillion: 2}, 1)
+
+	gone := errors.New("client disconnected")
+	userID := uuid.New()
+	sent := 0
+	_, err := meter.ForUser(userID, "assistant").ChatStream(context.Background(), ChatRequest{}, func(delta string) error {
+		if sent == 2 {
+			return gone
+		}
+		sent++
+		return nil
+	})
+	assert.Equal(t, gone, err)
+	require.Len(t, store.records, 1)
+	assert.Greater(t, store.records[0].Cost, 1.0)
+
+	_, err = meter.ForUser(userID, "assistant").ChatStream(context.Background(), ChatRequest{}, func(string) error { return nil })
+	assert.Equal(t, ErrCostLimitExceeded, err)
+}
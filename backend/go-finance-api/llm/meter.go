@@ -0,0 +1,137 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// ErrCostLimitExceeded is returned when a user has used up this month's LLM budget
+var ErrCostLimitExceeded = errors.New("monthly LLM cost limit reached")
+
+// Pricing is the price of a million tokens. Local models are free.
+type Pricing struct {
+	PromptPerMillion     float64
+	CompletionPerMillion float64
+}
+
+// Cost returns the price of a request's tokens, rounded to a millionth
+func (p Pricing) Cost(usage Usage) float64 {
+	cost := (float64(usage.PromptTokens)*p.PromptPerMillion + float64(usage.CompletionTokens)*p.CompletionPerMillion) / 1e6
+	return math.Round(cost*1e6) / 1e6
+}
+
+// UsageStore records usage and sums what a user has spent
+type UsageStore interface {
+	Record(usage *models.LLMUsage) error
+	CostSince(userID uuid.UUID, since time.Time) (float64, error)
+}
+
+// Meter charges each request to a user, refusing requests once the user's spend this
+// calendar month reaches the limit
+type Meter struct {
+	provider     Provider
+	store        UsageStore
+	pricing      Pricing
+	monthlyLimit float64
+	now          func() time.Time
+}
+
+// NewMeter creates a new Meter. A monthlyLimit of 0 means unlimited.
+func NewMeter(provider Provider, store UsageStore, pricing Pricing, monthlyLimit float64) *Meter {
+	return &Meter{
+		provider:     provider,
+		store:        store,
+		pricing:      pricing,
+		monthlyLimit: monthlyLimit,
+		now:          time.Now,
+	}
+}
+
+// MonthlyLimit returns the spend allowed per user per month, or 0 if unlimited
+func (m *Meter) MonthlyLimit() float64 {
+	return m.monthlyLimit
+}
+
+// MonthStart returns the start of the current month, from which spend is counted
+func (m *Meter) MonthStart() time.Time {
+	now := m.now().UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// ForUser returns a Provider whose requests are charged to userID under feature
+func (m *Meter) ForUser(userID uuid.UUID, feature string) Provider {
+	return &meteredProvider{meter: m, userID: userID, feature: feature}
+}
+
+// Check returns ErrCostLimitExceeded if the user has reached the monthly limit
+func (m *Meter) Check(userID uuid.UUID) error {
+	if m.monthlyLimit <= 0 {
+		return nil
+	}
+	spent, err := m.store.CostSince(userID, m.MonthStart())
+	if err != nil {
+		return err
+	}
+	if spent >= m.monthlyLimit {
+		return ErrCostLimitExceeded
+	}
+	return nil
+}
+
+// record stores the usage of a completed request
+func (m *Meter) record(userID uuid.UUID, feature, model string, usage Usage) error {
+	return m.store.Record(models.NewLLMUsage(userID, feature, model, usage.PromptTokens, usage.CompletionTokens, m.pricing.Cost(usage)))
+}
+
+// meteredProvider is a Provider bound to one user and feature
+type meteredProvider struct {
+	meter   *Meter
+	userID  uuid.UUID
+	feature string
+}
+
+// Chat checks the user's limit, then forwards the request and records its usage
+func (p *meteredProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	if err := p.meter.Check(p.userID); err != nil {
+		return nil, err
+	}
+	resp, err := p.meter.provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp, p.meter.record(p.userID, p.feature, resp.Model, resp.Usage)
+}
+
+// ChatStream checks the user's limit, then forwards the request and records its usage.
+// A stream that fails part way, such as when the client disconnects, is still charged
+// for the reply generated so far.
+func (p *meteredProvider) ChatStream(ctx context.Context, req ChatRequest, onDelta func(delta string) error) (*ChatResponse, error) {
+	if err := p.meter.Check(p.userID); err != nil {
+		return nil, err
+	}
+	resp, err := p.meter.provider.ChatStream(ctx, req, onDelta)
+	if resp == nil {
+		return nil, err
+	}
+	if recordErr := p.meter.record(p.userID, p.feature, resp.Model, resp.Usage); err == nil {
+		err = recordErr
+	}
+	return resp, err
+}
+
+// Embed checks the user's limit, then forwards the request and records its usage
+func (p *meteredProvider) Embed(ctx context.Context, texts []string) (*EmbedResponse, error) {
+	if err := p.meter.Check(p.userID); err != nil {
+		return nil, err
+	}
+	resp, err := p.meter.provider.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	return resp, p.meter.record(p.userID, p.feature, resp.Model, resp.Usage)
+}
@@ -0,0 +1,353 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OpenAIProvider talks to an OpenAI-compatible HTTP API, which includes OpenAI itself and
+// local servers such as llama.cpp, Ollama and vLLM
+type OpenAIProvider struct {
+	baseURL        string
+	apiKey         string
+	chatModel      string
+	embeddingModel string
+	timeout        time.Duration
+	client         *http.Client
+}
+
+// NewOpenAIProvider creates a new OpenAIProvider. apiKey may be empty for local servers.
+// The timeout bounds Chat and Embed requests and the wait for a stream to start; a
+// stream itself runs for as long as the caller's context allows.
+func NewOpenAIProvider(baseURL, apiKey, chatModel, embeddingModel string, timeout time.Duration) *OpenAIProvider {
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = timeout
+	return &OpenAIProvider{
+		baseURL:        strings.TrimRight(baseURL, "/"),
+		apiKey:         apiKey,
+		chatModel:      chatModel,
+		embeddingModel: embeddingModel,
+		timeout:        timeout,
+		client:         &http.Client{Transport: transport},
+	}
+}
+
+// openAIMessage is a chat message in the OpenAI wire format
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    *string          `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIToolCall struct {
+	Index    *int   `json:"index,omitempty"` // Only set on streamed deltas
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAITool struct {
+	Type     string `json:"type"`
+	Function Tool   `json:"function"`
+}
+
+type openAIChatRequest struct {
+	Model         string          `json:"model"`
+	Messages      []openAIMessage `json:"messages"`
+	Tools         []openAITool    `json:"tools,omitempty"`
+	Temperature   *float64        `json:"temperature,omitempty"`
+	MaxTokens     int             `json:"max_tokens,omitempty"`
+	Stream        bool            `json:"stream,omitempty"`
+	StreamOptions *struct {
+		IncludeUsage bool `json:"include_usage"`
+	} `json:"stream_options,omitempty"`
+}
+
+type openAIChoice struct {
+	Message      openAIMessage `json:"message"`
+	Delta        openAIMessage `json:"delta"`
+	FinishReason *string       `json:"finish_reason"`
+}
+
+type openAIChatResponse struct {
+	Model   string         `json:"model"`
+	Choices []openAIChoice `json:"choices"`
+	Usage   *Usage         `json:"usage"`
+}
+
+type openAIEmbedResponse struct {
+	Model string `json:"model"`
+	Data  []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Usage *struct {
+		PromptTokens int `json:"prompt_tokens"`
+	} `json:"usage"`
+}
+
+// Chat returns the assistant's reply to the request's messages
+func (p *OpenAIProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	resp, err := p.post(ctx, "/chat/completions", p.chatRequest(req, false))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body openAIChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode chat response: %w", err)
+	}
+	if len(body.Choices) == 0 {
+		return nil, fmt.Errorf("chat response has no choices")
+	}
+
+	choice := body.Choices[0]
+	result := &ChatResponse{Model: body.Model, Message: fromOpenAIMessage(choice.Message)}
+	if choice.FinishReason != nil {
+		result.FinishReason = *choice.FinishReason
+	}
+	if body.Usage != nil {
+		result.Usage = *body.Usage
+	} else {
+		result.Usage = estimateUsage(req, result.Message)
+	}
+	return result, nil
+}
+
+// ChatStream requests a streamed reply and passes each content delta to onDelta. Tool
+// call fragments are assembled into the returned message. If the stream is cut short,
+// the reply received so far is returned with the error, with its usage estimated.
+func (p *OpenAIProvider) ChatStream(ctx context.Context, req ChatRequest, onDelta func(delta string) error) (*ChatResponse, error) {
+	resp, err := p.post(ctx, "/chat/completions", p.chatRequest(req, true))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result := &ChatResponse{Message: Message{Role: RoleAssistant}}
+	var content strings.Builder
+	calls := make(map[int]*ToolCall)
+	var usage *Usage
+
+	var streamErr error
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+read:
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk openAIChatResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			streamErr = fmt.Errorf("failed to decode stream chunk: %w", err)
+			break
+		}
+		if chunk.Model != "" {
+			result.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		choice := chunk.Choices[0]
+		if choice.FinishReason != nil {
+			result.FinishReason = *choice.FinishReason
+		}
+		if delta := choice.Delta.Content; delta != nil && *delta != "" {
+			content.WriteString(*delta)
+			if err := onDelta(*delta); err != nil {
+				streamErr = err
+				break read
+			}
+		}
+		for i, fragment := range choice.Delta.ToolCalls {
+			index := i
+			if fragment.Index != nil {
+				index = *fragment.Index
+			}
+			call, ok := calls[index]
+			if !ok {
+				call = &ToolCall{}
+				calls[index] = call
+			}
+			if fragment.ID != "" {
+				call.ID = fragment.ID
+			}
+			call.Name += fragment.Function.Name
+			call.Arguments += fragment.Function.Arguments
+		}
+	}
+	if err := scanner.Err(); err != nil && streamErr == nil {
+		streamErr = fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	result.Message.Content = content.String()
+	indexes := make([]int, 0, len(calls))
+	for index := range calls {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		result.Message.ToolCalls = append(result.Message.ToolCalls, *calls[index])
+	}
+	if usage != nil {
+		result.Usage = *usage
+	} else {
+		result.Usage = estimateUsage(req, result.Message)
+	}
+	return result, streamErr
+}
+
+// Embed returns an embedding vector for each text
+func (p *OpenAIProvider) Embed(ctx context.Context, texts []string) (*EmbedResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	resp, err := p.post(ctx, "/embeddings", map[string]interface{}{
+		"model": p.embeddingModel,
+		"input": texts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body openAIEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding response: %w", err)
+	}
+	if len(body.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(body.Data))
+	}
+
+	result := &EmbedResponse{Model: body.Model, Vectors: make([][]float32, len(texts))}
+	for _, item := range body.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", item.Index)
+		}
+		result.Vectors[item.Index] = item.Embedding
+	}
+	if body.Usage != nil {
+		result.Usage.PromptTokens = body.Usage.PromptTokens
+	} else {
+		for _, text := range texts {
+			result.Usage.PromptTokens += EstimateTokens(text)
+		}
+	}
+	return result, nil
+}
+
+// chatRequest converts a ChatRequest to the OpenAI wire format
+func (p *OpenAIProvider) chatRequest(req ChatRequest, stream bool) *openAIChatRequest {
+	body := &openAIChatRequest{
+		Model:       req.Model,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+		Stream:      stream,
+	}
+	if body.Model == "" {
+		body.Model = p.chatModel
+	}
+	if stream {
+		body.StreamOptions = &struct {
+			IncludeUsage bool `json:"include_usage"`
+		}{IncludeUsage: true}
+	}
+	for _, message := range req.Messages {
+		body.Messages = append(body.Messages, toOpenAIMessage(message))
+	}
+	for _, tool := range req.Tools {
+		body.Tools = append(body.Tools, openAITool{Type: "function", Function: tool})
+	}
+	return body
+}
+
+// post sends a JSON request and returns the response if its status is 2xx
+func (p *OpenAIProvider) post(ctx context.Context, path string, payload interface{}) (*http.Response, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("LLM request to %s failed with status %d: %s", path, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return resp, nil
+}
+
+// toOpenAIMessage converts a Message to the OpenAI wire format
+func toOpenAIMessage(message Message) openAIMessage {
+	content := message.Content
+	wire := openAIMessage{Role: message.Role, Content: &content, ToolCallID: message.ToolCallID}
+	if message.Role == RoleAssistant && len(message.ToolCalls) > 0 && content == "" {
+		wire.Content = nil
+	}
+	for _, call := range message.ToolCalls {
+		var wireCall openAIToolCall
+		wireCall.ID = call.ID
+		wireCall.Type = "function"
+		wireCall.Function.Name = call.Name
+		wireCall.Function.Arguments = call.Arguments
+		wire.ToolCalls = append(wire.ToolCalls, wireCall)
+	}
+	return wire
+}
+
+// fromOpenAIMessage converts a message in the OpenAI wire format
+func fromOpenAIMessage(wire openAIMessage) Message {
+	message := Message{Role: wire.Role, ToolCallID: wire.ToolCallID}
+	if message.Role == "" {
+		message.Role = RoleAssistant
+	}
+	if wire.Content != nil {
+		message.Content = *wire.Content
+	}
+	for _, call := range wire.ToolCalls {
+		message.ToolCalls = append(message.ToolCalls, ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+	return message
+}
@@ -0,0 +1,131 @@
+// Package merchants normalizes raw bank transaction names and links transactions to
+// canonical merchants
+package merchants
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+)
+
+var (
+	// bankPrefix matches card and transfer descriptors banks put before the merchant
+	bankPrefix = regexp.MustCompile(`^(POS( DEBIT| PURCHASE)?|DEBIT( CARD)?( PURCHASE)?|CHECKCARD( \d{4})?|PURCHASE( AUTHORIZED ON \d\d/\d\d)?|RECURRING( PAYMENT)?|ACH (DEBIT|CREDIT))\b[\s-]*`)
+
+	// processorPrefix matches payment processors that prefix the merchant, as in
+	// "SQ *BLUE BOTTLE" or "PAYPAL *SPOTIFY"
+	processorPrefix = regexp.MustCompile(`^(SQ|SQU|TST|SP|PAYPAL|PP|PY|IC|DD|WPY|GOOGLE|GGL|APL|BT|EB|FS|CKE|LEVELUP|ZETTLE)\s?\*\s*`)
+
+	// storeNumber matches a token that starts the store number and location suffix
+	storeNumber = regexp.MustCompile(`^(#\S*|NO\.?\d+|\d{2,}\S*|[A-Z]?\d{3,}[A-Z]?)$`)
+
+	// domainSuffix matches a trailing domain, as in "NETFLIX.COM"
+	domainSuffix = regexp.MustCompile(`\.(COM|NET|ORG|CO|IO|US)$`)
+)
+
+// storeWords are trailing tokens that introduced a store number
+var storeWords = map[string]bool{"STORE": true, "STR": true, "LOC": true, "#": true}
+
+// locationTokens are trailing tokens dropped as locations: US states, Canadian
+// provinces and country codes
+var locationTokens = map[string]bool{}
+
+func init() {
+	for _, code := range strings.Fields(`AL AK AZ AR CA CO CT DE DC FL GA HI ID IL IN IA KS KY
+		LA ME MD MA MI MN MS MO MT NE NV NH NJ NM NY NC ND OH OK OR PA RI SC SD TN TX UT VT
+		VA WA WV WI WY AB BC MB NB NL NS ON PE QC SK US USA CAN GB`) {
+		locationTokens[code] = true
+	}
+}
+
+// Normalize cleans a raw bank transaction name into a merchant name: processor and
+// card prefixes, store numbers, locations and reference codes are stripped, and the
+// result is title cased. If nothing is left the trimmed raw name is returned.
+func Normalize(raw string) string {
+	name := strings.ToUpper(strings.TrimSpace(raw))
+
+	// Banks pad fixed-width columns, so a run of spaces separates the merchant from the
+	// location
+	if i := strings.Index(name, "  "); i > 0 {
+		name = name[:i]
+	}
+	for {
+		stripped := processorPrefix.ReplaceAllString(bankPrefix.ReplaceAllString(name, ""), "")
+		if stripped == name {
+			break
+		}
+		name = stripped
+	}
+	// Whatever follows a remaining asterisk is a reference code, as in "AMZN MKTP US*2K3L"
+	if i := strings.Index(name, "*"); i > 0 {
+		name = name[:i]
+	}
+
+	var tokens []string
+	for _, token := range strings.Fields(name) {
+		if storeNumber.MatchString(token) {
+			break
+		}
+		tokens = append(tokens, token)
+	}
+	for len(tokens) > 1 && (locationTokens[tokens[len(tokens)-1]] || storeWords[tokens[len(tokens)-1]]) {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) > 0 {
+		last := len(tokens) - 1
+		tokens[last] = domainSuffix.ReplaceAllString(tokens[last], "")
+	}
+
+	normalized := titleCase(strings.Join(tokens, " "))
+	if Key(normalized) == "" {
+		return strings.TrimSpace(raw)
+	}
+	return normalized
+}
+
+// Key reduces a merchant name to the key merchants are matched on: lowercased letters
+// and digits, with everything else collapsed to single spaces
+func Key(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// Name returns the merchant name of a transaction: Plaid's merchant name when it
+// provides one, otherwise the normalized bank name
+func Name(t *models.Transaction) string {
+	if name := strings.TrimSpace(t.MerchantName); name != "" {
+		return name
+	}
+	return Normalize(t.Name)
+}
+
+// titleCase capitalizes each word of an uppercase name, where words are runs of letters
+// and apostrophes. Words without vowels, such as "CVS" or "H&M", are taken to be
+// initialisms and left in capitals.
+func titleCase(name string) string {
+	var b strings.Builder
+	var word []rune
+	flush := func() {
+		if len(word) > 0 && strings.ContainsAny(string(word), "AEIOUY") {
+			lower := []rune(strings.ToLower(string(word)))
+			lower[0] = unicode.ToUpper(lower[0])
+			word = lower
+		}
+		b.WriteString(string(word))
+		word = word[:0]
+	}
+	for _, r := range name {
+		if unicode.IsLetter(r) || r == '\'' {
+			word = append(word, r)
+			continue
+		}
+		flush()
+		b.WriteRune(r)
+	}
+	flush()
+	return b.String()
+}
@@ -0,0 +1,42 @@
+package merchants
+
+import (
+	"testing"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNormalize tests stripping prefixes, store numbers and locations from bank names
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"SQ *BLUE BOTTLE 1234 SF":                 "Blue Bottle",
+		"TST* SWEETGREEN 0042 NEW YORK NY":        "Sweetgreen",
+		"PAYPAL *SPOTIFY":                         "Spotify",
+		"NETFLIX.COM":                             "Netflix",
+		"STARBUCKS STORE #1234   SEATTLE WA":      "Starbucks",
+		"POS DEBIT CVS/PHARMACY #04521 BOSTON MA": "CVS/Pharmacy",
+		"TARGET        00012345 MINNEAPOLIS MN":   "Target",
+		"Uber 063015 SF**POOL**":                  "Uber",
+		"7-ELEVEN 32145":                          "7-Eleven",
+		"H&M 0123":                                "H&M",
+		"Trader Joe's":                            "Trader Joe's",
+		"1234":                                    "1234", // Nothing left, so the raw name is kept
+	}
+	for raw, want := range cases {
+		assert.Equal(t, want, Normalize(raw), raw)
+	}
+}
+
+// TestKey tests that names differing in case and punctuation share a key
+func TestKey(t *testing.T) {
+	assert.Equal(t, "blue bottle", Key("Blue Bottle"))
+	assert.Equal(t, "cvs pharmacy", Key("CVS/Pharmacy"))
+	assert.Equal(t, "", Key(" -- "))
+}
+
+// TestName tests preferring Plaid's merchant name
+func TestName(t *testing.T) {
+	assert.Equal(t, "Blue Bottle Coffee", Name(&models.Transaction{Name: "SQ *BLUE BOTTLE 1234 SF", MerchantName: "Blue Bottle Coffee"}))
+	assert.Equal(t, "Blue Bottle", Name(&models.Transaction{Name: "SQ *BLUE BOTTLE 1234 SF"}))
+}
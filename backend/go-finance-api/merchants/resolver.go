@@ -0,0 +1,79 @@
+package merchants
+
+import (
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// linkBatch is the most unlinked transactions LinkUnlinked links in one run
+const linkBatch = 1000
+
+// Resolver links transactions to canonical merchants, creating merchants as new
+// normalized names appear. It caches merchants by key, so use one Resolver per run.
+type Resolver struct {
+	repos     *db.Repositories
+	merchants map[uuid.UUID]map[string]*models.Merchant // By user, then key
+}
+
+// NewResolver creates a new Resolver
+func NewResolver(repos *db.Repositories) *Resolver {
+	return &Resolver{repos: repos, merchants: make(map[uuid.UUID]map[string]*models.Merchant)}
+}
+
+// Link links a transaction to the merchant for its name. The logo and website, as
+// reported by Plaid, fill in a merchant that has none. Transactions without a name are
+// left unlinked.
+func (r *Resolver) Link(t *models.Transaction, logoURL, website string) error {
+	name := Name(t)
+	key := Key(name)
+	if key == "" {
+		return nil
+	}
+
+	byKey, ok := r.merchants[t.UserID]
+	if !ok {
+		byKey = make(map[string]*models.Merchant)
+		r.merchants[t.UserID] = byKey
+	}
+	merchant, ok := byKey[key]
+	if !ok || (merchant.LogoURL == "" && logoURL != "") || (merchant.Website == "" && website != "") {
+		resolved, err := r.repos.Merchant.Resolve(models.NewMerchant(t.UserID, key, name, logoURL, website))
+		if err != nil {
+			return err
+		}
+		merchant = resolved
+		byKey[key] = merchant
+	}
+	if merchant == nil {
+		return nil
+	}
+
+	if t.MerchantID != nil && *t.MerchantID == merchant.ID {
+		return nil
+	}
+	if err := r.repos.Transaction.UpdateMerchant(t.ID, merchant.ID); err != nil {
+		return err
+	}
+	t.MerchantID = &merchant.ID
+	return nil
+}
+
+// LinkUnlinked links a user's transactions that have no merchant yet, such as manual
+// and imported ones, and returns how many were linked
+func (r *Resolver) LinkUnlinked(userID uuid.UUID) (int, error) {
+	transactions, err := r.repos.Transaction.GetWithoutMerchant(userID, linkBatch)
+	if err != nil {
+		return 0, err
+	}
+	linked := 0
+	for _, t := range transactions {
+		if err := r.Link(t, "", ""); err != nil {
+			return linked, err
+		}
+		if t.MerchantID != nil {
+			linked++
+		}
+	}
+	return linked, nil
+}
@@ -6,23 +6,33 @@ import (
 	"github.com/google/uuid"
 )
 
-// Account represents a financial account from Plaid
+// Balance modes control where an account's balances come from
+const (
+	BalanceModePlaid    = "plaid"    // Refreshed from Plaid on every sync
+	BalanceModeManual   = "manual"   // Entered by the user
+	BalanceModeComputed = "computed" // Derived from the account's transactions
+)
+
+// Account represents a financial account, either from Plaid or entered manually
 type Account struct {
-	ID               uuid.UUID `json:"id" db:"id"`
-	ItemID           uuid.UUID `json:"item_id" db:"item_id"`
-	UserID           uuid.UUID `json:"user_id" db:"user_id"`
-	PlaidAccountID   string    `json:"plaid_account_id" db:"plaid_account_id"`
-	Name             string    `json:"name" db:"name"`
-	OfficialName     string    `json:"official_name" db:"official_name"`
-	Type             string    `json:"type" db:"type"`
-	Subtype          string    `json:"subtype" db:"subtype"`
-	Mask             string    `json:"mask" db:"mask"`
-	AvailableBalance float64   `json:"available_balance" db:"available_balance"`
-	CurrentBalance   float64   `json:"current_balance" db:"current_balance"`
-	CurrencyCode     string    `json:"currency_code" db:"currency_code"`
-	LastUpdated      time.Time `json:"last_updated" db:"last_updated"`
-	CreatedAt        time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+	ID               uuid.UUID  `json:"id" db:"id"`
+	ItemID           *uuid.UUID `json:"item_id" db:"item_id"` // Null for manual accounts
+	UserID           uuid.UUID  `json:"user_id" db:"user_id"`
+	PlaidAccountID   string     `json:"plaid_account_id" db:"plaid_account_id"`
+	Name             string     `json:"name" db:"name"`
+	OfficialName     string     `json:"official_name" db:"official_name"`
+	Type             string     `json:"type" db:"type"`
+	Subtype          string     `json:"subtype" db:"subtype"`
+	Mask             string     `json:"mask" db:"mask"`
+	AvailableBalance float64    `json:"available_balance" db:"available_balance"`
+	CurrentBalance   float64    `json:"current_balance" db:"current_balance"`
+	CurrencyCode     string     `json:"currency_code" db:"currency_code"`
+	BalanceMode      string     `json:"balance_mode" db:"balance_mode"`
+	PaymentDueDate   *time.Time `json:"payment_due_date,omitempty" db:"payment_due_date"` // Next payment on a credit card or loan
+	MinimumPayment   *float64   `json:"minimum_payment,omitempty" db:"minimum_payment"`
+	LastUpdated      time.Time  `json:"last_updated" db:"last_updated"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 // NewAccount creates a new Account record
@@ -42,7 +52,7 @@ func NewAccount(
 	now := time.Now().UTC()
 	return &Account{
 		ID:               uuid.New(),
-		ItemID:           itemID,
+		ItemID:           &itemID,
 		UserID:           userID,
 		PlaidAccountID:   plaidAccountID,
 		Name:             name,
@@ -53,12 +63,55 @@ func NewAccount(
 		AvailableBalance: availableBalance,
 		CurrentBalance:   currentBalance,
 		CurrencyCode:     currencyCode,
+		BalanceMode:      BalanceModePlaid,
 		LastUpdated:      now,
 		CreatedAt:        now,
 		UpdatedAt:        now,
 	}
 }
 
+// NewManualAccount creates a new Account that isn't linked to a Plaid Item, such as cash,
+// a house or a private loan. With BalanceModeComputed the given balance is ignored.
+func NewManualAccount(
+	userID uuid.UUID,
+	name string,
+	accountType string,
+	accountSubtype string,
+	currencyCode string,
+	balanceMode string,
+	currentBalance float64,
+) *Account {
+	now := time.Now().UTC()
+	if balanceMode == BalanceModeComputed {
+		currentBalance = 0
+	}
+	return &Account{
+		ID:               uuid.New(),
+		UserID:           userID,
+		Name:             name,
+		Type:             accountType,
+		Subtype:          accountSubtype,
+		AvailableBalance: currentBalance,
+		CurrentBalance:   currentBalance,
+		CurrencyCode:     currencyCode,
+		BalanceMode:      balanceMode,
+		LastUpdated:      now,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+}
+
+// IsManual reports whether the account was entered by the user rather than linked via Plaid
+func (a *Account) IsManual() bool {
+	return a.ItemID == nil
+}
+
+// IsLiability reports whether the account's balance is money owed, such as a credit card
+// or loan
+func (a *Account) IsLiability() bool {
+	return a.Type == "credit" || a.Type == "loan"
+}
+
 // UpdateBalances updates the account balances
 func (a *Account) UpdateBalances(availableBalance, currentBalance float64) {
 	now := time.Now().UTC()
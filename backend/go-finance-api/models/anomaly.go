@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Anomaly kinds
+const (
+	AnomalyKindUnusualAmount = "unusual_amount" // Far above the merchant's usual amount
+	AnomalyKindNewMerchant   = "new_merchant"   // A large first charge from a merchant
+	AnomalyKindDuplicate     = "duplicate"      // The same charge repeated moments apart
+	AnomalyKindForeign       = "foreign"        // Charged abroad or in another currency
+	AnomalyKindCategorySpike = "category_spike" // Took the month's category spending well above normal
+)
+
+// Anomaly statuses
+const (
+	AnomalyStatusOpen      = "open"
+	AnomalyStatusDismissed = "dismissed" // The user recognized the transaction
+	AnomalyStatusFraud     = "fraud"     // The user reported the transaction as fraud
+)
+
+// AnomalyFlag is one reason a transaction looks unusual
+type AnomalyFlag struct {
+	Kind   string  `json:"kind"`
+	Detail string  `json:"detail"`
+	Score  float64 `json:"score"` // Severity, 0 to 1
+}
+
+// Anomaly is a transaction flagged as unusual, with its combined score
+type Anomaly struct {
+	TransactionID uuid.UUID     `json:"transaction_id" db:"transaction_id"`
+	UserID        uuid.UUID     `json:"user_id" db:"user_id"`
+	Score         float64       `json:"score" db:"score"` // Combined severity of the flags, 0 to 1
+	Flags         []AnomalyFlag `json:"flags" db:"flags"`
+	Status        string        `json:"status" db:"status"`
+	Transaction   *Transaction  `json:"transaction,omitempty" db:"-"`
+	CreatedAt     time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// NewAnomaly creates a new open Anomaly record
+func NewAnomaly(transaction *Transaction, score float64, flags []AnomalyFlag) *Anomaly {
+	now := time.Now().UTC()
+	return &Anomaly{
+		TransactionID: transaction.ID,
+		UserID:        transaction.UserID,
+		Score:         score,
+		Flags:         flags,
+		Status:        AnomalyStatusOpen,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
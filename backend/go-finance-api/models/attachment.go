@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attachment represents a file, such as a receipt, attached to a transaction
+type Attachment struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	TransactionID uuid.UUID `json:"transaction_id" db:"transaction_id"`
+	UserID        uuid.UUID `json:"user_id" db:"user_id"`
+	FileName      string    `json:"file_name" db:"file_name"`
+	ContentType   string    `json:"content_type" db:"content_type"`
+	SizeBytes     int64     `json:"size_bytes" db:"size_bytes"`
+	StorageKey    string    `json:"-" db:"storage_key"` // Key in the blob store
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// NewAttachment creates a new Attachment record with a storage key derived from its IDs
+func NewAttachment(
+	transactionID uuid.UUID,
+	userID uuid.UUID,
+	fileName string,
+	contentType string,
+	sizeBytes int64,
+) *Attachment {
+	id := uuid.New()
+	return &Attachment{
+		ID:            id,
+		TransactionID: transactionID,
+		UserID:        userID,
+		FileName:      fileName,
+		ContentType:   contentType,
+		SizeBytes:     sizeBytes,
+		StorageKey:    userID.String() + "/" + transactionID.String() + "/" + id.String(),
+		CreatedAt:     time.Now().UTC(),
+	}
+}
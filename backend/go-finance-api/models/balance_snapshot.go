@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Balance snapshot sources
+const (
+	SnapshotSourceRefresh  = "refresh"  // Written whenever an account's balance is updated
+	SnapshotSourceDaily    = "daily"    // Written by the daily snapshot job
+	SnapshotSourceBackfill = "backfill" // Reconstructed from transaction history
+)
+
+// BalanceSnapshot records an account's balance on a given day. There is at most one
+// snapshot per account and day; later refreshes on the same day replace earlier ones.
+type BalanceSnapshot struct {
+	ID               uuid.UUID `json:"id" db:"id"`
+	AccountID        uuid.UUID `json:"account_id" db:"account_id"`
+	UserID           uuid.UUID `json:"user_id" db:"user_id"`
+	Date             time.Time `json:"date" db:"date"`
+	CurrentBalance   float64   `json:"current_balance" db:"current_balance"`
+	AvailableBalance *float64  `json:"available_balance" db:"available_balance"` // Unknown for backfilled days
+	CurrencyCode     string    `json:"currency_code" db:"currency_code"`
+	Source           string    `json:"source" db:"source"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// NewBalanceSnapshot creates a new BalanceSnapshot record
+func NewBalanceSnapshot(account *Account, date time.Time, currentBalance float64, source string) *BalanceSnapshot {
+	now := time.Now().UTC()
+	return &BalanceSnapshot{
+		ID:             uuid.New(),
+		AccountID:      account.ID,
+		UserID:         account.UserID,
+		Date:           date,
+		CurrentBalance: currentBalance,
+		CurrencyCode:   account.CurrencyCode,
+		Source:         source,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+// NetWorthPoint is a user's net worth on one day, computed from balance snapshots
+type NetWorthPoint struct {
+	Date        time.Time          `json:"date"`
+	Assets      float64            `json:"assets"`
+	Liabilities float64            `json:"liabilities"` // Positive amount owed
+	NetWorth    float64            `json:"net_worth"`
+	ByType      map[string]float64 `json:"by_type"` // Contribution per account type; negative for debts
+}
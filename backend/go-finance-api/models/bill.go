@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Bill kinds
+const (
+	BillKindRecurring = "recurring" // An expected charge from a recurring series
+	BillKindLiability = "liability" // A credit card or loan payment due
+)
+
+// Bill is an expected upcoming payment
+type Bill struct {
+	Date         time.Time  `json:"date"`
+	Name         string     `json:"name"`
+	Amount       float64    `json:"amount"` // Expected amount; positive
+	CurrencyCode string     `json:"currency_code"`
+	Category     string     `json:"category,omitempty"`
+	Kind         string     `json:"kind"`
+	SeriesID     *uuid.UUID `json:"series_id,omitempty"`
+	AccountID    *uuid.UUID `json:"account_id,omitempty"` // Account charged, or the liability being paid
+	AccountName  string     `json:"account_name,omitempty"`
+}
+
+// CalendarFeed grants access to a user's private bills calendar. Only a hash of the
+// token in the feed URL is stored.
+type CalendarFeed struct {
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	TokenHash string    `json:"-" db:"token_hash"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Budget is the amount a user plans to spend in a category during one month
+type Budget struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Month     time.Time `json:"month" db:"month"` // First day of the month
+	Category  string    `json:"category" db:"category"`
+	Amount    float64   `json:"amount" db:"amount"`
+	Rollover  bool      `json:"rollover" db:"rollover"` // Carry what's left, or overspent, into next month
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// NewBudget creates a new Budget record
+func NewBudget(userID uuid.UUID, month time.Time, category string, amount float64, rollover bool) *Budget {
+	now := time.Now().UTC()
+	return &Budget{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Month:     month,
+		Category:  category,
+		Amount:    amount,
+		Rollover:  rollover,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// BudgetStatus compares a category's budget with what was spent during the month
+type BudgetStatus struct {
+	BudgetID  uuid.UUID `json:"budget_id"`
+	Category  string    `json:"category"`
+	Budgeted  float64   `json:"budgeted"`  // This month's amount
+	Rollover  float64   `json:"rollover"`  // Carried in from earlier months; negative if overspent
+	Available float64   `json:"available"` // Budgeted plus rollover
+	Spent     float64   `json:"spent"`     // Net outflow; refunds reduce it
+	Remaining float64   `json:"remaining"` // Available minus spent
+}
+
+// MonthlyCategoryTotal is the sum of transaction amounts in a category during one month
+type MonthlyCategoryTotal struct {
+	Month    time.Time `json:"month"`
+	Category string    `json:"category"`
+	Total    float64   `json:"total"`
+}
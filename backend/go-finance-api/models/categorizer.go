@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Category prediction statuses
+const (
+	PredictionStatusApplied   = "applied"   // Confident enough to set the transaction's category
+	PredictionStatusReview    = "review"    // Waiting in the review queue
+	PredictionStatusAccepted  = "accepted"  // Accepted from the review queue
+	PredictionStatusCorrected = "corrected" // A different category was chosen in the review queue
+	PredictionStatusDismissed = "dismissed" // Removed from the review queue unchanged
+)
+
+// CategorizerModel is a user's serialized category classifier
+type CategorizerModel struct {
+	UserID         uuid.UUID `json:"user_id" db:"user_id"`
+	Model          []byte    `json:"-" db:"model"`
+	Examples       int       `json:"examples" db:"examples"`               // Labelled transactions learned from
+	TrainedThrough time.Time `json:"trained_through" db:"trained_through"` // Transactions updated after this are not yet learned
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CategorizerExample is a labelled transaction the classifier has learned from, kept so
+// the example can be unlearned if the label changes
+type CategorizerExample struct {
+	TransactionID uuid.UUID `json:"transaction_id" db:"transaction_id"`
+	UserID        uuid.UUID `json:"user_id" db:"user_id"`
+	Category      string    `json:"category" db:"category"`
+	Features      []string  `json:"features" db:"features"`
+}
+
+// CategoryPrediction is the classifier's category for a transaction the user had not
+// categorized
+type CategoryPrediction struct {
+	TransactionID uuid.UUID    `json:"transaction_id" db:"transaction_id"`
+	UserID        uuid.UUID    `json:"user_id" db:"user_id"`
+	Category      string       `json:"category" db:"category"`
+	Confidence    float64      `json:"confidence" db:"confidence"` // Probability of the category, 0 to 1
+	Status        string       `json:"status" db:"status"`
+	Transaction   *Transaction `json:"transaction,omitempty" db:"-"`
+	CreatedAt     time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// NewCategoryPrediction creates a new CategoryPrediction record
+func NewCategoryPrediction(transaction *Transaction, category string, confidence float64, status string) *CategoryPrediction {
+	now := time.Now().UTC()
+	return &CategoryPrediction{
+		TransactionID: transaction.ID,
+		UserID:        transaction.UserID,
+		Category:      category,
+		Confidence:    confidence,
+		Status:        status,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
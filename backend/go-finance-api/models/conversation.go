@@ -0,0 +1,55 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Conversation is a chat between a user and the finance assistant
+type Conversation struct {
+	ID        uuid.UUID              `json:"id" db:"id"`
+	UserID    uuid.UUID              `json:"user_id" db:"user_id"`
+	Title     string                 `json:"title" db:"title"` // The start of the first message
+	Messages  []*ConversationMessage `json:"messages,omitempty" db:"-"`
+	CreatedAt time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at" db:"updated_at"`
+}
+
+// NewConversation creates a new Conversation record
+func NewConversation(userID uuid.UUID, title string) *Conversation {
+	now := time.Now().UTC()
+	return &Conversation{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Title:     title,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// ConversationMessage is one stored turn of a conversation: a user message, an assistant
+// reply or tool request, or a tool result
+type ConversationMessage struct {
+	ID             uuid.UUID       `json:"id" db:"id"`
+	ConversationID uuid.UUID       `json:"conversation_id" db:"conversation_id"`
+	Position       int             `json:"position" db:"position"`
+	Role           string          `json:"role" db:"role"`
+	Content        string          `json:"content" db:"content"`
+	ToolCalls      json.RawMessage `json:"tool_calls,omitempty" db:"tool_calls"`     // Tool calls requested by the assistant
+	ToolCallID     string          `json:"tool_call_id,omitempty" db:"tool_call_id"` // The call a tool result answers
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+}
+
+// NewConversationMessage creates a new ConversationMessage record. Its position is
+// assigned when it is stored.
+func NewConversationMessage(conversationID uuid.UUID, role, content string) *ConversationMessage {
+	return &ConversationMessage{
+		ID:             uuid.New(),
+		ConversationID: conversationID,
+		Role:           role,
+		Content:        content,
+		CreatedAt:      time.Now().UTC(),
+	}
+}
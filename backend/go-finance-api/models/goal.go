@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Goal is a savings target such as an emergency fund or a vacation. Progress comes from
+// the balance of a linked account, or from a manual contribution log when no account is
+// linked.
+type Goal struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
+	Name         string     `json:"name" db:"name"`
+	TargetAmount float64    `json:"target_amount" db:"target_amount"`
+	TargetDate   *time.Time `json:"target_date,omitempty" db:"target_date"`
+	AccountID    *uuid.UUID `json:"account_id,omitempty" db:"account_id"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// NewGoal creates a new Goal record
+func NewGoal(userID uuid.UUID, name string, targetAmount float64, targetDate *time.Time, accountID *uuid.UUID) *Goal {
+	now := time.Now().UTC()
+	return &Goal{
+		ID:           uuid.New(),
+		UserID:       userID,
+		Name:         name,
+		TargetAmount: targetAmount,
+		TargetDate:   targetDate,
+		AccountID:    accountID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+// GoalContribution is a manually logged amount put toward a goal. Withdrawals are negative.
+type GoalContribution struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	GoalID    uuid.UUID `json:"goal_id" db:"goal_id"`
+	Date      time.Time `json:"date" db:"date"`
+	Amount    float64   `json:"amount" db:"amount"`
+	Note      string    `json:"note,omitempty" db:"note"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// NewGoalContribution creates a new GoalContribution record
+func NewGoalContribution(goalID uuid.UUID, date time.Time, amount float64, note string) *GoalContribution {
+	return &GoalContribution{
+		ID:        uuid.New(),
+		GoalID:    goalID,
+		Date:      date,
+		Amount:    amount,
+		Note:      note,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// GoalProgress summarizes how far a goal is and when it will be reached
+type GoalProgress struct {
+	Saved               float64    `json:"saved"`
+	Remaining           float64    `json:"remaining"`            // Never negative
+	PercentComplete     float64    `json:"percent_complete"`     // 0-100
+	MonthlyRate         float64    `json:"monthly_rate"`         // Recent saving rate per month
+	RequiredMonthly     *float64   `json:"required_monthly"`     // To reach the target by its date
+	ProjectedCompletion *time.Time `json:"projected_completion"` // At the recent rate; nil if not saving
+	OnTrack             *bool      `json:"on_track"`             // Projected to finish by the target date
+}
@@ -17,6 +17,7 @@ type Item struct {
 	Status          string    `json:"status" db:"status"`
 	WebhookURL      string    `json:"webhook_url" db:"webhook_url"`
 	Consent         string    `json:"consent" db:"consent"`
+	SyncCursor      string    `json:"-" db:"sync_cursor"` // Last /transactions/sync cursor
 	CreatedAt       time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
 }
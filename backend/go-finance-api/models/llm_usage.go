@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LLMUsage records the tokens and cost of one language model request made for a user
+type LLMUsage struct {
+	ID               uuid.UUID `json:"id" db:"id"`
+	UserID           uuid.UUID `json:"user_id" db:"user_id"`
+	Feature          string    `json:"feature" db:"feature"` // What the request was for, e.g. "assistant"
+	Model            string    `json:"model" db:"model"`
+	PromptTokens     int       `json:"prompt_tokens" db:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens" db:"completion_tokens"`
+	Cost             float64   `json:"cost" db:"cost"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// NewLLMUsage creates a new LLMUsage record
+func NewLLMUsage(userID uuid.UUID, feature, model string, promptTokens, completionTokens int, cost float64) *LLMUsage {
+	return &LLMUsage{
+		ID:               uuid.New(),
+		UserID:           userID,
+		Feature:          feature,
+		Model:            model,
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		Cost:             cost,
+		CreatedAt:        time.Now().UTC(),
+	}
+}
+
+// LLMUsageSummary totals a user's language model usage since a point in time
+type LLMUsageSummary struct {
+	Since            time.Time `json:"since"`
+	Requests         int       `json:"requests"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	Cost             float64   `json:"cost"`
+	Limit            *float64  `json:"limit"` // Monthly cost limit; nil if unlimited
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Merchant is a canonical merchant that a user's transactions are linked to. Raw bank
+// names are normalized to a key, and every transaction with the same key shares a
+// merchant. A merchant merged into another keeps its key as an alias.
+type Merchant struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
+	Key          string     `json:"key" db:"key"` // Normalized name that transactions are matched on
+	Name         string     `json:"name" db:"name"`
+	LogoURL      string     `json:"logo_url" db:"logo_url"`
+	Website      string     `json:"website" db:"website"`
+	MergedInto   *uuid.UUID `json:"merged_into,omitempty" db:"merged_into"`
+	Transactions int        `json:"transactions" db:"-"` // Linked transactions, when listed
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// NewMerchant creates a new Merchant record
+func NewMerchant(userID uuid.UUID, key, name, logoURL, website string) *Merchant {
+	now := time.Now().UTC()
+	return &Merchant{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Key:       key,
+		Name:      name,
+		LogoURL:   logoURL,
+		Website:   website,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
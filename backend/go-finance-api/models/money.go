@@ -0,0 +1,17 @@
+package models
+
+import "math"
+
+// DefaultCurrency is assumed for accounts without a currency code
+const DefaultCurrency = "USD"
+
+// ToMinorUnits converts an amount to ten-thousandths, matching the DECIMAL(19, 4) columns,
+// so amounts can be compared without floating point error
+func ToMinorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 10000))
+}
+
+// FromMinorUnits converts a fixed-point amount from ToMinorUnits back to a float
+func FromMinorUnits(units int64) float64 {
+	return float64(units) / 10000
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Monthly summary narrative sources
+const (
+	SummarySourceLLM      = "llm"      // Written by the language model from the facts
+	SummarySourceTemplate = "template" // Rendered from the facts without a language model
+)
+
+// CategoryChange is a category's spending in a month compared with the month before
+type CategoryChange struct {
+	Category      string   `json:"category"`
+	Spent         float64  `json:"spent"`
+	Previous      float64  `json:"previous"`
+	Change        float64  `json:"change"`
+	ChangePercent *float64 `json:"change_percent,omitempty"` // Nil without spending the month before
+}
+
+// BudgetMiss is a budget the month's spending went over
+type BudgetMiss struct {
+	Category  string  `json:"category"`
+	Available float64 `json:"available"`
+	Spent     float64 `json:"spent"`
+	Over      float64 `json:"over"`
+}
+
+// NewSubscription is a recurring charge first seen during the month
+type NewSubscription struct {
+	Merchant  string  `json:"merchant"`
+	Amount    float64 `json:"amount"`
+	Frequency string  `json:"frequency"`
+}
+
+// NetWorthChange is the change in net worth over the month
+type NetWorthChange struct {
+	Start  float64 `json:"start"` // At the end of the previous month
+	End    float64 `json:"end"`
+	Change float64 `json:"change"`
+}
+
+// MonthlyFacts are the figures a monthly summary is written from. They come from the
+// user's stored data, never from the language model.
+type MonthlyFacts struct {
+	Month                string             `json:"month"` // YYYY-MM
+	Income               float64            `json:"income"`
+	Expenses             float64            `json:"expenses"`
+	NetSavings           float64            `json:"net_savings"`
+	SavingsRate          *float64           `json:"savings_rate,omitempty"` // Percent of income saved
+	PreviousExpenses     float64            `json:"previous_expenses"`
+	ExpenseChangePercent *float64           `json:"expense_change_percent,omitempty"`
+	TopCategories        []*CategoryChange  `json:"top_categories"`
+	BiggestChanges       []*CategoryChange  `json:"biggest_changes"`
+	BudgetMisses         []*BudgetMiss      `json:"budget_misses"`
+	NewSubscriptions     []*NewSubscription `json:"new_subscriptions"`
+	NetWorth             *NetWorthChange    `json:"net_worth,omitempty"` // Nil without balance history
+}
+
+// MonthlySummary is a stored monthly summary: its facts and the narrative written from them
+type MonthlySummary struct {
+	ID        uuid.UUID     `json:"id" db:"id"`
+	UserID    uuid.UUID     `json:"user_id" db:"user_id"`
+	Month     time.Time     `json:"month" db:"month"`
+	Facts     *MonthlyFacts `json:"facts" db:"facts"`
+	Narrative string        `json:"narrative" db:"narrative"`
+	Source    string        `json:"source" db:"source"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// NewMonthlySummary creates a new MonthlySummary record
+func NewMonthlySummary(userID uuid.UUID, month time.Time, facts *MonthlyFacts, narrative, source string) *MonthlySummary {
+	now := time.Now().UTC()
+	return &MonthlySummary{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Month:     month,
+		Facts:     facts,
+		Narrative: narrative,
+		Source:    source,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
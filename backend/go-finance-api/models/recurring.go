@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Recurring series frequencies
+const (
+	FrequencyWeekly   = "weekly"
+	FrequencyBiweekly = "biweekly"
+	FrequencyMonthly  = "monthly"
+	FrequencyAnnual   = "annual"
+)
+
+// Recurring series statuses
+const (
+	RecurringStatusActive = "active" // Charged on schedule
+	RecurringStatusMissed = "missed" // The expected transaction hasn't arrived
+)
+
+// Recurring series sources
+const (
+	RecurringSourceDetected = "detected" // Found in the user's transaction history
+	RecurringSourcePlaid    = "plaid"    // Reported by Plaid's /transactions/recurring/get
+)
+
+// RecurringSeries is a repeating transaction such as a subscription, bill or paycheck.
+// Amounts follow the Plaid sign convention, so income series have negative amounts.
+type RecurringSeries struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	UserID         uuid.UUID  `json:"user_id" db:"user_id"`
+	AccountID      *uuid.UUID `json:"account_id,omitempty" db:"account_id"` // Account of the latest transaction
+	SeriesKey      string     `json:"-" db:"series_key"`                    // Identifies the series across detection runs
+	MerchantName   string     `json:"merchant_name" db:"merchant_name"`
+	Category       string     `json:"category" db:"category"`
+	Frequency      string     `json:"frequency" db:"frequency"`
+	AverageAmount  float64    `json:"average_amount" db:"average_amount"` // Over recent transactions
+	LastAmount     float64    `json:"last_amount" db:"last_amount"`
+	LastDate       time.Time  `json:"last_date" db:"last_date"`
+	NextDate       time.Time  `json:"next_date" db:"next_date"` // Expected date of the next transaction
+	Occurrences    int        `json:"occurrences" db:"occurrences"`
+	PriceIncrease  bool       `json:"price_increase" db:"price_increase"`   // The latest charge is higher than usual
+	PreviousAmount float64    `json:"previous_amount" db:"previous_amount"` // Average before the latest transaction
+	Status         string     `json:"status" db:"status"`
+	Source         string     `json:"source" db:"source"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// IsIncome reports whether the series brings money in
+func (s *RecurringSeries) IsIncome() bool {
+	return s.AverageAmount < 0
+}
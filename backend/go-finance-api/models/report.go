@@ -0,0 +1,20 @@
+package models
+
+// Report dimensions
+const (
+	ReportByCategory  = "category"
+	ReportByMerchant  = "merchant"
+	ReportByAccount   = "account"
+	ReportByDayOfWeek = "day_of_week"
+	ReportByMonth     = "month"
+)
+
+// SpendingTotal is the aggregate of a user's transactions sharing one value of a report
+// dimension, such as a category or a month. Transfers and hidden transactions are left out.
+type SpendingTotal struct {
+	Key      string  `json:"key"`
+	Spent    float64 `json:"spent"`    // Outflows
+	Received float64 `json:"received"` // Inflows such as refunds and income, as a positive amount
+	Net      float64 `json:"net"`      // Spent minus received
+	Count    int     `json:"count"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Rule represents a user-defined rule that categorizes or renames transactions automatically
+type Rule struct {
+	ID         uuid.UUID      `json:"id" db:"id"`
+	UserID     uuid.UUID      `json:"user_id" db:"user_id"`
+	Name       string         `json:"name" db:"name"`
+	Priority   int            `json:"priority" db:"priority"` // Lower values run first
+	Enabled    bool           `json:"enabled" db:"enabled"`
+	Conditions RuleConditions `json:"conditions" db:"conditions"`
+	Actions    RuleActions    `json:"actions" db:"actions"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// RuleConditions holds the criteria a transaction must meet for a rule to match.
+// Empty fields are ignored; all non-empty fields must match.
+type RuleConditions struct {
+	NamePattern     string     `json:"name_pattern,omitempty"`     // Case-insensitive regex against the transaction name
+	MerchantPattern string     `json:"merchant_pattern,omitempty"` // Case-insensitive regex against the merchant name
+	MinAmount       *float64   `json:"min_amount,omitempty"`       // Inclusive, Plaid sign convention (positive = outflow)
+	MaxAmount       *float64   `json:"max_amount,omitempty"`       // Inclusive, Plaid sign convention (positive = outflow)
+	AccountID       *uuid.UUID `json:"account_id,omitempty"`
+	DayOfMonthMin   *int       `json:"day_of_month_min,omitempty"` // Inclusive, 1-31
+	DayOfMonthMax   *int       `json:"day_of_month_max,omitempty"` // Inclusive, 1-31
+}
+
+// RuleActions holds the changes applied to a transaction when a rule matches
+type RuleActions struct {
+	SetCategory  string   `json:"set_category,omitempty"`
+	Rename       string   `json:"rename,omitempty"`
+	AddTags      []string `json:"add_tags,omitempty"`
+	MarkTransfer bool     `json:"mark_transfer,omitempty"`
+	Hide         bool     `json:"hide,omitempty"`
+}
+
+// IsEmpty reports whether the actions would leave a transaction unchanged
+func (a RuleActions) IsEmpty() bool {
+	return a.SetCategory == "" && a.Rename == "" && len(a.AddTags) == 0 && !a.MarkTransfer && !a.Hide
+}
+
+// NewRule creates a new Rule record
+func NewRule(
+	userID uuid.UUID,
+	name string,
+	priority int,
+	conditions RuleConditions,
+	actions RuleActions,
+) *Rule {
+	now := time.Now().UTC()
+	return &Rule{
+		ID:         uuid.New(),
+		UserID:     userID,
+		Name:       name,
+		Priority:   priority,
+		Enabled:    true,
+		Conditions: conditions,
+		Actions:    actions,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TransactionSplit represents a portion of a transaction assigned to its own category
+type TransactionSplit struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	TransactionID uuid.UUID `json:"transaction_id" db:"transaction_id"`
+	UserID        uuid.UUID `json:"user_id" db:"user_id"`
+	Amount        float64   `json:"amount" db:"amount"`
+	Category      string    `json:"category" db:"category"`
+	Note          string    `json:"note" db:"note"`
+	Stale         bool      `json:"stale" db:"stale"` // Set when Plaid changes the parent amount
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TransactionLine is a transaction, or one split of a split transaction, as it counts
+// toward spending totals
+type TransactionLine struct {
+	TransactionID uuid.UUID  `json:"transaction_id"`
+	SplitID       *uuid.UUID `json:"split_id,omitempty"` // Set for a split's line
+	AccountID     uuid.UUID  `json:"account_id"`
+	Date          time.Time  `json:"date"`
+	Amount        float64    `json:"amount"` // The split's amount for a split's line
+	Category      string     `json:"category"`
+	Name          string     `json:"name"` // The custom name when the user renamed the transaction
+	MerchantName  string     `json:"merchant_name"`
+	Pending       bool       `json:"pending"`
+}
+
+// NewTransactionSplit creates a new TransactionSplit record
+func NewTransactionSplit(
+	transactionID uuid.UUID,
+	userID uuid.UUID,
+	amount float64,
+	category string,
+	note string,
+) *TransactionSplit {
+	now := time.Now().UTC()
+	return &TransactionSplit{
+		ID:            uuid.New(),
+		TransactionID: transactionID,
+		UserID:        userID,
+		Amount:        amount,
+		Category:      category,
+		Note:          note,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+// ValidateSplits checks that a set of splits is usable for a parent transaction amount.
+// There must be at least two splits, each with a category, and their amounts must sum
+// exactly to the parent amount.
+func ValidateSplits(parentAmount float64, splits []*TransactionSplit) error {
+	if len(splits) < 2 {
+		return errors.New("a split transaction needs at least two splits")
+	}
+
+	var total int64
+	for _, split := range splits {
+		if split.Category == "" {
+			return errors.New("every split needs a category")
+		}
+		total += ToMinorUnits(split.Amount)
+	}
+
+	if total != ToMinorUnits(parentAmount) {
+		return fmt.Errorf("splits sum to %.2f but the transaction amount is %.2f", FromMinorUnits(total), parentAmount)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestValidateSplits tests the split sum and shape checks
+func TestValidateSplits(t *testing.T) {
+	txID, userID := uuid.New(), uuid.New()
+	split := func(amount float64, category string) *TransactionSplit {
+		return NewTransactionSplit(txID, userID, amount, category, "")
+	}
+
+	// 0.1 + 0.2 is not exactly 0.3 in floating point
+	assert.NoError(t, ValidateSplits(0.3, []*TransactionSplit{split(0.1, "Groceries"), split(0.2, "Household")}))
+	assert.NoError(t, ValidateSplits(182.47, []*TransactionSplit{
+		split(120.15, "Groceries"),
+		split(22.32, "Household"),
+		split(40, "Gas"),
+	}))
+
+	assert.Error(t, ValidateSplits(100, []*TransactionSplit{split(100, "Groceries")}))
+	assert.Error(t, ValidateSplits(100, []*TransactionSplit{split(60, "Groceries"), split(39.99, "Gas")}))
+	assert.Error(t, ValidateSplits(100, []*TransactionSplit{split(60, "Groceries"), split(40, "")}))
+}
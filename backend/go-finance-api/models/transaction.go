@@ -8,28 +8,37 @@ import (
 
 // Transaction represents a financial transaction from Plaid
 type Transaction struct {
-	ID                 uuid.UUID `json:"id" db:"id"`
-	AccountID          uuid.UUID `json:"account_id" db:"account_id"`
-	UserID             uuid.UUID `json:"user_id" db:"user_id"`
-	PlaidTransactionID string    `json:"plaid_transaction_id" db:"plaid_transaction_id"`
-	CategoryID         string    `json:"category_id" db:"category_id"`
-	Category           []string  `json:"category" db:"category"`
-	Name               string    `json:"name" db:"name"`
-	MerchantName       string    `json:"merchant_name" db:"merchant_name"`
-	Amount             float64   `json:"amount" db:"amount"`
-	IsoCurrencyCode    string    `json:"iso_currency_code" db:"iso_currency_code"`
-	Date               time.Time `json:"date" db:"date"`
-	Pending            bool      `json:"pending" db:"pending"`
-	PaymentChannel     string    `json:"payment_channel" db:"payment_channel"`
-	Address            string    `json:"address" db:"address"`
-	City               string    `json:"city" db:"city"`
-	Region             string    `json:"region" db:"region"`
-	PostalCode         string    `json:"postal_code" db:"postal_code"`
-	Country            string    `json:"country" db:"country"`
-	Latitude           float64   `json:"latitude" db:"latitude"`
-	Longitude          float64   `json:"longitude" db:"longitude"`
-	CreatedAt          time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
+	ID                   uuid.UUID  `json:"id" db:"id"`
+	AccountID            uuid.UUID  `json:"account_id" db:"account_id"`
+	UserID               uuid.UUID  `json:"user_id" db:"user_id"`
+	PlaidTransactionID   string     `json:"plaid_transaction_id" db:"plaid_transaction_id"`
+	CategoryID           string     `json:"category_id" db:"category_id"`
+	Category             []string   `json:"category" db:"category"`
+	Name                 string     `json:"name" db:"name"`
+	MerchantName         string     `json:"merchant_name" db:"merchant_name"`
+	Amount               float64    `json:"amount" db:"amount"`
+	IsoCurrencyCode      string     `json:"iso_currency_code" db:"iso_currency_code"`
+	Date                 time.Time  `json:"date" db:"date"`
+	Datetime             *time.Time `json:"datetime,omitempty" db:"datetime"` // When the transaction was authorized, if known
+	Pending              bool       `json:"pending" db:"pending"`
+	PendingTransactionID string     `json:"pending_transaction_id" db:"pending_transaction_id"` // Plaid ID of the pending transaction this one posted from
+	PaymentChannel       string     `json:"payment_channel" db:"payment_channel"`
+	Address              string     `json:"address" db:"address"`
+	City                 string     `json:"city" db:"city"`
+	Region               string     `json:"region" db:"region"`
+	PostalCode           string     `json:"postal_code" db:"postal_code"`
+	Country              string     `json:"country" db:"country"`
+	Latitude             float64    `json:"latitude" db:"latitude"`
+	Longitude            float64    `json:"longitude" db:"longitude"`
+	UserCategory         string     `json:"user_category" db:"user_category"` // Overrides Category when set
+	CustomName           string     `json:"custom_name" db:"custom_name"`     // Overrides Name when set
+	IsTransfer           bool       `json:"is_transfer" db:"is_transfer"`
+	Hidden               bool       `json:"hidden" db:"hidden"`
+	Notes                string     `json:"notes" db:"notes"`                       // Markdown
+	ImportHash           string     `json:"import_hash,omitempty" db:"import_hash"` // Set on transactions imported from a statement file
+	MerchantID           *uuid.UUID `json:"merchant_id,omitempty" db:"merchant_id"` // Canonical merchant, set once normalized
+	CreatedAt            time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 // NewTransaction creates a new Transaction record
@@ -84,3 +93,101 @@ func (t *Transaction) UpdatePendingStatus(pending bool) {
 	t.Pending = pending
 	t.UpdatedAt = time.Now().UTC()
 }
+
+// EffectiveCategory returns the user's category if set, otherwise Plaid's top-level category
+func (t *Transaction) EffectiveCategory() string {
+	if t.UserCategory != "" {
+		return t.UserCategory
+	}
+	if len(t.Category) > 0 {
+		return t.Category[0]
+	}
+	return UncategorizedCategory
+}
+
+// CategoryPath returns the user's category if set, otherwise Plaid's full category
+// hierarchy from broadest to most specific
+func (t *Transaction) CategoryPath() []string {
+	if t.UserCategory != "" {
+		return []string{t.UserCategory}
+	}
+	return t.Category
+}
+
+// DisplayName returns the user's custom name if set, otherwise the name reported by Plaid
+func (t *Transaction) DisplayName() string {
+	if t.CustomName != "" {
+		return t.CustomName
+	}
+	return t.Name
+}
+
+// Replaces reports whether t is the posted transaction that pending became: Plaid links
+// them by t's pending transaction ID
+func (t *Transaction) Replaces(pending *Transaction) bool {
+	return !t.Pending &&
+		t.ID != pending.ID &&
+		t.UserID == pending.UserID &&
+		t.PendingTransactionID != "" &&
+		t.PendingTransactionID == pending.PlaidTransactionID
+}
+
+// InheritAnnotations carries the user's annotations over from the pending transaction t
+// replaces. Values the user set while the transaction was pending take precedence, and
+// flags set on either are kept.
+func (t *Transaction) InheritAnnotations(pending *Transaction) {
+	if pending.UserCategory != "" {
+		t.UserCategory = pending.UserCategory
+	}
+	if pending.CustomName != "" {
+		t.CustomName = pending.CustomName
+	}
+	if pending.Notes != "" {
+		t.Notes = pending.Notes
+	}
+	t.IsTransfer = t.IsTransfer || pending.IsTransfer
+	t.Hidden = t.Hidden || pending.Hidden
+	t.UpdatedAt = time.Now().UTC()
+}
+
+// Transaction directions
+const (
+	DirectionOutflow = "outflow"
+	DirectionInflow  = "inflow"
+)
+
+// TransactionFilter selects a user's transactions in a search. Empty fields match every
+// transaction.
+type TransactionFilter struct {
+	Text       string     `json:"text,omitempty"`       // Matched against the name and merchant
+	Categories []string   `json:"categories,omitempty"` // Effective categories, case-insensitive
+	Direction  string     `json:"direction,omitempty"`  // DirectionOutflow or DirectionInflow
+	AccountID  *uuid.UUID `json:"account_id,omitempty"`
+	From       *time.Time `json:"from,omitempty"`
+	To         *time.Time `json:"to,omitempty"`
+	MinAmount  *float64   `json:"min_amount,omitempty"` // Plaid sign convention (positive = outflow)
+	MaxAmount  *float64   `json:"max_amount,omitempty"`
+}
+
+// UncategorizedCategory is the category reported for transactions without any category
+const UncategorizedCategory = "Uncategorized"
+
+// CategoryTotal is the sum of transaction amounts in a category over a period
+type CategoryTotal struct {
+	Category string  `json:"category"`
+	Total    float64 `json:"total"`
+	Count    int     `json:"count"`
+}
+
+// TransactionLink records that a posted transaction replaced a pending one, and that the
+// user's annotations were carried over from the pending row
+type TransactionLink struct {
+	ID                        uuid.UUID `json:"id" db:"id"`
+	UserID                    uuid.UUID `json:"user_id" db:"user_id"`
+	PendingTransactionID      uuid.UUID `json:"pending_transaction_id" db:"pending_transaction_id"`
+	PendingPlaidTransactionID string    `json:"pending_plaid_transaction_id" db:"pending_plaid_transaction_id"`
+	PendingAmount             float64   `json:"pending_amount" db:"pending_amount"`
+	PostedTransactionID       uuid.UUID `json:"posted_transaction_id" db:"posted_transaction_id"`
+	PostedAmount              float64   `json:"posted_amount" db:"posted_amount"`
+	CreatedAt                 time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TransactionEmbedding is the vector of a transaction's description under one embedding
+// model
+type TransactionEmbedding struct {
+	TransactionID uuid.UUID `json:"transaction_id" db:"transaction_id"`
+	UserID        uuid.UUID `json:"user_id" db:"user_id"`
+	Model         string    `json:"model" db:"model"`
+	TextHash      string    `json:"text_hash" db:"text_hash"` // Hash of the embedded text, to skip unchanged descriptions
+	Vector        []float32 `json:"-" db:"embedding"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// NewTransactionEmbedding creates a new TransactionEmbedding record
+func NewTransactionEmbedding(transaction *Transaction, model, textHash string, vector []float32) *TransactionEmbedding {
+	return &TransactionEmbedding{
+		TransactionID: transaction.ID,
+		UserID:        transaction.UserID,
+		Model:         model,
+		TextHash:      textHash,
+		Vector:        vector,
+		UpdatedAt:     time.Now().UTC(),
+	}
+}
+
+// EmbeddingMatch is a transaction found near a query vector
+type EmbeddingMatch struct {
+	TransactionID uuid.UUID `json:"transaction_id"`
+	Score         float64   `json:"score"` // Cosine similarity, -1 to 1
+}
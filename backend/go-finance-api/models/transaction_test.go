@@ -0,0 +1,86 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReplaces tests matching a posted transaction to the pending one it replaces
+func TestReplaces(t *testing.T) {
+	userID := uuid.New()
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	pending := NewTransaction(uuid.New(), userID, "pending-1", "", nil, "COFFEE", "", 4.5, "USD", date, true, "in store")
+
+	posted := func(pendingID string, stillPending bool, owner uuid.UUID) *Transaction {
+		transaction := NewTransaction(uuid.New(), owner, "posted-1", "", nil, "COFFEE", "", 5.4, "USD", date, stillPending, "in store")
+		transaction.PendingTransactionID = pendingID
+		return transaction
+	}
+
+	tests := []struct {
+		name   string
+		posted *Transaction
+		want   bool
+	}{
+		{"matches pending transaction ID", posted("pending-1", false, userID), true},
+		{"different pending transaction ID", posted("pending-2", false, userID), false},
+		{"no pending transaction ID", posted("", false, userID), false},
+		{"still pending", posted("pending-1", true, userID), false},
+		{"another user's transaction", posted("pending-1", false, uuid.New()), false},
+		{"the pending row itself", pending, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.posted.Replaces(pending))
+		})
+	}
+}
+
+// TestInheritAnnotations tests carrying the user's annotations from pending to posted
+func TestInheritAnnotations(t *testing.T) {
+	tests := []struct {
+		name    string
+		pending Transaction
+		posted  Transaction
+		want    Transaction
+	}{
+		{
+			name:    "pending annotations carried over",
+			pending: Transaction{UserCategory: "Coffee", CustomName: "Morning coffee", Notes: "With Sam", Hidden: true},
+			posted:  Transaction{},
+			want:    Transaction{UserCategory: "Coffee", CustomName: "Morning coffee", Notes: "With Sam", Hidden: true},
+		},
+		{
+			name:    "pending annotations take precedence",
+			pending: Transaction{UserCategory: "Coffee", Notes: "Pending note"},
+			posted:  Transaction{UserCategory: "Food and Drink", Notes: "Posted note", CustomName: "Cafe"},
+			want:    Transaction{UserCategory: "Coffee", Notes: "Pending note", CustomName: "Cafe"},
+		},
+		{
+			name:    "flags kept from either",
+			pending: Transaction{IsTransfer: true},
+			posted:  Transaction{Hidden: true},
+			want:    Transaction{IsTransfer: true, Hidden: true},
+		},
+		{
+			name:    "nothing to carry over",
+			pending: Transaction{},
+			posted:  Transaction{UserCategory: "Travel"},
+			want:    Transaction{UserCategory: "Travel"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posted := tt.posted
+			posted.InheritAnnotations(&tt.pending)
+			assert.Equal(t, tt.want.UserCategory, posted.UserCategory)
+			assert.Equal(t, tt.want.CustomName, posted.CustomName)
+			assert.Equal(t, tt.want.Notes, posted.Notes)
+			assert.Equal(t, tt.want.IsTransfer, posted.IsTransfer)
+			assert.Equal(t, tt.want.Hidden, posted.Hidden)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Transfer pair statuses
+const (
+	TransferStatusDetected  = "detected"  // Paired automatically
+	TransferStatusConfirmed = "confirmed" // Confirmed by the user
+	TransferStatusRejected  = "rejected"  // Unlinked by the user; never paired again
+)
+
+// TransferPair links the two sides of a transfer between a user's own accounts, such as a
+// credit card payment appearing as an outflow from checking and an inflow to the card
+type TransferPair struct {
+	ID                   uuid.UUID `json:"id" db:"id"`
+	UserID               uuid.UUID `json:"user_id" db:"user_id"`
+	OutflowTransactionID uuid.UUID `json:"outflow_transaction_id" db:"outflow_transaction_id"`
+	InflowTransactionID  uuid.UUID `json:"inflow_transaction_id" db:"inflow_transaction_id"`
+	OutflowAccountID     uuid.UUID `json:"outflow_account_id" db:"-"` // Account the money left
+	InflowAccountID      uuid.UUID `json:"inflow_account_id" db:"-"`  // Account the money arrived in
+	Amount               float64   `json:"amount" db:"amount"`        // Always positive
+	Status               string    `json:"status" db:"status"`
+	CreatedAt            time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// NewTransferPair creates a new detected TransferPair from an outflow and the matching inflow
+func NewTransferPair(userID uuid.UUID, outflow, inflow *Transaction) *TransferPair {
+	now := time.Now().UTC()
+	return &TransferPair{
+		ID:                   uuid.New(),
+		UserID:               userID,
+		OutflowTransactionID: outflow.ID,
+		InflowTransactionID:  inflow.ID,
+		OutflowAccountID:     outflow.AccountID,
+		InflowAccountID:      inflow.AccountID,
+		Amount:               outflow.Amount,
+		Status:               TransferStatusDetected,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	}
+}
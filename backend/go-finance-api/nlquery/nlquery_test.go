@@ -0,0 +1,113 @@
+package nlquery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/llm"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const marchVsApril = `{
+	"categories": ["Food and Drink"],
+	"direction": "spending",
+	"periods": [
+		{"label": "March", "from": "2024-03-01", "to": "2024-03-31"},
+		{"label": "April", "from": "2024-04-01", "to": "2024-04-30"}
+	],
+	"aggregate": "sum"
+}`
+
+// TestParseSpec tests decoding, defaults and rejected specs
+func TestParseSpec(t *testing.T) {
+	spec, err := ParseSpec([]byte(marchVsApril))
+	require.NoError(t, err)
+	assert.Equal(t, "Total spending in Food and Drink, March (2024-03-01 to 2024-03-31) vs April (2024-04-01 to 2024-04-30)", spec.Describe())
+
+	spec, err = ParseSpec([]byte(`{"periods": [{"from": "2024-01-01", "to": "2024-01-31"}], "aggregate": "list", "limit": 500}`))
+	require.NoError(t, err)
+	assert.Equal(t, DirectionSpending, spec.Direction)
+	assert.Equal(t, MaxListLimit, spec.Limit)
+	assert.Equal(t, "2024-01-01 to 2024-01-31", spec.Periods[0].Label)
+
+	invalid := []string{
+		`{"sql": "DROP TABLE transactions", "periods": [{"from": "2024-01-01", "to": "2024-01-31"}]}`,
+		`{"periods": []}`,
+		`{"periods": [{"from": "2024-02-01", "to": "2024-01-01"}]}`,
+		`{"periods": [{"from": "March", "to": "2024-01-01"}]}`,
+		`{"periods": [{"from": "2024-01-01", "to": "2024-01-31"}], "group_by": "user_id"}`,
+		`{"periods": [{"from": "2024-01-01", "to": "2024-01-31"}], "aggregate": "max"}`,
+		`{"periods": [{"from": "2024-01-01", "to": "2024-01-31"}], "min_amount": -5}`,
+		`{"periods": [{"from": "2024-01-01", "to": "2024-01-31"}], "min_amount": 50, "max_amount": 10}`,
+	}
+	for _, data := range invalid {
+		_, err := ParseSpec([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
+
+// TestFilter tests converting amounts to the sign convention of the direction
+func TestFilter(t *testing.T) {
+	min, max := 10.0, 100.0
+	spec := &Spec{Direction: DirectionIncome, MinAmount: &min, MaxAmount: &max, Periods: []Period{{From: "2024-01-01", To: "2024-01-31"}}}
+	require.NoError(t, spec.Validate())
+
+	filter := spec.Filter(spec.Periods[0])
+	assert.Equal(t, models.DirectionInflow, filter.Direction)
+	assert.Equal(t, -100.0, *filter.MinAmount)
+	assert.Equal(t, -10.0, *filter.MaxAmount)
+	assert.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), *filter.To)
+}
+
+// TestSummarize tests period values and groups for each aggregate
+func TestSummarize(t *testing.T) {
+	totals := []*models.SpendingTotal{
+		{Key: "2024-02", Spent: 90, Net: 90, Count: 2},
+		{Key: "2024-01", Spent: 30, Net: 30, Count: 4},
+	}
+	period := Period{Label: "Q1", From: "2024-01-01", To: "2024-03-31"}
+
+	sum := Summarize(&Spec{Direction: DirectionSpending, Aggregate: AggregateSum}, period, totals, nil)
+	assert.Equal(t, 120.0, sum.Value)
+	assert.Equal(t, 6, sum.Count)
+	assert.Nil(t, sum.Groups)
+
+	average := Summarize(&Spec{Direction: DirectionSpending, Aggregate: AggregateAverage, GroupBy: models.ReportByMonth}, period, totals, nil)
+	assert.Equal(t, 20.0, average.Value)
+	require.Len(t, average.Groups, 2)
+	assert.Equal(t, "2024-01", average.Groups[0].Key)
+	assert.Equal(t, 7.5, average.Groups[0].Value)
+	assert.Equal(t, 45.0, average.Groups[1].Value)
+
+	count := Summarize(&Spec{Direction: DirectionSpending, Aggregate: AggregateCount, GroupBy: models.ReportByAccount}, period, totals, map[string]string{"2024-02": "Checking"})
+	assert.Equal(t, 6.0, count.Value)
+	assert.Equal(t, "2024-01", count.Groups[0].Key) // Most transactions first
+	assert.Equal(t, "Checking", count.Groups[1].Name)
+}
+
+// TestTranslate tests that the model's call becomes a spec, with one retry after an
+// invalid one
+func TestTranslate(t *testing.T) {
+	today := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	provider := llm.NewFakeProvider(
+		&llm.ChatResponse{Message: llm.Message{ToolCalls: []llm.ToolCall{{ID: "1", Name: "run_query", Arguments: `{"periods": [], "aggregate": "sum"}`}}}},
+		&llm.ChatResponse{Message: llm.Message{ToolCalls: []llm.ToolCall{{ID: "2", Name: "run_query", Arguments: marchVsApril}}}},
+	)
+
+	spec, err := Translate(context.Background(), provider, "how much did I spend on restaurants in March vs April", today, []string{"Food and Drink", "Travel"})
+	require.NoError(t, err)
+	assert.Len(t, spec.Periods, 2)
+	assert.Equal(t, []string{"Food and Drink"}, spec.Categories)
+
+	requests := provider.Requests()
+	require.Len(t, requests, 2)
+	assert.Contains(t, requests[0].Messages[0].Content, "Friday, May 10, 2024")
+	assert.Contains(t, requests[0].Messages[0].Content, "Food and Drink, Travel")
+	assert.Equal(t, llm.RoleTool, requests[1].Messages[3].Role)
+
+	_, err = Translate(context.Background(), llm.NewFakeProvider(), "hello", today, nil)
+	assert.Equal(t, ErrNotUnderstood, err)
+}
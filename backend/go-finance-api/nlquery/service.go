@@ -0,0 +1,179 @@
+package nlquery
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/llm"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/davidwang/go-finance-api/go-finance-api/reports"
+	"github.com/google/uuid"
+)
+
+// Result is the answer to a query, with the spec it ran so the UI can show and edit it
+type Result struct {
+	Question       string          `json:"question,omitempty"`
+	Spec           *Spec           `json:"spec"`
+	Interpretation string          `json:"interpretation"`
+	Periods        []*PeriodResult `json:"periods"`
+	Change         *float64        `json:"change,omitempty"` // Last period's value minus the first's, when comparing
+}
+
+// PeriodResult is a query's answer for one period
+type PeriodResult struct {
+	Period
+	Value        float64                   `json:"value"` // The sum, count or average
+	Count        int                       `json:"count"`
+	Groups       []*GroupResult            `json:"groups,omitempty"`
+	Transactions []*models.TransactionLine `json:"transactions,omitempty"` // For list queries
+}
+
+// GroupResult is the value of one group within a period
+type GroupResult struct {
+	Key   string  `json:"key"`
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+	Count int     `json:"count"`
+}
+
+// Service translates and runs transaction queries
+type Service struct {
+	repos *db.Repositories
+	meter *llm.Meter
+	now   func() time.Time
+}
+
+// NewService creates a new Service. meter may be nil, in which case only specs can be
+// run.
+func NewService(repos *db.Repositories, meter *llm.Meter) *Service {
+	return &Service{repos: repos, meter: meter, now: time.Now}
+}
+
+// Ask translates a question with the model and runs the resulting spec
+func (s *Service) Ask(ctx context.Context, userID uuid.UUID, question string) (*Result, error) {
+	today := s.now().UTC().Truncate(24 * time.Hour)
+	totals, err := s.repos.Transaction.SumByCategory(userID, today.AddDate(-1, 0, 0), today)
+	if err != nil {
+		return nil, err
+	}
+	categories := make([]string, 0, len(totals))
+	for _, total := range totals {
+		categories = append(categories, total.Category)
+	}
+	sort.Strings(categories)
+
+	spec, err := Translate(ctx, s.meter.ForUser(userID, Feature), question, today, categories)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.Run(userID, spec)
+	if err != nil {
+		return nil, err
+	}
+	result.Question = question
+	return result, nil
+}
+
+// Run executes a validated spec against the user's transactions
+func (s *Service) Run(userID uuid.UUID, spec *Spec) (*Result, error) {
+	var names map[string]string
+	if spec.GroupBy == models.ReportByAccount {
+		accounts, err := s.repos.Account.GetByUserID(userID)
+		if err != nil {
+			return nil, err
+		}
+		names = make(map[string]string, len(accounts))
+		for _, account := range accounts {
+			names[account.ID.String()] = account.Name
+		}
+	}
+
+	result := &Result{Spec: spec, Interpretation: spec.Describe()}
+	for _, period := range spec.Periods {
+		filter := spec.Filter(period)
+		if spec.Aggregate == AggregateList {
+			// Lines rather than transactions, so a list agrees with the sum of the same spec
+			lines, err := s.repos.Transaction.SearchLines(userID, filter, spec.Limit)
+			if err != nil {
+				return nil, err
+			}
+			result.Periods = append(result.Periods, &PeriodResult{Period: period, Count: len(lines), Transactions: lines})
+			continue
+		}
+
+		totals, err := s.repos.Transaction.AggregateLines(userID, filter, spec.GroupBy)
+		if err != nil {
+			return nil, err
+		}
+		result.Periods = append(result.Periods, Summarize(spec, period, totals, names))
+	}
+
+	if n := len(result.Periods); n > 1 && spec.Aggregate != AggregateList {
+		change := roundValue(spec, result.Periods[n-1].Value-result.Periods[0].Value)
+		result.Change = &change
+	}
+	return result, nil
+}
+
+// Summarize computes a period's value, and its groups' values if the spec is grouped,
+// from the period's totals. names maps group keys to display names.
+func Summarize(spec *Spec, period Period, totals []*models.SpendingTotal, names map[string]string) *PeriodResult {
+	total := reports.Sum(totals)
+	result := &PeriodResult{Period: period, Value: value(spec, total), Count: total.Count}
+	if spec.GroupBy == "" {
+		return result
+	}
+
+	result.Groups = make([]*GroupResult, 0, len(totals))
+	for _, group := range totals {
+		name := group.Key
+		if named, ok := names[group.Key]; ok {
+			name = named
+		}
+		result.Groups = append(result.Groups, &GroupResult{Key: group.Key, Name: name, Value: value(spec, group), Count: group.Count})
+	}
+	sort.SliceStable(result.Groups, func(i, j int) bool {
+		if spec.GroupBy == models.ReportByMonth {
+			return result.Groups[i].Key < result.Groups[j].Key
+		}
+		return result.Groups[i].Value > result.Groups[j].Value
+	})
+	return result
+}
+
+// value is the spec's aggregate of a total: spending is what went out, income what came
+// in, and all directions the net outflow
+func value(spec *Spec, total *models.SpendingTotal) float64 {
+	var amount float64
+	switch spec.Direction {
+	case DirectionSpending:
+		amount = total.Spent
+	case DirectionIncome:
+		amount = total.Received
+	default:
+		amount = total.Net
+	}
+
+	switch spec.Aggregate {
+	case AggregateCount:
+		return float64(total.Count)
+	case AggregateAverage:
+		if total.Count == 0 {
+			return 0
+		}
+		return roundValue(spec, amount/float64(total.Count))
+	default:
+		return amount
+	}
+}
+
+// roundValue rounds amounts to cents; counts are whole already
+func roundValue(spec *Spec, v float64) float64 {
+	if spec.Aggregate == AggregateCount {
+		return v
+	}
+	return math.Round(v*100) / 100
+}
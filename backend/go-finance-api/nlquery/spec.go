@@ -0,0 +1,259 @@
+// Package nlquery answers natural-language questions about transactions by translating
+// them into a validated query spec, which is run through the repositories and never
+// becomes SQL text
+package nlquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+)
+
+// Spec limits
+const (
+	MaxPeriods       = 4
+	MaxCategories    = 10
+	MaxTextLength    = 100
+	MaxPeriodDays    = 5 * 366
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// Aggregations
+const (
+	AggregateSum     = "sum"
+	AggregateCount   = "count"
+	AggregateAverage = "average"
+	AggregateList    = "list"
+)
+
+// Directions of money a spec selects
+const (
+	DirectionSpending = "spending"
+	DirectionIncome   = "income"
+	DirectionAll      = "all"
+)
+
+// Groupings
+var groupings = map[string]bool{
+	"":                      true,
+	models.ReportByCategory: true,
+	models.ReportByMerchant: true,
+	models.ReportByAccount:  true,
+	models.ReportByMonth:    true,
+}
+
+// Spec is a structured transaction query. The same filter is applied to each period, so
+// "March vs April" is one spec with two periods.
+type Spec struct {
+	Text       string   `json:"text,omitempty"`       // Words in the transaction or merchant name
+	Categories []string `json:"categories,omitempty"` // Any of these categories
+	Direction  string   `json:"direction"`            // spending, income or all
+	MinAmount  *float64 `json:"min_amount,omitempty"` // Smallest amount, as a positive number
+	MaxAmount  *float64 `json:"max_amount,omitempty"` // Largest amount, as a positive number
+	Periods    []Period `json:"periods"`
+	Aggregate  string   `json:"aggregate"`          // sum, count, average or list
+	GroupBy    string   `json:"group_by,omitempty"` // category, merchant, account or month
+	Limit      int      `json:"limit,omitempty"`    // Transactions per period for list
+}
+
+// Period is a labelled, inclusive date range (YYYY-MM-DD)
+type Period struct {
+	Label string `json:"label"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// ParseSpec strictly decodes a JSON spec and validates it. Unknown fields are rejected.
+func ParseSpec(data []byte) (*Spec, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var spec Spec
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("invalid query: %v", err)
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// Validate checks the spec and fills in defaults
+func (s *Spec) Validate() error {
+	s.Text = strings.TrimSpace(s.Text)
+	if len([]rune(s.Text)) > MaxTextLength {
+		return fmt.Errorf("text must be at most %d characters", MaxTextLength)
+	}
+
+	if len(s.Categories) > MaxCategories {
+		return fmt.Errorf("at most %d categories may be given", MaxCategories)
+	}
+	categories := s.Categories[:0]
+	for _, category := range s.Categories {
+		if category = strings.TrimSpace(category); category != "" {
+			categories = append(categories, category)
+		}
+	}
+	s.Categories = categories
+
+	switch s.Direction {
+	case "":
+		s.Direction = DirectionSpending
+	case DirectionSpending, DirectionIncome, DirectionAll:
+	default:
+		return fmt.Errorf("direction must be spending, income or all")
+	}
+
+	for _, amount := range []*float64{s.MinAmount, s.MaxAmount} {
+		if amount != nil && (*amount < 0 || math.IsInf(*amount, 0) || math.IsNaN(*amount)) {
+			return fmt.Errorf("amounts must be positive numbers")
+		}
+	}
+	if s.MinAmount != nil && s.MaxAmount != nil && *s.MinAmount > *s.MaxAmount {
+		return fmt.Errorf("min_amount must not be more than max_amount")
+	}
+
+	if len(s.Periods) == 0 || len(s.Periods) > MaxPeriods {
+		return fmt.Errorf("between 1 and %d periods must be given", MaxPeriods)
+	}
+	for i := range s.Periods {
+		if err := s.Periods[i].validate(); err != nil {
+			return err
+		}
+	}
+
+	switch s.Aggregate {
+	case "":
+		s.Aggregate = AggregateSum
+	case AggregateSum, AggregateCount, AggregateAverage, AggregateList:
+	default:
+		return fmt.Errorf("aggregate must be sum, count, average or list")
+	}
+	if !groupings[s.GroupBy] {
+		return fmt.Errorf("group_by must be category, merchant, account or month")
+	}
+	if s.Aggregate == AggregateList {
+		s.GroupBy = ""
+		if s.Limit <= 0 {
+			s.Limit = DefaultListLimit
+		} else if s.Limit > MaxListLimit {
+			s.Limit = MaxListLimit
+		}
+	} else {
+		s.Limit = 0
+	}
+	return nil
+}
+
+// validate checks a period's dates and labels it with them if it has no label
+func (p *Period) validate() error {
+	from, to, err := p.Dates()
+	if err != nil {
+		return err
+	}
+	if to.Before(from) {
+		return fmt.Errorf("period %s to %s ends before it starts", p.From, p.To)
+	}
+	if to.Sub(from) > MaxPeriodDays*24*time.Hour {
+		return fmt.Errorf("periods may be at most five years long")
+	}
+	p.Label = strings.TrimSpace(p.Label)
+	if p.Label == "" {
+		p.Label = p.From + " to " + p.To
+	}
+	return nil
+}
+
+// Dates parses the period's dates
+func (p Period) Dates() (from, to time.Time, err error) {
+	if from, err = time.Parse("2006-01-02", p.From); err != nil {
+		return from, to, fmt.Errorf("period from must be a date in YYYY-MM-DD format")
+	}
+	if to, err = time.Parse("2006-01-02", p.To); err != nil {
+		return from, to, fmt.Errorf("period to must be a date in YYYY-MM-DD format")
+	}
+	return from, to, nil
+}
+
+// Filter returns the repository filter for one period of a valid spec. Amounts are
+// converted to the Plaid sign convention of the spec's direction.
+func (s *Spec) Filter(period Period) models.TransactionFilter {
+	from, to, _ := period.Dates()
+	filter := models.TransactionFilter{
+		Text:       s.Text,
+		Categories: s.Categories,
+		From:       &from,
+		To:         &to,
+	}
+	switch s.Direction {
+	case DirectionSpending:
+		filter.Direction = models.DirectionOutflow
+		filter.MinAmount, filter.MaxAmount = s.MinAmount, s.MaxAmount
+	case DirectionIncome:
+		filter.Direction = models.DirectionInflow
+		filter.MinAmount, filter.MaxAmount = negate(s.MaxAmount), negate(s.MinAmount)
+	}
+	return filter
+}
+
+// Describe restates a valid spec in words, for the UI to show how a question was read
+func (s *Spec) Describe() string {
+	var b strings.Builder
+	switch s.Aggregate {
+	case AggregateSum:
+		b.WriteString("Total ")
+	case AggregateCount:
+		b.WriteString("Number of ")
+	case AggregateAverage:
+		b.WriteString("Average ")
+	case AggregateList:
+		b.WriteString("List of ")
+	}
+	switch s.Direction {
+	case DirectionSpending:
+		b.WriteString("spending")
+	case DirectionIncome:
+		b.WriteString("income")
+	default:
+		b.WriteString("transactions")
+	}
+	if len(s.Categories) > 0 {
+		b.WriteString(" in " + strings.Join(s.Categories, " or "))
+	}
+	if s.Text != "" {
+		b.WriteString(fmt.Sprintf(" matching %q", s.Text))
+	}
+	if s.MinAmount != nil {
+		b.WriteString(fmt.Sprintf(" of at least %.2f", *s.MinAmount))
+	}
+	if s.MaxAmount != nil {
+		b.WriteString(fmt.Sprintf(" of at most %.2f", *s.MaxAmount))
+	}
+	if s.GroupBy != "" {
+		b.WriteString(" by " + strings.ReplaceAll(s.GroupBy, "_", " "))
+	}
+
+	labels := make([]string, len(s.Periods))
+	for i, period := range s.Periods {
+		labels[i] = period.Label
+		if period.Label != period.From+" to "+period.To {
+			labels[i] += " (" + period.From + " to " + period.To + ")"
+		}
+	}
+	b.WriteString(", " + strings.Join(labels, " vs "))
+	return b.String()
+}
+
+// negate returns the negative of an optional amount
+func negate(amount *float64) *float64 {
+	if amount == nil {
+		return nil
+	}
+	negated := -*amount
+	return &negated
+}
@@ -0,0 +1,116 @@
+package nlquery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/llm"
+)
+
+// Feature is the name usage is recorded under
+const Feature = "query"
+
+// translateAttempts is how many times the model may try to produce a valid spec
+const translateAttempts = 2
+
+// ErrNotUnderstood is returned when the model cannot turn a question into a valid spec
+var ErrNotUnderstood = errors.New("the question could not be turned into a transaction query")
+
+// queryTool is the only tool offered to the model; its arguments are the spec
+var queryTool = llm.Tool{
+	Name:        "run_query",
+	Description: "Run a query over the user's transactions.",
+	Parameters: json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"text": {"type": "string", "description": "Words that must appear in the transaction or merchant name"},
+			"categories": {"type": "array", "items": {"type": "string"}, "description": "Match any of these categories, chosen from the user's categories"},
+			"direction": {"type": "string", "enum": ["spending", "income", "all"]},
+			"min_amount": {"type": "number", "description": "Smallest amount, positive"},
+			"max_amount": {"type": "number", "description": "Largest amount, positive"},
+			"periods": {
+				"type": "array",
+				"minItems": 1,
+				"maxItems": 4,
+				"description": "One period, or one per period being compared",
+				"items": {
+					"type": "object",
+					"properties": {
+						"label": {"type": "string", "description": "Short name such as March 2024"},
+						"from": {"type": "string", "description": "YYYY-MM-DD"},
+						"to": {"type": "string", "description": "YYYY-MM-DD, inclusive"}
+					},
+					"required": ["label", "from", "to"],
+					"additionalProperties": false
+				}
+			},
+			"aggregate": {"type": "string", "enum": ["sum", "count", "average", "list"]},
+			"group_by": {"type": "string", "enum": ["category", "merchant", "account", "month"]},
+			"limit": {"type": "integer", "description": "Transactions per period when listing"}
+		},
+		"required": ["direction", "periods", "aggregate"],
+		"additionalProperties": false
+	}`),
+}
+
+// translatePrompt instructs the model. The arguments are today's date and the user's
+// categories.
+const translatePrompt = `You turn questions about a user's bank transactions into a query by calling run_query.
+Today is %s. Resolve relative dates such as "last month" or "March" (the most recent March that has started) to exact dates.
+Use only these categories, exactly as written, and leave categories empty if none fits: %s.
+Call run_query exactly once and do not answer in text.`
+
+// Translate asks the model to express a question as a valid Spec. categories lists the
+// user's categories so the model can map words such as "restaurants" onto them.
+func Translate(ctx context.Context, provider llm.Provider, question string, today time.Time, categories []string) (*Spec, error) {
+	known := "none"
+	if len(categories) > 0 {
+		known = strings.Join(categories, ", ")
+	}
+	messages := []llm.Message{
+		{Role: llm.RoleSystem, Content: fmt.Sprintf(translatePrompt, today.Format("Monday, January 2, 2006"), known)},
+		{Role: llm.RoleUser, Content: question},
+	}
+
+	for attempt := 0; attempt < translateAttempts; attempt++ {
+		resp, err := provider.Chat(ctx, llm.ChatRequest{Messages: messages, Tools: []llm.Tool{queryTool}})
+		if err != nil {
+			return nil, err
+		}
+		call := findCall(resp.Message.ToolCalls)
+		if call == nil {
+			return nil, ErrNotUnderstood
+		}
+
+		spec, err := ParseSpec([]byte(call.Arguments))
+		if err == nil {
+			return spec, nil
+		}
+		// Show the model what was wrong and let it try again
+		messages = append(messages,
+			llm.Message{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{*call}},
+			llm.Message{Role: llm.RoleTool, ToolCallID: call.ID, Content: `{"error": ` + quote(err.Error()) + `}`},
+		)
+	}
+	return nil, ErrNotUnderstood
+}
+
+// findCall returns the run_query call among a reply's tool calls
+func findCall(calls []llm.ToolCall) *llm.ToolCall {
+	for i := range calls {
+		if calls[i].Name == queryTool.Name {
+			return &calls[i]
+		}
+	}
+	return nil
+}
+
+// quote encodes s as a JSON string
+func quote(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
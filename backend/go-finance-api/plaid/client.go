@@ -151,6 +151,34 @@ func (c *Client) SyncTransactions(accessToken string, cursor string) (*plaid.Tra
 	return &resp, nil
 }
 
+// GetRecurringTransactions retrieves the recurring transaction streams Plaid detected
+// for an Item's accounts
+func (c *Client) GetRecurringTransactions(accessToken string, accountIDs []string) (*plaid.TransactionsRecurringGetResponse, error) {
+	ctx := context.Background()
+
+	request := plaid.NewTransactionsRecurringGetRequest(accessToken, accountIDs)
+	resp, _, err := c.client.PlaidApi.TransactionsRecurringGet(ctx).TransactionsRecurringGetRequest(*request).Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// GetLiabilities retrieves payment details for an Item's credit card, student loan and
+// mortgage accounts
+func (c *Client) GetLiabilities(accessToken string) (*plaid.LiabilitiesGetResponse, error) {
+	ctx := context.Background()
+
+	request := plaid.NewLiabilitiesGetRequest(accessToken)
+	resp, _, err := c.client.PlaidApi.LiabilitiesGet(ctx).LiabilitiesGetRequest(*request).Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
 // GetItem retrieves item information
 func (c *Client) GetItem(accessToken string) (*plaid.ItemGetResponse, error) {
 	ctx := context.Background()
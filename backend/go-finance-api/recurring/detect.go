@@ -0,0 +1,311 @@
+// Package recurring detects repeating transactions such as subscriptions, bills and income
+package recurring
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+const (
+	// AmountTolerance is how far apart, as a fraction, neighbouring amounts in a series may be
+	AmountTolerance = 0.2
+
+	// priceIncreaseThreshold is how much, as a fraction, the latest charge must exceed the
+	// earlier average to count as a price increase
+	priceIncreaseThreshold = 0.03
+
+	// recentOccurrences is how many of the latest transactions the average amount covers
+	recentOccurrences = 6
+)
+
+// cadence describes how often a frequency repeats and how much slack it allows
+type cadence struct {
+	frequency      string
+	minDays        float64 // Shortest interval between transactions
+	maxDays        float64 // Longest interval between transactions
+	minOccurrences int
+	graceDays      int // How late a transaction may be before it is missed
+}
+
+// cadences are tried in order against the median interval of a series
+var cadences = []cadence{
+	{models.FrequencyWeekly, 5, 9, 3, 3},
+	{models.FrequencyBiweekly, 12, 16, 3, 5},
+	{models.FrequencyMonthly, 26, 35, 3, 7},
+	{models.FrequencyAnnual, 350, 380, 2, 30},
+}
+
+// NormalizeMerchant reduces a transaction's merchant to a key shared by all of its
+// charges: the merchant name when Plaid provides one, otherwise the display name,
+// lowercased with digits and punctuation removed
+func NormalizeMerchant(t *models.Transaction) string {
+	return normalizeName(merchantName(t))
+}
+
+// normalizeName lowercases a merchant name and drops everything but letters
+func normalizeName(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// Matches reports whether a transaction belongs to a series' merchant and direction
+func Matches(s *models.RecurringSeries, t *models.Transaction) bool {
+	key := groupKey(t)
+	return key != "" && strings.HasPrefix(s.SeriesKey, key+":")
+}
+
+// groupKey is the direction and normalized merchant of a transaction, the prefix of the
+// key of any series it belongs to. It is empty if the merchant has no name.
+func groupKey(t *models.Transaction) string {
+	merchant := NormalizeMerchant(t)
+	if merchant == "" {
+		return ""
+	}
+	if t.Amount < 0 {
+		return "in:" + merchant
+	}
+	return "out:" + merchant
+}
+
+// NextDate returns the date a series with the given frequency repeats after last.
+// Monthly and annual series fall on the last day of the month when it is shorter than
+// last's day.
+func NextDate(frequency string, last time.Time) time.Time {
+	switch frequency {
+	case models.FrequencyWeekly:
+		return last.AddDate(0, 0, 7)
+	case models.FrequencyBiweekly:
+		return last.AddDate(0, 0, 14)
+	case models.FrequencyAnnual:
+		return addMonths(last, 12, last.Day())
+	default:
+		return addMonths(last, 1, last.Day())
+	}
+}
+
+// Dates returns the dates a series is expected on from its next date through until.
+// Monthly and annual dates keep the day of the month of the series' last transaction,
+// so a bill on the 31st falls on the 29th in February and on the 31st again in March.
+func Dates(s *models.RecurringSeries, until time.Time) []time.Time {
+	var dates []time.Time
+	if s.Frequency == models.FrequencyWeekly || s.Frequency == models.FrequencyBiweekly {
+		for date := s.NextDate; !date.After(until); date = NextDate(s.Frequency, date) {
+			dates = append(dates, date)
+		}
+		return dates
+	}
+
+	step, day := 1, s.NextDate.Day()
+	if s.Frequency == models.FrequencyAnnual {
+		step = 12
+	}
+	if !s.LastDate.IsZero() {
+		day = s.LastDate.Day()
+	}
+	for n := 0; ; n++ {
+		date := addMonths(s.NextDate, n*step, day)
+		if date.After(until) {
+			return dates
+		}
+		dates = append(dates, date)
+	}
+}
+
+// addMonths returns day of the month months after t's, or the last day of that month if
+// it is shorter
+func addMonths(t time.Time, months, day int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// Status reports whether a series expected on next has been missed as of today
+func Status(frequency string, next, today time.Time) string {
+	for _, c := range cadences {
+		if c.frequency == frequency && today.After(next.AddDate(0, 0, c.graceDays)) {
+			return models.RecurringStatusMissed
+		}
+	}
+	return models.RecurringStatusActive
+}
+
+// Detect finds recurring series in a user's transaction history. Posted transactions
+// are grouped by normalized merchant and direction, split into runs of similar amounts,
+// and kept when the intervals between them match a weekly, biweekly, monthly or annual
+// cadence. Transfers and hidden transactions are ignored.
+func Detect(userID uuid.UUID, transactions []*models.Transaction, today time.Time) []*models.RecurringSeries {
+	groups := make(map[string][]*models.Transaction)
+	for _, t := range transactions {
+		if t.Pending || t.IsTransfer || t.Hidden || t.Amount == 0 {
+			continue
+		}
+		key := groupKey(t)
+		if key == "" {
+			continue
+		}
+		groups[key] = append(groups[key], t)
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var series []*models.RecurringSeries
+	seen := make(map[string]int)
+	for _, key := range keys {
+		for _, cluster := range clusterByAmount(groups[key]) {
+			s := detectSeries(userID, cluster, today)
+			if s == nil {
+				continue
+			}
+			// A merchant can bill several series at the same cadence, such as two plans
+			s.SeriesKey = key + ":" + s.Frequency
+			if seen[s.SeriesKey]++; seen[s.SeriesKey] > 1 {
+				s.SeriesKey += fmt.Sprintf("#%d", seen[s.SeriesKey])
+			}
+			series = append(series, s)
+		}
+	}
+	return series
+}
+
+// clusterByAmount splits transactions into runs whose neighbouring absolute amounts are
+// within AmountTolerance of each other, so gradual price changes stay in one run
+func clusterByAmount(transactions []*models.Transaction) [][]*models.Transaction {
+	sorted := append([]*models.Transaction(nil), transactions...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return math.Abs(sorted[i].Amount) < math.Abs(sorted[j].Amount)
+	})
+
+	var clusters [][]*models.Transaction
+	var current []*models.Transaction
+	for _, t := range sorted {
+		if len(current) > 0 {
+			previous := math.Abs(current[len(current)-1].Amount)
+			if math.Abs(t.Amount) > previous*(1+AmountTolerance) {
+				clusters = append(clusters, current)
+				current = nil
+			}
+		}
+		current = append(current, t)
+	}
+	if len(current) > 0 {
+		clusters = append(clusters, current)
+	}
+	return clusters
+}
+
+// detectSeries returns the series formed by a run of similar transactions, or nil if
+// they don't repeat at a known cadence
+func detectSeries(userID uuid.UUID, cluster []*models.Transaction, today time.Time) *models.RecurringSeries {
+	// One transaction per day; a second charge on the same day isn't a new occurrence
+	byDate := append([]*models.Transaction(nil), cluster...)
+	sort.SliceStable(byDate, func(i, j int) bool {
+		return byDate[i].Date.Before(byDate[j].Date)
+	})
+	occurrences := byDate[:0:0]
+	for _, t := range byDate {
+		if len(occurrences) > 0 && occurrences[len(occurrences)-1].Date.Equal(t.Date) {
+			continue
+		}
+		occurrences = append(occurrences, t)
+	}
+	if len(occurrences) < 2 {
+		return nil
+	}
+
+	intervals := make([]float64, 0, len(occurrences)-1)
+	for i := 1; i < len(occurrences); i++ {
+		intervals = append(intervals, occurrences[i].Date.Sub(occurrences[i-1].Date).Hours()/24)
+	}
+	c, ok := matchCadence(intervals, len(occurrences))
+	if !ok {
+		return nil
+	}
+
+	last := occurrences[len(occurrences)-1]
+	recent := occurrences
+	if len(recent) > recentOccurrences {
+		recent = recent[len(recent)-recentOccurrences:]
+	}
+	previous := recent[:len(recent)-1]
+
+	now := time.Now().UTC()
+	accountID := last.AccountID
+	s := &models.RecurringSeries{
+		ID:             uuid.New(),
+		UserID:         userID,
+		AccountID:      &accountID,
+		MerchantName:   merchantName(last),
+		Category:       last.EffectiveCategory(),
+		Frequency:      c.frequency,
+		AverageAmount:  averageAmount(recent),
+		LastAmount:     last.Amount,
+		LastDate:       last.Date,
+		NextDate:       NextDate(c.frequency, last.Date),
+		Occurrences:    len(occurrences),
+		PreviousAmount: averageAmount(previous),
+		Source:         models.RecurringSourceDetected,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	s.PriceIncrease = math.Abs(s.LastAmount) > math.Abs(s.PreviousAmount)*(1+priceIncreaseThreshold)
+	s.Status = Status(s.Frequency, s.NextDate, today)
+	return s
+}
+
+// matchCadence finds the cadence of a series from the intervals between its transactions.
+// The median interval picks the cadence, and at least three quarters of the intervals
+// must fit it.
+func matchCadence(intervals []float64, occurrences int) (cadence, bool) {
+	sorted := append([]float64(nil), intervals...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+
+	for _, c := range cadences {
+		if median < c.minDays || median > c.maxDays || occurrences < c.minOccurrences {
+			continue
+		}
+		fitting := 0
+		for _, interval := range intervals {
+			if interval >= c.minDays && interval <= c.maxDays {
+				fitting++
+			}
+		}
+		if float64(fitting) >= 0.75*float64(len(intervals)) {
+			return c, true
+		}
+	}
+	return cadence{}, false
+}
+
+// merchantName is the name shown for a series
+func merchantName(t *models.Transaction) string {
+	if t.MerchantName != "" {
+		return t.MerchantName
+	}
+	return t.DisplayName()
+}
+
+// averageAmount is the mean amount of transactions, rounded to cents
+func averageAmount(transactions []*models.Transaction) float64 {
+	var total int64
+	for _, t := range transactions {
+		total += models.ToMinorUnits(t.Amount)
+	}
+	cents := math.Round(float64(total) / float64(len(transactions)) / 100)
+	return cents / 100
+}
@@ -0,0 +1,230 @@
+package recurring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTransaction builds a transaction with the fields detection looks at
+func newTestTransaction(accountID uuid.UUID, name string, amount float64, date time.Time) *models.Transaction {
+	return &models.Transaction{
+		ID:        uuid.New(),
+		AccountID: accountID,
+		Name:      name,
+		Amount:    amount,
+		Date:      date,
+		Category:  []string{"Service"},
+	}
+}
+
+// TestNormalizeMerchant tests merchant keys
+func TestNormalizeMerchant(t *testing.T) {
+	tx := newTestTransaction(uuid.New(), "NETFLIX.COM 866-579-7172 CA", 15.49, time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, "netflix com ca", NormalizeMerchant(tx))
+
+	tx.MerchantName = "Netflix"
+	assert.Equal(t, "netflix", NormalizeMerchant(tx))
+}
+
+// TestDetectMonthlySubscriptionWithPriceIncrease tests a monthly series whose price went up
+func TestDetectMonthlySubscriptionWithPriceIncrease(t *testing.T) {
+	userID, checking := uuid.New(), uuid.New()
+	transactions := []*models.Transaction{
+		newTestTransaction(checking, "NETFLIX.COM", 15.49, time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)),
+		newTestTransaction(checking, "NETFLIX.COM", 15.49, time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)),
+		newTestTransaction(checking, "NETFLIX.COM", 15.49, time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC)),
+		newTestTransaction(checking, "NETFLIX.COM", 17.99, time.Date(2024, 4, 5, 0, 0, 0, 0, time.UTC)),
+		newTestTransaction(checking, "GROCERY OUTLET", 54.10, time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC)),
+		newTestTransaction(checking, "GROCERY OUTLET", 81.77, time.Date(2024, 1, 30, 0, 0, 0, 0, time.UTC)),
+		newTestTransaction(checking, "GROCERY OUTLET", 23.05, time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC)),
+	}
+
+	series := Detect(userID, transactions, time.Date(2024, 4, 20, 0, 0, 0, 0, time.UTC))
+
+	require.Len(t, series, 1)
+	s := series[0]
+	assert.Equal(t, "out:netflix com:monthly", s.SeriesKey)
+	assert.Equal(t, models.FrequencyMonthly, s.Frequency)
+	assert.Equal(t, 4, s.Occurrences)
+	assert.Equal(t, 17.99, s.LastAmount)
+	assert.Equal(t, 15.49, s.PreviousAmount)
+	assert.Equal(t, 16.12, s.AverageAmount)
+	assert.True(t, s.PriceIncrease)
+	assert.Equal(t, time.Date(2024, 5, 5, 0, 0, 0, 0, time.UTC), s.NextDate)
+	assert.Equal(t, models.RecurringStatusActive, s.Status)
+	assert.Equal(t, "Service", s.Category)
+}
+
+// TestDetectIncomeAndMissedCharges tests biweekly income and a lapsed subscription
+func TestDetectIncomeAndMissedCharges(t *testing.T) {
+	userID, checking := uuid.New(), uuid.New()
+	var transactions []*models.Transaction
+	for _, date := range []time.Time{time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 19, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 16, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)} {
+		transactions = append(transactions, newTestTransaction(checking, "ACME PAYROLL", -2500, date))
+	}
+	for _, date := range []time.Time{time.Date(2023, 10, 10, 0, 0, 0, 0, time.UTC), time.Date(2023, 11, 10, 0, 0, 0, 0, time.UTC), time.Date(2023, 12, 11, 0, 0, 0, 0, time.UTC)} {
+		transactions = append(transactions, newTestTransaction(checking, "GYM CLUB", 40, date))
+	}
+	// Pending and transfer transactions are ignored
+	pending := newTestTransaction(checking, "GYM CLUB", 40, time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC))
+	pending.Pending = true
+	transactions = append(transactions, pending)
+	for _, date := range []time.Time{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)} {
+		transfer := newTestTransaction(checking, "TRANSFER TO SAVINGS", 500, date)
+		transfer.IsTransfer = true
+		transactions = append(transactions, transfer)
+	}
+
+	series := Detect(userID, transactions, time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC))
+
+	require.Len(t, series, 2)
+	income, gym := series[0], series[1]
+	assert.Equal(t, "in:acme payroll:biweekly", income.SeriesKey)
+	assert.True(t, income.IsIncome())
+	assert.Equal(t, -2500.0, income.AverageAmount)
+	assert.Equal(t, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), income.NextDate)
+	assert.Equal(t, models.RecurringStatusActive, income.Status)
+
+	assert.Equal(t, models.FrequencyMonthly, gym.Frequency)
+	assert.Equal(t, models.RecurringStatusMissed, gym.Status)
+}
+
+// TestDetectSeparatesPlansByAmount tests two series from one merchant
+func TestDetectSeparatesPlansByAmount(t *testing.T) {
+	userID, card := uuid.New(), uuid.New()
+	var transactions []*models.Transaction
+	for _, date := range []time.Time{time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)} {
+		transactions = append(transactions, newTestTransaction(card, "APPLE.COM/BILL", 2.99, date))
+		transactions = append(transactions, newTestTransaction(card, "APPLE.COM/BILL", 10.99, date))
+	}
+	transactions = append(transactions,
+		newTestTransaction(card, "APPLE.COM/BILL", 99, time.Date(2023, 1, 20, 0, 0, 0, 0, time.UTC)),
+		newTestTransaction(card, "APPLE.COM/BILL", 99, time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)),
+	)
+
+	series := Detect(userID, transactions, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC))
+
+	require.Len(t, series, 3)
+	assert.Equal(t, "out:apple com bill:monthly", series[0].SeriesKey)
+	assert.Equal(t, 2.99, series[0].AverageAmount)
+	assert.Equal(t, "out:apple com bill:monthly#2", series[1].SeriesKey)
+	assert.Equal(t, 10.99, series[1].AverageAmount)
+	assert.Equal(t, models.FrequencyAnnual, series[2].Frequency)
+	assert.Equal(t, time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC), series[2].NextDate)
+}
+
+// TestMatches tests matching transactions to a detected series
+func TestMatches(t *testing.T) {
+	checking := uuid.New()
+	var transactions []*models.Transaction
+	for _, date := range []time.Time{time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)} {
+		transactions = append(transactions, newTestTransaction(checking, "NETFLIX.COM", 15.49, date))
+	}
+	series := Detect(uuid.New(), transactions, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC))
+	require.Len(t, series, 1)
+
+	assert.True(t, Matches(series[0], newTestTransaction(checking, "Netflix.com", 19.99, time.Date(2024, 4, 5, 0, 0, 0, 0, time.UTC))))
+	assert.False(t, Matches(series[0], newTestTransaction(checking, "NETFLIX.COM", -15.49, time.Date(2024, 4, 6, 0, 0, 0, 0, time.UTC))))
+	assert.False(t, Matches(series[0], newTestTransaction(checking, "NETFLIX", 15.49, time.Date(2024, 4, 5, 0, 0, 0, 0, time.UTC))))
+}
+
+// TestNextDate tests stepping each frequency, clamping to the end of shorter months
+func TestNextDate(t *testing.T) {
+	tests := []struct {
+		frequency string
+		last      time.Time
+		want      time.Time
+	}{
+		{models.FrequencyWeekly, time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{models.FrequencyBiweekly, time.Date(2024, 2, 23, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{models.FrequencyMonthly, time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 5, 0, 0, 0, 0, time.UTC)},
+		{models.FrequencyMonthly, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{models.FrequencyMonthly, time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC), time.Date(2023, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{models.FrequencyMonthly, time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{models.FrequencyAnnual, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, NextDate(tt.frequency, tt.last), "%s after %s", tt.frequency, tt.last.Format("2006-01-02"))
+	}
+}
+
+// TestDates tests that monthly dates return to the last transaction's day after a short month
+func TestDates(t *testing.T) {
+	tests := []struct {
+		name   string
+		series *models.RecurringSeries
+		until  time.Time
+		want   []time.Time
+	}{
+		{
+			name: "monthly on the 31st in a leap year",
+			series: &models.RecurringSeries{
+				Frequency: models.FrequencyMonthly,
+				LastDate:  time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+				NextDate:  time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			},
+			until: time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "monthly on the 31st in a common year",
+			series: &models.RecurringSeries{
+				Frequency: models.FrequencyMonthly,
+				LastDate:  time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC),
+				NextDate:  time.Date(2023, 2, 28, 0, 0, 0, 0, time.UTC),
+			},
+			until: time.Date(2023, 3, 31, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2023, 2, 28, 0, 0, 0, 0, time.UTC),
+				time.Date(2023, 3, 31, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "weekly",
+			series: &models.RecurringSeries{
+				Frequency: models.FrequencyWeekly,
+				LastDate:  time.Date(2024, 2, 23, 0, 0, 0, 0, time.UTC),
+				NextDate:  time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			},
+			until: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "annual",
+			series: &models.RecurringSeries{
+				Frequency: models.FrequencyAnnual,
+				LastDate:  time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC),
+				NextDate:  time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC),
+			},
+			until: time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC),
+			want:  []time.Time{time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "next date after the range",
+			series: &models.RecurringSeries{
+				Frequency: models.FrequencyMonthly,
+				NextDate:  time.Date(2024, 4, 5, 0, 0, 0, 0, time.UTC),
+			},
+			until: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Dates(tt.series, tt.until))
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package recurring
+
+import (
+	"log"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/davidwang/go-finance-api/go-finance-api/plaid"
+	"github.com/google/uuid"
+	plaidlib "github.com/plaid/plaid-go/v20/plaid"
+)
+
+// historyDays is how much transaction history detection looks at; annual series need
+// a little over a year
+const historyDays = 400
+
+// Detector finds and stores a user's recurring series
+type Detector struct {
+	repos       *db.Repositories
+	plaidClient *plaid.Client
+}
+
+// NewDetector creates a new Detector. plaidClient may be nil, in which case only the
+// user's transaction history is used.
+func NewDetector(repos *db.Repositories, plaidClient *plaid.Client) *Detector {
+	return &Detector{repos: repos, plaidClient: plaidClient}
+}
+
+// DetectForUser detects the user's recurring series and replaces the stored ones.
+// Streams reported by Plaid take the place of the matching detected series; Items whose
+// streams can't be fetched, such as those without the recurring product, fall back to
+// detection alone.
+func (d *Detector) DetectForUser(userID uuid.UUID) ([]*models.RecurringSeries, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	var transactions []*models.Transaction
+	err := d.repos.Transaction.ForEachByDateRange(userID, today.AddDate(0, 0, -historyDays), today, func(t *models.Transaction) error {
+		transactions = append(transactions, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	detected := Detect(userID, transactions, today)
+
+	streams, err := d.plaidStreams(userID, today)
+	if err != nil {
+		return nil, err
+	}
+	series := merge(detected, streams)
+
+	if err := d.repos.Recurring.ReplaceForUser(userID, series); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// plaidStreams fetches the recurring streams Plaid reports across the user's Items
+func (d *Detector) plaidStreams(userID uuid.UUID, today time.Time) ([]*models.RecurringSeries, error) {
+	if d.plaidClient == nil {
+		return nil, nil
+	}
+	items, err := d.repos.Item.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var series []*models.RecurringSeries
+	for _, item := range items {
+		accounts, err := d.repos.Account.GetByItemID(item.ID)
+		if err != nil {
+			return nil, err
+		}
+		accountIDs := make(map[string]uuid.UUID, len(accounts))
+		plaidAccountIDs := make([]string, 0, len(accounts))
+		for _, account := range accounts {
+			accountIDs[account.PlaidAccountID] = account.ID
+			plaidAccountIDs = append(plaidAccountIDs, account.PlaidAccountID)
+		}
+
+		resp, err := d.plaidClient.GetRecurringTransactions(item.AccessToken, plaidAccountIDs)
+		if err != nil {
+			log.Printf("Recurring streams unavailable for item %s: %v", item.ID, err)
+			continue
+		}
+
+		add := func(stream plaidlib.TransactionStream, inflow bool) {
+			var accountID *uuid.UUID
+			if id, ok := accountIDs[stream.GetAccountId()]; ok {
+				accountID = &id
+			}
+			if s := FromPlaidStream(userID, accountID, stream, inflow, today); s != nil {
+				series = append(series, s)
+			}
+		}
+		for _, stream := range resp.GetOutflowStreams() {
+			add(stream, false)
+		}
+		for _, stream := range resp.GetInflowStreams() {
+			add(stream, true)
+		}
+	}
+	return series, nil
+}
+
+// merge combines detected series with Plaid's, preferring Plaid's for the same key
+func merge(detected, streams []*models.RecurringSeries) []*models.RecurringSeries {
+	byKey := make(map[string]bool, len(streams))
+	for _, s := range streams {
+		byKey[s.SeriesKey] = true
+	}
+
+	merged := make([]*models.RecurringSeries, 0, len(detected)+len(streams))
+	for _, s := range detected {
+		if !byKey[s.SeriesKey] {
+			merged = append(merged, s)
+		}
+	}
+	// Plaid may report one merchant on several accounts; keep the first of each key
+	seen := make(map[string]bool, len(streams))
+	for _, s := range streams {
+		if !seen[s.SeriesKey] {
+			seen[s.SeriesKey] = true
+			merged = append(merged, s)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,83 @@
+package recurring
+
+import (
+	"math"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	plaidlib "github.com/plaid/plaid-go/v20/plaid"
+)
+
+// plaidFrequencies maps Plaid stream frequencies to ours. Semi-monthly and unknown
+// streams are left to local detection.
+var plaidFrequencies = map[plaidlib.RecurringTransactionFrequency]string{
+	plaidlib.RECURRINGTRANSACTIONFREQUENCY_WEEKLY:   models.FrequencyWeekly,
+	plaidlib.RECURRINGTRANSACTIONFREQUENCY_BIWEEKLY: models.FrequencyBiweekly,
+	plaidlib.RECURRINGTRANSACTIONFREQUENCY_MONTHLY:  models.FrequencyMonthly,
+	plaidlib.RECURRINGTRANSACTIONFREQUENCY_ANNUALLY: models.FrequencyAnnual,
+}
+
+// FromPlaidStream converts a stream from Plaid's /transactions/recurring/get into a
+// series keyed like a detected one, so it takes the detected series' place. It returns
+// nil for streams Plaid has retired or whose frequency isn't supported.
+func FromPlaidStream(userID uuid.UUID, accountID *uuid.UUID, stream plaidlib.TransactionStream, inflow bool, today time.Time) *models.RecurringSeries {
+	frequency, ok := plaidFrequencies[stream.GetFrequency()]
+	if !ok || stream.GetStatus() == plaidlib.TRANSACTIONSTREAMSTATUS_TOMBSTONED {
+		return nil
+	}
+	lastDate, err := time.Parse("2006-01-02", stream.GetLastDate())
+	if err != nil {
+		return nil
+	}
+
+	merchant := stream.GetMerchantName()
+	if merchant == "" {
+		merchant = stream.GetDescription()
+	}
+	key := normalizeName(merchant)
+	if key == "" {
+		return nil
+	}
+
+	// Outflows are positive and inflows negative, whatever sign Plaid reports
+	sign, direction := 1.0, "out"
+	if inflow {
+		sign, direction = -1.0, "in"
+	}
+	averageAmount := stream.GetAverageAmount()
+	lastAmount := stream.GetLastAmount()
+	average := sign * math.Abs(averageAmount.GetAmount())
+	last := sign * math.Abs(lastAmount.GetAmount())
+
+	category := models.UncategorizedCategory
+	if categories := stream.GetCategory(); len(categories) > 0 {
+		category = categories[0]
+	}
+
+	now := time.Now().UTC()
+	s := &models.RecurringSeries{
+		ID:             uuid.New(),
+		UserID:         userID,
+		AccountID:      accountID,
+		SeriesKey:      direction + ":" + key + ":" + frequency,
+		MerchantName:   merchant,
+		Category:       category,
+		Frequency:      frequency,
+		AverageAmount:  average,
+		LastAmount:     last,
+		LastDate:       lastDate,
+		NextDate:       NextDate(frequency, lastDate),
+		Occurrences:    len(stream.GetTransactionIds()),
+		PriceIncrease:  math.Abs(last) > math.Abs(average)*(1+priceIncreaseThreshold),
+		PreviousAmount: average,
+		Source:         models.RecurringSourcePlaid,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	s.Status = Status(frequency, s.NextDate, today)
+	if !stream.GetIsActive() {
+		s.Status = models.RecurringStatusMissed
+	}
+	return s
+}
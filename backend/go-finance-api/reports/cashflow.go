@@ -0,0 +1,239 @@
+package reports
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/davidwang/go-finance-api/go-finance-api/recurring"
+	"github.com/google/uuid"
+)
+
+// Transaction classes
+const (
+	ClassIncome   = "income"
+	ClassExpense  = "expense"
+	ClassTransfer = "transfer"
+)
+
+// transferCategories are categories of money moving between accounts rather than being
+// earned or spent, lowercased with underscores as spaces
+var transferCategories = map[string]bool{
+	"transfer":            true,
+	"transfer in":         true,
+	"transfer out":        true,
+	"payment":             true,
+	"credit card":         true,
+	"credit card payment": true,
+	"loan payments":       true,
+}
+
+// incomeCategories are categories of inflows that count as income. Other inflows, such
+// as refunds, reduce expenses instead.
+var incomeCategories = map[string]bool{
+	"income":          true,
+	"payroll":         true,
+	"paycheck":        true,
+	"salary":          true,
+	"interest":        true,
+	"interest earned": true,
+	"dividends":       true,
+	"deposit":         true,
+}
+
+// Classify decides whether a transaction line with the given category path and amount
+// (Plaid sign convention) is income, an expense or a transfer. The path runs from the
+// broadest category to the most specific, as Plaid reports it, and the most specific
+// known category decides: Plaid files paychecks as ["Transfer", "Payroll"], which is
+// income. Lines of transactions paired by the transfer matcher are always transfers.
+func Classify(isTransfer bool, categories []string, amount float64) string {
+	if isTransfer {
+		return ClassTransfer
+	}
+	for i := len(categories) - 1; i >= 0; i-- {
+		normalized := strings.ToLower(strings.ReplaceAll(categories[i], "_", " "))
+		switch {
+		case incomeCategories[normalized] && amount < 0:
+			return ClassIncome
+		case transferCategories[normalized]:
+			return ClassTransfer
+		}
+	}
+	return ClassExpense
+}
+
+// CashFlowMonth is a month's income, expenses and savings. Expenses are net of refunds
+// and split into fixed costs, which belong to a recurring series, and discretionary spend.
+type CashFlowMonth struct {
+	Month                   string                  `json:"month"` // YYYY-MM, or "total"
+	Income                  float64                 `json:"income"`
+	Expenses                float64                 `json:"expenses"`
+	FixedExpenses           float64                 `json:"fixed_expenses"`
+	DiscretionaryExpenses   float64                 `json:"discretionary_expenses"`
+	NetSavings              float64                 `json:"net_savings"`  // Income minus expenses
+	SavingsRate             *float64                `json:"savings_rate"` // Percent of income saved; nil without income
+	Transfers               float64                 `json:"transfers"`    // Outflows moved between accounts
+	FixedByCategory         []*models.CategoryTotal `json:"fixed_by_category"`
+	DiscretionaryByCategory []*models.CategoryTotal `json:"discretionary_by_category"`
+}
+
+// CashFlowReport is the monthly cash flow over a range of months with its total
+type CashFlowReport struct {
+	From   string           `json:"from"`
+	To     string           `json:"to"`
+	Months []*CashFlowMonth `json:"months"`
+	Total  *CashFlowMonth   `json:"total"`
+}
+
+// cashFlowAccumulator sums one month in minor units
+type cashFlowAccumulator struct {
+	income, transfers              int64
+	fixed, discretionary           map[string]int64
+	fixedCount, discretionaryCount map[string]int
+}
+
+func newCashFlowAccumulator() *cashFlowAccumulator {
+	return &cashFlowAccumulator{
+		fixed:              make(map[string]int64),
+		discretionary:      make(map[string]int64),
+		fixedCount:         make(map[string]int),
+		discretionaryCount: make(map[string]int),
+	}
+}
+
+// add records one transaction line
+func (a *cashFlowAccumulator) add(class, category string, amount int64, fixed bool) {
+	switch class {
+	case ClassTransfer:
+		if amount > 0 {
+			a.transfers += amount
+		}
+	case ClassIncome:
+		a.income -= amount
+	case ClassExpense:
+		if fixed {
+			a.fixed[category] += amount
+			a.fixedCount[category]++
+		} else {
+			a.discretionary[category] += amount
+			a.discretionaryCount[category]++
+		}
+	}
+}
+
+// merge adds another month's sums
+func (a *cashFlowAccumulator) merge(other *cashFlowAccumulator) {
+	a.income += other.income
+	a.transfers += other.transfers
+	for category, amount := range other.fixed {
+		a.fixed[category] += amount
+		a.fixedCount[category] += other.fixedCount[category]
+	}
+	for category, amount := range other.discretionary {
+		a.discretionary[category] += amount
+		a.discretionaryCount[category] += other.discretionaryCount[category]
+	}
+}
+
+// month converts the sums to a CashFlowMonth
+func (a *cashFlowAccumulator) month(label string) *CashFlowMonth {
+	fixed, fixedTotal := categoryTotals(a.fixed, a.fixedCount)
+	discretionary, discretionaryTotal := categoryTotals(a.discretionary, a.discretionaryCount)
+	expenses := fixedTotal + discretionaryTotal
+
+	month := &CashFlowMonth{
+		Month:                   label,
+		Income:                  models.FromMinorUnits(a.income),
+		Expenses:                models.FromMinorUnits(expenses),
+		FixedExpenses:           models.FromMinorUnits(fixedTotal),
+		DiscretionaryExpenses:   models.FromMinorUnits(discretionaryTotal),
+		NetSavings:              models.FromMinorUnits(a.income - expenses),
+		Transfers:               models.FromMinorUnits(a.transfers),
+		FixedByCategory:         fixed,
+		DiscretionaryByCategory: discretionary,
+	}
+	if a.income > 0 {
+		rate := math.Round(float64(a.income-expenses)/float64(a.income)*1000) / 10
+		month.SavingsRate = &rate
+	}
+	return month
+}
+
+// categoryTotals lists per-category sums, largest first, along with their total
+func categoryTotals(amounts map[string]int64, counts map[string]int) ([]*models.CategoryTotal, int64) {
+	totals := make([]*models.CategoryTotal, 0, len(amounts))
+	var sum int64
+	for category, amount := range amounts {
+		totals = append(totals, &models.CategoryTotal{
+			Category: category,
+			Total:    models.FromMinorUnits(amount),
+			Count:    counts[category],
+		})
+		sum += amount
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Total != totals[j].Total {
+			return totals[i].Total > totals[j].Total
+		}
+		return totals[i].Category < totals[j].Category
+	})
+	return totals, sum
+}
+
+// CashFlow builds the monthly cash flow from the first month of from to the month of to.
+// splits holds the splits of split transactions by transaction ID; each split is
+// classified under its own category. Expense lines of transactions that belong to one of
+// the recurring series are fixed costs. Pending and hidden transactions are left out.
+func CashFlow(transactions []*models.Transaction, splits map[uuid.UUID][]*models.TransactionSplit, series []*models.RecurringSeries, from, to time.Time) *CashFlowReport {
+	first := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	last := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var labels []string
+	months := make(map[string]*cashFlowAccumulator)
+	for month := first; !month.After(last); month = month.AddDate(0, 1, 0) {
+		label := month.Format("2006-01")
+		labels = append(labels, label)
+		months[label] = newCashFlowAccumulator()
+	}
+
+	for _, t := range transactions {
+		if t.Pending || t.Hidden {
+			continue
+		}
+		month, ok := months[t.Date.Format("2006-01")]
+		if !ok {
+			continue
+		}
+		fixed := isFixed(t, series)
+		if lines := splits[t.ID]; len(lines) > 0 {
+			for _, split := range lines {
+				class := Classify(t.IsTransfer, []string{split.Category}, split.Amount)
+				month.add(class, split.Category, models.ToMinorUnits(split.Amount), fixed)
+			}
+			continue
+		}
+		category := t.EffectiveCategory()
+		month.add(Classify(t.IsTransfer, t.CategoryPath(), t.Amount), category, models.ToMinorUnits(t.Amount), fixed)
+	}
+
+	report := &CashFlowReport{From: first.Format("2006-01"), To: last.Format("2006-01")}
+	total := newCashFlowAccumulator()
+	for _, label := range labels {
+		report.Months = append(report.Months, months[label].month(label))
+		total.merge(months[label])
+	}
+	report.Total = total.month("total")
+	return report
+}
+
+// isFixed reports whether an outflow belongs to one of the recurring expense series
+func isFixed(t *models.Transaction, series []*models.RecurringSeries) bool {
+	for _, s := range series {
+		if !s.IsIncome() && recurring.Matches(s, t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package reports
+
+import (
+	"testing"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClassify tests classifying lines by transfer flag, category path and sign
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name       string
+		isTransfer bool
+		categories []string
+		amount     float64
+		want       string
+	}{
+		{"matched transfer", true, []string{"Groceries"}, 50, ClassTransfer},
+		{"transfer category", false, []string{"TRANSFER_OUT"}, 500, ClassTransfer},
+		{"payment", false, []string{"Payment"}, -200, ClassTransfer},
+		{"payroll", false, []string{"Payroll"}, -3000, ClassIncome},
+		{"outflow in an income category", false, []string{"Payroll"}, 10, ClassExpense},
+		{"refund", false, []string{"Shops"}, -25, ClassExpense},
+		{"purchase", false, []string{"Shops"}, 25, ClassExpense},
+		{"Plaid paycheck", false, []string{"Transfer", "Payroll"}, -3000, ClassIncome},
+		{"Plaid deposit", false, []string{"Transfer", "Deposit"}, -500, ClassIncome},
+		{"Plaid interest", false, []string{"Interest", "Interest Earned"}, -1.25, ClassIncome},
+		{"Plaid account transfer", false, []string{"Transfer", "Internal Account Transfer"}, -1000, ClassTransfer},
+		{"Plaid card payment", false, []string{"Payment", "Credit Card"}, 800, ClassTransfer},
+		{"Plaid restaurant", false, []string{"Food and Drink", "Restaurants"}, 42, ClassExpense},
+		{"uncategorized", false, nil, 10, ClassExpense},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Classify(tt.isTransfer, tt.categories, tt.amount))
+		})
+	}
+}
+
+// newCashFlowTransaction builds a posted transaction on date
+func newCashFlowTransaction(name, category string, amount float64, date time.Time) *models.Transaction {
+	return &models.Transaction{ID: uuid.New(), Name: name, UserCategory: category, Amount: amount, Date: date}
+}
+
+// TestCashFlow tests monthly income, fixed and discretionary expenses and savings rate
+func TestCashFlow(t *testing.T) {
+	jan := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC)
+
+	rent := newCashFlowTransaction("Landlord", "Rent", 1500, jan)
+	shopping := newCashFlowTransaction("Costco", "Groceries", 300, jan)
+	transactions := []*models.Transaction{
+		newCashFlowTransaction("Acme Payroll", "Payroll", -4000, jan),
+		rent,
+		shopping,
+		newCashFlowTransaction("Costco", "Groceries", -50, jan), // Refund
+		{ID: uuid.New(), Name: "Card Payment", UserCategory: "Payment", Amount: 800, Date: jan, IsTransfer: true},
+		{ID: uuid.New(), Name: "Pending", UserCategory: "Groceries", Amount: 99, Date: jan, Pending: true},
+		newCashFlowTransaction("Landlord", "Rent", 1500, feb),
+	}
+	splits := map[uuid.UUID][]*models.TransactionSplit{
+		shopping.ID: {
+			{TransactionID: shopping.ID, Amount: 200, Category: "Groceries"},
+			{TransactionID: shopping.ID, Amount: 100, Category: "Household"},
+		},
+	}
+	series := []*models.RecurringSeries{{SeriesKey: "out:landlord:monthly", AverageAmount: 1500}}
+
+	report := CashFlow(transactions, splits, series, jan, feb)
+	require.Len(t, report.Months, 2)
+	assert.Equal(t, "2024-01", report.From)
+	assert.Equal(t, "2024-02", report.To)
+
+	january := report.Months[0]
+	assert.Equal(t, 4000.0, january.Income)
+	assert.Equal(t, 1500.0, january.FixedExpenses)
+	assert.Equal(t, 250.0, january.DiscretionaryExpenses)
+	assert.Equal(t, 1750.0, january.Expenses)
+	assert.Equal(t, 2250.0, january.NetSavings)
+	require.NotNil(t, january.SavingsRate)
+	assert.Equal(t, 56.3, *january.SavingsRate)
+	assert.Equal(t, 800.0, january.Transfers)
+	require.Len(t, january.DiscretionaryByCategory, 2)
+	assert.Equal(t, "Groceries", january.DiscretionaryByCategory[0].Category)
+	assert.Equal(t, 150.0, january.DiscretionaryByCategory[0].Total)
+	assert.Equal(t, 2, january.DiscretionaryByCategory[0].Count)
+
+	february := report.Months[1]
+	assert.Equal(t, -1500.0, february.NetSavings)
+	assert.Nil(t, february.SavingsRate)
+
+	assert.Equal(t, 3000.0, report.Total.FixedExpenses)
+	assert.Equal(t, 750.0, report.Total.NetSavings)
+	require.NotNil(t, report.Total.SavingsRate)
+	assert.Equal(t, 18.8, *report.Total.SavingsRate)
+}
+
+// TestCashFlowPlaidCategories tests income filed under Plaid's Transfer hierarchy
+func TestCashFlowPlaidCategories(t *testing.T) {
+	jan := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	plaid := func(name string, amount float64, category ...string) *models.Transaction {
+		return &models.Transaction{ID: uuid.New(), Name: name, Category: category, Amount: amount, Date: jan}
+	}
+	transactions := []*models.Transaction{
+		plaid("ACME PAYROLL", -4000, "Transfer", "Payroll"),
+		plaid("MOBILE DEPOSIT", -250, "Transfer", "Deposit"),
+		plaid("TRANSFER TO SAVINGS", 1000, "Transfer", "Internal Account Transfer"),
+		plaid("SAFEWAY", 850, "Shops", "Supermarkets and Groceries"),
+	}
+
+	report := CashFlow(transactions, nil, nil, jan, jan)
+	require.Len(t, report.Months, 1)
+	january := report.Months[0]
+	assert.Equal(t, 4250.0, january.Income)
+	assert.Equal(t, 850.0, january.Expenses)
+	assert.Equal(t, 1000.0, january.Transfers)
+	require.NotNil(t, january.SavingsRate)
+	assert.Equal(t, 80.0, *january.SavingsRate)
+	require.Len(t, january.DiscretionaryByCategory, 1)
+	assert.Equal(t, "Shops", january.DiscretionaryByCategory[0].Category)
+}
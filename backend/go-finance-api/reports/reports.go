@@ -0,0 +1,168 @@
+// Package reports aggregates spending by category, merchant, account, day of week and
+// month, compared with earlier periods
+package reports
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+)
+
+// Dimensions lists the supported report dimensions
+var Dimensions = []string{
+	models.ReportByCategory,
+	models.ReportByMerchant,
+	models.ReportByAccount,
+	models.ReportByDayOfWeek,
+	models.ReportByMonth,
+}
+
+// Period is an inclusive date range
+type Period struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// Previous returns the period of the same length that ends the day before p starts
+func (p Period) Previous() Period {
+	days := int(p.To.Sub(p.From).Hours() / 24)
+	to := p.From.AddDate(0, 0, -1)
+	return Period{From: to.AddDate(0, 0, -days), To: to}
+}
+
+// LastYear returns the same dates one year earlier
+func (p Period) LastYear() Period {
+	return Period{From: p.From.AddDate(-1, 0, 0), To: p.To.AddDate(-1, 0, 0)}
+}
+
+// Row is one value of a report dimension with its totals and comparisons
+type Row struct {
+	models.SpendingTotal
+	Name           string   `json:"name"`                      // Display name of the key
+	PreviousPeriod *float64 `json:"previous_period,omitempty"` // Net in the comparison period
+	LastYear       *float64 `json:"last_year,omitempty"`       // Net in the same period last year
+	ChangePercent  *float64 `json:"change_percent,omitempty"`  // Change in net from the previous period
+}
+
+// Report is a spending report for one dimension and period
+type Report struct {
+	By             string `json:"by"`
+	Period         Period `json:"period"`
+	PreviousPeriod Period `json:"previous_period"`
+	LastYear       Period `json:"last_year"`
+	Rows           []*Row `json:"rows"`
+	Total          *Row   `json:"total"`
+}
+
+// ValidDimension reports whether by is a supported report dimension
+func ValidDimension(by string) bool {
+	for _, dimension := range Dimensions {
+		if dimension == by {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare joins the current period's totals with the same keys in the previous period
+// and last year. Month keys (YYYY-MM) are compared with the month before and the same
+// month a year earlier instead, so previous and lastYear must cover those months.
+func Compare(by string, current, previous, lastYear []*models.SpendingTotal) []*Row {
+	previousByKey := netByKey(previous)
+	lastYearByKey := netByKey(lastYear)
+
+	rows := make([]*Row, 0, len(current))
+	for _, total := range current {
+		previousKey, lastYearKey := total.Key, total.Key
+		if by == models.ReportByMonth {
+			previousKey, lastYearKey = shiftMonth(total.Key, -1), shiftMonth(total.Key, -12)
+		}
+		row := &Row{SpendingTotal: *total, Name: total.Key}
+		if net, ok := previousByKey[previousKey]; ok {
+			row.PreviousPeriod = &net
+			row.ChangePercent = changePercent(net, total.Net)
+		}
+		if net, ok := lastYearByKey[lastYearKey]; ok {
+			row.LastYear = &net
+		}
+		rows = append(rows, row)
+	}
+
+	if by == models.ReportByMonth || by == models.ReportByDayOfWeek {
+		sort.SliceStable(rows, func(i, j int) bool {
+			return keyOrder(rows[i].Key) < keyOrder(rows[j].Key)
+		})
+	}
+	if by == models.ReportByDayOfWeek {
+		for _, row := range rows {
+			row.Name = dayName(row.Key)
+		}
+	}
+	return rows
+}
+
+// Sum totals all of a period's rows
+func Sum(totals []*models.SpendingTotal) *models.SpendingTotal {
+	var spent, received int64
+	count := 0
+	for _, total := range totals {
+		spent += models.ToMinorUnits(total.Spent)
+		received += models.ToMinorUnits(total.Received)
+		count += total.Count
+	}
+	return &models.SpendingTotal{
+		Key:      "total",
+		Spent:    models.FromMinorUnits(spent),
+		Received: models.FromMinorUnits(received),
+		Net:      models.FromMinorUnits(spent - received),
+		Count:    count,
+	}
+}
+
+// netByKey indexes totals' net amounts by key
+func netByKey(totals []*models.SpendingTotal) map[string]float64 {
+	byKey := make(map[string]float64, len(totals))
+	for _, total := range totals {
+		byKey[total.Key] = total.Net
+	}
+	return byKey
+}
+
+// changePercent is the percentage change from before to after, rounded to one decimal.
+// It is nil when there is nothing to compare against.
+func changePercent(before, after float64) *float64 {
+	if before == 0 {
+		return nil
+	}
+	change := math.Round((after-before)/math.Abs(before)*1000) / 10
+	return &change
+}
+
+// shiftMonth moves a YYYY-MM key by a number of months
+func shiftMonth(key string, months int) string {
+	month, err := time.Parse("2006-01", key)
+	if err != nil {
+		return key
+	}
+	return month.AddDate(0, months, 0).Format("2006-01")
+}
+
+// keyOrder sorts day-of-week numbers and month keys chronologically
+func keyOrder(key string) string {
+	if len(key) == 1 {
+		return "0" + key
+	}
+	return key
+}
+
+// dayName turns a day-of-week key (0 = Sunday) into its name
+func dayName(key string) string {
+	var dow int
+	if _, err := fmt.Sscanf(key, "%d", &dow); err != nil || dow < 0 || dow > 6 {
+		return key
+	}
+	return time.Weekday(dow).String()
+}
@@ -0,0 +1,97 @@
+package reports
+
+import (
+	"testing"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPeriods tests deriving the comparison periods
+func TestPeriods(t *testing.T) {
+	period := Period{
+		From: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+	}
+
+	previous := period.Previous()
+	assert.Equal(t, time.Date(2024, 1, 30, 0, 0, 0, 0, time.UTC), previous.From)
+	assert.Equal(t, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), previous.To)
+
+	lastYear := period.LastYear()
+	assert.Equal(t, time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC), lastYear.From)
+	assert.Equal(t, time.Date(2023, 3, 31, 0, 0, 0, 0, time.UTC), lastYear.To)
+}
+
+// TestCompare tests joining rows with the previous period and last year
+func TestCompare(t *testing.T) {
+	current := []*models.SpendingTotal{
+		{Key: "Groceries", Spent: 300, Net: 300, Count: 6},
+		{Key: "Travel", Spent: 500, Net: 500, Count: 1},
+	}
+	previous := []*models.SpendingTotal{{Key: "Groceries", Spent: 250, Net: 250, Count: 5}}
+	lastYear := []*models.SpendingTotal{{Key: "Travel", Spent: 800, Net: 800, Count: 2}}
+
+	rows := Compare(models.ReportByCategory, current, previous, lastYear)
+	require.Len(t, rows, 2)
+
+	require.NotNil(t, rows[0].PreviousPeriod)
+	assert.Equal(t, 250.0, *rows[0].PreviousPeriod)
+	require.NotNil(t, rows[0].ChangePercent)
+	assert.Equal(t, 20.0, *rows[0].ChangePercent)
+	assert.Nil(t, rows[0].LastYear)
+
+	assert.Nil(t, rows[1].PreviousPeriod)
+	assert.Nil(t, rows[1].ChangePercent)
+	require.NotNil(t, rows[1].LastYear)
+	assert.Equal(t, 800.0, *rows[1].LastYear)
+}
+
+// TestCompareMonths tests comparing each month with the month before and a year earlier
+func TestCompareMonths(t *testing.T) {
+	current := []*models.SpendingTotal{
+		{Key: "2024-02", Net: 200},
+		{Key: "2024-01", Net: 100},
+	}
+	previous := []*models.SpendingTotal{
+		{Key: "2023-12", Net: 50},
+		{Key: "2024-01", Net: 100},
+	}
+	lastYear := []*models.SpendingTotal{{Key: "2023-01", Net: 80}}
+
+	rows := Compare(models.ReportByMonth, current, previous, lastYear)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, "2024-01", rows[0].Key)
+	assert.Equal(t, 50.0, *rows[0].PreviousPeriod)
+	assert.Equal(t, 80.0, *rows[0].LastYear)
+
+	assert.Equal(t, "2024-02", rows[1].Key)
+	assert.Equal(t, 100.0, *rows[1].PreviousPeriod)
+	assert.Equal(t, 100.0, *rows[1].ChangePercent)
+	assert.Nil(t, rows[1].LastYear)
+}
+
+// TestCompareDayOfWeek tests ordering and naming days of the week
+func TestCompareDayOfWeek(t *testing.T) {
+	current := []*models.SpendingTotal{{Key: "5", Net: 90}, {Key: "0", Net: 40}}
+
+	rows := Compare(models.ReportByDayOfWeek, current, nil, nil)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "Sunday", rows[0].Name)
+	assert.Equal(t, "Friday", rows[1].Name)
+}
+
+// TestSum tests totalling a period in minor units
+func TestSum(t *testing.T) {
+	total := Sum([]*models.SpendingTotal{
+		{Spent: 0.1, Received: 0, Count: 1},
+		{Spent: 0.2, Received: 1000, Count: 2},
+	})
+	assert.Equal(t, 0.3, total.Spent)
+	assert.Equal(t, 1000.0, total.Received)
+	assert.Equal(t, -999.7, total.Net)
+	assert.Equal(t, 3, total.Count)
+}
@@ -0,0 +1,117 @@
+package reports
+
+import (
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// Service builds spending and cash flow reports
+type Service struct {
+	repos *db.Repositories
+}
+
+// NewService creates a new Service
+func NewService(repos *db.Repositories) *Service {
+	return &Service{repos: repos}
+}
+
+// Report totals a user's spending in a period by a dimension and compares each row with
+// the previous period and the same period last year. The summary is rebuilt first if the
+// user's transactions changed since the last sync.
+func (s *Service) Report(userID uuid.UUID, by string, period Period) (*Report, error) {
+	if _, err := s.repos.SpendingSummary.EnsureFresh(userID); err != nil {
+		return nil, err
+	}
+
+	previous := period.Previous()
+	if by == models.ReportByMonth {
+		// Each month is compared with the month before it
+		previous = Period{From: period.From.AddDate(0, -1, 0), To: period.To.AddDate(0, -1, 0)}
+	}
+	lastYear := period.LastYear()
+
+	current, err := s.repos.SpendingSummary.Aggregate(userID, by, period.From, period.To)
+	if err != nil {
+		return nil, err
+	}
+	previousTotals, err := s.repos.SpendingSummary.Aggregate(userID, by, previous.From, previous.To)
+	if err != nil {
+		return nil, err
+	}
+	lastYearTotals, err := s.repos.SpendingSummary.Aggregate(userID, by, lastYear.From, lastYear.To)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := Compare(by, current, previousTotals, lastYearTotals)
+	if by == models.ReportByAccount {
+		if err := s.nameAccounts(userID, rows); err != nil {
+			return nil, err
+		}
+	}
+
+	total := Compare("", []*models.SpendingTotal{Sum(current)},
+		[]*models.SpendingTotal{Sum(previousTotals)}, []*models.SpendingTotal{Sum(lastYearTotals)})[0]
+	total.Name = "Total"
+
+	return &Report{
+		By:             by,
+		Period:         period,
+		PreviousPeriod: previous,
+		LastYear:       lastYear,
+		Rows:           rows,
+		Total:          total,
+	}, nil
+}
+
+// nameAccounts replaces account ID keys with the accounts' names
+func (s *Service) nameAccounts(userID uuid.UUID, rows []*Row) error {
+	accounts, err := s.repos.Account.GetByUserID(userID)
+	if err != nil {
+		return err
+	}
+	names := make(map[string]string, len(accounts))
+	for _, account := range accounts {
+		names[account.ID.String()] = account.Name
+	}
+	for _, row := range rows {
+		if name, ok := names[row.Key]; ok {
+			row.Name = name
+		}
+	}
+	return nil
+}
+
+// CashFlow reports a user's monthly income, expenses and savings rate for the months from
+// from to to
+func (s *Service) CashFlow(userID uuid.UUID, from, to time.Time) (*CashFlowReport, error) {
+	start := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, -1)
+
+	var transactions []*models.Transaction
+	err := s.repos.Transaction.ForEachByDateRange(userID, start, end, func(t *models.Transaction) error {
+		transactions = append(transactions, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	splits := make(map[uuid.UUID][]*models.TransactionSplit)
+	userSplits, err := s.repos.Split.GetByUserAndDateRange(userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, split := range userSplits {
+		splits[split.TransactionID] = append(splits[split.TransactionID], split)
+	}
+
+	series, err := s.repos.Recurring.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return CashFlow(transactions, splits, series, start, end), nil
+}
@@ -0,0 +1,169 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// Engine evaluates a user's rules against transactions
+type Engine struct {
+	rules []*compiledRule
+}
+
+// compiledRule is a rule with its patterns compiled once up front
+type compiledRule struct {
+	rule     *models.Rule
+	name     *regexp.Regexp
+	merchant *regexp.Regexp
+}
+
+// Outcome is the result of evaluating every rule against a transaction
+type Outcome struct {
+	MatchedRuleIDs []uuid.UUID
+	Actions        models.RuleActions
+}
+
+// Matched reports whether any rule matched
+func (o *Outcome) Matched() bool {
+	return len(o.MatchedRuleIDs) > 0
+}
+
+// NewEngine compiles the given rules. Disabled rules are skipped and the rest are
+// evaluated in priority order.
+func NewEngine(rules []*models.Rule) (*Engine, error) {
+	engine := &Engine{}
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		compiled, err := compile(rule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+		engine.rules = append(engine.rules, compiled)
+	}
+
+	sort.SliceStable(engine.rules, func(i, j int) bool {
+		return engine.rules[i].rule.Priority < engine.rules[j].rule.Priority
+	})
+
+	return engine, nil
+}
+
+// Validate checks that a rule's conditions and actions are well formed
+func Validate(rule *models.Rule) error {
+	if _, err := compile(rule); err != nil {
+		return err
+	}
+	if rule.Actions.IsEmpty() {
+		return fmt.Errorf("rule must have at least one action")
+	}
+	return nil
+}
+
+// compile validates a rule and compiles its patterns
+func compile(rule *models.Rule) (*compiledRule, error) {
+	cond := rule.Conditions
+	compiled := &compiledRule{rule: rule}
+
+	var err error
+	if cond.NamePattern != "" {
+		if compiled.name, err = regexp.Compile("(?i)" + cond.NamePattern); err != nil {
+			return nil, fmt.Errorf("invalid name_pattern: %w", err)
+		}
+	}
+	if cond.MerchantPattern != "" {
+		if compiled.merchant, err = regexp.Compile("(?i)" + cond.MerchantPattern); err != nil {
+			return nil, fmt.Errorf("invalid merchant_pattern: %w", err)
+		}
+	}
+	if cond.MinAmount != nil && cond.MaxAmount != nil && *cond.MinAmount > *cond.MaxAmount {
+		return nil, fmt.Errorf("min_amount must not exceed max_amount")
+	}
+	for _, day := range []*int{cond.DayOfMonthMin, cond.DayOfMonthMax} {
+		if day != nil && (*day < 1 || *day > 31) {
+			return nil, fmt.Errorf("day of month must be between 1 and 31")
+		}
+	}
+	if cond.DayOfMonthMin != nil && cond.DayOfMonthMax != nil && *cond.DayOfMonthMin > *cond.DayOfMonthMax {
+		return nil, fmt.Errorf("day_of_month_min must not exceed day_of_month_max")
+	}
+
+	return compiled, nil
+}
+
+// matches reports whether a transaction satisfies every condition of the rule
+func (c *compiledRule) matches(tx *models.Transaction) bool {
+	cond := c.rule.Conditions
+
+	if c.name != nil && !c.name.MatchString(tx.Name) {
+		return false
+	}
+	if c.merchant != nil && !c.merchant.MatchString(tx.MerchantName) {
+		return false
+	}
+	if cond.MinAmount != nil && tx.Amount < *cond.MinAmount {
+		return false
+	}
+	if cond.MaxAmount != nil && tx.Amount > *cond.MaxAmount {
+		return false
+	}
+	if cond.AccountID != nil && tx.AccountID != *cond.AccountID {
+		return false
+	}
+	day := tx.Date.Day()
+	if cond.DayOfMonthMin != nil && day < *cond.DayOfMonthMin {
+		return false
+	}
+	if cond.DayOfMonthMax != nil && day > *cond.DayOfMonthMax {
+		return false
+	}
+
+	return true
+}
+
+// Matches reports whether a single rule matches a transaction, ignoring its enabled flag
+func Matches(rule *models.Rule, tx *models.Transaction) (bool, error) {
+	compiled, err := compile(rule)
+	if err != nil {
+		return false, err
+	}
+	return compiled.matches(tx), nil
+}
+
+// Evaluate runs every rule against a transaction and merges the actions of those that
+// match. The first matching rule to set the category or name wins; tags accumulate and
+// the transfer and hide flags are set if any matching rule sets them.
+func (e *Engine) Evaluate(tx *models.Transaction) *Outcome {
+	outcome := &Outcome{}
+	seenTags := make(map[string]bool)
+
+	for _, c := range e.rules {
+		if !c.matches(tx) {
+			continue
+		}
+		outcome.MatchedRuleIDs = append(outcome.MatchedRuleIDs, c.rule.ID)
+
+		actions := c.rule.Actions
+		if outcome.Actions.SetCategory == "" {
+			outcome.Actions.SetCategory = actions.SetCategory
+		}
+		if outcome.Actions.Rename == "" {
+			outcome.Actions.Rename = actions.Rename
+		}
+		for _, tag := range actions.AddTags {
+			if !seenTags[tag] {
+				seenTags[tag] = true
+				outcome.Actions.AddTags = append(outcome.Actions.AddTags, tag)
+			}
+		}
+		outcome.Actions.MarkTransfer = outcome.Actions.MarkTransfer || actions.MarkTransfer
+		outcome.Actions.Hide = outcome.Actions.Hide || actions.Hide
+	}
+
+	return outcome
+}
@@ -0,0 +1,104 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+func intPtr(v int) *int           { return &v }
+
+// newTestTransaction builds a transaction with the fields the engine looks at
+func newTestTransaction(name, merchant string, amount float64, date time.Time) *models.Transaction {
+	return &models.Transaction{
+		ID:           uuid.New(),
+		AccountID:    uuid.New(),
+		Name:         name,
+		MerchantName: merchant,
+		Amount:       amount,
+		Date:         date,
+	}
+}
+
+// TestEvaluateMergesMatchingRules tests priority ordering and action merging
+func TestEvaluateMergesMatchingRules(t *testing.T) {
+	userID := uuid.New()
+	coffee := models.NewRule(userID, "Coffee", 10,
+		models.RuleConditions{MerchantPattern: "blue bottle|starbucks"},
+		models.RuleActions{SetCategory: "Coffee", AddTags: []string{"caffeine"}},
+	)
+	dining := models.NewRule(userID, "Dining", 20,
+		models.RuleConditions{NamePattern: "bottle"},
+		models.RuleActions{SetCategory: "Dining", Rename: "Blue Bottle", AddTags: []string{"caffeine", "food"}},
+	)
+
+	engine, err := NewEngine([]*models.Rule{dining, coffee})
+	require.NoError(t, err)
+
+	outcome := engine.Evaluate(newTestTransaction("SQ *BLUE BOTTLE 1234", "Blue Bottle Coffee", 5.5, time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, outcome.Matched())
+	assert.Equal(t, []uuid.UUID{coffee.ID, dining.ID}, outcome.MatchedRuleIDs)
+	assert.Equal(t, "Coffee", outcome.Actions.SetCategory)
+	assert.Equal(t, "Blue Bottle", outcome.Actions.Rename)
+	assert.Equal(t, []string{"caffeine", "food"}, outcome.Actions.AddTags)
+}
+
+// TestEvaluateConditions tests amount, account and day-of-month conditions
+func TestEvaluateConditions(t *testing.T) {
+	tx := newTestTransaction("RENT PAYMENT", "", 1800, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name       string
+		conditions models.RuleConditions
+		want       bool
+	}{
+		{"amount in range", models.RuleConditions{MinAmount: floatPtr(1000), MaxAmount: floatPtr(2000)}, true},
+		{"amount below range", models.RuleConditions{MinAmount: floatPtr(2000)}, false},
+		{"matching account", models.RuleConditions{AccountID: &tx.AccountID}, true},
+		{"other account", models.RuleConditions{AccountID: &uuid.UUID{}}, false},
+		{"day in range", models.RuleConditions{DayOfMonthMin: intPtr(1), DayOfMonthMax: intPtr(5)}, true},
+		{"day out of range", models.RuleConditions{DayOfMonthMin: intPtr(10)}, false},
+		{"merchant pattern on empty merchant", models.RuleConditions{MerchantPattern: "rent"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := models.NewRule(uuid.New(), tt.name, 0, tt.conditions, models.RuleActions{Hide: true})
+			got, err := Matches(rule, tx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestNewEngineSkipsDisabledRules tests that disabled rules never match
+func TestNewEngineSkipsDisabledRules(t *testing.T) {
+	rule := models.NewRule(uuid.New(), "Everything", 0, models.RuleConditions{}, models.RuleActions{Hide: true})
+	rule.Enabled = false
+
+	engine, err := NewEngine([]*models.Rule{rule})
+	require.NoError(t, err)
+	assert.False(t, engine.Evaluate(newTestTransaction("ANY", "", 1, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))).Matched())
+}
+
+// TestValidate tests rejection of malformed rules
+func TestValidate(t *testing.T) {
+	userID := uuid.New()
+
+	badPattern := models.NewRule(userID, "Bad", 0, models.RuleConditions{NamePattern: "("}, models.RuleActions{Hide: true})
+	assert.Error(t, Validate(badPattern))
+
+	badRange := models.NewRule(userID, "Bad", 0, models.RuleConditions{MinAmount: floatPtr(10), MaxAmount: floatPtr(1)}, models.RuleActions{Hide: true})
+	assert.Error(t, Validate(badRange))
+
+	noActions := models.NewRule(userID, "Empty", 0, models.RuleConditions{}, models.RuleActions{})
+	assert.Error(t, Validate(noActions))
+
+	ok := models.NewRule(userID, "Ok", 0, models.RuleConditions{NamePattern: "uber"}, models.RuleActions{SetCategory: "Travel"})
+	assert.NoError(t, Validate(ok))
+}
@@ -0,0 +1,71 @@
+package semantic
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/llm"
+	"github.com/davidwang/go-finance-api/go-finance-api/merchants"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// Feature is the name usage is recorded under
+const Feature = "search"
+
+// HashModel is the model name of embeddings made by llm.HashEmbed, used when no provider
+// is configured. Hash embeddings only match shared words, not related concepts.
+const HashModel = "hash"
+
+// Text returns the description of a transaction that is embedded: its merchant name
+// followed by its categories, so that a café categorized as a coffee shop is found by
+// "coffee"
+func Text(t *models.Transaction) string {
+	var categories []string
+	seen := make(map[string]bool)
+	for _, category := range append([]string{t.UserCategory}, t.Category...) {
+		category = strings.TrimSpace(category)
+		if category == "" || seen[strings.ToLower(category)] {
+			continue
+		}
+		seen[strings.ToLower(category)] = true
+		categories = append(categories, category)
+	}
+
+	name := merchants.Name(t)
+	if len(categories) == 0 {
+		return name
+	}
+	return name + " (" + strings.Join(categories, ", ") + ")"
+}
+
+// TextHash returns the hash stored with an embedding, to tell whether a transaction's
+// text has changed since it was embedded
+func TextHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// embed returns a vector for each text, from the user's metered provider or, without
+// one, from llm.HashEmbed
+func (s *Service) embed(ctx context.Context, userID uuid.UUID, texts []string) ([][]float32, error) {
+	if s.meter == nil {
+		vectors := make([][]float32, len(texts))
+		for i, text := range texts {
+			vectors[i] = llm.HashEmbed(text)
+		}
+		return vectors, nil
+	}
+
+	resp, err := s.meter.ForUser(userID, Feature).Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Vectors) != len(texts) {
+		return nil, fmt.Errorf("embedding provider returned %d vectors for %d texts", len(resp.Vectors), len(texts))
+	}
+	return resp.Vectors, nil
+}
@@ -0,0 +1,72 @@
+package semantic
+
+import (
+	"math"
+	"sort"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// Index is an in-memory vector index searched by brute-force cosine similarity. It is
+// used when the database has no pgvector; a user's transactions number in the
+// thousands, so a linear scan is fast enough.
+type Index struct {
+	ids     []uuid.UUID
+	vectors [][]float32
+}
+
+// NewIndex creates an empty Index
+func NewIndex() *Index {
+	return &Index{}
+}
+
+// Add adds a transaction's vector to the index
+func (x *Index) Add(transactionID uuid.UUID, vector []float32) {
+	x.ids = append(x.ids, transactionID)
+	x.vectors = append(x.vectors, vector)
+}
+
+// Len returns the number of vectors in the index
+func (x *Index) Len() int {
+	return len(x.ids)
+}
+
+// Search returns up to limit of the transactions most similar to query, most similar
+// first, after skipping offset. The exclude transaction, if any, and vectors of another
+// length are skipped.
+func (x *Index) Search(query []float32, exclude uuid.UUID, offset, limit int) []*models.EmbeddingMatch {
+	var matches []*models.EmbeddingMatch
+	for i, vector := range x.vectors {
+		if x.ids[i] == exclude || len(vector) != len(query) {
+			continue
+		}
+		matches = append(matches, &models.EmbeddingMatch{TransactionID: x.ids[i], Score: Cosine(query, vector)})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if offset >= len(matches) {
+		return nil
+	}
+	matches = matches[offset:]
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// Cosine returns the cosine similarity of two vectors of equal length, or 0 if either is
+// zero
+func Cosine(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
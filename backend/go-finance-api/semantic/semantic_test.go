@@ -0,0 +1,251 @@
+package semantic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/llm"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestText tests the embedded description of a transaction
+func TestText(t *testing.T) {
+	cafe := &models.Transaction{Name: "SQ *BLUE BOTTLE 0042", Category: []string{"Food and Drink", "Restaurants", "Coffee Shop"}}
+	assert.Equal(t, "Blue Bottle (Food and Drink, Restaurants, Coffee Shop)", Text(cafe))
+
+	cafe.UserCategory = "Restaurants"
+	assert.Equal(t, "Blue Bottle (Restaurants, Food and Drink, Coffee Shop)", Text(cafe))
+
+	assert.Equal(t, "Netflix", Text(&models.Transaction{Name: "NETFLIX.COM", MerchantName: "Netflix"}))
+	assert.NotEqual(t, TextHash(Text(cafe)), TextHash("Blue Bottle"))
+}
+
+// TestIndexSearch tests that a concept query finds transactions by their categories
+func TestIndexSearch(t *testing.T) {
+	transactions := []*models.Transaction{
+		{ID: uuid.New(), Name: "BLUE BOTTLE", Category: []string{"Food and Drink", "Coffee Shop"}},
+		{ID: uuid.New(), Name: "SHELL OIL 5521", Category: []string{"Travel", "Gas Stations"}},
+		{ID: uuid.New(), Name: "STARBUCKS STORE 123", Category: []string{"Food and Drink", "Coffee Shop"}},
+		{ID: uuid.New(), Name: "SAFEWAY", Category: []string{"Shops", "Supermarkets and Groceries"}},
+	}
+	index := NewIndex()
+	for _, transaction := range transactions {
+		index.Add(transaction.ID, llm.HashEmbed(Text(transaction)))
+	}
+	require.Equal(t, 4, index.Len())
+
+	matches := index.Search(llm.HashEmbed("coffee"), uuid.Nil, 0, 2)
+	require.Len(t, matches, 2)
+	found := []uuid.UUID{matches[0].TransactionID, matches[1].TransactionID}
+	assert.ElementsMatch(t, []uuid.UUID{transactions[0].ID, transactions[2].ID}, found)
+	assert.Greater(t, matches[1].Score, 0.0)
+
+	// Like a coffee shop, excluding itself
+	matches = index.Search(llm.HashEmbed(Text(transactions[0])), transactions[0].ID, 0, 1)
+	require.Len(t, matches, 1)
+	assert.Equal(t, transactions[2].ID, matches[0].TransactionID)
+}
+
+// TestCosine tests cosine similarity
+func TestCosine(t *testing.T) {
+	assert.InDelta(t, 1.0, Cosine([]float32{1, 2}, []float32{2, 4}), 1e-9)
+	assert.InDelta(t, 0.0, Cosine([]float32{1, 0}, []float32{0, 3}), 1e-9)
+	assert.InDelta(t, -1.0, Cosine([]float32{1, 1}, []float32{-1, -1}), 1e-9)
+	assert.Equal(t, 0.0, Cosine([]float32{0, 0}, []float32{1, 1}))
+}
+
+// memoryStore is an in-memory transactionStore and embeddingStore
+type memoryStore struct {
+	transactions []*models.Transaction
+	embeddings   map[uuid.UUID]*models.TransactionEmbedding
+	touched      []uuid.UUID
+	staleReads   int // Calls to GetWithoutEmbedding
+	builds       int // Calls to ForEachByUserID
+}
+
+func newMemoryStore(transactions ...*models.Transaction) *memoryStore {
+	return &memoryStore{transactions: transactions, embeddings: make(map[uuid.UUID]*models.TransactionEmbedding)}
+}
+
+func (m *memoryStore) GetWithoutEmbedding(userID uuid.UUID, model string, limit int) ([]*models.Transaction, error) {
+	m.staleReads++
+	var stale []*models.Transaction
+	for _, t := range m.transactions {
+		embedding := m.embeddings[t.ID]
+		if t.UserID == userID && (embedding == nil || embedding.Model != model || embedding.UpdatedAt.Before(t.UpdatedAt)) {
+			stale = append(stale, t)
+		}
+		if len(stale) == limit {
+			break
+		}
+	}
+	return stale, nil
+}
+
+func (m *memoryStore) GetByIDs(ids []uuid.UUID) (map[uuid.UUID]*models.Transaction, error) {
+	found := make(map[uuid.UUID]*models.Transaction)
+	for _, t := range m.transactions {
+		for _, id := range ids {
+			if t.ID == id {
+				found[id] = t
+			}
+		}
+	}
+	return found, nil
+}
+
+func (m *memoryStore) Upsert(embedding *models.TransactionEmbedding) error {
+	stored := *embedding
+	m.embeddings[embedding.TransactionID] = &stored
+	return nil
+}
+
+func (m *memoryStore) Touch(transactionIDs []uuid.UUID) error {
+	for _, id := range transactionIDs {
+		m.embeddings[id].UpdatedAt = time.Now().UTC()
+	}
+	m.touched = append(m.touched, transactionIDs...)
+	return nil
+}
+
+func (m *memoryStore) GetByTransactionIDs(transactionIDs []uuid.UUID, model string) (map[uuid.UUID]*models.TransactionEmbedding, error) {
+	found := make(map[uuid.UUID]*models.TransactionEmbedding)
+	for _, id := range transactionIDs {
+		if embedding := m.embeddings[id]; embedding != nil && embedding.Model == model {
+			found[id] = embedding
+		}
+	}
+	return found, nil
+}
+
+func (m *memoryStore) ForEachByUserID(userID uuid.UUID, model string, fn func(*models.TransactionEmbedding) error) error {
+	m.builds++
+	for _, embedding := range m.embeddings {
+		if embedding.UserID == userID && embedding.Model == model {
+			if err := fn(embedding); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *memoryStore) Version(userID uuid.UUID, model string) (int, time.Time, error) {
+	var count int
+	var latest time.Time
+	for _, embedding := range m.embeddings {
+		if embedding.UserID == userID && embedding.Model == model {
+			count++
+			if embedding.UpdatedAt.After(latest) {
+				latest = embedding.UpdatedAt
+			}
+		}
+	}
+	return count, latest, nil
+}
+
+func (m *memoryStore) VectorSearchAvailable() (bool, error) {
+	return false, nil
+}
+
+func (m *memoryStore) EnsureVectorIndex(dimensions int) error {
+	return errors.New("no pgvector")
+}
+
+func (m *memoryStore) Nearest(userID uuid.UUID, model string, vector []float32, exclude uuid.UUID, offset, limit int) ([]*models.EmbeddingMatch, error) {
+	return nil, errors.New("no pgvector")
+}
+
+// newTestService creates a Service over store that embeds with llm.HashEmbed
+func newTestService(store *memoryStore) *Service {
+	return &Service{
+		transactions:  store,
+		embeddings:    store,
+		model:         HashModel,
+		indexes:       make(map[uuid.UUID]*cachedIndex),
+		vectorIndexed: make(map[int]bool),
+	}
+}
+
+// TestServiceIndex tests that new and changed transactions are embedded while those
+// whose text is unchanged are only marked current
+func TestServiceIndex(t *testing.T) {
+	userID := uuid.New()
+	lastWeek := time.Now().UTC().AddDate(0, 0, -7)
+	yesterday := lastWeek.AddDate(0, 0, 6)
+	noted := &models.Transaction{ID: uuid.New(), UserID: userID, Name: "BLUE BOTTLE", Notes: "with Sam", UpdatedAt: yesterday}
+	recategorized := &models.Transaction{ID: uuid.New(), UserID: userID, Name: "SHELL OIL", UserCategory: "Travel", UpdatedAt: yesterday}
+	added := &models.Transaction{ID: uuid.New(), UserID: userID, Name: "SAFEWAY", UpdatedAt: yesterday}
+
+	store := newMemoryStore(noted, recategorized, added)
+	sentinel := []float32{1, 0, 0}
+	for _, transaction := range []*models.Transaction{noted, recategorized} {
+		embedding := models.NewTransactionEmbedding(transaction, HashModel, TextHash(Text(transaction)), sentinel)
+		embedding.UpdatedAt = lastWeek
+		store.embeddings[transaction.ID] = embedding
+	}
+	store.embeddings[recategorized.ID].TextHash = TextHash("SHELL OIL") // Embedded before the category changed
+
+	service := newTestService(store)
+	indexed, err := service.Index(context.Background(), userID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, indexed)
+	assert.Equal(t, []uuid.UUID{noted.ID}, store.touched)
+	assert.Equal(t, sentinel, store.embeddings[noted.ID].Vector)
+	assert.Equal(t, llm.HashEmbed(Text(recategorized)), store.embeddings[recategorized.ID].Vector)
+	assert.Equal(t, llm.HashEmbed(Text(added)), store.embeddings[added.ID].Vector)
+
+	indexed, err = service.Index(context.Background(), userID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, indexed)
+	assert.Len(t, store.touched, 1)
+}
+
+// TestServiceIndexStaysStale tests that transactions still stale after being embedded,
+// as a clock running ahead of the database's makes them, don't loop forever
+func TestServiceIndexStaysStale(t *testing.T) {
+	userID := uuid.New()
+	ahead := time.Now().UTC().Add(time.Hour)
+	var transactions []*models.Transaction
+	for i := 0; i < BatchSize; i++ {
+		transactions = append(transactions, &models.Transaction{ID: uuid.New(), UserID: userID, Name: "COFFEE", UpdatedAt: ahead})
+	}
+	store := newMemoryStore(transactions...)
+
+	indexed, err := newTestService(store).Index(context.Background(), userID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, BatchSize, indexed)
+	assert.Equal(t, 2, store.staleReads)
+}
+
+// TestServiceSearch tests paging through search results and reusing the in-memory index
+// until the user's embeddings change
+func TestServiceSearch(t *testing.T) {
+	userID := uuid.New()
+	blueBottle := &models.Transaction{ID: uuid.New(), UserID: userID, Name: "BLUE BOTTLE", Category: []string{"Food and Drink", "Coffee Shop"}}
+	starbucks := &models.Transaction{ID: uuid.New(), UserID: userID, Name: "STARBUCKS", Category: []string{"Food and Drink", "Coffee Shop"}}
+	shell := &models.Transaction{ID: uuid.New(), UserID: userID, Name: "SHELL OIL", Category: []string{"Travel", "Gas Stations"}}
+	store := newMemoryStore(blueBottle, starbucks, shell)
+	service := newTestService(store)
+
+	first, err := service.Search(context.Background(), userID, "coffee", 0, 1)
+	require.NoError(t, err)
+	second, err := service.Search(context.Background(), userID, "coffee", 1, 1)
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+	require.Len(t, second, 1)
+	assert.ElementsMatch(t, []uuid.UUID{blueBottle.ID, starbucks.ID}, []uuid.UUID{first[0].Transaction.ID, second[0].Transaction.ID})
+	assert.Equal(t, 1, store.builds)
+
+	peets := &models.Transaction{ID: uuid.New(), UserID: userID, Name: "PEETS", Category: []string{"Food and Drink", "Coffee Shop"}}
+	store.transactions = append(store.transactions, peets)
+	results, err := service.Search(context.Background(), userID, "coffee", 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.Equal(t, 2, store.builds)
+}
@@ -0,0 +1,323 @@
+package semantic
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/llm"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// BatchSize is the number of transactions embedded per provider request
+const BatchSize = 64
+
+// searchIndexLimit bounds how many new or changed transactions a search embeds before it
+// runs, so the first search after a large import stays responsive
+const searchIndexLimit = 512
+
+// maxCachedIndexes bounds how many users' in-memory indexes are kept between searches
+const maxCachedIndexes = 32
+
+// Result is a transaction found by a search, with its similarity to the query
+type Result struct {
+	Transaction *models.Transaction `json:"transaction"`
+	Score       float64             `json:"score"` // Cosine similarity, 0 to 1
+}
+
+// transactionStore reads the transactions the Service embeds and returns
+type transactionStore interface {
+	GetWithoutEmbedding(userID uuid.UUID, model string, limit int) ([]*models.Transaction, error)
+	GetByIDs(ids []uuid.UUID) (map[uuid.UUID]*models.Transaction, error)
+}
+
+// embeddingStore stores and searches transaction embeddings
+type embeddingStore interface {
+	Upsert(embedding *models.TransactionEmbedding) error
+	Touch(transactionIDs []uuid.UUID) error
+	GetByTransactionIDs(transactionIDs []uuid.UUID, model string) (map[uuid.UUID]*models.TransactionEmbedding, error)
+	ForEachByUserID(userID uuid.UUID, model string, fn func(*models.TransactionEmbedding) error) error
+	Version(userID uuid.UUID, model string) (int, time.Time, error)
+	VectorSearchAvailable() (bool, error)
+	EnsureVectorIndex(dimensions int) error
+	Nearest(userID uuid.UUID, model string, vector []float32, exclude uuid.UUID, offset, limit int) ([]*models.EmbeddingMatch, error)
+}
+
+// userLister lists the users whose transactions are indexed
+type userLister interface {
+	GetIDs() ([]uuid.UUID, error)
+}
+
+// Service embeds transactions and searches them by meaning
+type Service struct {
+	transactions transactionStore
+	embeddings   embeddingStore
+	users        userLister
+	meter        *llm.Meter // nil when no provider is configured
+	model        string
+
+	mu            sync.Mutex
+	indexes       map[uuid.UUID]*cachedIndex // By user, for databases without pgvector
+	vectorIndexed map[int]bool               // Dimensions EnsureVectorIndex has run for
+}
+
+// cachedIndex is a user's in-memory Index and the embeddings version it was built from
+type cachedIndex struct {
+	index    *Index
+	count    int
+	latest   time.Time
+	lastUsed time.Time
+}
+
+// NewService creates a new Service that embeds with model. Without a meter transactions
+// are embedded with llm.HashEmbed under HashModel.
+func NewService(repos *db.Repositories, meter *llm.Meter, model string) *Service {
+	if meter == nil {
+		model = HashModel
+	}
+	return &Service{
+		transactions:  repos.Transaction,
+		embeddings:    repos.Embedding,
+		users:         repos.User,
+		meter:         meter,
+		model:         model,
+		indexes:       make(map[uuid.UUID]*cachedIndex),
+		vectorIndexed: make(map[int]bool),
+	}
+}
+
+// Index embeds up to limit of the user's transactions that are new or have changed since
+// they were embedded, or all of them if limit is 0, and returns how many it embedded.
+// Transactions whose text is unchanged are marked current without being re-embedded.
+func (s *Service) Index(ctx context.Context, userID uuid.UUID, limit int) (int, error) {
+	indexed := 0
+	seen := make(map[uuid.UUID]bool)
+	for limit <= 0 || indexed < limit {
+		if err := ctx.Err(); err != nil {
+			return indexed, err
+		}
+		transactions, err := s.transactions.GetWithoutEmbedding(userID, s.model, BatchSize)
+		if err != nil {
+			return indexed, err
+		}
+
+		ids := make([]uuid.UUID, 0, len(transactions))
+		for _, t := range transactions {
+			if seen[t.ID] {
+				// Still stale after being embedded, which a clock skew between the
+				// application and database can cause; leave it for the next run
+				return indexed, nil
+			}
+			seen[t.ID] = true
+			ids = append(ids, t.ID)
+		}
+		existing, err := s.embeddings.GetByTransactionIDs(ids, s.model)
+		if err != nil {
+			return indexed, err
+		}
+
+		var unchanged []uuid.UUID
+		var pending []*models.Transaction
+		var texts, hashes []string
+		for _, t := range transactions {
+			text := Text(t)
+			hash := TextHash(text)
+			if embedding := existing[t.ID]; embedding != nil && embedding.TextHash == hash {
+				unchanged = append(unchanged, t.ID)
+				continue
+			}
+			pending = append(pending, t)
+			texts = append(texts, text)
+			hashes = append(hashes, hash)
+		}
+
+		if len(unchanged) > 0 {
+			if err := s.embeddings.Touch(unchanged); err != nil {
+				return indexed, err
+			}
+		}
+		if len(pending) > 0 {
+			vectors, err := s.embed(ctx, userID, texts)
+			if err != nil {
+				return indexed, err
+			}
+			for i, t := range pending {
+				if err := s.embeddings.Upsert(models.NewTransactionEmbedding(t, s.model, hashes[i], vectors[i])); err != nil {
+					return indexed, err
+				}
+			}
+			indexed += len(pending)
+		}
+
+		if len(transactions) < BatchSize {
+			break
+		}
+	}
+	return indexed, nil
+}
+
+// IndexAll embeds every user's new and changed transactions and returns how many it
+// embedded. A failure for one user is logged and the rest are still indexed.
+func (s *Service) IndexAll(ctx context.Context) (int, error) {
+	userIDs, err := s.users.GetIDs()
+	if err != nil {
+		return 0, err
+	}
+	indexed := 0
+	for _, userID := range userIDs {
+		if err := ctx.Err(); err != nil {
+			return indexed, err
+		}
+		n, err := s.Index(ctx, userID, 0)
+		indexed += n
+		if err != nil {
+			log.Printf("Failed to index transactions for user %s: %v", userID, err)
+		}
+	}
+	return indexed, nil
+}
+
+// Search returns up to limit of the user's transactions closest in meaning to query,
+// after skipping offset, so "coffee" finds cafés whose names never mention it
+func (s *Service) Search(ctx context.Context, userID uuid.UUID, query string, offset, limit int) ([]*Result, error) {
+	if _, err := s.Index(ctx, userID, searchIndexLimit); err != nil {
+		return nil, err
+	}
+	vectors, err := s.embed(ctx, userID, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	return s.nearest(userID, vectors[0], uuid.Nil, offset, limit)
+}
+
+// Similar returns up to limit of the user's other transactions most like transaction,
+// after skipping offset
+func (s *Service) Similar(ctx context.Context, userID uuid.UUID, transaction *models.Transaction, offset, limit int) ([]*Result, error) {
+	if _, err := s.Index(ctx, userID, searchIndexLimit); err != nil {
+		return nil, err
+	}
+
+	text := Text(transaction)
+	embeddings, err := s.embeddings.GetByTransactionIDs([]uuid.UUID{transaction.ID}, s.model)
+	if err != nil {
+		return nil, err
+	}
+	embedding := embeddings[transaction.ID]
+	if embedding == nil || embedding.TextHash != TextHash(text) {
+		vectors, err := s.embed(ctx, userID, []string{text})
+		if err != nil {
+			return nil, err
+		}
+		embedding = models.NewTransactionEmbedding(transaction, s.model, TextHash(text), vectors[0])
+		if err := s.embeddings.Upsert(embedding); err != nil {
+			return nil, err
+		}
+	}
+	return s.nearest(userID, embedding.Vector, transaction.ID, offset, limit)
+}
+
+// nearest finds the transactions closest to vector, in the database when it has
+// pgvector and in the user's cached Index otherwise. Matches that aren't similar at all
+// are dropped.
+func (s *Service) nearest(userID uuid.UUID, vector []float32, exclude uuid.UUID, offset, limit int) ([]*Result, error) {
+	available, err := s.embeddings.VectorSearchAvailable()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*models.EmbeddingMatch
+	if available {
+		s.ensureVectorIndex(len(vector))
+		if matches, err = s.embeddings.Nearest(userID, s.model, vector, exclude, offset, limit); err != nil {
+			return nil, err
+		}
+	} else {
+		index, err := s.index(userID)
+		if err != nil {
+			return nil, err
+		}
+		matches = index.Search(vector, exclude, offset, limit)
+	}
+
+	ids := make([]uuid.UUID, len(matches))
+	for i, match := range matches {
+		ids[i] = match.TransactionID
+	}
+	transactions, err := s.transactions.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []*Result{}
+	for _, match := range matches {
+		if t := transactions[match.TransactionID]; t != nil && match.Score > 0 {
+			results = append(results, &Result{Transaction: t, Score: match.Score})
+		}
+	}
+	return results, nil
+}
+
+// ensureVectorIndex creates the database index for vectors of the given dimensions once
+// per Service. Without it Nearest still works, by scanning the user's rows, so a failure
+// such as too many dimensions for HNSW is only logged.
+func (s *Service) ensureVectorIndex(dimensions int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.vectorIndexed[dimensions] {
+		return
+	}
+	if err := s.embeddings.EnsureVectorIndex(dimensions); err != nil {
+		log.Printf("Failed to create vector index for %d dimensions: %v", dimensions, err)
+	}
+	s.vectorIndexed[dimensions] = true
+}
+
+// index returns the user's in-memory Index, rebuilding it when their embeddings have
+// changed since it was built, whether by this Service or another process
+func (s *Service) index(userID uuid.UUID) (*Index, error) {
+	count, latest, err := s.embeddings.Version(userID, s.model)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	cached := s.indexes[userID]
+	if cached != nil && cached.count == count && cached.latest.Equal(latest) {
+		cached.lastUsed = time.Now()
+		s.mu.Unlock()
+		return cached.index, nil
+	}
+	s.mu.Unlock()
+
+	index := NewIndex()
+	err = s.embeddings.ForEachByUserID(userID, s.model, func(embedding *models.TransactionEmbedding) error {
+		index.Add(embedding.TransactionID, embedding.Vector)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.indexes[userID]; !ok && len(s.indexes) >= maxCachedIndexes {
+		s.evictIndex()
+	}
+	s.indexes[userID] = &cachedIndex{index: index, count: count, latest: latest, lastUsed: time.Now()}
+	return index, nil
+}
+
+// evictIndex drops the least recently used cached index. The caller holds s.mu.
+func (s *Service) evictIndex() {
+	var oldest uuid.UUID
+	var oldestUsed time.Time
+	for userID, cached := range s.indexes {
+		if oldestUsed.IsZero() || cached.lastUsed.Before(oldestUsed) {
+			oldest, oldestUsed = userID, cached.lastUsed
+		}
+	}
+	delete(s.indexes, oldest)
+}
@@ -0,0 +1,178 @@
+// Package summaries writes monthly financial summaries. The figures come from
+// deterministic queries over the user's data; the language model only turns them into
+// prose, and a template does so when no model is configured.
+package summaries
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/davidwang/go-finance-api/go-finance-api/recurring"
+	"github.com/davidwang/go-finance-api/go-finance-api/reports"
+)
+
+const (
+	// TopCategoryCount is how many of the month's largest categories are reported
+	TopCategoryCount = 5
+
+	// ChangeCount is how many of the largest category changes are reported, and
+	// MinChange the smallest change worth reporting
+	ChangeCount = 3
+	MinChange   = 25.0
+)
+
+// Input is the data a month's facts are compiled from
+type Input struct {
+	Current        *reports.CashFlowMonth         // Cash flow for the month
+	Previous       *reports.CashFlowMonth         // Cash flow for the month before
+	CategoryTotals []*models.MonthlyCategoryTotal // For the month and the month before
+	Budgets        []*models.BudgetStatus         // For the month
+	Series         []*models.RecurringSeries
+	Transactions   []*models.Transaction   // History up to the end of the month, in date order
+	NetWorth       []*models.NetWorthPoint // Daily from the end of the month before; nil without balance history
+}
+
+// Compile computes the facts for a month from input
+func Compile(month time.Time, input Input) *models.MonthlyFacts {
+	facts := &models.MonthlyFacts{
+		Month:            month.Format("2006-01"),
+		TopCategories:    []*models.CategoryChange{},
+		BiggestChanges:   []*models.CategoryChange{},
+		BudgetMisses:     []*models.BudgetMiss{},
+		NewSubscriptions: []*models.NewSubscription{},
+	}
+	if input.Current != nil {
+		facts.Income = input.Current.Income
+		facts.Expenses = input.Current.Expenses
+		facts.NetSavings = input.Current.NetSavings
+		facts.SavingsRate = input.Current.SavingsRate
+	}
+	if input.Previous != nil {
+		facts.PreviousExpenses = input.Previous.Expenses
+		facts.ExpenseChangePercent = changePercent(facts.Expenses, facts.PreviousExpenses)
+	}
+
+	changes := categoryChanges(month, input.CategoryTotals)
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Spent > changes[j].Spent
+	})
+	for _, change := range changes {
+		if len(facts.TopCategories) < TopCategoryCount && change.Spent > 0 {
+			facts.TopCategories = append(facts.TopCategories, change)
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return math.Abs(changes[i].Change) > math.Abs(changes[j].Change)
+	})
+	for _, change := range changes {
+		if len(facts.BiggestChanges) < ChangeCount && math.Abs(change.Change) >= MinChange {
+			facts.BiggestChanges = append(facts.BiggestChanges, change)
+		}
+	}
+
+	for _, status := range input.Budgets {
+		if status.Remaining < 0 {
+			facts.BudgetMisses = append(facts.BudgetMisses, &models.BudgetMiss{
+				Category:  status.Category,
+				Available: status.Available,
+				Spent:     status.Spent,
+				Over:      -status.Remaining,
+			})
+		}
+	}
+	sort.SliceStable(facts.BudgetMisses, func(i, j int) bool {
+		return facts.BudgetMisses[i].Over > facts.BudgetMisses[j].Over
+	})
+
+	facts.NewSubscriptions = newSubscriptions(month, input.Series, input.Transactions)
+
+	if len(input.NetWorth) >= 2 {
+		start, end := input.NetWorth[0].NetWorth, input.NetWorth[len(input.NetWorth)-1].NetWorth
+		facts.NetWorth = &models.NetWorthChange{
+			Start:  start,
+			End:    end,
+			Change: models.FromMinorUnits(models.ToMinorUnits(end) - models.ToMinorUnits(start)),
+		}
+	}
+	return facts
+}
+
+// categoryChanges compares each spending category's net outflow in month with the month
+// before. Categories with net inflows, such as income, are left out.
+func categoryChanges(month time.Time, totals []*models.MonthlyCategoryTotal) []*models.CategoryChange {
+	previousMonth := month.AddDate(0, -1, 0)
+	byCategory := make(map[string]*models.CategoryChange)
+	var order []string
+	for _, total := range totals {
+		if !total.Month.Equal(month) && !total.Month.Equal(previousMonth) {
+			continue
+		}
+		change, ok := byCategory[total.Category]
+		if !ok {
+			change = &models.CategoryChange{Category: total.Category}
+			byCategory[total.Category] = change
+			order = append(order, total.Category)
+		}
+		if total.Month.Equal(month) {
+			change.Spent = math.Max(total.Total, 0)
+		} else {
+			change.Previous = math.Max(total.Total, 0)
+		}
+	}
+
+	changes := make([]*models.CategoryChange, 0, len(order))
+	for _, category := range order {
+		change := byCategory[category]
+		if change.Spent == 0 && change.Previous == 0 {
+			continue
+		}
+		change.Change = models.FromMinorUnits(models.ToMinorUnits(change.Spent) - models.ToMinorUnits(change.Previous))
+		change.ChangePercent = changePercent(change.Spent, change.Previous)
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// newSubscriptions lists the recurring charges whose first transaction falls in month.
+// Nothing is new to a user whose history starts in the month.
+func newSubscriptions(month time.Time, series []*models.RecurringSeries, transactions []*models.Transaction) []*models.NewSubscription {
+	subscriptions := []*models.NewSubscription{}
+	if len(transactions) == 0 || !transactions[0].Date.Before(month) {
+		return subscriptions
+	}
+	end := month.AddDate(0, 1, 0)
+	for _, s := range series {
+		if s.IsIncome() {
+			continue
+		}
+		for _, t := range transactions {
+			if !recurring.Matches(s, t) {
+				continue
+			}
+			if !t.Date.Before(month) && t.Date.Before(end) {
+				subscriptions = append(subscriptions, &models.NewSubscription{
+					Merchant:  s.MerchantName,
+					Amount:    s.AverageAmount,
+					Frequency: s.Frequency,
+				})
+			}
+			break // Only the first transaction of the series matters
+		}
+	}
+	sort.SliceStable(subscriptions, func(i, j int) bool {
+		return subscriptions[i].Amount > subscriptions[j].Amount
+	})
+	return subscriptions
+}
+
+// changePercent is the percent change from previous to current, rounded to one decimal
+// place, or nil if previous is zero
+func changePercent(current, previous float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	percent := math.Round((current-previous)/previous*1000) / 10
+	return &percent
+}
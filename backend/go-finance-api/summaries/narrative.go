@@ -0,0 +1,309 @@
+package summaries
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/llm"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+)
+
+// Feature is the name usage is recorded under
+const Feature = "summary"
+
+// maxSmallNumber is the largest whole number a narrative may use without it appearing in
+// the facts, for counts such as "three categories" written as digits
+const maxSmallNumber = 12
+
+// ErrInventedNumber is returned when a narrative states a number that isn't in the facts
+var ErrInventedNumber = errors.New("narrative contains a number not in the facts")
+
+// ErrWrongDirection is returned when a narrative states a change in the wrong direction
+var ErrWrongDirection = errors.New("narrative states a change in the wrong direction")
+
+// numberPattern matches numbers in prose, with optional thousands separators and decimals
+var numberPattern = regexp.MustCompile(`\d[\d,]*(\.\d+)?`)
+
+const narratePrompt = `You write a short monthly summary of a user's finances from facts given as JSON.
+Write two or three friendly paragraphs in plain text, addressed to the user as "you".
+Use only the facts given. Every number you write must appear in the facts, written with
+the same digits, such as 1234.50; do not add, subtract or round numbers yourself.
+Amounts are in the user's currency; don't name a currency. Positive changes are increases.`
+
+// Narrate asks the language model to write a summary of facts. The narrative is
+// rejected if it fails CheckNumbers.
+func Narrate(ctx context.Context, provider llm.Provider, facts *models.MonthlyFacts) (string, error) {
+	data, err := json.Marshal(facts)
+	if err != nil {
+		return "", err
+	}
+	temperature := 0.3
+	resp, err := provider.Chat(ctx, llm.ChatRequest{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: narratePrompt},
+			{Role: llm.RoleUser, Content: string(data)},
+		},
+		Temperature: &temperature,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	narrative := strings.TrimSpace(resp.Message.Content)
+	if narrative == "" {
+		return "", errors.New("language model returned an empty summary")
+	}
+	if err := CheckNumbers(narrative, facts); err != nil {
+		return "", err
+	}
+	return narrative, nil
+}
+
+// CheckNumbers reports an error wrapping ErrInventedNumber if narrative states a number
+// that isn't in facts at the precision written, ignoring small whole numbers. Signs
+// aren't written, since prose says "down 20" for a change of -20, so a change stated
+// with a direction, such as "up 20" or "20% less", is checked against the change's sign
+// and reported with ErrWrongDirection if they disagree.
+func CheckNumbers(narrative string, facts *models.MonthlyFacts) error {
+	known := factNumbers(facts)
+	for _, bounds := range numberPattern.FindAllStringIndex(narrative, -1) {
+		match := narrative[bounds[0]:bounds[1]]
+		text := strings.TrimRight(strings.ReplaceAll(match, ",", ""), ".")
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			continue
+		}
+		decimals := 0
+		if i := strings.IndexByte(text, '.'); i >= 0 {
+			decimals = len(text) - i - 1
+		}
+		if decimals == 0 && value <= maxSmallNumber {
+			continue
+		}
+
+		matching := matchesOf(value, decimals, known)
+		if len(matching) == 0 {
+			return fmt.Errorf("%w: %s", ErrInventedNumber, match)
+		}
+		stated := statedDirection(narrative[:bounds[0]], narrative[bounds[1]:])
+		if stated != 0 && !agrees(stated, matching) {
+			return fmt.Errorf("%w: %s", ErrWrongDirection, match)
+		}
+	}
+	return nil
+}
+
+// factNumber is a number in the facts, as an absolute value. Sign is the direction of a
+// change, and 0 for other numbers.
+type factNumber struct {
+	Value float64
+	Sign  int
+}
+
+// changeFields are the facts fields holding signed changes
+var changeFields = map[string]bool{
+	"change":                 true,
+	"change_percent":         true,
+	"expense_change_percent": true,
+}
+
+// factNumbers collects every number in facts, along with the years of the month and the
+// month before
+func factNumbers(facts *models.MonthlyFacts) []factNumber {
+	var numbers []factNumber
+	if month, err := time.Parse("2006-01", facts.Month); err == nil {
+		numbers = append(numbers,
+			factNumber{Value: float64(month.Year())},
+			factNumber{Value: float64(month.AddDate(0, -1, 0).Year())},
+		)
+	}
+
+	data, err := json.Marshal(facts)
+	if err != nil {
+		return numbers
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return numbers
+	}
+	var walk func(key string, value interface{})
+	walk = func(key string, value interface{}) {
+		switch v := value.(type) {
+		case float64:
+			number := factNumber{Value: math.Abs(v)}
+			if changeFields[key] {
+				number.Sign = sign(v)
+			}
+			numbers = append(numbers, number)
+		case []interface{}:
+			for _, item := range v {
+				walk(key, item)
+			}
+		case map[string]interface{}:
+			for field, item := range v {
+				walk(field, item)
+			}
+		}
+	}
+	walk("", decoded)
+	return numbers
+}
+
+// matchesOf returns the known numbers equal to value rounded to decimals places
+func matchesOf(value float64, decimals int, known []factNumber) []factNumber {
+	scale := math.Pow(10, float64(decimals))
+	var matching []factNumber
+	for _, k := range known {
+		if math.Round(k.Value*scale) == math.Round(value*scale) {
+			matching = append(matching, k)
+		}
+	}
+	return matching
+}
+
+// agrees reports whether a direction stated for a number fits one of the facts it
+// matches. Only changes have a direction; a number that matches something else, such as
+// "rose by 1250.75 to 42250.75", is accepted.
+func agrees(stated int, matching []factNumber) bool {
+	for _, number := range matching {
+		if number.Sign == 0 || number.Sign == stated {
+			return true
+		}
+	}
+	return false
+}
+
+// Words that give the direction of the number next to them
+var (
+	directionBefore = map[string]int{
+		"up": 1, "rose": 1, "increased": 1, "grew": 1, "climbed": 1,
+		"down": -1, "fell": -1, "decreased": -1, "dropped": -1, "shrank": -1,
+	}
+	directionAfter = map[string]int{
+		"more": 1, "higher": 1, "increase": 1,
+		"less": -1, "lower": -1, "fewer": -1, "decrease": -1,
+	}
+)
+
+// statedDirection returns the direction prose states for a number, 1 for up, -1 for down
+// or 0 for none, from the word before it, skipping "by", or the word after it
+func statedDirection(before, after string) int {
+	words := strings.Fields(strings.ToLower(before))
+	if n := len(words); n > 0 && words[n-1] == "by" {
+		words = words[:n-1]
+	}
+	if n := len(words); n > 0 {
+		if direction, ok := directionBefore[strings.Trim(words[n-1], ",;:")]; ok {
+			return direction
+		}
+	}
+	words = strings.Fields(strings.ToLower(strings.TrimLeft(after, "%")))
+	if len(words) > 0 {
+		return directionAfter[strings.Trim(words[0], ".,;:!")]
+	}
+	return 0
+}
+
+// sign returns 1 for a positive value, -1 for a negative one and 0 for zero
+func sign(value float64) int {
+	switch {
+	case value > 0:
+		return 1
+	case value < 0:
+		return -1
+	}
+	return 0
+}
+
+// Template renders facts as a plain summary without a language model
+func Template(facts *models.MonthlyFacts) string {
+	month, err := time.Parse("2006-01", facts.Month)
+	if err != nil {
+		month = time.Time{}
+	}
+	var paragraphs []string
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "In %s you earned %.2f and spent %.2f", month.Format("January 2006"), facts.Income, facts.Expenses)
+	if facts.NetSavings >= 0 {
+		fmt.Fprintf(&b, ", saving %.2f", facts.NetSavings)
+	} else {
+		fmt.Fprintf(&b, ", %.2f more than you earned", -facts.NetSavings)
+	}
+	if facts.SavingsRate != nil && *facts.SavingsRate > 0 {
+		fmt.Fprintf(&b, " (%.1f%% of income)", *facts.SavingsRate)
+	}
+	b.WriteString(".")
+	if facts.ExpenseChangePercent != nil {
+		fmt.Fprintf(&b, " Spending was %s %.1f%% from %.2f in %s.",
+			direction(*facts.ExpenseChangePercent), math.Abs(*facts.ExpenseChangePercent),
+			facts.PreviousExpenses, month.AddDate(0, -1, 0).Format("January"))
+	}
+	paragraphs = append(paragraphs, b.String())
+
+	if len(facts.TopCategories) > 0 {
+		names := make([]string, len(facts.TopCategories))
+		for i, category := range facts.TopCategories {
+			names[i] = fmt.Sprintf("%s (%.2f)", category.Category, category.Spent)
+		}
+		sentence := "Your top spending categories were " + list(names) + "."
+		if len(facts.BiggestChanges) > 0 {
+			changes := make([]string, len(facts.BiggestChanges))
+			for i, change := range facts.BiggestChanges {
+				changes[i] = fmt.Sprintf("%s %s %.2f", change.Category, direction(change.Change), math.Abs(change.Change))
+			}
+			sentence += " Compared with last month: " + list(changes) + "."
+		}
+		paragraphs = append(paragraphs, sentence)
+	}
+
+	var notes []string
+	if len(facts.BudgetMisses) > 0 {
+		misses := make([]string, len(facts.BudgetMisses))
+		for i, miss := range facts.BudgetMisses {
+			misses[i] = fmt.Sprintf("%s by %.2f", miss.Category, miss.Over)
+		}
+		notes = append(notes, "You went over budget in "+list(misses)+".")
+	}
+	if len(facts.NewSubscriptions) > 0 {
+		subscriptions := make([]string, len(facts.NewSubscriptions))
+		for i, subscription := range facts.NewSubscriptions {
+			subscriptions[i] = fmt.Sprintf("%s (%.2f %s)", subscription.Merchant, subscription.Amount, subscription.Frequency)
+		}
+		notes = append(notes, "New recurring charges: "+list(subscriptions)+".")
+	}
+	if facts.NetWorth != nil {
+		verb := "rose"
+		if facts.NetWorth.Change < 0 {
+			verb = "fell"
+		}
+		notes = append(notes, fmt.Sprintf("Your net worth %s by %.2f to %.2f.", verb, math.Abs(facts.NetWorth.Change), facts.NetWorth.End))
+	}
+	if len(notes) > 0 {
+		paragraphs = append(paragraphs, strings.Join(notes, " "))
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// direction describes the sign of a change
+func direction(change float64) string {
+	if change < 0 {
+		return "down"
+	}
+	return "up"
+}
+
+// list joins items as prose: "a", "a and b", "a, b and c"
+func list(items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
@@ -0,0 +1,151 @@
+package summaries
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/balances"
+	"github.com/davidwang/go-finance-api/go-finance-api/budgets"
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/llm"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/davidwang/go-finance-api/go-finance-api/reports"
+	"github.com/google/uuid"
+)
+
+// subscriptionHistoryMonths is how far back a recurring charge is looked for before it
+// counts as new
+const subscriptionHistoryMonths = 13
+
+// Service compiles, writes and stores monthly summaries
+type Service struct {
+	repos *db.Repositories
+	meter *llm.Meter // nil when no provider is configured
+}
+
+// NewService creates a new Service. Without a meter summaries are rendered from the
+// template.
+func NewService(repos *db.Repositories, meter *llm.Meter) *Service {
+	return &Service{repos: repos, meter: meter}
+}
+
+// Facts compiles the facts of the user's month from their transactions, budgets,
+// recurring series and balance history
+func (s *Service) Facts(userID uuid.UUID, month time.Time) (*models.MonthlyFacts, error) {
+	month = budgets.MonthStart(month)
+	previous := month.AddDate(0, -1, 0)
+	end := month.AddDate(0, 1, -1)
+	input := Input{}
+
+	cashFlow, err := reports.NewService(s.repos).CashFlow(userID, previous, month)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range cashFlow.Months {
+		switch m.Month {
+		case month.Format("2006-01"):
+			input.Current = m
+		case previous.Format("2006-01"):
+			input.Previous = m
+		}
+	}
+
+	if input.CategoryTotals, err = s.repos.Transaction.SumByCategoryAndMonth(userID, previous, end); err != nil {
+		return nil, err
+	}
+
+	budgetReport, err := budgets.NewService(s.repos).Report(userID, month)
+	if err != nil {
+		return nil, err
+	}
+	input.Budgets = budgetReport.Budgets
+
+	if input.Series, err = s.repos.Recurring.GetByUserID(userID); err != nil {
+		return nil, err
+	}
+	err = s.repos.Transaction.ForEachByDateRange(userID, month.AddDate(0, -subscriptionHistoryMonths, 0), end, func(t *models.Transaction) error {
+		if t.Amount > 0 && !t.Pending && !t.IsTransfer {
+			input.Transactions = append(input.Transactions, t)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.repos.Account.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	previousEnd := month.AddDate(0, 0, -1)
+	snapshots, err := s.repos.BalanceSnapshot.GetByUserIDAsOf(userID, previousEnd, end)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) > 0 {
+		primary, _ := balances.InCurrency(accounts, balances.PrimaryCurrency(accounts))
+		input.NetWorth = balances.NetWorth(primary, snapshots, previousEnd, end)
+	}
+
+	return Compile(month, input), nil
+}
+
+// Generate compiles the user's facts for month, writes the narrative and stores the
+// summary, replacing any earlier one. The template is used when there is no language
+// model, the user is over their cost limit or the model's narrative fails CheckNumbers.
+func (s *Service) Generate(ctx context.Context, userID uuid.UUID, month time.Time) (*models.MonthlySummary, error) {
+	month = budgets.MonthStart(month)
+	facts, err := s.Facts(userID, month)
+	if err != nil {
+		return nil, err
+	}
+
+	narrative, source := Template(facts), models.SummarySourceTemplate
+	if s.meter != nil {
+		written, err := Narrate(ctx, s.meter.ForUser(userID, Feature), facts)
+		if err != nil {
+			log.Printf("Using the summary template for user %s: %v", userID, err)
+		} else {
+			narrative, source = written, models.SummarySourceLLM
+		}
+	}
+
+	summary := models.NewMonthlySummary(userID, month, facts, narrative, source)
+	if err := s.repos.MonthlySummary.Upsert(summary); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// GenerateMissing generates the summary for month of every user who doesn't have one and
+// returns how many were stored. Running it daily catches up users who joined, or whose
+// generation failed, after the month ended. A failure for one user is logged and
+// doesn't stop the others.
+func (s *Service) GenerateMissing(ctx context.Context, month time.Time) (int, error) {
+	month = budgets.MonthStart(month)
+	userIDs, err := s.repos.User.GetIDs()
+	if err != nil {
+		return 0, err
+	}
+	generated := 0
+	for _, userID := range userIDs {
+		if err := ctx.Err(); err != nil {
+			return generated, err
+		}
+		existing, err := s.repos.MonthlySummary.GetByUserAndMonth(userID, month)
+		if err != nil {
+			log.Printf("Failed to load monthly summary for user %s: %v", userID, err)
+			continue
+		}
+		if existing != nil {
+			continue
+		}
+		if _, err := s.Generate(ctx, userID, month); err != nil {
+			log.Printf("Failed to generate monthly summary for user %s: %v", userID, err)
+			continue
+		}
+		generated++
+	}
+	return generated, nil
+}
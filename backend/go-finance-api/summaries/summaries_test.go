@@ -0,0 +1,131 @@
+package summaries
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/llm"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/davidwang/go-finance-api/go-finance-api/reports"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testFacts compiles the facts of March 2024 from a small history
+func testFacts(t *testing.T) *models.MonthlyFacts {
+	t.Helper()
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	february := march.AddDate(0, -1, 0)
+	rate := 36.0
+
+	streaming := &models.RecurringSeries{MerchantName: "Netflix", SeriesKey: "out:netflix:monthly", Frequency: models.FrequencyMonthly, AverageAmount: 15.49}
+	gym := &models.RecurringSeries{MerchantName: "Gym", SeriesKey: "out:gym:monthly", Frequency: models.FrequencyMonthly, AverageAmount: 40}
+	transactions := []*models.Transaction{
+		{ID: uuid.New(), Name: "Gym", Amount: 40, Date: february.AddDate(0, 0, 2)},
+		{ID: uuid.New(), Name: "Gym", Amount: 40, Date: march.AddDate(0, 0, 2)},
+		{ID: uuid.New(), Name: "Netflix", Amount: 15.49, Date: march.AddDate(0, 0, 9)},
+	}
+
+	facts := Compile(march, Input{
+		Current:  &reports.CashFlowMonth{Month: "2024-03", Income: 5000, Expenses: 3200, NetSavings: 1800, SavingsRate: &rate},
+		Previous: &reports.CashFlowMonth{Month: "2024-02", Income: 5000, Expenses: 2800},
+		CategoryTotals: []*models.MonthlyCategoryTotal{
+			{Month: february, Category: "Groceries", Total: 600},
+			{Month: february, Category: "Travel", Total: 100},
+			{Month: february, Category: "Income", Total: -5000},
+			{Month: march, Category: "Groceries", Total: 610},
+			{Month: march, Category: "Travel", Total: 480.5},
+			{Month: march, Category: "Dining", Total: 210},
+			{Month: march, Category: "Income", Total: -5000},
+		},
+		Budgets: []*models.BudgetStatus{
+			{Category: "Dining", Available: 150, Spent: 210, Remaining: -60},
+			{Category: "Groceries", Available: 700, Spent: 610, Remaining: 90},
+		},
+		Series:       []*models.RecurringSeries{streaming, gym},
+		Transactions: transactions,
+		NetWorth: []*models.NetWorthPoint{
+			{Date: february.AddDate(0, 1, -1), NetWorth: 41000},
+			{Date: march.AddDate(0, 1, -1), NetWorth: 42250.75},
+		},
+	})
+	return facts
+}
+
+// TestCompile tests computing a month's facts
+func TestCompile(t *testing.T) {
+	facts := testFacts(t)
+
+	assert.Equal(t, "2024-03", facts.Month)
+	assert.Equal(t, 1800.0, facts.NetSavings)
+	require.NotNil(t, facts.ExpenseChangePercent)
+	assert.Equal(t, 14.3, *facts.ExpenseChangePercent)
+
+	require.Len(t, facts.TopCategories, 3) // Income is not spending
+	assert.Equal(t, "Groceries", facts.TopCategories[0].Category)
+	assert.Equal(t, "Travel", facts.TopCategories[1].Category)
+	assert.Nil(t, facts.TopCategories[2].ChangePercent) // Dining is new this month
+
+	require.Len(t, facts.BiggestChanges, 2) // Groceries only moved by 10
+	assert.Equal(t, "Travel", facts.BiggestChanges[0].Category)
+	assert.Equal(t, 380.5, facts.BiggestChanges[0].Change)
+	assert.Equal(t, 380.5, *facts.BiggestChanges[0].ChangePercent)
+
+	require.Len(t, facts.BudgetMisses, 1)
+	assert.Equal(t, 60.0, facts.BudgetMisses[0].Over)
+
+	require.Len(t, facts.NewSubscriptions, 1) // The gym was already charged in February
+	assert.Equal(t, "Netflix", facts.NewSubscriptions[0].Merchant)
+
+	require.NotNil(t, facts.NetWorth)
+	assert.Equal(t, 1250.75, facts.NetWorth.Change)
+}
+
+// TestTemplate tests that the template only states numbers from the facts
+func TestTemplate(t *testing.T) {
+	facts := testFacts(t)
+	text := Template(facts)
+
+	assert.Contains(t, text, "In March 2024 you earned 5000.00 and spent 3200.00, saving 1800.00 (36.0% of income).")
+	assert.Contains(t, text, "Spending was up 14.3% from 2800.00 in February.")
+	assert.Contains(t, text, "You went over budget in Dining by 60.00.")
+	assert.Contains(t, text, "Netflix (15.49 monthly)")
+	assert.Contains(t, text, "Your net worth rose by 1250.75 to 42250.75.")
+	assert.NoError(t, CheckNumbers(text, facts))
+}
+
+// TestCheckNumbers tests rejecting numbers that aren't in the facts and changes stated
+// in the wrong direction
+func TestCheckNumbers(t *testing.T) {
+	facts := testFacts(t)
+
+	assert.NoError(t, CheckNumbers("You saved 1,800 in March 2024, 36% of your income, across 3 categories.", facts))
+	assert.NoError(t, CheckNumbers("Travel was up 380.50.", facts))
+	assert.ErrorIs(t, CheckNumbers("You saved 1,900 this month.", facts), ErrInventedNumber)
+	assert.ErrorIs(t, CheckNumbers("Dining cost 210.01.", facts), ErrInventedNumber)
+
+	assert.NoError(t, CheckNumbers("You spent 380.50 more on Travel.", facts))
+	assert.NoError(t, CheckNumbers("Your net worth rose by 1250.75 to 42250.75.", facts))
+	assert.ErrorIs(t, CheckNumbers("Travel was down 380.50.", facts), ErrWrongDirection)
+	assert.ErrorIs(t, CheckNumbers("Spending was 14.3% lower than February.", facts), ErrWrongDirection)
+	assert.ErrorIs(t, CheckNumbers("Your net worth fell by 1250.75.", facts), ErrWrongDirection)
+}
+
+// TestNarrate tests writing the narrative with a language model
+func TestNarrate(t *testing.T) {
+	facts := testFacts(t)
+	respond := func(content string) *llm.ChatResponse {
+		return &llm.ChatResponse{Message: llm.Message{Role: llm.RoleAssistant, Content: content}}
+	}
+
+	provider := llm.NewFakeProvider(respond("  You saved 1800.00 in March, 36.0% of what you earned.  "))
+	text, err := Narrate(context.Background(), provider, facts)
+	require.NoError(t, err)
+	assert.Equal(t, "You saved 1800.00 in March, 36.0% of what you earned.", text)
+	assert.Contains(t, provider.Requests()[0].Messages[1].Content, `"net_savings":1800`)
+
+	_, err = Narrate(context.Background(), llm.NewFakeProvider(respond("You saved 2500.00 in March.")), facts)
+	assert.ErrorIs(t, err, ErrInventedNumber)
+}
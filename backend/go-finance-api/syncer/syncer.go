@@ -0,0 +1,420 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/anomalies"
+	"github.com/davidwang/go-finance-api/go-finance-api/blobstore"
+	"github.com/davidwang/go-finance-api/go-finance-api/categorizer"
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/merchants"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/davidwang/go-finance-api/go-finance-api/plaid"
+	"github.com/davidwang/go-finance-api/go-finance-api/recurring"
+	"github.com/davidwang/go-finance-api/go-finance-api/rules"
+	"github.com/davidwang/go-finance-api/go-finance-api/transfers"
+	"github.com/google/uuid"
+	plaidlib "github.com/plaid/plaid-go/v20/plaid"
+)
+
+// Syncer pulls accounts and transactions from Plaid into the database
+type Syncer struct {
+	plaidClient *plaid.Client
+	repos       *db.Repositories
+	blobStore   blobstore.BlobStore
+}
+
+// NewSyncer creates a new Syncer. The blob store holds attachment files, which are
+// deleted when Plaid removes their transaction.
+func NewSyncer(plaidClient *plaid.Client, repos *db.Repositories, blobStore blobstore.BlobStore) *Syncer {
+	return &Syncer{
+		plaidClient: plaidClient,
+		repos:       repos,
+		blobStore:   blobStore,
+	}
+}
+
+// Result summarizes the changes applied by a sync
+type Result struct {
+	Added        int `json:"added"`
+	Modified     int `json:"modified"`
+	Removed      int `json:"removed"`
+	RulesApplied int `json:"rules_applied"`
+	StaleSplits  int `json:"stale_splits"`
+	Reconciled   int `json:"reconciled"`
+	Transfers    int `json:"transfers"`
+	Recurring    int `json:"recurring"`
+	Categorized  int `json:"categorized"`
+	Anomalies    int `json:"anomalies"`
+}
+
+// add accumulates another result into r
+func (r *Result) add(other *Result) {
+	r.Added += other.Added
+	r.Modified += other.Modified
+	r.Removed += other.Removed
+	r.RulesApplied += other.RulesApplied
+	r.StaleSplits += other.StaleSplits
+	r.Reconciled += other.Reconciled
+	r.Transfers += other.Transfers
+	r.Recurring += other.Recurring
+	r.Categorized += other.Categorized
+	r.Anomalies += other.Anomalies
+}
+
+// SyncUser syncs every Item belonging to a user, then links manual and imported
+// transactions to merchants, pairs up transfers between the user's accounts, which may
+// span Items, categorizes new transactions with the user's classifier, refreshes the
+// user's recurring series and spending summary, and flags unusual transactions
+func (s *Syncer) SyncUser(userID uuid.UUID) (*Result, error) {
+	items, err := s.repos.Item.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load items: %w", err)
+	}
+
+	total := &Result{}
+	for _, item := range items {
+		result, err := s.SyncItem(item)
+		if err != nil {
+			return total, fmt.Errorf("failed to sync item %s: %w", item.ID, err)
+		}
+		total.add(result)
+	}
+
+	if _, err := merchants.NewResolver(s.repos).LinkUnlinked(userID); err != nil {
+		return total, fmt.Errorf("failed to link merchants: %w", err)
+	}
+
+	pairs, err := transfers.NewDetector(s.repos.Transfer).DetectForUser(userID, transfers.DefaultWindowDays)
+	if err != nil {
+		return total, fmt.Errorf("failed to detect transfers: %w", err)
+	}
+	total.Transfers = len(pairs)
+
+	// The classifier only fills in missing categories, so a failure shouldn't fail the sync
+	if categorized, err := categorizer.New(s.repos).Run(userID, false); err != nil {
+		log.Printf("Failed to categorize transactions for user %s: %v", userID, err)
+	} else {
+		total.Categorized = categorized.Applied
+	}
+
+	series, err := recurring.NewDetector(s.repos, s.plaidClient).DetectForUser(userID)
+	if err != nil {
+		return total, fmt.Errorf("failed to detect recurring transactions: %w", err)
+	}
+	total.Recurring = len(series)
+
+	if err := s.repos.SpendingSummary.Refresh(userID); err != nil {
+		return total, fmt.Errorf("failed to refresh spending summary: %w", err)
+	}
+
+	flagged, err := anomalies.NewDetector(s.repos).DetectForUser(userID)
+	if err != nil {
+		return total, fmt.Errorf("failed to detect anomalies: %w", err)
+	}
+	total.Anomalies = len(flagged)
+	return total, nil
+}
+
+// SyncItem refreshes an Item's accounts and applies all transaction updates since the
+// Item's stored cursor. Added and modified transactions are upserted and run through
+// the user's rules. Posted transactions inherit the annotations of the pending transaction
+// they replace, so removed transactions are only deleted once every page has been applied.
+func (s *Syncer) SyncItem(item *models.Item) (*Result, error) {
+	accounts, err := s.syncAccounts(item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync accounts: %w", err)
+	}
+
+	userRules, err := s.repos.Rule.GetEnabledByUserID(item.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rules: %w", err)
+	}
+	engine, err := rules.NewEngine(userRules)
+	if err != nil {
+		return nil, err
+	}
+	resolver := merchants.NewResolver(s.repos)
+
+	result := &Result{}
+	cursor := item.SyncCursor
+	var removedIDs []string
+	for {
+		resp, err := s.plaidClient.SyncTransactions(item.AccessToken, cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, plaidTx := range resp.GetAdded() {
+			transaction, applied, err := s.upsertTransaction(item, accounts, engine, resolver, plaidTx)
+			if err != nil {
+				return nil, err
+			}
+			if transaction == nil {
+				continue
+			}
+			result.Added++
+			if applied {
+				result.RulesApplied++
+			}
+			if err := s.reconcilePending(transaction, result); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, plaidTx := range resp.GetModified() {
+			transaction, applied, err := s.upsertTransaction(item, accounts, engine, resolver, plaidTx)
+			if err != nil {
+				return nil, err
+			}
+			if transaction == nil {
+				continue
+			}
+			result.Modified++
+			if applied {
+				result.RulesApplied++
+			}
+
+			// A changed amount invalidates any splits the user made
+			stale, err := s.repos.Split.MarkStaleIfUnbalanced(transaction.ID)
+			if err != nil {
+				return nil, err
+			}
+			if stale {
+				result.StaleSplits++
+			}
+			if err := s.reconcilePending(transaction, result); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, removed := range resp.GetRemoved() {
+			removedIDs = append(removedIDs, removed.GetTransactionId())
+		}
+
+		cursor = resp.GetNextCursor()
+		if !resp.GetHasMore() {
+			break
+		}
+	}
+
+	for _, plaidTransactionID := range removedIDs {
+		if err := s.removeTransaction(plaidTransactionID); err != nil {
+			return nil, err
+		}
+		result.Removed++
+	}
+
+	if err := s.repos.Item.UpdateSyncCursor(item.ID, cursor); err != nil {
+		return nil, fmt.Errorf("failed to save sync cursor: %w", err)
+	}
+	item.SyncCursor = cursor
+
+	return result, nil
+}
+
+// removeTransaction deletes a transaction Plaid removed, then the files of its
+// attachments, whose rows are deleted with it. A file that can't be deleted is only
+// logged, since the transaction is already gone.
+func (s *Syncer) removeTransaction(plaidTransactionID string) error {
+	attachments, err := s.repos.Attachment.GetByPlaidTransactionID(plaidTransactionID)
+	if err != nil {
+		return err
+	}
+	if err := s.repos.Transaction.DeleteByPlaidTransactionID(plaidTransactionID); err != nil {
+		return err
+	}
+	for _, attachment := range attachments {
+		if err := s.blobStore.Delete(context.Background(), attachment.StorageKey); err != nil {
+			log.Printf("Failed to delete attachment file %s: %v", attachment.StorageKey, err)
+		}
+	}
+	return nil
+}
+
+// syncAccounts upserts the Item's accounts and returns them keyed by Plaid account ID
+func (s *Syncer) syncAccounts(item *models.Item) (map[string]*models.Account, error) {
+	resp, err := s.plaidClient.GetAccounts(item.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make(map[string]*models.Account)
+	for _, plaidAccount := range resp.GetAccounts() {
+		balances := plaidAccount.GetBalances()
+		account := models.NewAccount(
+			item.ID,
+			item.UserID,
+			plaidAccount.GetAccountId(),
+			plaidAccount.GetName(),
+			plaidAccount.GetOfficialName(),
+			string(plaidAccount.GetType()),
+			string(plaidAccount.GetSubtype()),
+			plaidAccount.GetMask(),
+			balances.GetAvailable(),
+			balances.GetCurrent(),
+			balances.GetIsoCurrencyCode(),
+		)
+		if err := s.repos.Account.UpsertByPlaidAccountID(account); err != nil {
+			return nil, err
+		}
+		accounts[account.PlaidAccountID] = account
+	}
+
+	s.syncLiabilities(item, accounts)
+	return accounts, nil
+}
+
+// syncLiabilities refreshes the payment due dates of the Item's credit cards and loans.
+// Items without the liabilities product are skipped, so failures are only logged.
+func (s *Syncer) syncLiabilities(item *models.Item, accounts map[string]*models.Account) {
+	hasLiability := false
+	for _, account := range accounts {
+		hasLiability = hasLiability || account.IsLiability()
+	}
+	if !hasLiability {
+		return
+	}
+
+	resp, err := s.plaidClient.GetLiabilities(item.AccessToken)
+	if err != nil {
+		log.Printf("Liabilities unavailable for item %s: %v", item.ID, err)
+		return
+	}
+
+	update := func(plaidAccountID, dueDate string, minimumPayment *float64) {
+		account, ok := accounts[plaidAccountID]
+		if !ok {
+			return
+		}
+		var due *time.Time
+		if parsed, err := time.Parse("2006-01-02", dueDate); err == nil {
+			due = &parsed
+		}
+		if err := s.repos.Account.UpdatePaymentDue(account.ID, due, minimumPayment); err != nil {
+			log.Printf("Failed to save payment due date for account %s: %v", account.ID, err)
+		}
+	}
+	liabilities := resp.GetLiabilities()
+	for _, credit := range liabilities.GetCredit() {
+		minimum, _ := credit.GetMinimumPaymentAmountOk()
+		update(credit.GetAccountId(), credit.GetNextPaymentDueDate(), minimum)
+	}
+	for _, student := range liabilities.GetStudent() {
+		minimum, _ := student.GetMinimumPaymentAmountOk()
+		update(student.GetAccountId(), student.GetNextPaymentDueDate(), minimum)
+	}
+	for _, mortgage := range liabilities.GetMortgage() {
+		payment, _ := mortgage.GetNextMonthlyPaymentOk()
+		update(mortgage.GetAccountId(), mortgage.GetNextPaymentDueDate(), payment)
+	}
+}
+
+// upsertTransaction stores a Plaid transaction, links it to its merchant and applies any
+// matching rules. It returns the stored transaction, or nil if it was skipped, and reports
+// whether a rule matched.
+func (s *Syncer) upsertTransaction(
+	item *models.Item,
+	accounts map[string]*models.Account,
+	engine *rules.Engine,
+	resolver *merchants.Resolver,
+	plaidTx plaidlib.Transaction,
+) (*models.Transaction, bool, error) {
+	account, ok := accounts[plaidTx.GetAccountId()]
+	if !ok {
+		log.Printf("Skipping transaction %s for unknown account %s", plaidTx.GetTransactionId(), plaidTx.GetAccountId())
+		return nil, false, nil
+	}
+
+	transaction, err := toTransaction(item.UserID, account.ID, plaidTx)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.repos.Transaction.Upsert(transaction); err != nil {
+		return nil, false, err
+	}
+	if err := resolver.Link(transaction, plaidTx.GetLogoUrl(), plaidTx.GetWebsite()); err != nil {
+		return nil, false, fmt.Errorf("failed to link merchant: %w", err)
+	}
+
+	outcome := engine.Evaluate(transaction)
+	if !outcome.Matched() {
+		return transaction, false, nil
+	}
+	if err := s.repos.Transaction.ApplyRuleActions(transaction, outcome.Actions, false); err != nil {
+		return nil, false, err
+	}
+	return transaction, true, nil
+}
+
+// reconcilePending moves the user's annotations from the pending transaction that a posted
+// transaction replaces, if any
+func (s *Syncer) reconcilePending(transaction *models.Transaction, result *Result) error {
+	if transaction.Pending || transaction.PendingTransactionID == "" {
+		return nil
+	}
+
+	reconciled, err := s.repos.Transaction.ReconcilePending(transaction)
+	if err != nil {
+		return fmt.Errorf("failed to reconcile pending transaction %s: %w", transaction.PendingTransactionID, err)
+	}
+	if !reconciled {
+		return nil
+	}
+	result.Reconciled++
+
+	stale, err := s.repos.Split.MarkStaleIfUnbalanced(transaction.ID)
+	if err != nil {
+		return err
+	}
+	if stale {
+		result.StaleSplits++
+	}
+	return nil
+}
+
+// toTransaction converts a Plaid transaction into a Transaction record
+func toTransaction(userID, accountID uuid.UUID, plaidTx plaidlib.Transaction) (*models.Transaction, error) {
+	date, err := time.Parse("2006-01-02", plaidTx.GetDate())
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q for transaction %s: %w", plaidTx.GetDate(), plaidTx.GetTransactionId(), err)
+	}
+
+	transaction := models.NewTransaction(
+		accountID,
+		userID,
+		plaidTx.GetTransactionId(),
+		plaidTx.GetCategoryId(),
+		plaidTx.GetCategory(),
+		plaidTx.GetName(),
+		plaidTx.GetMerchantName(),
+		plaidTx.GetAmount(),
+		plaidTx.GetIsoCurrencyCode(),
+		date,
+		plaidTx.GetPending(),
+		plaidTx.GetPaymentChannel(),
+	)
+
+	transaction.PendingTransactionID = plaidTx.GetPendingTransactionId()
+	if authorized, ok := plaidTx.GetAuthorizedDatetimeOk(); ok && authorized != nil {
+		transaction.Datetime = authorized
+	} else if posted, ok := plaidTx.GetDatetimeOk(); ok && posted != nil {
+		transaction.Datetime = posted
+	}
+
+	location := plaidTx.GetLocation()
+	transaction.SetLocation(
+		location.GetAddress(),
+		location.GetCity(),
+		location.GetRegion(),
+		location.GetPostalCode(),
+		location.GetCountry(),
+		location.GetLat(),
+		location.GetLon(),
+	)
+
+	return transaction, nil
+}
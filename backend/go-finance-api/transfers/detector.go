@@ -0,0 +1,53 @@
+package transfers
+
+import (
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/db"
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// lookbackDays limits detection to recent transactions; older ones have had their chance
+const lookbackDays = 90
+
+// Detector finds and records transfers between a user's accounts
+type Detector struct {
+	transferRepo *db.TransferRepository
+}
+
+// NewDetector creates a new Detector
+func NewDetector(transferRepo *db.TransferRepository) *Detector {
+	return &Detector{transferRepo: transferRepo}
+}
+
+// DetectForUser pairs the user's recent unpaired transactions and marks each new pair as
+// a transfer. Pairs the user has unlinked are not suggested again.
+func (d *Detector) DetectForUser(userID uuid.UUID, windowDays int) ([]*models.TransferPair, error) {
+	since := time.Now().UTC().AddDate(0, 0, -lookbackDays)
+	candidates, err := d.transferRepo.GetCandidates(userID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs, err := d.transferRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	excluded := make(map[PairKey]bool)
+	for _, pair := range pairs {
+		if pair.Status == models.TransferStatusRejected {
+			excluded[PairKey{OutflowID: pair.OutflowTransactionID, InflowID: pair.InflowTransactionID}] = true
+		}
+	}
+
+	var created []*models.TransferPair
+	for _, match := range FindMatches(candidates, windowDays, excluded) {
+		pair := models.NewTransferPair(userID, match.Outflow, match.Inflow)
+		if err := d.transferRepo.Create(pair); err != nil {
+			return created, err
+		}
+		created = append(created, pair)
+	}
+	return created, nil
+}
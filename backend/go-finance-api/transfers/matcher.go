@@ -0,0 +1,82 @@
+package transfers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+)
+
+// DefaultWindowDays is how far apart the two sides of a transfer may post by default.
+// Banks commonly take a couple of business days to settle a payment.
+const DefaultWindowDays = 3
+
+// Match is a candidate transfer: an outflow from one account and an equal inflow to another
+type Match struct {
+	Outflow *models.Transaction
+	Inflow  *models.Transaction
+}
+
+// PairKey identifies an outflow/inflow combination
+type PairKey struct {
+	OutflowID uuid.UUID
+	InflowID  uuid.UUID
+}
+
+// FindMatches pairs outflows with inflows of exactly the same amount and currency in a
+// different account, posted at most windowDays apart. Each transaction is used at most
+// once; an outflow takes the unused inflow closest to it in date, and outflows are
+// considered oldest first. Combinations in excluded are never paired.
+func FindMatches(transactions []*models.Transaction, windowDays int, excluded map[PairKey]bool) []Match {
+	type amountKey struct {
+		minorUnits int64
+		currency   string
+	}
+
+	var outflows []*models.Transaction
+	inflows := make(map[amountKey][]*models.Transaction)
+	for _, t := range transactions {
+		switch {
+		case t.Amount > 0:
+			outflows = append(outflows, t)
+		case t.Amount < 0:
+			key := amountKey{models.ToMinorUnits(-t.Amount), t.IsoCurrencyCode}
+			inflows[key] = append(inflows[key], t)
+		}
+	}
+	sort.SliceStable(outflows, func(i, j int) bool {
+		return outflows[i].Date.Before(outflows[j].Date)
+	})
+
+	window := time.Duration(windowDays) * 24 * time.Hour
+	used := make(map[uuid.UUID]bool)
+	var matches []Match
+	for _, outflow := range outflows {
+		var best *models.Transaction
+		var bestGap time.Duration
+		for _, inflow := range inflows[amountKey{models.ToMinorUnits(outflow.Amount), outflow.IsoCurrencyCode}] {
+			if used[inflow.ID] || inflow.AccountID == outflow.AccountID {
+				continue
+			}
+			if excluded[PairKey{OutflowID: outflow.ID, InflowID: inflow.ID}] {
+				continue
+			}
+			gap := inflow.Date.Sub(outflow.Date)
+			if gap < 0 {
+				gap = -gap
+			}
+			if gap > window {
+				continue
+			}
+			if best == nil || gap < bestGap {
+				best, bestGap = inflow, gap
+			}
+		}
+		if best != nil {
+			used[best.ID] = true
+			matches = append(matches, Match{Outflow: outflow, Inflow: best})
+		}
+	}
+	return matches
+}
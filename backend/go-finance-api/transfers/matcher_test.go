@@ -0,0 +1,85 @@
+package transfers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/davidwang/go-finance-api/go-finance-api/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTransaction builds a transaction with the fields the matcher looks at
+func newTestTransaction(accountID uuid.UUID, amount float64, date time.Time) *models.Transaction {
+	return &models.Transaction{
+		ID:              uuid.New(),
+		AccountID:       accountID,
+		Amount:          amount,
+		IsoCurrencyCode: "USD",
+		Date:            date,
+	}
+}
+
+// TestFindMatchesPairsCardPayment tests the basic checking-to-card payment case
+func TestFindMatchesPairsCardPayment(t *testing.T) {
+	checking, card := uuid.New(), uuid.New()
+	payment := newTestTransaction(checking, 512.34, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	received := newTestTransaction(card, -512.34, time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC))
+	groceries := newTestTransaction(card, 512.34, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC))
+
+	matches := FindMatches([]*models.Transaction{payment, received, groceries}, DefaultWindowDays, nil)
+
+	require.Len(t, matches, 1)
+	assert.Equal(t, payment.ID, matches[0].Outflow.ID)
+	assert.Equal(t, received.ID, matches[0].Inflow.ID)
+}
+
+// TestFindMatchesRequiresSeparateAccountsAndWindow tests the pairing constraints
+func TestFindMatchesRequiresSeparateAccountsAndWindow(t *testing.T) {
+	checking, savings := uuid.New(), uuid.New()
+
+	refund := []*models.Transaction{
+		newTestTransaction(checking, 40, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)),
+		newTestTransaction(checking, -40, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)),
+	}
+	assert.Empty(t, FindMatches(refund, DefaultWindowDays, nil))
+
+	tooFar := []*models.Transaction{
+		newTestTransaction(checking, 40, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)),
+		newTestTransaction(savings, -40, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)),
+	}
+	assert.Empty(t, FindMatches(tooFar, DefaultWindowDays, nil))
+
+	otherCurrency := []*models.Transaction{
+		newTestTransaction(checking, 40, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)),
+		newTestTransaction(savings, -40, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)),
+	}
+	otherCurrency[1].IsoCurrencyCode = "EUR"
+	assert.Empty(t, FindMatches(otherCurrency, DefaultWindowDays, nil))
+}
+
+// TestFindMatchesUsesEachTransactionOnce tests that repeated equal transfers pair up by date
+func TestFindMatchesUsesEachTransactionOnce(t *testing.T) {
+	checking, savings := uuid.New(), uuid.New()
+	firstOut := newTestTransaction(checking, 100, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	secondOut := newTestTransaction(checking, 100, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC))
+	firstIn := newTestTransaction(savings, -100, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC))
+	secondIn := newTestTransaction(savings, -100, time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC))
+
+	matches := FindMatches([]*models.Transaction{secondIn, secondOut, firstIn, firstOut}, DefaultWindowDays, nil)
+
+	require.Len(t, matches, 2)
+	assert.Equal(t, firstIn.ID, matches[0].Inflow.ID)
+	assert.Equal(t, secondIn.ID, matches[1].Inflow.ID)
+}
+
+// TestFindMatchesSkipsExcludedPairs tests that unlinked pairs are not suggested again
+func TestFindMatchesSkipsExcludedPairs(t *testing.T) {
+	checking, savings := uuid.New(), uuid.New()
+	out := newTestTransaction(checking, 25, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	in := newTestTransaction(savings, -25, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	excluded := map[PairKey]bool{{OutflowID: out.ID, InflowID: in.ID}: true}
+	assert.Empty(t, FindMatches([]*models.Transaction{out, in}, DefaultWindowDays, excluded))
+}
@@ -1,18 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/davidwang/go-finance-api/go-finance-api/auth"
+	"github.com/davidwang/go-finance-api/go-finance-api/blobstore"
+	"github.com/davidwang/go-finance-api/go-finance-api/budgets"
 	"github.com/davidwang/go-finance-api/go-finance-api/config"
 	"github.com/davidwang/go-finance-api/go-finance-api/db"
 	"github.com/davidwang/go-finance-api/go-finance-api/handlers"
+	"github.com/davidwang/go-finance-api/go-finance-api/jobs"
+	"github.com/davidwang/go-finance-api/go-finance-api/llm"
 	"github.com/davidwang/go-finance-api/go-finance-api/middleware"
 	"github.com/davidwang/go-finance-api/go-finance-api/plaid"
+	"github.com/davidwang/go-finance-api/go-finance-api/semantic"
+	"github.com/davidwang/go-finance-api/go-finance-api/summaries"
+	"github.com/davidwang/go-finance-api/go-finance-api/syncer"
 )
 
 func main() {
@@ -62,11 +72,110 @@ func main() {
 
 	// Initialize handlers
 	var authHandler *handlers.AuthHandler
+	var syncHandler *handlers.SyncHandler
+	var ruleHandler *handlers.RuleHandler
+	var transactionHandler *handlers.TransactionHandler
+	var annotationHandler *handlers.AnnotationHandler
+	var transferHandler *handlers.TransferHandler
+	var accountHandler *handlers.AccountHandler
+	var importHandler *handlers.ImportHandler
+	var exportHandler *handlers.ExportHandler
+	var balanceHandler *handlers.BalanceHandler
+	var budgetHandler *handlers.BudgetHandler
+	var goalHandler *handlers.GoalHandler
+	var recurringHandler *handlers.RecurringHandler
+	var billHandler *handlers.BillHandler
+	var forecastHandler *handlers.ForecastHandler
+	var reportHandler *handlers.ReportHandler
+	var llmHandler *handlers.LLMHandler
+	var assistantHandler *handlers.AssistantHandler
+	var queryHandler *handlers.QueryHandler
+	var categorizerHandler *handlers.CategorizerHandler
+	var merchantHandler *handlers.MerchantHandler
+	var anomalyHandler *handlers.AnomalyHandler
+	var summaryHandler *handlers.SummaryHandler
+	var searchHandler *handlers.SearchHandler
+	var llmMeter *llm.Meter // nil when no language model is configured
 	plaidHandler := handlers.NewPlaidHandler(plaidClient)
 
-	// Initialize auth handler if database is available
+	// Initialize database-backed handlers if database is available
 	if !skipDB {
-		authHandler = handlers.NewAuthHandler(database.Repositories.User, jwtConfig)
+		repos := database.Repositories
+		blobStore, err := blobstore.New(cfg.Blob)
+		if err != nil {
+			log.Fatalf("Failed to initialize blob store: %v", err)
+		}
+		authHandler = handlers.NewAuthHandler(repos.User, jwtConfig)
+		syncHandler = handlers.NewSyncHandler(syncer.NewSyncer(plaidClient, repos, blobStore))
+		ruleHandler = handlers.NewRuleHandler(repos.Rule, repos.Transaction)
+		transactionHandler = handlers.NewTransactionHandler(repos.Transaction, repos.Split)
+		annotationHandler = handlers.NewAnnotationHandler(repos.Transaction, repos.Tag, repos.Attachment, blobStore)
+		transferHandler = handlers.NewTransferHandler(repos.Transfer)
+		accountHandler = handlers.NewAccountHandler(repos.Account, repos.Transaction, repos.Attachment, blobStore)
+		importHandler = handlers.NewImportHandler(repos)
+		exportHandler = handlers.NewExportHandler(repos)
+		balanceHandler = handlers.NewBalanceHandler(repos)
+		budgetHandler = handlers.NewBudgetHandler(repos)
+		goalHandler = handlers.NewGoalHandler(repos)
+		recurringHandler = handlers.NewRecurringHandler(repos, plaidClient)
+		billHandler = handlers.NewBillHandler(repos)
+		forecastHandler = handlers.NewForecastHandler(repos)
+		reportHandler = handlers.NewReportHandler(repos)
+
+		// The language model is optional; AI features are disabled without one
+		llmProvider, err := llm.New(cfg.LLM)
+		switch {
+		case err == nil:
+			llmMeter = llm.NewMeter(llmProvider, repos.LLMUsage, cfg.LLM.Pricing, cfg.LLM.MonthlyLimit)
+			log.Printf("Using %s LLM provider", cfg.LLM.Backend)
+		case errors.Is(err, llm.ErrNotConfigured):
+			log.Println("No LLM provider configured (set LLM_BACKEND); AI features are disabled")
+		default:
+			log.Fatalf("Failed to initialize LLM provider: %v", err)
+		}
+		llmHandler = handlers.NewLLMHandler(repos.LLMUsage, llmMeter)
+		assistantHandler = handlers.NewAssistantHandler(repos, llmMeter)
+		queryHandler = handlers.NewQueryHandler(repos, llmMeter)
+		categorizerHandler = handlers.NewCategorizerHandler(repos)
+		merchantHandler = handlers.NewMerchantHandler(repos)
+		anomalyHandler = handlers.NewAnomalyHandler(repos)
+		summaryHandler = handlers.NewSummaryHandler(repos, llmMeter)
+		searchHandler = handlers.NewSearchHandler(repos, llmMeter, cfg.LLM.EmbeddingModel)
+	}
+
+	// Start background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if !skipDB {
+		repos := database.Repositories
+		jobs.Daily(jobsCtx, "balance snapshots", 0, func(ctx context.Context) error {
+			n, err := repos.BalanceSnapshot.SnapshotAll(time.Now().UTC())
+			if err == nil {
+				log.Printf("Recorded %d balance snapshots", n)
+			}
+			return err
+		})
+
+		// Summarize last month for every user who doesn't have a summary of it yet
+		summaryService := summaries.NewService(repos, llmMeter)
+		jobs.Daily(jobsCtx, "monthly summaries", 2, func(ctx context.Context) error {
+			lastMonth := budgets.MonthStart(time.Now().UTC()).AddDate(0, -1, 0)
+			n, err := summaryService.GenerateMissing(ctx, lastMonth)
+			if err == nil {
+				log.Printf("Generated %d monthly summaries", n)
+			}
+			return err
+		})
+
+		// Embed new and changed transactions so searches rarely have to wait on them
+		searchService := semantic.NewService(repos, llmMeter, cfg.LLM.EmbeddingModel)
+		jobs.Daily(jobsCtx, "transaction embeddings", 3, func(ctx context.Context) error {
+			n, err := searchService.IndexAll(ctx)
+			if err == nil {
+				log.Printf("Embedded %d transactions", n)
+			}
+			return err
+		})
 	}
 
 	// Set up Gin router
@@ -111,6 +220,194 @@ func main() {
 			}
 		}
 
+		// Database-backed endpoints - only if database is available
+		if !skipDB {
+			// Calendar apps fetch the bills feed without a session; the token in its URL
+			// authenticates it
+			api.GET("/calendar/:token", billHandler.CalendarFeed)
+
+			protected := api.Group("")
+			protected.Use(middleware.AuthMiddleware(jwtConfig))
+			{
+				// Pull the latest Plaid data for all of the user's Items
+				protected.POST("/sync", syncHandler.Sync)
+
+				// Transaction rules
+				ruleRoutes := protected.Group("/rules")
+				{
+					ruleRoutes.GET("", ruleHandler.ListRules)
+					ruleRoutes.POST("", ruleHandler.CreateRule)
+					ruleRoutes.PUT("/:id", ruleHandler.UpdateRule)
+					ruleRoutes.DELETE("/:id", ruleHandler.DeleteRule)
+					ruleRoutes.POST("/preview", ruleHandler.PreviewRule)
+					ruleRoutes.POST("/apply", ruleHandler.ApplyAllRules)
+					ruleRoutes.POST("/:id/apply", ruleHandler.ApplyRule)
+				}
+
+				// Accounts; manual accounts and their transactions can be edited
+				accountRoutes := protected.Group("/accounts")
+				{
+					accountRoutes.GET("", accountHandler.ListAccounts)
+					accountRoutes.POST("", accountHandler.CreateAccount)
+					accountRoutes.GET("/:id", accountHandler.GetAccount)
+					accountRoutes.PUT("/:id", accountHandler.UpdateAccount)
+					accountRoutes.DELETE("/:id", accountHandler.DeleteAccount)
+					accountRoutes.GET("/:id/transactions", accountHandler.ListAccountTransactions)
+					accountRoutes.POST("/:id/transactions", accountHandler.CreateAccountTransaction)
+					accountRoutes.PUT("/:id/transactions/:transactionId", accountHandler.UpdateAccountTransaction)
+					accountRoutes.DELETE("/:id/transactions/:transactionId", accountHandler.DeleteAccountTransaction)
+
+					// Balance history
+					accountRoutes.GET("/:id/balances", balanceHandler.GetBalances)
+					accountRoutes.POST("/:id/balances/backfill", balanceHandler.BackfillBalances)
+
+					// Statement file import
+					accountRoutes.POST("/:id/import/preview", importHandler.PreviewImport)
+					accountRoutes.POST("/:id/import", importHandler.ImportStatement)
+				}
+
+				// Stored transactions
+				transactionRoutes := protected.Group("/transactions")
+				{
+					transactionRoutes.GET("/categories", transactionHandler.GetCategoryTotals)
+					transactionRoutes.GET("/:id/splits", transactionHandler.GetSplits)
+					transactionRoutes.PUT("/:id/splits", transactionHandler.SetSplits)
+					transactionRoutes.DELETE("/:id/splits", transactionHandler.DeleteSplits)
+					transactionRoutes.GET("/:id/links", transactionHandler.GetLinks)
+					transactionRoutes.PUT("/:id/category", categorizerHandler.SetCategory)
+					transactionRoutes.GET("/:id/similar", searchHandler.Similar)
+
+					// Notes, tags and attachments
+					transactionRoutes.PUT("/:id/notes", annotationHandler.UpdateNotes)
+					transactionRoutes.GET("/:id/tags", annotationHandler.GetTransactionTags)
+					transactionRoutes.POST("/:id/tags", annotationHandler.AddTransactionTag)
+					transactionRoutes.DELETE("/:id/tags/:name", annotationHandler.RemoveTransactionTag)
+					transactionRoutes.GET("/:id/attachments", annotationHandler.ListAttachments)
+					transactionRoutes.POST("/:id/attachments", annotationHandler.UploadAttachment)
+					transactionRoutes.GET("/:id/attachments/:attachmentId", annotationHandler.DownloadAttachment)
+					transactionRoutes.DELETE("/:id/attachments/:attachmentId", annotationHandler.DeleteAttachment)
+				}
+
+				// Tags
+				protected.GET("/tags", annotationHandler.ListTags)
+				protected.DELETE("/tags/:id", annotationHandler.DeleteTag)
+
+				// Net worth over time from balance snapshots
+				protected.GET("/net-worth", balanceHandler.GetNetWorth)
+
+				// Projected balances of depository accounts
+				protected.GET("/forecast", forecastHandler.GetForecast)
+
+				// Monthly category budgets
+				budgetRoutes := protected.Group("/budgets")
+				{
+					budgetRoutes.GET("/:month", budgetHandler.GetBudgets)
+					budgetRoutes.PUT("/:month", budgetHandler.SetBudget)
+					budgetRoutes.DELETE("/:month", budgetHandler.DeleteBudget)
+					budgetRoutes.POST("/:month/copy", budgetHandler.CopyBudgets)
+				}
+
+				// Savings goals
+				goalRoutes := protected.Group("/goals")
+				{
+					goalRoutes.GET("", goalHandler.ListGoals)
+					goalRoutes.POST("", goalHandler.CreateGoal)
+					goalRoutes.GET("/:id", goalHandler.GetGoal)
+					goalRoutes.PUT("/:id", goalHandler.UpdateGoal)
+					goalRoutes.DELETE("/:id", goalHandler.DeleteGoal)
+					goalRoutes.POST("/:id/contributions", goalHandler.AddContribution)
+					goalRoutes.DELETE("/:id/contributions/:contributionId", goalHandler.DeleteContribution)
+				}
+
+				// Recurring transactions: subscriptions, bills and income
+				protected.GET("/recurring", recurringHandler.ListRecurring)
+				protected.POST("/recurring/detect", recurringHandler.DetectRecurring)
+
+				// Upcoming bills and the private calendar feed URL
+				billRoutes := protected.Group("/bills")
+				{
+					billRoutes.GET("", billHandler.ListBills)
+					billRoutes.POST("/calendar", billHandler.CreateCalendarFeed)
+					billRoutes.DELETE("/calendar", billHandler.DeleteCalendarFeed)
+				}
+
+				// Spending reports with period comparisons, and monthly cash flow
+				protected.GET("/reports/spending", reportHandler.GetSpendingReport)
+				protected.GET("/reports/cash-flow", reportHandler.GetCashFlowReport)
+
+				// Language model usage and cost this month
+				protected.GET("/llm/usage", llmHandler.GetUsage)
+
+				// Finance assistant conversations; replies stream as server-sent events
+				assistantRoutes := protected.Group("/assistant")
+				{
+					assistantRoutes.POST("/chat", assistantHandler.Chat)
+					assistantRoutes.GET("/conversations", assistantHandler.ListConversations)
+					assistantRoutes.GET("/conversations/:id", assistantHandler.GetConversation)
+					assistantRoutes.DELETE("/conversations/:id", assistantHandler.DeleteConversation)
+				}
+
+				// Natural-language transaction queries and the specs they compile to
+				protected.POST("/query", queryHandler.Ask)
+				protected.POST("/query/run", queryHandler.RunSpec)
+
+				// On-box category classifier and its queue of low-confidence predictions
+				categorizerRoutes := protected.Group("/categorizer")
+				{
+					categorizerRoutes.GET("", categorizerHandler.GetStatus)
+					categorizerRoutes.POST("/train", categorizerHandler.Train)
+					categorizerRoutes.GET("/review", categorizerHandler.ListReview)
+					categorizerRoutes.POST("/review/:transactionId", categorizerHandler.ResolveReview)
+					categorizerRoutes.DELETE("/review/:transactionId", categorizerHandler.DismissReview)
+				}
+
+				// Canonical merchants normalized from bank transaction names
+				merchantRoutes := protected.Group("/merchants")
+				{
+					merchantRoutes.GET("", merchantHandler.ListMerchants)
+					merchantRoutes.POST("/link", merchantHandler.LinkMerchants)
+					merchantRoutes.PUT("/:id", merchantHandler.UpdateMerchant)
+					merchantRoutes.POST("/:id/merge", merchantHandler.MergeMerchant)
+				}
+
+				// Transactions flagged as unusual, which the user can dismiss or report as fraud
+				anomalyRoutes := protected.Group("/insights/anomalies")
+				{
+					anomalyRoutes.GET("", anomalyHandler.ListAnomalies)
+					anomalyRoutes.POST("/detect", anomalyHandler.DetectAnomalies)
+					anomalyRoutes.POST("/:transactionId/dismiss", anomalyHandler.DismissAnomaly)
+					anomalyRoutes.POST("/:transactionId/fraud", anomalyHandler.ReportFraud)
+				}
+
+				// Monthly summaries written from facts computed over the user's data
+				summaryRoutes := protected.Group("/summaries")
+				{
+					summaryRoutes.GET("", summaryHandler.ListSummaries)
+					summaryRoutes.GET("/:month", summaryHandler.GetSummary)
+					summaryRoutes.POST("/:month", summaryHandler.GenerateSummary)
+				}
+
+				// Semantic search over transaction descriptions
+				searchRoutes := protected.Group("/search")
+				{
+					searchRoutes.GET("", searchHandler.Search)
+					searchRoutes.POST("/index", searchHandler.IndexTransactions)
+				}
+
+				// Export to CSV, OFX, Ledger or Beancount
+				protected.GET("/export", exportHandler.Export)
+
+				// Transfers between the user's own accounts
+				transferRoutes := protected.Group("/transfers")
+				{
+					transferRoutes.GET("", transferHandler.ListTransfers)
+					transferRoutes.POST("/detect", transferHandler.DetectTransfers)
+					transferRoutes.POST("/:id/confirm", transferHandler.ConfirmTransfer)
+					transferRoutes.DELETE("/:id", transferHandler.UnlinkTransfer)
+				}
+			}
+		}
+
 		// Plaid endpoints
 		plaidRoutes := api.Group("/plaid")
 		// Apply auth middleware if database is available